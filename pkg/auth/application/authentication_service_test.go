@@ -1878,7 +1878,7 @@ func TestFindOrCreateAgent_DispatchesEventsWhenConfigured(t *testing.T) {
 
 	var mu sync.Mutex
 	var received []string
-	if err := dispatcher.SubscribeWildcard(func(_ context.Context, env esDomain.EventEnvelope[any]) error {
+	if _, err := dispatcher.SubscribeWildcard(func(_ context.Context, env esDomain.EventEnvelope[any]) error {
 		mu.Lock()
 		defer mu.Unlock()
 		received = append(received, env.EventType)
@@ -1965,7 +1965,7 @@ func TestFindOrCreateAgent_DispatchHandlerErrorIsNonFatal(t *testing.T) {
 	ctx := context.Background()
 
 	dispatcher := esDomain.NewEventDispatcher()
-	if err := dispatcher.SubscribeWildcard(func(_ context.Context, _ esDomain.EventEnvelope[any]) error {
+	if _, err := dispatcher.SubscribeWildcard(func(_ context.Context, _ esDomain.EventEnvelope[any]) error {
 		return errors.New("handler intentionally failing")
 	}); err != nil {
 		t.Fatalf("SubscribeWildcard() error: %v", err)
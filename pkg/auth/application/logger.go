@@ -1,6 +1,9 @@
 package application
 
-import "context"
+import (
+	"context"
+	"log/slog"
+)
 
 // Logger defines the interface for structured logging in the auth package.
 type Logger interface {
@@ -16,3 +19,33 @@ type NoOpLogger struct{}
 func (NoOpLogger) Info(_ context.Context, _ string, _ ...interface{})  {}
 func (NoOpLogger) Warn(_ context.Context, _ string, _ ...interface{})  {}
 func (NoOpLogger) Error(_ context.Context, _ string, _ ...interface{}) {}
+
+// SlogLogger is a Logger writing to a log/slog handler, so auth logs share
+// the format and fields of the rest of an application's logs. Key/value
+// pairs become slog attributes, and ctx is passed to the handler.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger creates a SlogLogger writing to handler, for example
+// slog.NewJSONHandler(os.Stderr, nil). The handler decides which levels are
+// written; give it a *slog.LevelVar as HandlerOptions.Level to change the
+// level at runtime.
+func NewSlogLogger(handler slog.Handler) *SlogLogger {
+	return &SlogLogger{logger: slog.New(handler)}
+}
+
+// Info logs msg at slog.LevelInfo.
+func (l *SlogLogger) Info(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.logger.InfoContext(ctx, msg, keysAndValues...)
+}
+
+// Warn logs msg at slog.LevelWarn.
+func (l *SlogLogger) Warn(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.logger.WarnContext(ctx, msg, keysAndValues...)
+}
+
+// Error logs msg at slog.LevelError.
+func (l *SlogLogger) Error(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.logger.ErrorContext(ctx, msg, keysAndValues...)
+}
@@ -0,0 +1,49 @@
+package application_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/auth/application"
+)
+
+func TestSlogLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	var logger application.Logger = application.NewSlogLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+	ctx := context.Background()
+
+	logger.Info(ctx, "agent signed in", "agent_id", "agent-1", "attempts", 2)
+	logger.Warn(ctx, "token near expiry", "session_id", "sess-1")
+	logger.Error(ctx, "code exchange failed", "error", errors.New("bad code"))
+	level.Set(slog.LevelWarn)
+	logger.Info(ctx, "filtered out")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d log lines, want 3:\n%s", len(lines), buf.String())
+	}
+	want := []map[string]any{
+		{"level": "INFO", "msg": "agent signed in", "agent_id": "agent-1", "attempts": float64(2)},
+		{"level": "WARN", "msg": "token near expiry", "session_id": "sess-1"},
+		{"level": "ERROR", "msg": "code exchange failed", "error": "bad code"},
+	}
+	for i, line := range lines {
+		var got map[string]any
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d is not JSON: %v\n%s", i, err, line)
+		}
+		for key, value := range want[i] {
+			if got[key] != value {
+				t.Errorf("line %d: %s = %v, want %v", i, key, got[key], value)
+			}
+		}
+	}
+}
@@ -988,7 +988,7 @@ func TestRegisterPassword_DispatchesEventsWhenConfigured(t *testing.T) {
 
 	var mu sync.Mutex
 	var received []string
-	if err := dispatcher.SubscribeWildcard(func(_ context.Context, env esdomain.EventEnvelope[any]) error {
+	if _, err := dispatcher.SubscribeWildcard(func(_ context.Context, env esdomain.EventEnvelope[any]) error {
 		mu.Lock()
 		defer mu.Unlock()
 		received = append(received, env.EventType)
@@ -1,6 +1,10 @@
 package auth
 
-import "context"
+import (
+	"context"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
 
 // contextKey is an unexported type used for context keys in this package,
 // preventing collisions with keys defined in other packages.
@@ -26,6 +30,11 @@ func AgentFromCtx(ctx context.Context) *Identity {
 }
 
 // ContextWithAgent returns a new context with the given Identity attached.
+// The agent and its active account are also recorded as the actor of events
+// committed under the context (see domain.ContextWithActor).
 func ContextWithAgent(ctx context.Context, id *Identity) context.Context {
+	if id != nil {
+		ctx = domain.ContextWithActor(ctx, id.AgentID, id.ActiveAccountID)
+	}
 	return context.WithValue(ctx, identityKey, id)
 }
@@ -2,7 +2,9 @@ package cqrs
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -15,8 +17,10 @@ import (
 // REQ-CD-001
 type CommandReceiver[T any] func(ctx context.Context, env CommandEnvelope[T]) (any, error)
 
-// receiverFunc is the internal representation of a receiver that accepts CommandEnvelope[any].
-type receiverFunc func(ctx context.Context, env CommandEnvelope[any]) (any, error)
+// ReceiverFunc is a receiver as the dispatchers invoke it, with the payload
+// untyped. RegisterReceiver wraps typed receivers into one; CommandMiddleware
+// wraps it further.
+type ReceiverFunc func(ctx context.Context, env CommandEnvelope[any]) (any, error)
 
 // CommandEnvelope wraps a command payload with metadata fields.
 // REQ-CD-002
@@ -63,6 +67,10 @@ type CommandResult struct {
 type Watchable struct {
 	results chan CommandResult
 	done    chan struct{}
+
+	// rejected is set when the command was rejected before any receiver
+	// ran; see rejectCommand.
+	rejected bool
 }
 
 func newWatchable(bufferSize int) *Watchable {
@@ -101,6 +109,48 @@ func (w *Watchable) First() (CommandResult, bool) {
 	return r, ok
 }
 
+var (
+	// ErrNoResult is returned by FirstAs when no receiver handled the command.
+	ErrNoResult = errors.New("no command result")
+
+	// ErrNilResult is returned by FirstAs when the first receiver succeeded
+	// but returned a nil value, which receivers use to signal "not found".
+	ErrNilResult = errors.New("command result is nil")
+
+	// ErrResultType is returned by FirstAs when the first result is not of
+	// the requested type.
+	ErrResultType = errors.New("unexpected command result type")
+
+	// ErrReceiverPanic is returned, wrapped, for a receiver that panicked.
+	// The error message includes the panic value.
+	ErrReceiverPanic = errors.New("receiver panicked")
+)
+
+// FirstAs waits for the first result like Watchable.First and asserts its
+// value to R, so callers get a typed value or an error instead of a panicking
+// type assertion. A receiver error is returned as is. A nil value is reported
+// as ErrNilResult rather than as the zero R, so "not found" stays distinct
+// from a genuine zero value; a value of another type is reported as
+// ErrResultType naming the expected and actual types.
+func FirstAs[R any](w *Watchable) (R, error) {
+	var zero R
+	result, ok := w.First()
+	if !ok {
+		return zero, ErrNoResult
+	}
+	if result.Error != nil {
+		return zero, result.Error
+	}
+	if result.Value == nil {
+		return zero, fmt.Errorf("%w for command type %q", ErrNilResult, result.CommandType)
+	}
+	value, ok := result.Value.(R)
+	if !ok {
+		return zero, fmt.Errorf("%w for command type %q: expected %s, got %T", ErrResultType, result.CommandType, reflect.TypeFor[R](), result.Value)
+	}
+	return value, nil
+}
+
 // Done returns a receive-only channel closed when all receivers complete, for use in select statements.
 // REQ-CD-036
 func (w *Watchable) Done() <-chan struct{} {
@@ -112,30 +162,36 @@ func (w *Watchable) Done() <-chan struct{} {
 type CommandDispatcher interface {
 	Dispatch(ctx context.Context, envelope CommandEnvelope[any]) *Watchable
 	RegisterWildcardReceiver(receiver func(context.Context, CommandEnvelope[any]) (any, error)) error
+	RegisterDefaultReceiver(receiver func(context.Context, CommandEnvelope[any]) (any, error)) error
+	Use(middleware CommandMiddleware)
 	Close() error
 }
 
 // receiverRegistrar is an internal interface for generic receiver registration.
 type receiverRegistrar interface {
-	addReceiver(commandType string, fn receiverFunc) error
+	addReceiver(commandType string, fn ReceiverFunc) error
 }
 
 // commandRegistry provides shared registration and resolution logic for dispatchers.
 type commandRegistry struct {
 	mu                sync.RWMutex
-	receivers         map[string][]receiverFunc
-	wildcardReceivers []receiverFunc
+	receivers         map[string][]ReceiverFunc
+	wildcardReceivers []ReceiverFunc
+	defaultReceiver   ReceiverFunc
+
+	// middlewares wrap every receiver invocation, outermost first; see Use.
+	middlewares []CommandMiddleware
 }
 
 func newCommandRegistry() commandRegistry {
 	return commandRegistry{
-		receivers:         make(map[string][]receiverFunc),
-		wildcardReceivers: make([]receiverFunc, 0),
+		receivers:         make(map[string][]ReceiverFunc),
+		wildcardReceivers: make([]ReceiverFunc, 0),
 	}
 }
 
 // addReceiver stores a receiver function for a command type.
-func (r *commandRegistry) addReceiver(commandType string, fn receiverFunc) error {
+func (r *commandRegistry) addReceiver(commandType string, fn ReceiverFunc) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.receivers[commandType] = append(r.receivers[commandType], fn)
@@ -154,19 +210,46 @@ func (r *commandRegistry) RegisterWildcardReceiver(receiver func(context.Context
 	return nil
 }
 
-// resolveReceivers returns all receivers matching the command type using dot-separated pattern matching.
+// RegisterDefaultReceiver registers a fallback receiver invoked only when no
+// receiver is registered for the command type or a pattern matching it, which
+// enables gateway-style forwarding or explicit rejection of unknown commands.
+// The fallback receives the untyped envelope since it cannot know the payload
+// type. Wildcard receivers still run alongside it. Only one default receiver
+// may be registered; without one, unmatched commands complete with no results
+// as before.
+func (r *commandRegistry) RegisterDefaultReceiver(receiver func(context.Context, CommandEnvelope[any]) (any, error)) error {
+	if receiver == nil {
+		return fmt.Errorf("receiver cannot be nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.defaultReceiver != nil {
+		return fmt.Errorf("default receiver is already registered")
+	}
+	r.defaultReceiver = receiver
+	return nil
+}
+
+// resolveReceivers returns all receivers matching the command type using dot-separated pattern matching,
+// each wrapped in the middleware chain.
 // The lock is acquired during resolution and released before returning, so receivers execute lock-free.
 // REQ-CD-021, REQ-CD-071
-func (r *commandRegistry) resolveReceivers(commandType string) []receiverFunc {
+func (r *commandRegistry) resolveReceivers(commandType string) []ReceiverFunc {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	patterns := getMatchingPatterns(commandType)
-	var all []receiverFunc
+	var all []ReceiverFunc
 	for _, p := range patterns {
 		all = append(all, r.receivers[p]...)
 	}
+	if len(all) == 0 && r.defaultReceiver != nil {
+		all = append(all, r.defaultReceiver)
+	}
 	all = append(all, r.wildcardReceivers...)
+	for i, fn := range all {
+		all[i] = r.applyMiddlewares(fn)
+	}
 	return all
 }
 
@@ -208,13 +291,15 @@ func RegisterReceiver[T any](d CommandDispatcher, commandType string, receiver C
 	return reg.addReceiver(commandType, wrapped)
 }
 
-// executeReceiver invokes a receiver with panic recovery and sends the result to the Watchable.
+// executeReceiver invokes a receiver with panic recovery, sends the result to
+// the Watchable and returns the result's error.
 // REQ-CD-060, REQ-CD-061
-func executeReceiver(fn receiverFunc, ctx context.Context, envelope CommandEnvelope[any], w *Watchable) {
+func executeReceiver(fn ReceiverFunc, ctx context.Context, envelope CommandEnvelope[any], w *Watchable) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrReceiverPanic, r)
 			w.results <- CommandResult{
-				Error:       fmt.Errorf("receiver panicked: %v", r),
+				Error:       err,
 				CommandType: envelope.CommandType,
 			}
 		}
@@ -228,6 +313,53 @@ func executeReceiver(fn receiverFunc, ctx context.Context, envelope CommandEnvel
 		result.Value = value
 	}
 	w.results <- result
+	return err
+}
+
+// receiverRunner invokes a dispatch's receivers through executeReceiver and
+// returns the errors they reported.
+type receiverRunner func(ctx context.Context, receivers []ReceiverFunc, envelope CommandEnvelope[any], w *Watchable) []error
+
+// dispatch runs the pipeline both dispatchers share around run: it scopes
+// ctx to the command, logs the request and waits for the command's turn
+// under WithAggregateSerialization before running the receivers, then
+// completes the Watchable.
+func (c *dispatcherConfig) dispatch(ctx context.Context, receivers []ReceiverFunc, envelope CommandEnvelope[any], run receiverRunner) *Watchable {
+	w := newWatchable(len(receivers))
+
+	// REQ-CD-022: no receivers match -> immediately complete
+	if len(receivers) == 0 {
+		close(w.results)
+		close(w.done)
+		return w
+	}
+
+	ctx = c.commandContext(ctx, envelope)
+	finish := c.startRequest(ctx, envelope, len(receivers))
+	ticket, err := c.serializeAggregates(envelope)
+	if err != nil {
+		finish([]error{err})
+		rejectCommand(w, envelope, err)
+		return w
+	}
+
+	go func() {
+		if err := ticket.wait(ctx); err != nil {
+			finish([]error{err})
+			rejectCommand(w, envelope, err)
+			return
+		}
+
+		errs := run(ctx, receivers, envelope, w)
+
+		// REQ-CD-032: close results channel after all receivers complete
+		ticket.release()
+		finish(errs)
+		close(w.results)
+		close(w.done)
+	}()
+
+	return w
 }
 
 // --- Async Command Dispatcher ---
@@ -236,46 +368,42 @@ func executeReceiver(fn receiverFunc, ctx context.Context, envelope CommandEnvel
 // REQ-CD-040
 type AsyncCommandDispatcher struct {
 	commandRegistry
+	dispatcherConfig
 }
 
 // NewAsyncCommandDispatcher creates a new AsyncCommandDispatcher.
-func NewAsyncCommandDispatcher() *AsyncCommandDispatcher {
+func NewAsyncCommandDispatcher(opts ...DispatcherOption) *AsyncCommandDispatcher {
 	return &AsyncCommandDispatcher{
-		commandRegistry: newCommandRegistry(),
+		commandRegistry:  newCommandRegistry(),
+		dispatcherConfig: newDispatcherConfig(opts),
 	}
 }
 
 // Dispatch dispatches a command to all matching receivers concurrently and returns a Watchable.
 // REQ-CD-020, REQ-CD-040, REQ-CD-041, REQ-CD-042
 func (d *AsyncCommandDispatcher) Dispatch(ctx context.Context, envelope CommandEnvelope[any]) *Watchable {
-	receivers := d.resolveReceivers(envelope.CommandType)
-	w := newWatchable(len(receivers))
-
-	// REQ-CD-022: no receivers match -> immediately complete
-	if len(receivers) == 0 {
-		close(w.results)
-		close(w.done)
-		return w
-	}
+	return d.dispatch(ctx, d.resolveReceivers(envelope.CommandType), envelope, runConcurrently)
+}
 
+// runConcurrently runs every receiver in its own goroutine.
+func runConcurrently(ctx context.Context, receivers []ReceiverFunc, envelope CommandEnvelope[any], w *Watchable) []error {
 	var wg sync.WaitGroup
 	wg.Add(len(receivers))
 
+	var errsMu sync.Mutex
+	var errs []error
 	for i := range receivers {
-		go func(fn receiverFunc) {
+		go func(fn ReceiverFunc) {
 			defer wg.Done()
-			executeReceiver(fn, ctx, envelope, w)
+			if err := executeReceiver(fn, ctx, envelope, w); err != nil {
+				errsMu.Lock()
+				errs = append(errs, err)
+				errsMu.Unlock()
+			}
 		}(receivers[i])
 	}
-
-	// REQ-CD-032: close results channel after all receivers complete
-	go func() {
-		wg.Wait()
-		close(w.results)
-		close(w.done)
-	}()
-
-	return w
+	wg.Wait()
+	return errs
 }
 
 // Close releases resources held by the dispatcher.
@@ -289,46 +417,41 @@ func (d *AsyncCommandDispatcher) Close() error {
 // REQ-CD-050
 type QueuedCommandDispatcher struct {
 	commandRegistry
+	dispatcherConfig
 }
 
 // NewQueuedCommandDispatcher creates a new QueuedCommandDispatcher.
-func NewQueuedCommandDispatcher() *QueuedCommandDispatcher {
+func NewQueuedCommandDispatcher(opts ...DispatcherOption) *QueuedCommandDispatcher {
 	return &QueuedCommandDispatcher{
-		commandRegistry: newCommandRegistry(),
+		commandRegistry:  newCommandRegistry(),
+		dispatcherConfig: newDispatcherConfig(opts),
 	}
 }
 
 // Dispatch dispatches a command to all matching receivers sequentially and returns a Watchable.
 // REQ-CD-020, REQ-CD-050, REQ-CD-051, REQ-CD-052, REQ-CD-053
 func (d *QueuedCommandDispatcher) Dispatch(ctx context.Context, envelope CommandEnvelope[any]) *Watchable {
-	receivers := d.resolveReceivers(envelope.CommandType)
-	w := newWatchable(len(receivers))
-
-	// REQ-CD-022: no receivers match -> immediately complete
-	if len(receivers) == 0 {
-		close(w.results)
-		close(w.done)
-		return w
-	}
-
-	go func() {
-		defer close(w.results)
-		defer close(w.done)
-
-		for _, fn := range receivers {
-			// REQ-CD-053: check context cancellation between receivers
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
+	return d.dispatch(ctx, d.resolveReceivers(envelope.CommandType), envelope, runSequentially)
+}
 
-			// REQ-CD-051: send result before invoking next receiver
-			executeReceiver(fn, ctx, envelope, w)
+// runSequentially runs the receivers one after another in registration
+// order, stopping once ctx is done.
+func runSequentially(ctx context.Context, receivers []ReceiverFunc, envelope CommandEnvelope[any], w *Watchable) []error {
+	var errs []error
+	for _, fn := range receivers {
+		// REQ-CD-053: check context cancellation between receivers
+		select {
+		case <-ctx.Done():
+			return append(errs, ctx.Err())
+		default:
 		}
-	}()
 
-	return w
+		// REQ-CD-051: send result before invoking next receiver
+		if err := executeReceiver(fn, ctx, envelope, w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
 }
 
 // Close releases resources held by the dispatcher.
@@ -448,6 +448,49 @@ func TestRegisterWildcardReceiverCombinedWithSpecific(t *testing.T) {
 	})
 }
 
+func TestRegisterDefaultReceiver(t *testing.T) {
+	t.Parallel()
+
+	runForBothDispatchers(t, func(t *testing.T, name string, d cqrs.CommandDispatcher, regCU func(string, cqrs.CommandReceiver[CommandDispatcherTestCreateUser]) error, _ func(string, cqrs.CommandReceiver[CommandDispatcherTestUpdateUser]) error) {
+		defer func() { _ = d.Close() }()
+
+		var defaultCount int64
+		if err := d.RegisterDefaultReceiver(func(ctx context.Context, env cqrs.CommandEnvelope[any]) (any, error) {
+			atomic.AddInt64(&defaultCount, 1)
+			return nil, fmt.Errorf("unsupported command %q", env.CommandType)
+		}); err != nil {
+			t.Fatalf("Failed to register default: %v", err)
+		}
+		if err := regCU("user.create", func(ctx context.Context, env cqrs.CommandEnvelope[CommandDispatcherTestCreateUser]) (any, error) {
+			return "specific", nil
+		}); err != nil {
+			t.Fatalf("Failed to register specific: %v", err)
+		}
+
+		results := d.Dispatch(context.Background(), makeEnvelope("user.create", CommandDispatcherTestCreateUser{})).Wait()
+		if len(results) != 1 || results[0].Value != "specific" {
+			t.Errorf("Expected only the specific receiver for a matched command, got %+v", results)
+		}
+
+		results = d.Dispatch(context.Background(), makeEnvelope("order.refund", "p")).Wait()
+		if len(results) != 1 || results[0].Error == nil || !strings.Contains(results[0].Error.Error(), "order.refund") {
+			t.Errorf("Expected the default receiver's rejection, got %+v", results)
+		}
+		if got := atomic.LoadInt64(&defaultCount); got != 1 {
+			t.Errorf("Expected default receiver called once, got %d", got)
+		}
+
+		if err := d.RegisterDefaultReceiver(func(ctx context.Context, env cqrs.CommandEnvelope[any]) (any, error) {
+			return nil, nil
+		}); err == nil {
+			t.Error("Expected error registering a second default receiver")
+		}
+		if err := d.RegisterDefaultReceiver(nil); err == nil {
+			t.Error("Expected error for nil default receiver")
+		}
+	})
+}
+
 // =============================================================================
 // REQ-CD-020: Dispatch accepts context and CommandEnvelope[any], returns Watchable
 // =============================================================================
@@ -2035,3 +2078,87 @@ func TestCommandDispatcherMultipleDispatchesIndependent(t *testing.T) {
 		}
 	})
 }
+
+func TestFirstAs(t *testing.T) {
+	t.Parallel()
+
+	type userDTO struct{ Email string }
+	sentinel := errors.New("receiver failed")
+
+	tests := []struct {
+		name      string
+		receiver  func(context.Context, cqrs.CommandEnvelope[any]) (any, error)
+		wantValue userDTO
+		wantErr   error
+		wantMsg   string
+	}{
+		{
+			name: "typed value",
+			receiver: func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+				return userDTO{Email: "a@example.com"}, nil
+			},
+			wantValue: userDTO{Email: "a@example.com"},
+		},
+		{
+			name: "zero value is not nil",
+			receiver: func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+				return userDTO{}, nil
+			},
+		},
+		{
+			name: "nil value is not found",
+			receiver: func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+				return nil, nil
+			},
+			wantErr: cqrs.ErrNilResult,
+		},
+		{
+			name: "receiver error",
+			receiver: func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+				return nil, sentinel
+			},
+			wantErr: sentinel,
+		},
+		{
+			name: "type mismatch names both types",
+			receiver: func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+				return "a@example.com", nil
+			},
+			wantErr: cqrs.ErrResultType,
+			wantMsg: "expected cqrs_test.userDTO, got string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := cqrs.NewAsyncCommandDispatcher()
+			defer func() { _ = d.Close() }()
+			if err := d.RegisterWildcardReceiver(tt.receiver); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+
+			got, err := cqrs.FirstAs[userDTO](d.Dispatch(context.Background(), makeEnvelope("user.get", "u-1")))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FirstAs() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error %q does not contain %q", err, tt.wantMsg)
+			}
+			if got != tt.wantValue {
+				t.Errorf("FirstAs() = %+v, want %+v", got, tt.wantValue)
+			}
+		})
+	}
+
+	t.Run("no receivers", func(t *testing.T) {
+		t.Parallel()
+
+		d := cqrs.NewAsyncCommandDispatcher()
+		defer func() { _ = d.Close() }()
+		if _, err := cqrs.FirstAs[userDTO](d.Dispatch(context.Background(), makeEnvelope("user.get", "u-1"))); !errors.Is(err, cqrs.ErrNoResult) {
+			t.Fatalf("expected ErrNoResult, got %v", err)
+		}
+	})
+}
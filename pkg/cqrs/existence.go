@@ -0,0 +1,122 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+var (
+	// ErrAggregateNotFound is returned, wrapped, for a command whose target
+	// aggregate must exist but does not.
+	ErrAggregateNotFound = errors.New("aggregate not found")
+
+	// ErrAggregateExists is returned, wrapped, for a command whose target
+	// aggregate must not exist but does.
+	ErrAggregateExists = errors.New("aggregate already exists")
+)
+
+// ExistenceRequirement states whether a command's target aggregate must
+// already exist when the command is handled.
+type ExistenceRequirement int
+
+const (
+	// ExistenceUnchecked skips the existence check.
+	ExistenceUnchecked ExistenceRequirement = iota
+
+	// MustExist rejects the command with ErrAggregateNotFound when the
+	// aggregate does not exist, as for updates and deletes.
+	MustExist
+
+	// MustNotExist rejects the command with ErrAggregateExists when the
+	// aggregate already exists, as for creates.
+	MustNotExist
+)
+
+// ExistenceDeclarer is implemented by command payloads that state whether
+// their target aggregate must exist. ExistenceCheckMiddleware checks it
+// before the receiver runs; payloads that do not implement it, or return an
+// empty ID or ExistenceUnchecked, are not checked.
+type ExistenceDeclarer interface {
+	ExistenceRequirement() (aggregateID string, requirement ExistenceRequirement)
+}
+
+// ExistenceChecker reports whether an aggregate exists.
+type ExistenceChecker interface {
+	Exists(ctx context.Context, aggregateID string) (bool, error)
+}
+
+// ExistenceCheckerFunc adapts a function to ExistenceChecker.
+type ExistenceCheckerFunc func(ctx context.Context, aggregateID string) (bool, error)
+
+// Exists calls f.
+func (f ExistenceCheckerFunc) Exists(ctx context.Context, aggregateID string) (bool, error) {
+	return f(ctx, aggregateID)
+}
+
+// EventStoreExistence returns an ExistenceChecker that treats an aggregate as
+// existing once the store holds any event for it.
+func EventStoreExistence(store domain.EventStore) ExistenceChecker {
+	return ExistenceCheckerFunc(func(ctx context.Context, aggregateID string) (bool, error) {
+		version, err := store.GetCurrentVersion(ctx, aggregateID)
+		if err != nil {
+			return false, err
+		}
+		return version > 0, nil
+	})
+}
+
+// ExistenceCheckMiddleware rejects commands whose payload implements
+// ExistenceDeclarer when their target aggregate's existence does not match
+// the declared requirement, without calling the rest of the chain. The
+// receiver's CommandResult carries ErrAggregateNotFound or
+// ErrAggregateExists instead, so receivers need not load the aggregate just
+// to report a missing or duplicate one. A failed lookup rejects the command
+// with the checker's error. With several receivers matching a command the
+// check runs once per receiver.
+//
+// Middlewares run after WithAggregateSerialization has let the command
+// through, so the check sees the commands queued ahead for the aggregate
+// finished. It is still only an optimization: without serialization, or with
+// writers outside this dispatcher, the aggregate can be created or deleted
+// between the check and the receiver's save. The store's optimistic
+// concurrency check and unique constraints remain the authoritative
+// enforcement, so receivers must keep handling domain.ErrConcurrencyConflict
+// and domain.ErrStreamExists (or use domain.SaveIfNotExists for creates).
+func ExistenceCheckMiddleware(checker ExistenceChecker) CommandMiddleware {
+	return func(next ReceiverFunc) ReceiverFunc {
+		return func(ctx context.Context, env CommandEnvelope[any]) (any, error) {
+			if err := checkExistence(ctx, checker, env); err != nil {
+				return nil, err
+			}
+			return next(ctx, env)
+		}
+	}
+}
+
+// checkExistence returns ErrAggregateNotFound or ErrAggregateExists, wrapped,
+// when the payload declares an existence requirement its aggregate fails.
+func checkExistence(ctx context.Context, checker ExistenceChecker, envelope CommandEnvelope[any]) error {
+	declarer, ok := envelope.Payload.(ExistenceDeclarer)
+	if !ok {
+		return nil
+	}
+	aggregateID, requirement := declarer.ExistenceRequirement()
+	if aggregateID == "" || requirement == ExistenceUnchecked {
+		return nil
+	}
+
+	exists, err := checker.Exists(ctx, aggregateID)
+	if err != nil {
+		return fmt.Errorf("failed to check existence of aggregate %s: %w", aggregateID, err)
+	}
+	switch {
+	case requirement == MustExist && !exists:
+		return fmt.Errorf("%w: aggregate %s for command %q", ErrAggregateNotFound, aggregateID, envelope.CommandType)
+	case requirement == MustNotExist && exists:
+		return fmt.Errorf("%w: aggregate %s for command %q", ErrAggregateExists, aggregateID, envelope.CommandType)
+	}
+	return nil
+}
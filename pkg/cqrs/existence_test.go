@@ -0,0 +1,103 @@
+package cqrs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+type existenceCommand struct {
+	ID          string
+	Requirement cqrs.ExistenceRequirement
+}
+
+func (c existenceCommand) ExistenceRequirement() (string, cqrs.ExistenceRequirement) {
+	return c.ID, c.Requirement
+}
+
+func TestExistenceCheckMiddleware(t *testing.T) {
+	t.Parallel()
+
+	store := infrastructure.NewMemoryStore()
+	existing := domain.NewEventEnvelope[any](map[string]string{"name": "Ada"}, "user-1", "user.created", 1)
+	if err := store.Append(context.Background(), "user-1", 0, existing); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	lookupErr := errors.New("store down")
+
+	dispatchers := map[string]func(...cqrs.DispatcherOption) cqrs.CommandDispatcher{
+		"AsyncCommandDispatcher": func(opts ...cqrs.DispatcherOption) cqrs.CommandDispatcher {
+			return cqrs.NewAsyncCommandDispatcher(opts...)
+		},
+		"QueuedCommandDispatcher": func(opts ...cqrs.DispatcherOption) cqrs.CommandDispatcher {
+			return cqrs.NewQueuedCommandDispatcher(opts...)
+		},
+	}
+	tests := []struct {
+		name      string
+		checker   cqrs.ExistenceChecker
+		payload   any
+		wantCalls int32
+		wantErr   error
+	}{
+		{name: "update of existing aggregate runs", payload: existenceCommand{ID: "user-1", Requirement: cqrs.MustExist}, wantCalls: 1},
+		{name: "update of missing aggregate is not found", payload: existenceCommand{ID: "user-2", Requirement: cqrs.MustExist}, wantErr: cqrs.ErrAggregateNotFound},
+		{name: "create of new aggregate runs", payload: existenceCommand{ID: "user-2", Requirement: cqrs.MustNotExist}, wantCalls: 1},
+		{name: "create of existing aggregate conflicts", payload: existenceCommand{ID: "user-1", Requirement: cqrs.MustNotExist}, wantErr: cqrs.ErrAggregateExists},
+		{name: "unchecked requirement runs", payload: existenceCommand{ID: "user-2", Requirement: cqrs.ExistenceUnchecked}, wantCalls: 1},
+		{name: "non-declaring command runs", payload: CommandDispatcherTestCreateUser{}, wantCalls: 1},
+		{
+			name: "failed lookup rejects the command",
+			checker: cqrs.ExistenceCheckerFunc(func(context.Context, string) (bool, error) {
+				return false, lookupErr
+			}),
+			payload: existenceCommand{ID: "user-1", Requirement: cqrs.MustExist},
+			wantErr: lookupErr,
+		},
+	}
+
+	for dispatcherName, newDispatcher := range dispatchers {
+		for _, tt := range tests {
+			t.Run(dispatcherName+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				checker := tt.checker
+				if checker == nil {
+					checker = cqrs.EventStoreExistence(store)
+				}
+				d := newDispatcher(cqrs.WithAggregateSerialization(0))
+				d.Use(cqrs.ExistenceCheckMiddleware(checker))
+				var calls atomic.Int32
+				receiver := func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+					calls.Add(1)
+					return "ok", nil
+				}
+				if err := cqrs.RegisterReceiver(d, "user.change", cqrs.CommandReceiver[any](receiver)); err != nil {
+					t.Fatalf("RegisterReceiver() error = %v", err)
+				}
+
+				results := d.Dispatch(context.Background(), makeEnvelope("user.change", tt.payload)).Wait()
+
+				if got := calls.Load(); got != tt.wantCalls {
+					t.Errorf("receiver calls = %d, want %d", got, tt.wantCalls)
+				}
+				if tt.wantErr == nil {
+					for _, r := range results {
+						if r.Error != nil {
+							t.Errorf("unexpected result error: %v", r.Error)
+						}
+					}
+					return
+				}
+				if len(results) != 1 || !errors.Is(results[0].Error, tt.wantErr) {
+					t.Errorf("results = %+v, want a single result wrapping %v", results, tt.wantErr)
+				}
+			})
+		}
+	}
+}
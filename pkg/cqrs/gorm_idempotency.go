@@ -0,0 +1,190 @@
+package cqrs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ IdempotencyStore = (*GormIdempotencyStore)(nil)
+
+// GormIdempotencyKeyModel is one command idempotency key, claimed and, once
+// its command has been handled, completed with its results.
+type GormIdempotencyKeyModel struct {
+	Key       string    `gorm:"primaryKey;column:idempotency_key"`
+	Completed bool      `gorm:"column:completed"`
+	Results   string    `gorm:"column:results;type:text"`
+	CreatedAt time.Time `gorm:"column:created_at;index"`
+}
+
+// TableName returns the table name for the idempotency key model.
+func (GormIdempotencyKeyModel) TableName() string {
+	return "idempotency_keys"
+}
+
+// recordedResult is a CommandResult as stored in the results column.
+type recordedResult struct {
+	CommandType string `json:"command_type"`
+	Value       any    `json:"value,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// GormIdempotencyStore is an IdempotencyStore kept in an idempotency_keys
+// table, so duplicates are caught across processes sharing the database.
+// Results are stored as JSON: replayed values come back JSON-decoded (maps,
+// float64s and strings rather than the receiver's types), and replayed errors
+// keep only their message, so errors.Is and errors.As no longer match them.
+// Receivers whose results callers inspect should return JSON-friendly values.
+type GormIdempotencyStore struct {
+	db           *gorm.DB
+	ttl          time.Duration
+	claimTimeout time.Duration
+}
+
+// NewGormIdempotencyStore creates an idempotency store on db and auto-migrates
+// its table. Keys expire ttl after they were claimed; zero keeps them
+// forever. Claims that are neither completed nor released also expire after
+// the claim timeout (see WithClaimTimeout), so a claim left behind by a
+// crashed process does not block its command for good. An expired key no
+// longer deduplicates, and Cleanup deletes it. Pass the store to
+// NewDeduplicatingCommandBus.
+func NewGormIdempotencyStore(db *gorm.DB, ttl time.Duration, opts ...IdempotencyStoreOption) (*GormIdempotencyStore, error) {
+	if err := db.AutoMigrate(&GormIdempotencyKeyModel{}); err != nil {
+		return nil, fmt.Errorf("failed to auto-migrate idempotency key table: %w", err)
+	}
+	cfg := newIdempotencyStoreConfig(opts)
+	return &GormIdempotencyStore{db: db, ttl: ttl, claimTimeout: cfg.claimTimeout}, nil
+}
+
+// Claim implements IdempotencyStore. The claim is an insert guarded by
+// the key's primary key, so concurrent claims of one key have one winner.
+func (s *GormIdempotencyStore) Claim(ctx context.Context, key string) ([]CommandResult, bool, error) {
+	var results []CommandResult
+	claimed := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if expired, args := s.expiredCondition(time.Now()); expired != "" {
+			err := tx.Where("idempotency_key = ?", key).Where(expired, args...).
+				Delete(&GormIdempotencyKeyModel{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		insert := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&GormIdempotencyKeyModel{Key: key, CreatedAt: time.Now()})
+		if insert.Error != nil {
+			return insert.Error
+		}
+		if insert.RowsAffected == 1 {
+			claimed = true
+			return nil
+		}
+
+		var row GormIdempotencyKeyModel
+		if err := tx.Where("idempotency_key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+		if !row.Completed {
+			return fmt.Errorf("%w: %q", ErrCommandInProgress, key)
+		}
+		var err error
+		results, err = decodeResults(row.Results)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return results, claimed, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *GormIdempotencyStore) Complete(ctx context.Context, key string, results []CommandResult) error {
+	encoded, err := encodeResults(results)
+	if err != nil {
+		return err
+	}
+	row := GormIdempotencyKeyModel{Key: key, Completed: true, Results: encoded, CreatedAt: time.Now()}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "idempotency_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"completed", "results"}),
+	}).Create(&row).Error
+}
+
+// Release implements IdempotencyStore.
+func (s *GormIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("idempotency_key = ? AND completed = ?", key, false).
+		Delete(&GormIdempotencyKeyModel{}).Error
+}
+
+// Cleanup deletes the keys that have expired and returns how many it deleted.
+// Run it periodically; it is a no-op on a store where neither keys nor claims
+// expire.
+func (s *GormIdempotencyStore) Cleanup(ctx context.Context) (int64, error) {
+	expired, args := s.expiredCondition(time.Now())
+	if expired == "" {
+		return 0, nil
+	}
+	result := s.db.WithContext(ctx).Where(expired, args...).
+		Delete(&GormIdempotencyKeyModel{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to clean up idempotency keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// expiredCondition returns the condition matching the keys expired at now,
+// any key past the TTL and a claim not yet completed past the claim timeout,
+// or "" when neither expires.
+func (s *GormIdempotencyStore) expiredCondition(now time.Time) (string, []any) {
+	var conditions []string
+	var args []any
+	if s.ttl > 0 {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, now.Add(-s.ttl))
+	}
+	if s.claimTimeout > 0 {
+		conditions = append(conditions, "(completed = ? AND created_at < ?)")
+		args = append(args, false, now.Add(-s.claimTimeout))
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// encodeResults serializes results for the results column.
+func encodeResults(results []CommandResult) (string, error) {
+	recorded := make([]recordedResult, len(results))
+	for i, result := range results {
+		recorded[i] = recordedResult{CommandType: result.CommandType, Value: result.Value}
+		if result.Error != nil {
+			recorded[i].Error = result.Error.Error()
+		}
+	}
+	data, err := json.Marshal(recorded)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode command results: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodeResults reverses encodeResults.
+func decodeResults(data string) ([]CommandResult, error) {
+	var recorded []recordedResult
+	if err := json.Unmarshal([]byte(data), &recorded); err != nil {
+		return nil, fmt.Errorf("failed to decode command results: %w", err)
+	}
+	results := make([]CommandResult, len(recorded))
+	for i, r := range recorded {
+		results[i] = CommandResult{CommandType: r.CommandType, Value: r.Value}
+		if r.Error != "" {
+			results[i].Error = errors.New(r.Error)
+		}
+	}
+	return results, nil
+}
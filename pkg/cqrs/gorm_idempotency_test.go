@@ -0,0 +1,140 @@
+package cqrs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type keyedCommand struct {
+	Key string
+}
+
+func (c keyedCommand) IdempotencyKey() string { return c.Key }
+
+func newIdempotencyTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open in-memory sqlite: %v", err)
+	}
+	return db
+}
+
+func TestGormIdempotencyStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	store, err := cqrs.NewGormIdempotencyStore(newIdempotencyTestDB(t), 0)
+	if err != nil {
+		t.Fatalf("NewGormIdempotencyStore() error = %v", err)
+	}
+
+	d := cqrs.NewDeduplicatingCommandBus(cqrs.NewQueuedCommandDispatcher(), store)
+	var calls atomic.Int32
+	if err := d.RegisterWildcardReceiver(func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+		calls.Add(1)
+		return map[string]any{"id": "user-1"}, nil
+	}); err != nil {
+		t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+	}
+
+	envelope := cqrs.ToAnyCommandEnvelope(cqrs.NewCommandEnvelope(keyedCommand{Key: "req-1"}, "user.create"))
+	for i := range 2 {
+		results := d.Dispatch(ctx, envelope).Wait()
+		if len(results) != 1 || results[0].Error != nil || results[0].Value.(map[string]any)["id"] != "user-1" {
+			t.Errorf("dispatch %d results = %+v, want the created ID", i, results)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("receiver calls = %d, want 1", got)
+	}
+
+	if _, claimed, err := store.Claim(ctx, "req-2"); err != nil || !claimed {
+		t.Fatalf("Claim() = %v, %v, want claimed", claimed, err)
+	}
+	if _, _, err := store.Claim(ctx, "req-2"); !errors.Is(err, cqrs.ErrCommandInProgress) {
+		t.Errorf("second Claim() error = %v, want ErrCommandInProgress", err)
+	}
+	if err := store.Release(ctx, "req-2"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, claimed, err := store.Claim(ctx, "req-2"); err != nil || !claimed {
+		t.Errorf("Claim() after Release = %v, %v, want claimed", claimed, err)
+	}
+
+	failed := []cqrs.CommandResult{{CommandType: "user.create", Error: errors.New("email taken")}}
+	if err := store.Complete(ctx, "req-2", failed); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if results, claimed, err := store.Claim(ctx, "req-2"); err != nil || claimed || len(results) != 1 || results[0].Error.Error() != "email taken" {
+		t.Errorf("Claim() = %+v, %v, %v, want the recorded error", results, claimed, err)
+	}
+}
+
+func TestGormIdempotencyStore_TTL(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	store, err := cqrs.NewGormIdempotencyStore(newIdempotencyTestDB(t), 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewGormIdempotencyStore() error = %v", err)
+	}
+	for _, key := range []string{"req-1", "req-2"} {
+		if _, _, err := store.Claim(ctx, key); err != nil {
+			t.Fatalf("Claim(%q) error = %v", key, err)
+		}
+		if err := store.Complete(ctx, key, nil); err != nil {
+			t.Fatalf("Complete(%q) error = %v", key, err)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, claimed, err := store.Claim(ctx, "req-1"); err != nil || !claimed {
+		t.Errorf("Claim() of an expired key = %v, %v, want claimed again", claimed, err)
+	}
+	if n, err := store.Cleanup(ctx); err != nil || n != 1 {
+		t.Errorf("Cleanup() = %d, %v, want the other expired key deleted", n, err)
+	}
+}
+
+func TestGormIdempotencyStore_ClaimTimeout(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	store, err := cqrs.NewGormIdempotencyStore(newIdempotencyTestDB(t), 0, cqrs.WithClaimTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewGormIdempotencyStore() error = %v", err)
+	}
+	for _, key := range []string{"abandoned", "completed", "stale"} {
+		if _, claimed, err := store.Claim(ctx, key); err != nil || !claimed {
+			t.Fatalf("Claim(%q) = %v, %v, want claimed", key, claimed, err)
+		}
+	}
+	if err := store.Complete(ctx, "completed", nil); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if _, _, err := store.Claim(ctx, "abandoned"); !errors.Is(err, cqrs.ErrCommandInProgress) {
+		t.Fatalf("Claim() of a live claim error = %v, want ErrCommandInProgress", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, claimed, err := store.Claim(ctx, "abandoned"); err != nil || !claimed {
+		t.Errorf("Claim() of an expired claim = %v, %v, want claimed again", claimed, err)
+	}
+	if _, claimed, err := store.Claim(ctx, "completed"); err != nil || claimed {
+		t.Errorf("Claim() of a completed key = %v, %v, want it kept", claimed, err)
+	}
+	if n, err := store.Cleanup(ctx); err != nil || n != 1 {
+		t.Errorf("Cleanup() = %d, %v, want the stale claim deleted", n, err)
+	}
+}
@@ -0,0 +1,331 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyClaimTimeout is how long an idempotency store holds a
+// claim that is neither completed nor released, such as one left behind by a
+// crashed process, unless WithClaimTimeout sets another timeout.
+const DefaultIdempotencyClaimTimeout = 5 * time.Minute
+
+// ErrCommandInProgress is returned, wrapped, for a command whose idempotency
+// key is held by a dispatch that has not finished yet. Retry it once the
+// original completes to receive its results.
+var ErrCommandInProgress = errors.New("command with this idempotency key is in progress")
+
+// IdempotentCommand is implemented by command payloads carrying an
+// idempotency key, such as one supplied by an API client for its retries.
+// DeduplicatingCommandBus handles commands sharing a key once; payloads that
+// do not implement it, or return an empty key, are always handled.
+type IdempotentCommand interface {
+	IdempotencyKey() string
+}
+
+// IdempotencyStore records the idempotency keys of handled commands together
+// with their results. Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Claim reserves key for a dispatch about to handle its command. When key
+	// was already completed it returns the recorded results and claimed is
+	// false; when another dispatch holds the claim it returns
+	// ErrCommandInProgress.
+	Claim(ctx context.Context, key string) (results []CommandResult, claimed bool, err error)
+
+	// Complete records the results of the claimed key's command.
+	Complete(ctx context.Context, key string, results []CommandResult) error
+
+	// Release gives up a claim without recording results, so the command is
+	// handled again on its next dispatch.
+	Release(ctx context.Context, key string) error
+}
+
+// DeduplicatingCommandBus decorates a CommandDispatcher so each command
+// whose payload implements IdempotentCommand is handled at most once per
+// key: a repeat of a completed command is not dispatched, and its Watchable
+// replays the results recorded the first time, errors included. A repeat
+// arriving while the original is still being handled is rejected with
+// ErrCommandInProgress. A keyed command's results are delivered together
+// once the key is completed, so a caller may retry as soon as it has a
+// result.
+//
+// The key is claimed before the command reaches the wrapped dispatcher, so
+// the retry of a create that succeeded gets the original result rather than
+// ErrAggregateExists from ExistenceCheckMiddleware. A command with no
+// receivers, rejected before its receivers run, rejected as invalid (see
+// ValidationMiddleware), or whose receivers return an error that is not
+// final (see WithFinalErrors) releases its key, so it is handled again on its
+// next dispatch; with several receivers, those that succeeded run again too.
+// Once its receivers have finished, a command's results are recorded even
+// if its ctx is done by then. A store failure rejects the command; a failure
+// to record the results is logged and the results are returned anyway.
+type DeduplicatingCommandBus struct {
+	CommandDispatcher
+	store   IdempotencyStore
+	isFinal func(err error) bool
+}
+
+var _ CommandDispatcher = (*DeduplicatingCommandBus)(nil)
+
+// DeduplicatingOption configures a DeduplicatingCommandBus.
+type DeduplicatingOption func(*DeduplicatingCommandBus)
+
+// WithFinalErrors records receiver errors for which isFinal returns true,
+// such as a business rule rejecting the command, so a repeat of the command
+// replays them instead of being handled again. By default every receiver
+// error releases the key, so transient failures are retried. A
+// ValidationError always releases it.
+func WithFinalErrors(isFinal func(err error) bool) DeduplicatingOption {
+	return func(b *DeduplicatingCommandBus) {
+		b.isFinal = isFinal
+	}
+}
+
+// NewDeduplicatingCommandBus wraps next, recording the keys of handled
+// commands in store. Receivers and middlewares may be registered through the
+// bus or next alike.
+func NewDeduplicatingCommandBus(next CommandDispatcher, store IdempotencyStore, opts ...DeduplicatingOption) *DeduplicatingCommandBus {
+	b := &DeduplicatingCommandBus{CommandDispatcher: next, store: store}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Dispatch claims the command's idempotency key and dispatches it to the
+// wrapped dispatcher, or replays the results recorded for the key.
+func (b *DeduplicatingCommandBus) Dispatch(ctx context.Context, envelope CommandEnvelope[any]) *Watchable {
+	command, ok := envelope.Payload.(IdempotentCommand)
+	if !ok || command.IdempotencyKey() == "" {
+		return b.CommandDispatcher.Dispatch(ctx, envelope)
+	}
+	key := command.IdempotencyKey()
+
+	recorded, claimed, err := b.store.Claim(ctx, key)
+	if err != nil {
+		w := newWatchable(1)
+		rejectCommand(w, envelope, fmt.Errorf("failed to claim idempotency key %q for command %q: %w", key, envelope.CommandType, err))
+		return w
+	}
+	if !claimed {
+		w := newWatchable(len(recorded))
+		for _, result := range recorded {
+			w.results <- result
+		}
+		close(w.results)
+		close(w.done)
+		return w
+	}
+
+	inner := b.CommandDispatcher.Dispatch(ctx, envelope)
+	w := newWatchable(cap(inner.results))
+	go func() {
+		results := inner.Wait()
+		if len(results) == 0 || inner.rejected || b.retryable(results) {
+			b.release(ctx, key)
+		} else if err := b.store.Complete(context.WithoutCancel(ctx), key, results); err != nil {
+			LoggerFromContext(ctx).WarnContext(ctx, "failed to record command results for idempotency key",
+				slog.String("idempotency_key", key), slog.Any("error", err))
+		}
+		for _, result := range results {
+			w.results <- result
+		}
+		close(w.results)
+		close(w.done)
+	}()
+	return w
+}
+
+// addReceiver lets RegisterReceiver register through the bus.
+func (b *DeduplicatingCommandBus) addReceiver(commandType string, fn ReceiverFunc) error {
+	reg, ok := b.CommandDispatcher.(receiverRegistrar)
+	if !ok {
+		return fmt.Errorf("dispatcher does not support receiver registration")
+	}
+	return reg.addReceiver(commandType, fn)
+}
+
+// release gives up the claim on key.
+func (b *DeduplicatingCommandBus) release(ctx context.Context, key string) {
+	ctx = context.WithoutCancel(ctx)
+	if err := b.store.Release(ctx, key); err != nil {
+		LoggerFromContext(ctx).WarnContext(ctx, "failed to release idempotency key",
+			slog.String("idempotency_key", key), slog.Any("error", err))
+	}
+}
+
+// retryable reports whether results include an error the command should be
+// handled again after: a ValidationError, or an error WithFinalErrors does
+// not classify as final.
+func (b *DeduplicatingCommandBus) retryable(results []CommandResult) bool {
+	for _, result := range results {
+		if result.Error == nil {
+			continue
+		}
+		var validationErr *ValidationError
+		if errors.As(result.Error, &validationErr) || b.isFinal == nil || !b.isFinal(result.Error) {
+			return true
+		}
+	}
+	return false
+}
+
+// IdempotencyStoreOption configures a MemoryIdempotencyStore or a
+// GormIdempotencyStore.
+type IdempotencyStoreOption func(*idempotencyStoreConfig)
+
+type idempotencyStoreConfig struct {
+	claimTimeout time.Duration
+}
+
+// WithClaimTimeout sets how long a claim that is neither completed nor
+// released holds its key, DefaultIdempotencyClaimTimeout by default. Once it
+// expires the key may be claimed again, so a process that crashed while
+// handling a command does not block its retries forever; zero keeps claims
+// until they are completed or released. Set it well above the longest a
+// command may take: a dispatch outliving its claim may be handled twice.
+func WithClaimTimeout(timeout time.Duration) IdempotencyStoreOption {
+	return func(c *idempotencyStoreConfig) {
+		c.claimTimeout = timeout
+	}
+}
+
+// newIdempotencyStoreConfig applies opts to the default configuration.
+func newIdempotencyStoreConfig(opts []IdempotencyStoreOption) idempotencyStoreConfig {
+	cfg := idempotencyStoreConfig{claimTimeout: DefaultIdempotencyClaimTimeout}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore. Recorded results
+// are kept as they are, values and errors alike.
+type MemoryIdempotencyStore struct {
+	ttl          time.Duration
+	claimTimeout time.Duration
+	mu           sync.Mutex
+	entries      map[string]*memoryIdempotencyEntry
+	lastSweep    time.Time
+}
+
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+type memoryIdempotencyEntry struct {
+	claimedAt time.Time
+	completed bool
+	results   []CommandResult
+}
+
+// NewMemoryIdempotencyStore creates a MemoryIdempotencyStore whose keys
+// expire ttl after they were claimed; zero keeps them forever. Expired keys
+// are forgotten, so their commands are handled again. Claims that are neither
+// completed nor released also expire after the claim timeout (see
+// WithClaimTimeout). Claim drops expired keys from memory at most once per
+// ttl or claim timeout, whichever is shorter, and Cleanup drops them on
+// demand.
+func NewMemoryIdempotencyStore(ttl time.Duration, opts ...IdempotencyStoreOption) *MemoryIdempotencyStore {
+	cfg := newIdempotencyStoreConfig(opts)
+	return &MemoryIdempotencyStore{
+		ttl:          ttl,
+		claimTimeout: cfg.claimTimeout,
+		entries:      make(map[string]*memoryIdempotencyEntry),
+	}
+}
+
+// Claim implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Claim(_ context.Context, key string) ([]CommandResult, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if interval := s.sweepInterval(); interval > 0 && now.Sub(s.lastSweep) >= interval {
+		s.sweep(now)
+	}
+	if entry, ok := s.entries[key]; ok && !s.expired(entry, now) {
+		if !entry.completed {
+			return nil, false, fmt.Errorf("%w: %q", ErrCommandInProgress, key)
+		}
+		return slices.Clone(entry.results), false, nil
+	}
+	s.entries[key] = &memoryIdempotencyEntry{claimedAt: now}
+	return nil, true, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, results []CommandResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryIdempotencyEntry{claimedAt: time.Now()}
+		s.entries[key] = entry
+	}
+	entry.completed = true
+	entry.results = slices.Clone(results)
+	return nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && !entry.completed {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Cleanup deletes the keys that have expired and returns how many it deleted.
+// It is a no-op on a store where neither keys nor claims expire.
+func (s *MemoryIdempotencyStore) Cleanup(_ context.Context) (int64, error) {
+	if s.sweepInterval() <= 0 {
+		return 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sweep(time.Now()), nil
+}
+
+// sweepInterval returns the shorter of the ttl and the claim timeout that
+// are set, or zero when neither is.
+func (s *MemoryIdempotencyStore) sweepInterval() time.Duration {
+	switch {
+	case s.ttl <= 0:
+		return max(s.claimTimeout, 0)
+	case s.claimTimeout <= 0:
+		return s.ttl
+	default:
+		return min(s.ttl, s.claimTimeout)
+	}
+}
+
+// expired reports whether entry has expired at now: any key past the ttl,
+// and a claim not yet completed past the claim timeout.
+func (s *MemoryIdempotencyStore) expired(entry *memoryIdempotencyEntry, now time.Time) bool {
+	age := now.Sub(entry.claimedAt)
+	if s.ttl > 0 && age >= s.ttl {
+		return true
+	}
+	return !entry.completed && s.claimTimeout > 0 && age >= s.claimTimeout
+}
+
+// sweep deletes the keys expired at now. s.mu must be held.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) int64 {
+	var deleted int64
+	for key, entry := range s.entries {
+		if s.expired(entry, now) {
+			delete(s.entries, key)
+			deleted++
+		}
+	}
+	s.lastSweep = now
+	return deleted
+}
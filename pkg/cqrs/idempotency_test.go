@@ -0,0 +1,297 @@
+package cqrs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+)
+
+type idempotentCreateUser struct {
+	Key   string
+	Email string
+}
+
+func (c idempotentCreateUser) IdempotencyKey() string { return c.Key }
+
+func (c idempotentCreateUser) Validate() error {
+	if c.Email == "" {
+		return errEmailRequired
+	}
+	return nil
+}
+
+func TestDeduplicatingCommandBus(t *testing.T) {
+	t.Parallel()
+
+	dispatchers := map[string]func() cqrs.CommandDispatcher{
+		"AsyncCommandDispatcher":  func() cqrs.CommandDispatcher { return cqrs.NewAsyncCommandDispatcher() },
+		"QueuedCommandDispatcher": func() cqrs.CommandDispatcher { return cqrs.NewQueuedCommandDispatcher() },
+	}
+
+	for name, newDispatcher := range dispatchers {
+		t.Run(name+"/a repeated command is handled once", func(t *testing.T) {
+			t.Parallel()
+
+			d := cqrs.NewDeduplicatingCommandBus(newDispatcher(), cqrs.NewMemoryIdempotencyStore(0))
+			var calls atomic.Int32
+			if err := cqrs.RegisterReceiver(d, "user.create", cqrs.CommandReceiver[idempotentCreateUser](func(context.Context, cqrs.CommandEnvelope[idempotentCreateUser]) (any, error) {
+				return calls.Add(1), nil
+			})); err != nil {
+				t.Fatalf("RegisterReceiver() error = %v", err)
+			}
+
+			command := idempotentCreateUser{Key: "req-1", Email: "a@example.com"}
+			first, err := cqrs.FirstAs[int32](d.Dispatch(context.Background(), makeEnvelope("user.create", command)))
+			if err != nil {
+				t.Fatalf("first dispatch error = %v", err)
+			}
+			second, err := cqrs.FirstAs[int32](d.Dispatch(context.Background(), makeEnvelope("user.create", command)))
+			if err != nil {
+				t.Fatalf("second dispatch error = %v", err)
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("receiver calls = %d, want 1", got)
+			}
+			if first != 1 || second != 1 {
+				t.Errorf("results = %d, %d, want the first result replayed", first, second)
+			}
+
+			// Another key is handled on its own.
+			other := idempotentCreateUser{Key: "req-2", Email: "a@example.com"}
+			if _, err := cqrs.FirstAs[int32](d.Dispatch(context.Background(), makeEnvelope("user.create", other))); err != nil {
+				t.Fatalf("dispatch error = %v", err)
+			}
+			if got := calls.Load(); got != 2 {
+				t.Errorf("receiver calls = %d, want 2", got)
+			}
+		})
+	}
+
+	t.Run("final receiver errors are replayed", func(t *testing.T) {
+		t.Parallel()
+
+		sentinel := errors.New("email taken")
+		d := cqrs.NewDeduplicatingCommandBus(cqrs.NewQueuedCommandDispatcher(), cqrs.NewMemoryIdempotencyStore(0),
+			cqrs.WithFinalErrors(func(err error) bool { return errors.Is(err, sentinel) }))
+		var calls atomic.Int32
+		if err := d.RegisterWildcardReceiver(func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+			calls.Add(1)
+			return nil, sentinel
+		}); err != nil {
+			t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+		}
+
+		command := idempotentCreateUser{Key: "req-1", Email: "a@example.com"}
+		for range 2 {
+			results := d.Dispatch(context.Background(), makeEnvelope("user.create", command)).Wait()
+			if len(results) != 1 || !errors.Is(results[0].Error, sentinel) {
+				t.Errorf("results = %+v, want the receiver's error", results)
+			}
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("receiver calls = %d, want 1", got)
+		}
+	})
+
+	t.Run("other receiver errors release the key", func(t *testing.T) {
+		t.Parallel()
+
+		d := cqrs.NewDeduplicatingCommandBus(cqrs.NewQueuedCommandDispatcher(), cqrs.NewMemoryIdempotencyStore(0),
+			cqrs.WithFinalErrors(func(err error) bool { return false }))
+		unavailable := errors.New("database unavailable")
+		var calls atomic.Int32
+		if err := d.RegisterWildcardReceiver(func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+			if calls.Add(1) == 1 {
+				return nil, unavailable
+			}
+			return "ok", nil
+		}); err != nil {
+			t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+		}
+
+		command := idempotentCreateUser{Key: "req-1", Email: "a@example.com"}
+		if results := d.Dispatch(context.Background(), makeEnvelope("user.create", command)).Wait(); len(results) != 1 || !errors.Is(results[0].Error, unavailable) {
+			t.Fatalf("first results = %+v, want the receiver's error", results)
+		}
+		for range 2 {
+			if got, err := cqrs.FirstAs[string](d.Dispatch(context.Background(), makeEnvelope("user.create", command))); err != nil || got != "ok" {
+				t.Errorf("retry = %q, %v, want the retried result", got, err)
+			}
+		}
+		if got := calls.Load(); got != 2 {
+			t.Errorf("receiver calls = %d, want the failure retried once and the success recorded", got)
+		}
+	})
+
+	t.Run("results are recorded when ctx is done after the receivers return", func(t *testing.T) {
+		t.Parallel()
+
+		d := cqrs.NewDeduplicatingCommandBus(cqrs.NewQueuedCommandDispatcher(), cqrs.NewMemoryIdempotencyStore(0))
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls atomic.Int32
+		if err := d.RegisterWildcardReceiver(func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+			calls.Add(1)
+			// The caller gives up as the receiver returns, after its events
+			// were committed.
+			defer cancel()
+			return "ok", nil
+		}); err != nil {
+			t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+		}
+
+		command := idempotentCreateUser{Key: "req-1", Email: "a@example.com"}
+		d.Dispatch(ctx, makeEnvelope("user.create", command)).Wait()
+		if got, err := cqrs.FirstAs[string](d.Dispatch(context.Background(), makeEnvelope("user.create", command))); err != nil || got != "ok" {
+			t.Errorf("retry = %q, %v, want the recorded result", got, err)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("receiver calls = %d, want 1", got)
+		}
+	})
+
+	t.Run("invalid commands do not claim their key", func(t *testing.T) {
+		t.Parallel()
+
+		d := cqrs.NewDeduplicatingCommandBus(cqrs.NewQueuedCommandDispatcher(), cqrs.NewMemoryIdempotencyStore(0))
+		d.Use(cqrs.ValidationMiddleware())
+		var calls atomic.Int32
+		if err := d.RegisterWildcardReceiver(func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+			calls.Add(1)
+			return "ok", nil
+		}); err != nil {
+			t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+		}
+
+		d.Dispatch(context.Background(), makeEnvelope("user.create", idempotentCreateUser{Key: "req-1"})).Wait()
+		d.Dispatch(context.Background(), makeEnvelope("user.create", idempotentCreateUser{Key: "req-1", Email: "a@example.com"})).Wait()
+		if got := calls.Load(); got != 1 {
+			t.Errorf("receiver calls = %d, want the corrected command handled", got)
+		}
+	})
+
+	t.Run("commands without receivers do not claim their key", func(t *testing.T) {
+		t.Parallel()
+
+		d := cqrs.NewDeduplicatingCommandBus(cqrs.NewQueuedCommandDispatcher(), cqrs.NewMemoryIdempotencyStore(0))
+		command := idempotentCreateUser{Key: "req-1", Email: "a@example.com"}
+		if results := d.Dispatch(context.Background(), makeEnvelope("user.create", command)).Wait(); len(results) != 0 {
+			t.Fatalf("results = %+v, want none without receivers", results)
+		}
+
+		var calls atomic.Int32
+		if err := cqrs.RegisterReceiver(d, "user.create", cqrs.CommandReceiver[idempotentCreateUser](func(context.Context, cqrs.CommandEnvelope[idempotentCreateUser]) (any, error) {
+			return calls.Add(1), nil
+		})); err != nil {
+			t.Fatalf("RegisterReceiver() error = %v", err)
+		}
+		d.Dispatch(context.Background(), makeEnvelope("user.create", command)).Wait()
+		if got := calls.Load(); got != 1 {
+			t.Errorf("receiver calls = %d, want the command handled once a receiver exists", got)
+		}
+	})
+
+	t.Run("a duplicate in flight is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		d := cqrs.NewDeduplicatingCommandBus(cqrs.NewAsyncCommandDispatcher(), cqrs.NewMemoryIdempotencyStore(0))
+		release := make(chan struct{})
+		if err := d.RegisterWildcardReceiver(func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+			<-release
+			return "ok", nil
+		}); err != nil {
+			t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+		}
+
+		command := idempotentCreateUser{Key: "req-1", Email: "a@example.com"}
+		original := d.Dispatch(context.Background(), makeEnvelope("user.create", command))
+		results := d.Dispatch(context.Background(), makeEnvelope("user.create", command)).Wait()
+		if len(results) != 1 || !errors.Is(results[0].Error, cqrs.ErrCommandInProgress) {
+			t.Errorf("results = %+v, want ErrCommandInProgress", results)
+		}
+		close(release)
+		original.Wait()
+	})
+}
+
+func TestMemoryIdempotencyStoreTTL(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	store := cqrs.NewMemoryIdempotencyStore(10 * time.Millisecond)
+	if _, claimed, err := store.Claim(ctx, "req-1"); err != nil || !claimed {
+		t.Fatalf("Claim() = %v, %v, want claimed", claimed, err)
+	}
+	if err := store.Complete(ctx, "req-1", []cqrs.CommandResult{{Value: "ok"}}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if results, claimed, err := store.Claim(ctx, "req-1"); err != nil || claimed || len(results) != 1 {
+		t.Fatalf("Claim() = %v, %v, %v, want the recorded results", results, claimed, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, claimed, err := store.Claim(ctx, "req-1"); err != nil || !claimed {
+		t.Errorf("Claim() after expiry = %v, %v, want claimed again", claimed, err)
+	}
+}
+
+func TestMemoryIdempotencyStoreClaimTimeout(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	store := cqrs.NewMemoryIdempotencyStore(0, cqrs.WithClaimTimeout(10*time.Millisecond))
+	for _, key := range []string{"abandoned", "completed"} {
+		if _, claimed, err := store.Claim(ctx, key); err != nil || !claimed {
+			t.Fatalf("Claim(%q) = %v, %v, want claimed", key, claimed, err)
+		}
+	}
+	if err := store.Complete(ctx, "completed", []cqrs.CommandResult{{Value: "ok"}}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if _, _, err := store.Claim(ctx, "abandoned"); !errors.Is(err, cqrs.ErrCommandInProgress) {
+		t.Fatalf("Claim() of a live claim error = %v, want ErrCommandInProgress", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, claimed, err := store.Claim(ctx, "abandoned"); err != nil || !claimed {
+		t.Errorf("Claim() of an expired claim = %v, %v, want claimed again", claimed, err)
+	}
+	if results, claimed, err := store.Claim(ctx, "completed"); err != nil || claimed || len(results) != 1 {
+		t.Errorf("Claim() of a completed key = %v, %v, %v, want the recorded results kept", results, claimed, err)
+	}
+}
+
+func TestMemoryIdempotencyStoreDropsExpiredKeys(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	store := cqrs.NewMemoryIdempotencyStore(10 * time.Millisecond)
+	for _, key := range []string{"req-1", "req-2"} {
+		if _, claimed, err := store.Claim(ctx, key); err != nil || !claimed {
+			t.Fatalf("Claim(%q) = %v, %v, want claimed", key, claimed, err)
+		}
+	}
+	if n, err := store.Cleanup(ctx); err != nil || n != 0 {
+		t.Fatalf("Cleanup() before expiry = %d, %v, want nothing deleted", n, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if n, err := store.Cleanup(ctx); err != nil || n != 2 {
+		t.Errorf("Cleanup() = %d, %v, want both expired keys deleted", n, err)
+	}
+
+	// Claim sweeps expired keys on its own.
+	if _, _, err := store.Claim(ctx, "req-3"); err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, _, err := store.Claim(ctx, "req-4"); err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if n, err := store.Cleanup(ctx); err != nil || n != 0 {
+		t.Errorf("Cleanup() = %d, %v, want req-3 already dropped by Claim", n, err)
+	}
+}
@@ -0,0 +1,155 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// loggedMetadataKeys are command metadata keys copied onto the scoped logger
+// when present, so every log line in a receiver carries them.
+var loggedMetadataKeys = []string{domain.MetadataCorrelationID, domain.MetadataAccountID, domain.MetadataActorID}
+
+// MetadataProvider is implemented by command payloads carrying context that
+// should be stamped on every event emitted while the command is handled, such
+// as a feature-flag cohort or A/B variant. The dispatcher records it with
+// domain.ContextWithEventMetadata and the unit of work merges it at commit.
+// Reserved keys (domain.IsReservedMetadataKey) are ignored with a warning.
+type MetadataProvider interface {
+	EventMetadata() map[string]string
+}
+
+// DispatcherOption configures an AsyncCommandDispatcher or QueuedCommandDispatcher.
+type DispatcherOption func(*dispatcherConfig)
+
+// dispatcherConfig holds settings shared by both dispatcher implementations.
+type dispatcherConfig struct {
+	logger *slog.Logger
+
+	// Request logging; see WithRequestLogging.
+	requestLogging bool
+	redactPayload  func(payload any) any
+
+	// Per-aggregate serialization; see WithAggregateSerialization.
+	serializer *aggregateSerializer
+}
+
+func newDispatcherConfig(opts []DispatcherOption) dispatcherConfig {
+	cfg := dispatcherConfig{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.logger == nil {
+		cfg.logger = slog.Default()
+	}
+	return cfg
+}
+
+// WithLogger sets the base logger from which each dispatch derives a
+// command-scoped child logger. The default is slog.Default().
+func WithLogger(logger *slog.Logger) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.logger = logger
+	}
+}
+
+// WithRequestLogging logs the start and end of every dispatched command
+// through its command-scoped logger, so each line carries the command type
+// and ID plus the correlation, account (tenant) and actor IDs from the command
+// metadata. The end line adds the duration in milliseconds and the outcome
+// ("success" or "error", with the receivers' errors). Payloads are never
+// logged, since they may hold personal data; see WithRedactedPayloadLogging.
+func WithRequestLogging() DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.requestLogging = true
+	}
+}
+
+// WithRedactedPayloadLogging enables WithRequestLogging and adds the payload,
+// as returned by redact, to the start line. redact must strip or mask every
+// sensitive field; a nil redact leaves payloads unlogged.
+func WithRedactedPayloadLogging(redact func(payload any) any) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.requestLogging = true
+		c.redactPayload = redact
+	}
+}
+
+// ContextWithLogger returns a copy of ctx carrying logger. It is
+// domain.ContextWithLogger, so event handlers dispatched under ctx see the
+// same logger.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return domain.ContextWithLogger(ctx, logger)
+}
+
+// LoggerFromContext returns the command-scoped logger installed by the
+// dispatcher, or slog.Default() when ctx carries none. Receivers should log
+// through it so lines are consistently tagged with the command type, command
+// ID and any correlation/account IDs from the command metadata.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	return domain.LoggerFromContext(ctx)
+}
+
+// commandContext derives the context receivers run with: a child of the base
+// logger enriched with command fields, the command type recorded as the
+// trigger of any events committed while handling it (see
+// domain.ContextWithTrigger), the command's correlation ID (or its own ID)
+// and its ID as their cause (see domain.ContextWithCorrelation), and the
+// payload's MetadataProvider metadata.
+// slog.Logger.With never mutates its receiver, so concurrent dispatches
+// cannot leak fields into each other.
+func (c *dispatcherConfig) commandContext(ctx context.Context, envelope CommandEnvelope[any]) context.Context {
+	attrs := []any{
+		slog.String("command_type", envelope.CommandType),
+		slog.String("command_id", envelope.ID),
+	}
+	for _, key := range loggedMetadataKeys {
+		if v, ok := envelope.Metadata[key]; ok {
+			attrs = append(attrs, slog.Any(key, v))
+		}
+	}
+	logger := c.logger.With(attrs...)
+	ctx = domain.ContextWithTrigger(ctx, envelope.CommandType)
+	correlationID, _ := envelope.Metadata[domain.MetadataCorrelationID].(string)
+	if correlationID == "" {
+		correlationID = envelope.ID
+	}
+	ctx = domain.ContextWithCorrelation(ctx, correlationID, envelope.ID)
+	if provider, ok := envelope.Payload.(MetadataProvider); ok {
+		metadata := provider.EventMetadata()
+		for key := range metadata {
+			if domain.IsReservedMetadataKey(key) {
+				logger.Warn("command event metadata uses a reserved key; ignoring it", slog.String("key", key))
+			}
+		}
+		ctx = domain.ContextWithEventMetadata(ctx, metadata)
+	}
+	return ContextWithLogger(ctx, logger)
+}
+
+// startRequest logs the start of a dispatch when request logging is enabled
+// and returns the function that logs its end with the receivers' errors.
+func (c *dispatcherConfig) startRequest(ctx context.Context, envelope CommandEnvelope[any], receivers int) func(errs []error) {
+	if !c.requestLogging {
+		return func([]error) {}
+	}
+	logger := LoggerFromContext(ctx)
+	attrs := []any{slog.Int("receivers", receivers)}
+	if c.redactPayload != nil {
+		attrs = append(attrs, slog.Any("payload", c.redactPayload(envelope.Payload)))
+	}
+	logger.InfoContext(ctx, "command started", attrs...)
+
+	start := time.Now()
+	return func(errs []error) {
+		duration := slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000)
+		if err := errors.Join(errs...); err != nil {
+			logger.ErrorContext(ctx, "command finished", duration, slog.String("outcome", "error"), slog.Any("error", err))
+			return
+		}
+		logger.InfoContext(ctx, "command finished", duration, slog.String("outcome", "success"))
+	}
+}
@@ -0,0 +1,390 @@
+package cqrs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+	"github.com/akeemphilbert/pericarp/pkg/ddd"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/application"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+// syncBuffer serialises writes from concurrent log calls.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) lines(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestLoggerFromContextDefault(t *testing.T) {
+	t.Parallel()
+
+	if got := cqrs.LoggerFromContext(context.Background()); got != slog.Default() {
+		t.Error("Expected slog.Default() when context carries no logger")
+	}
+}
+
+func TestDispatchInjectsScopedLogger(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		new  func(...cqrs.DispatcherOption) cqrs.CommandDispatcher
+	}{
+		{"AsyncCommandDispatcher", func(o ...cqrs.DispatcherOption) cqrs.CommandDispatcher { return cqrs.NewAsyncCommandDispatcher(o...) }},
+		{"QueuedCommandDispatcher", func(o ...cqrs.DispatcherOption) cqrs.CommandDispatcher { return cqrs.NewQueuedCommandDispatcher(o...) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &syncBuffer{}
+			base := slog.New(slog.NewJSONHandler(buf, nil))
+			d := tc.new(cqrs.WithLogger(base))
+			defer func() { _ = d.Close() }()
+
+			if err := cqrs.RegisterReceiver(d, "user.create", func(ctx context.Context, env cqrs.CommandEnvelope[CommandDispatcherTestCreateUser]) (any, error) {
+				cqrs.LoggerFromContext(ctx).Info("handling", "email", env.Payload.Email)
+				return nil, nil
+			}); err != nil {
+				t.Fatalf("Failed to register: %v", err)
+			}
+
+			const n = 20
+			var wg sync.WaitGroup
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					env := makeEnvelope("user.create", CommandDispatcherTestCreateUser{Email: fmt.Sprintf("u%d@example.com", i)})
+					env.Metadata["correlation_id"] = fmt.Sprintf("corr-%d", i)
+					d.Dispatch(context.Background(), env).Wait()
+				}(i)
+			}
+			wg.Wait()
+
+			lines := buf.lines(t)
+			if len(lines) != n {
+				t.Fatalf("Expected %d log lines, got %d", n, len(lines))
+			}
+			for _, l := range lines {
+				if l["command_type"] != "user.create" {
+					t.Errorf("Expected command_type user.create, got %v", l["command_type"])
+				}
+				if l["command_id"] == "" || l["command_id"] == nil {
+					t.Error("Expected command_id on log line")
+				}
+				// Each line must carry its own correlation ID only, proving the
+				// base logger was never mutated by a concurrent dispatch.
+				email, _ := l["email"].(string)
+				want := "corr-" + strings.TrimSuffix(strings.TrimPrefix(email, "u"), "@example.com")
+				if l["correlation_id"] != want {
+					t.Errorf("Expected correlation_id %s, got %v", want, l["correlation_id"])
+				}
+			}
+		})
+	}
+}
+
+func TestScopedLoggerOddKeyValues(t *testing.T) {
+	t.Parallel()
+
+	buf := &syncBuffer{}
+	base := slog.New(slog.NewJSONHandler(buf, nil))
+	d := cqrs.NewQueuedCommandDispatcher(cqrs.WithLogger(base))
+	defer func() { _ = d.Close() }()
+
+	if err := cqrs.RegisterReceiver(d, "user.create", func(ctx context.Context, env cqrs.CommandEnvelope[CommandDispatcherTestCreateUser]) (any, error) {
+		// A dangling key must not panic or corrupt the line.
+		kv := []any{"dangling"}
+		cqrs.LoggerFromContext(ctx).With(kv...).Info("odd")
+		return nil, nil
+	}); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	results := d.Dispatch(context.Background(), makeEnvelope("user.create", CommandDispatcherTestCreateUser{})).Wait()
+	if len(results) != 1 || results[0].Error != nil {
+		t.Fatalf("Expected one successful result, got %+v", results)
+	}
+
+	base.Info("base")
+	lines := buf.lines(t)
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d", len(lines))
+	}
+	if _, ok := lines[1]["command_type"]; ok {
+		t.Error("Expected base logger to be unchanged by scoped children")
+	}
+}
+
+func TestDispatchRecordsCommandTrigger(t *testing.T) {
+	t.Parallel()
+
+	d := cqrs.NewQueuedCommandDispatcher()
+	defer func() { _ = d.Close() }()
+
+	if err := cqrs.RegisterReceiver(d, "user.create", func(ctx context.Context, env cqrs.CommandEnvelope[CommandDispatcherTestCreateUser]) (any, error) {
+		return domain.TriggerFromContext(ctx), nil
+	}); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	results := d.Dispatch(context.Background(), makeEnvelope("user.create", CommandDispatcherTestCreateUser{})).Wait()
+	if len(results) != 1 || results[0].Value != "user.create" {
+		t.Errorf("Expected receiver context to carry trigger user.create, got %+v", results)
+	}
+}
+
+func TestDispatchRecordsCorrelation(t *testing.T) {
+	t.Parallel()
+
+	store := infrastructure.NewMemoryStore()
+	d := cqrs.NewQueuedCommandDispatcher()
+	defer func() { _ = d.Close() }()
+
+	if err := cqrs.RegisterReceiver(d, "user.create", func(ctx context.Context, env cqrs.CommandEnvelope[CommandDispatcherTestCreateUser]) (any, error) {
+		user := ddd.NewBaseEntity(env.Payload.Email)
+		if err := user.RecordEvent(map[string]any{}, "user.created"); err != nil {
+			return nil, err
+		}
+		uow := application.NewSimpleUnitOfWork(store, nil)
+		if err := uow.Track(user); err != nil {
+			return nil, err
+		}
+		return nil, uow.Commit(ctx)
+	}); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		email           string
+		correlationID   string
+		wantCorrelation func(env cqrs.CommandEnvelope[any]) string
+	}{
+		{name: "command's correlation ID", email: "a@example.com", correlationID: "corr-1",
+			wantCorrelation: func(cqrs.CommandEnvelope[any]) string { return "corr-1" }},
+		{name: "command ID starts a correlation", email: "b@example.com",
+			wantCorrelation: func(env cqrs.CommandEnvelope[any]) string { return env.ID }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := makeEnvelope("user.create", CommandDispatcherTestCreateUser{Email: tt.email})
+			if tt.correlationID != "" {
+				env.Metadata[domain.MetadataCorrelationID] = tt.correlationID
+			}
+			if results := d.Dispatch(context.Background(), env).Wait(); len(results) != 1 || results[0].Error != nil {
+				t.Fatalf("Expected one successful result, got %+v", results)
+			}
+
+			events, err := store.GetEvents(context.Background(), tt.email)
+			if err != nil || len(events) != 1 {
+				t.Fatalf("Expected one stored event, got %v, %v", events, err)
+			}
+			if got, want := events[0].Metadata[domain.MetadataCorrelationID], tt.wantCorrelation(env); got != want {
+				t.Errorf("Expected %s %q, got %v", domain.MetadataCorrelationID, want, got)
+			}
+			if got := events[0].Metadata[domain.MetadataCausationID]; got != env.ID {
+				t.Errorf("Expected %s to be the command ID %q, got %v", domain.MetadataCausationID, env.ID, got)
+			}
+		})
+	}
+}
+
+func TestDispatchLoggerReachesEventHandlers(t *testing.T) {
+	t.Parallel()
+
+	buf := &syncBuffer{}
+	d := cqrs.NewQueuedCommandDispatcher(cqrs.WithLogger(slog.New(slog.NewJSONHandler(buf, nil))))
+	defer func() { _ = d.Close() }()
+
+	events := domain.NewEventDispatcher()
+	if _, err := domain.Subscribe(events, "user.created", func(ctx context.Context, _ domain.EventEnvelope[any]) error {
+		domain.LoggerFromContext(ctx).Info("sending welcome email")
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if err := cqrs.RegisterReceiver(d, "user.create", func(ctx context.Context, env cqrs.CommandEnvelope[CommandDispatcherTestCreateUser]) (any, error) {
+		user := ddd.NewBaseEntity(env.Payload.Email)
+		if err := user.RecordEvent(map[string]any{}, "user.created"); err != nil {
+			return nil, err
+		}
+		uow := application.NewSimpleUnitOfWork(infrastructure.NewMemoryStore(), events)
+		if err := uow.Track(user); err != nil {
+			return nil, err
+		}
+		return nil, uow.Commit(ctx)
+	}); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	env := makeEnvelope("user.create", CommandDispatcherTestCreateUser{Email: "a@example.com"})
+	env.Metadata[domain.MetadataActorID] = "agent-7"
+	if results := d.Dispatch(context.Background(), env).Wait(); len(results) != 1 || results[0].Error != nil {
+		t.Fatalf("Expected one successful result, got %+v", results)
+	}
+
+	lines := buf.lines(t)
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 log line, got %d", len(lines))
+	}
+	if got := lines[0]["actor_id"]; got != "agent-7" {
+		t.Errorf("Expected the event handler's line to carry actor_id agent-7, got %v", got)
+	}
+	if got := lines[0]["command_id"]; got != env.ID {
+		t.Errorf("Expected command_id %s, got %v", env.ID, got)
+	}
+}
+
+// cohortCommand carries experiment context to stamp on its events.
+type cohortCommand struct{}
+
+func (cohortCommand) EventMetadata() map[string]string {
+	return map[string]string{"ab_variant": "B", domain.MetadataCorrelationID: "spoofed"}
+}
+
+func TestDispatchRecordsCommandEventMetadata(t *testing.T) {
+	t.Parallel()
+
+	var buf syncBuffer
+	d := cqrs.NewQueuedCommandDispatcher(cqrs.WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	defer func() { _ = d.Close() }()
+
+	if err := cqrs.RegisterReceiver(d, "experiment.enrol", func(ctx context.Context, env cqrs.CommandEnvelope[cohortCommand]) (any, error) {
+		return domain.EventMetadataFromContext(ctx), nil
+	}); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	results := d.Dispatch(context.Background(), makeEnvelope("experiment.enrol", cohortCommand{})).Wait()
+	if len(results) != 1 || results[0].Error != nil {
+		t.Fatalf("Expected one successful result, got %+v", results)
+	}
+	metadata := results[0].Value.(map[string]string)
+	if metadata["ab_variant"] != "B" {
+		t.Errorf("Expected ab_variant B in context metadata, got %v", metadata)
+	}
+	if _, ok := metadata[domain.MetadataCorrelationID]; ok {
+		t.Errorf("Expected reserved key %s to be dropped, got %v", domain.MetadataCorrelationID, metadata)
+	}
+
+	var warned bool
+	for _, line := range buf.lines(t) {
+		if line["level"] == "WARN" && line["key"] == domain.MetadataCorrelationID {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Error("Expected a warning for the reserved metadata key")
+	}
+}
+
+func TestDispatchRequestLogging(t *testing.T) {
+	t.Parallel()
+
+	redact := func(payload any) any {
+		cmd := payload.(CommandDispatcherTestCreateUser)
+		cmd.Email = "[REDACTED]"
+		return cmd
+	}
+
+	for _, tc := range []struct {
+		name        string
+		new         func(...cqrs.DispatcherOption) cqrs.CommandDispatcher
+		opt         cqrs.DispatcherOption
+		fail        bool
+		wantPayload bool
+	}{
+		{"async success", func(o ...cqrs.DispatcherOption) cqrs.CommandDispatcher { return cqrs.NewAsyncCommandDispatcher(o...) }, cqrs.WithRequestLogging(), false, false},
+		{"queued failure", func(o ...cqrs.DispatcherOption) cqrs.CommandDispatcher { return cqrs.NewQueuedCommandDispatcher(o...) }, cqrs.WithRequestLogging(), true, false},
+		{"redacted payload", func(o ...cqrs.DispatcherOption) cqrs.CommandDispatcher { return cqrs.NewQueuedCommandDispatcher(o...) }, cqrs.WithRedactedPayloadLogging(redact), false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &syncBuffer{}
+			d := tc.new(cqrs.WithLogger(slog.New(slog.NewJSONHandler(buf, nil))), tc.opt)
+			defer func() { _ = d.Close() }()
+
+			if err := cqrs.RegisterReceiver(d, "user.create", func(ctx context.Context, env cqrs.CommandEnvelope[CommandDispatcherTestCreateUser]) (any, error) {
+				if tc.fail {
+					return nil, fmt.Errorf("email taken")
+				}
+				return nil, nil
+			}); err != nil {
+				t.Fatalf("Failed to register: %v", err)
+			}
+
+			env := makeEnvelope("user.create", CommandDispatcherTestCreateUser{Email: "jane@example.com"})
+			env.Metadata[domain.MetadataCorrelationID] = "corr-1"
+			env.Metadata[domain.MetadataAccountID] = "acct-1"
+			env.Metadata[domain.MetadataActorID] = "user-7"
+			d.Dispatch(context.Background(), env).Wait()
+
+			lines := buf.lines(t)
+			if len(lines) != 2 {
+				t.Fatalf("Expected start and end lines, got %v", lines)
+			}
+			for _, l := range lines {
+				for key, want := range map[string]any{"command_type": "user.create", "command_id": env.ID, "correlation_id": "corr-1", "account_id": "acct-1", "actor_id": "user-7"} {
+					if l[key] != want {
+						t.Errorf("%s: expected %s %v, got %v", l["msg"], key, want, l[key])
+					}
+				}
+				if strings.Contains(fmt.Sprint(l), "jane@example.com") {
+					t.Errorf("Expected the email never to be logged, got %v", l)
+				}
+			}
+
+			start, end := lines[0], lines[1]
+			if start["msg"] != "command started" || end["msg"] != "command finished" {
+				t.Fatalf("Expected started then finished, got %v and %v", start["msg"], end["msg"])
+			}
+			if _, ok := start["payload"]; ok != tc.wantPayload {
+				t.Errorf("payload logged = %v, want %v", ok, tc.wantPayload)
+			}
+			if _, ok := end["duration_ms"]; !ok {
+				t.Error("Expected duration_ms on the end line")
+			}
+			wantOutcome, wantLevel := "success", "INFO"
+			if tc.fail {
+				wantOutcome, wantLevel = "error", "ERROR"
+			}
+			if end["outcome"] != wantOutcome || end["level"] != wantLevel {
+				t.Errorf("Expected outcome %s at %s, got %v at %v", wantOutcome, wantLevel, end["outcome"], end["level"])
+			}
+		})
+	}
+}
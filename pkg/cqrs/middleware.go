@@ -0,0 +1,65 @@
+package cqrs
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CommandMiddleware wraps every receiver invocation of a dispatcher, for
+// cross-cutting concerns such as authorization, metrics and tracing that
+// should not be repeated in each receiver.
+//
+// Middlewares run once the dispatcher has accepted the command: one still
+// waiting under WithAggregateSerialization has not reached them yet. The
+// dispatcher recovers a panic in a receiver or middleware outside the whole
+// chain; add RecoveryMiddleware where middlewares added before it should see
+// the panic as an error.
+type CommandMiddleware func(next ReceiverFunc) ReceiverFunc
+
+// Use adds middleware around every receiver, typed, wildcard and default
+// alike, including receivers registered before the call. Middlewares run in
+// the order they were added, the first outermost. With several receivers
+// matching a command the chain runs once per receiver.
+func (r *commandRegistry) Use(middleware CommandMiddleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, middleware)
+}
+
+// applyMiddlewares wraps fn in r's middleware chain. r.mu must be held.
+func (r *commandRegistry) applyMiddlewares(fn ReceiverFunc) ReceiverFunc {
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		fn = r.middlewares[i](fn)
+	}
+	return fn
+}
+
+// TimeoutMiddleware gives each receiver a context that expires after timeout.
+// The receiver must honour ctx cancellation for the deadline to have effect;
+// one that ignores it runs to completion.
+func TimeoutMiddleware(timeout time.Duration) CommandMiddleware {
+	return func(next ReceiverFunc) ReceiverFunc {
+		return func(ctx context.Context, env CommandEnvelope[any]) (any, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next(ctx, env)
+		}
+	}
+}
+
+// RecoveryMiddleware turns a panic in the rest of the chain into an error
+// wrapping ErrReceiverPanic, so middlewares added before it, such as logging
+// or metrics, observe the failure as they would a returned error.
+func RecoveryMiddleware() CommandMiddleware {
+	return func(next ReceiverFunc) ReceiverFunc {
+		return func(ctx context.Context, env CommandEnvelope[any]) (value any, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					value, err = nil, fmt.Errorf("%w: %v", ErrReceiverPanic, r)
+				}
+			}()
+			return next(ctx, env)
+		}
+	}
+}
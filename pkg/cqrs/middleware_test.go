@@ -0,0 +1,120 @@
+package cqrs_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+)
+
+func TestDispatcherMiddleware(t *testing.T) {
+	t.Parallel()
+
+	dispatchers := map[string]func() cqrs.CommandDispatcher{
+		"AsyncCommandDispatcher":  func() cqrs.CommandDispatcher { return cqrs.NewAsyncCommandDispatcher() },
+		"QueuedCommandDispatcher": func() cqrs.CommandDispatcher { return cqrs.NewQueuedCommandDispatcher() },
+	}
+
+	for name, newDispatcher := range dispatchers {
+		t.Run(name+"/runs in order around every receiver", func(t *testing.T) {
+			t.Parallel()
+
+			d := newDispatcher()
+			var mu sync.Mutex
+			var trace []string
+			record := func(s string) {
+				mu.Lock()
+				defer mu.Unlock()
+				trace = append(trace, s)
+			}
+			tag := func(label string) cqrs.CommandMiddleware {
+				return func(next cqrs.ReceiverFunc) cqrs.ReceiverFunc {
+					return func(ctx context.Context, env cqrs.CommandEnvelope[any]) (any, error) {
+						record(label + ">")
+						defer record("<" + label)
+						return next(ctx, env)
+					}
+				}
+			}
+			if err := cqrs.RegisterReceiver(d, "user.create", cqrs.CommandReceiver[validatedCreateUser](func(context.Context, cqrs.CommandEnvelope[validatedCreateUser]) (any, error) {
+				record("receiver")
+				return "ok", nil
+			})); err != nil {
+				t.Fatalf("RegisterReceiver() error = %v", err)
+			}
+			d.Use(cqrs.ValidationMiddleware())
+			d.Use(tag("outer"))
+			d.Use(tag("inner"))
+
+			results := d.Dispatch(context.Background(), makeEnvelope("user.create", validatedCreateUser{Email: "a@example.com"})).Wait()
+			if len(results) != 1 || results[0].Error != nil || results[0].Value != "ok" {
+				t.Fatalf("results = %+v, want the receiver's value", results)
+			}
+			if got := strings.Join(trace, " "); got != "outer> inner> receiver <inner <outer" {
+				t.Errorf("trace = %q", got)
+			}
+
+			// A command failing validation reaches neither the middlewares
+			// added after ValidationMiddleware nor the receiver.
+			trace = nil
+			results = d.Dispatch(context.Background(), makeEnvelope("user.create", validatedCreateUser{})).Wait()
+			var validationErr *cqrs.ValidationError
+			if len(results) != 1 || !errors.As(results[0].Error, &validationErr) {
+				t.Fatalf("results = %+v, want a ValidationError", results)
+			}
+			if len(trace) != 0 {
+				t.Errorf("trace = %v, want nothing run for an invalid command", trace)
+			}
+		})
+	}
+
+	t.Run("TimeoutMiddleware bounds the receiver's context", func(t *testing.T) {
+		t.Parallel()
+
+		d := cqrs.NewQueuedCommandDispatcher()
+		d.Use(cqrs.TimeoutMiddleware(20 * time.Millisecond))
+		if err := d.RegisterWildcardReceiver(func(ctx context.Context, env cqrs.CommandEnvelope[any]) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}); err != nil {
+			t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+		}
+
+		results := d.Dispatch(context.Background(), makeEnvelope("user.create", nil)).Wait()
+		if len(results) != 1 || !errors.Is(results[0].Error, context.DeadlineExceeded) {
+			t.Errorf("results = %+v, want context.DeadlineExceeded", results)
+		}
+	})
+
+	t.Run("RecoveryMiddleware reports a panic to the middlewares before it", func(t *testing.T) {
+		t.Parallel()
+
+		d := cqrs.NewQueuedCommandDispatcher()
+		var observed error
+		d.Use(func(next cqrs.ReceiverFunc) cqrs.ReceiverFunc {
+			return func(ctx context.Context, env cqrs.CommandEnvelope[any]) (any, error) {
+				value, err := next(ctx, env)
+				observed = err
+				return value, err
+			}
+		})
+		d.Use(cqrs.RecoveryMiddleware())
+		if err := d.RegisterWildcardReceiver(func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+			panic("boom")
+		}); err != nil {
+			t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+		}
+
+		results := d.Dispatch(context.Background(), makeEnvelope("user.create", nil)).Wait()
+		if len(results) != 1 || !errors.Is(results[0].Error, cqrs.ErrReceiverPanic) {
+			t.Fatalf("results = %+v, want ErrReceiverPanic", results)
+		}
+		if !errors.Is(observed, cqrs.ErrReceiverPanic) || !strings.Contains(observed.Error(), "boom") {
+			t.Errorf("observed error = %v, want ErrReceiverPanic carrying the panic value", observed)
+		}
+	})
+}
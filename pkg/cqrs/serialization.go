@@ -0,0 +1,171 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// DefaultAggregateQueueSize caps how many commands may wait for one aggregate
+// when WithAggregateSerialization is given a non-positive size.
+const DefaultAggregateQueueSize = 100
+
+// ErrAggregateQueueFull is returned, wrapped, for a command rejected because
+// one of its target aggregates already has the maximum number of commands
+// waiting.
+var ErrAggregateQueueFull = errors.New("aggregate command queue is full")
+
+// AggregateTargeter is implemented by command payloads that name the
+// aggregates they modify. With WithAggregateSerialization, commands sharing a
+// target aggregate are handled one at a time; payloads that do not implement
+// it, or return no IDs, are dispatched without serialization.
+type AggregateTargeter interface {
+	TargetAggregateIDs() []string
+}
+
+// WithAggregateSerialization serializes commands per target aggregate, so
+// concurrent commands for one hot aggregate queue up instead of racing to
+// append and failing with concurrency conflicts. Commands for different
+// aggregates still run concurrently.
+//
+// A command whose payload implements AggregateTargeter takes its place in the
+// queue of every aggregate it targets when Dispatch is called, and its
+// receivers run once every command queued ahead of it on any of those
+// aggregates has finished, so each aggregate's commands run in dispatch order.
+// A command targeting several aggregates holds all of them while it runs;
+// because it joins all its queues at once, such commands cannot deadlock each
+// other.
+//
+// At most maxPending commands may wait behind the running one for an
+// aggregate (DefaultAggregateQueueSize when maxPending <= 0); a command that
+// would exceed this for any of its targets is rejected with
+// ErrAggregateQueueFull without joining any queue. A command whose context
+// is cancelled while waiting completes with the context error and never runs.
+// Middlewares, ValidationMiddleware included, run only once the command's
+// turn comes. Receivers must not dispatch a command for an aggregate they
+// hold and wait for its result, which would deadlock.
+func WithAggregateSerialization(maxPending int) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		if maxPending <= 0 {
+			maxPending = DefaultAggregateQueueSize
+		}
+		c.serializer = &aggregateSerializer{
+			maxPending: maxPending,
+			queues:     make(map[string]*aggregateQueue),
+		}
+	}
+}
+
+// aggregateSerializer tracks, per aggregate, the commands dispatched for it
+// that have not finished.
+type aggregateSerializer struct {
+	mu         sync.Mutex
+	maxPending int
+	queues     map[string]*aggregateQueue
+}
+
+// aggregateQueue is one aggregate's line of commands: how many have not
+// finished, and the done channel of the most recently queued one.
+type aggregateQueue struct {
+	pending int
+	tail    chan struct{}
+}
+
+// aggregateTicket is a command's place in the queues of its target
+// aggregates. A nil ticket stands for an unserialized command.
+type aggregateTicket struct {
+	serializer   *aggregateSerializer
+	ids          []string
+	predecessors []chan struct{}
+	done         chan struct{}
+}
+
+// serializeAggregates queues envelope behind the unfinished commands for its
+// target aggregates. It returns a nil ticket when serialization is off or the
+// payload names no aggregate.
+func (c *dispatcherConfig) serializeAggregates(envelope CommandEnvelope[any]) (*aggregateTicket, error) {
+	if c.serializer == nil {
+		return nil, nil
+	}
+	targeter, ok := envelope.Payload.(AggregateTargeter)
+	if !ok {
+		return nil, nil
+	}
+	ids := slices.DeleteFunc(slices.Clone(targeter.TargetAggregateIDs()), func(id string) bool { return id == "" })
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return c.serializer.enqueue(ids)
+}
+
+// enqueue joins the queues of all ids at once, or none of them if any is full.
+func (s *aggregateSerializer) enqueue(ids []string) (*aggregateTicket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if q := s.queues[id]; q != nil && q.pending > s.maxPending {
+			return nil, fmt.Errorf("%w: aggregate %s has %d commands waiting", ErrAggregateQueueFull, id, q.pending-1)
+		}
+	}
+
+	ticket := &aggregateTicket{serializer: s, ids: ids, done: make(chan struct{})}
+	for _, id := range ids {
+		q := s.queues[id]
+		if q == nil {
+			q = &aggregateQueue{}
+			s.queues[id] = q
+		} else {
+			ticket.predecessors = append(ticket.predecessors, q.tail)
+		}
+		q.pending++
+		q.tail = ticket.done
+	}
+	return ticket, nil
+}
+
+// wait blocks until every command queued ahead of t has finished. If ctx is
+// done first it returns the context error and hands t's place on once the
+// predecessors finish, so the caller must not release t.
+func (t *aggregateTicket) wait(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	for i, predecessor := range t.predecessors {
+		select {
+		case <-predecessor:
+		case <-ctx.Done():
+			remaining := t.predecessors[i:]
+			go func() {
+				for _, p := range remaining {
+					<-p
+				}
+				t.release()
+			}()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// release marks t's command finished, letting the next queued command run.
+func (t *aggregateTicket) release() {
+	if t == nil {
+		return
+	}
+	s := t.serializer
+	s.mu.Lock()
+	for _, id := range t.ids {
+		q := s.queues[id]
+		q.pending--
+		if q.pending == 0 {
+			delete(s.queues, id)
+		}
+	}
+	s.mu.Unlock()
+	close(t.done)
+}
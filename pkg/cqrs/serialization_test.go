@@ -0,0 +1,236 @@
+package cqrs_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+)
+
+type targetedCommand struct {
+	Seq        int
+	Aggregates []string
+}
+
+func (c targetedCommand) TargetAggregateIDs() []string {
+	return c.Aggregates
+}
+
+func TestWithAggregateSerialization(t *testing.T) {
+	t.Parallel()
+
+	dispatchers := map[string]func(opts ...cqrs.DispatcherOption) cqrs.CommandDispatcher{
+		"AsyncCommandDispatcher": func(opts ...cqrs.DispatcherOption) cqrs.CommandDispatcher {
+			return cqrs.NewAsyncCommandDispatcher(opts...)
+		},
+		"QueuedCommandDispatcher": func(opts ...cqrs.DispatcherOption) cqrs.CommandDispatcher {
+			return cqrs.NewQueuedCommandDispatcher(opts...)
+		},
+	}
+
+	for name, newDispatcher := range dispatchers {
+		t.Run(name+"/same aggregate runs one at a time in dispatch order", func(t *testing.T) {
+			t.Parallel()
+			d := newDispatcher(cqrs.WithAggregateSerialization(0))
+
+			var running, maxRunning atomic.Int32
+			var mu sync.Mutex
+			var order []int
+			receiver := func(_ context.Context, env cqrs.CommandEnvelope[targetedCommand]) (any, error) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				order = append(order, env.Payload.Seq)
+				mu.Unlock()
+				return nil, nil
+			}
+			if err := cqrs.RegisterReceiver(d, "order.update", cqrs.CommandReceiver[targetedCommand](receiver)); err != nil {
+				t.Fatalf("RegisterReceiver() error = %v", err)
+			}
+
+			var watchables []*cqrs.Watchable
+			for seq := range 10 {
+				cmd := targetedCommand{Seq: seq, Aggregates: []string{"order-1"}}
+				watchables = append(watchables, d.Dispatch(context.Background(), makeEnvelope("order.update", cmd)))
+			}
+			for _, w := range watchables {
+				w.Wait()
+			}
+
+			if got := maxRunning.Load(); got != 1 {
+				t.Errorf("max concurrent commands for one aggregate = %d, want 1", got)
+			}
+			if want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !slices.Equal(order, want) {
+				t.Errorf("order = %v, want %v", order, want)
+			}
+		})
+
+		t.Run(name+"/different aggregates run concurrently", func(t *testing.T) {
+			t.Parallel()
+			d := newDispatcher(cqrs.WithAggregateSerialization(0))
+
+			var started sync.WaitGroup
+			started.Add(2)
+			receiver := func(context.Context, cqrs.CommandEnvelope[targetedCommand]) (any, error) {
+				started.Done()
+				started.Wait()
+				return nil, nil
+			}
+			if err := cqrs.RegisterReceiver(d, "order.update", cqrs.CommandReceiver[targetedCommand](receiver)); err != nil {
+				t.Fatalf("RegisterReceiver() error = %v", err)
+			}
+
+			w1 := d.Dispatch(context.Background(), makeEnvelope("order.update", targetedCommand{Aggregates: []string{"order-1"}}))
+			w2 := d.Dispatch(context.Background(), makeEnvelope("order.update", targetedCommand{Aggregates: []string{"order-2"}}))
+			select {
+			case <-w1.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatal("commands for different aggregates did not run concurrently")
+			}
+			<-w2.Done()
+		})
+
+		t.Run(name+"/multi-aggregate command waits for each target", func(t *testing.T) {
+			t.Parallel()
+			d := newDispatcher(cqrs.WithAggregateSerialization(0))
+
+			release := make(chan struct{})
+			var transferRan atomic.Bool
+			block := func(context.Context, cqrs.CommandEnvelope[targetedCommand]) (any, error) {
+				<-release
+				return nil, nil
+			}
+			transfer := func(context.Context, cqrs.CommandEnvelope[targetedCommand]) (any, error) {
+				transferRan.Store(true)
+				return nil, nil
+			}
+			if err := cqrs.RegisterReceiver(d, "account.hold", cqrs.CommandReceiver[targetedCommand](block)); err != nil {
+				t.Fatalf("RegisterReceiver() error = %v", err)
+			}
+			if err := cqrs.RegisterReceiver(d, "account.transfer", cqrs.CommandReceiver[targetedCommand](transfer)); err != nil {
+				t.Fatalf("RegisterReceiver() error = %v", err)
+			}
+
+			hold := d.Dispatch(context.Background(), makeEnvelope("account.hold", targetedCommand{Aggregates: []string{"acct-b"}}))
+			moved := d.Dispatch(context.Background(), makeEnvelope("account.transfer", targetedCommand{Aggregates: []string{"acct-a", "acct-b", "acct-a"}}))
+
+			select {
+			case <-moved.Done():
+				t.Fatal("transfer ran while one of its aggregates was busy")
+			case <-time.After(20 * time.Millisecond):
+			}
+			close(release)
+			hold.Wait()
+			moved.Wait()
+			if !transferRan.Load() {
+				t.Error("transfer did not run after its aggregates were free")
+			}
+		})
+
+		t.Run(name+"/full queue rejects the command", func(t *testing.T) {
+			t.Parallel()
+			d := newDispatcher(cqrs.WithAggregateSerialization(1))
+
+			release := make(chan struct{})
+			var calls atomic.Int32
+			receiver := func(context.Context, cqrs.CommandEnvelope[targetedCommand]) (any, error) {
+				calls.Add(1)
+				<-release
+				return nil, nil
+			}
+			if err := cqrs.RegisterReceiver(d, "order.update", cqrs.CommandReceiver[targetedCommand](receiver)); err != nil {
+				t.Fatalf("RegisterReceiver() error = %v", err)
+			}
+
+			cmd := targetedCommand{Aggregates: []string{"order-1"}}
+			running := d.Dispatch(context.Background(), makeEnvelope("order.update", cmd))
+			waiting := d.Dispatch(context.Background(), makeEnvelope("order.update", cmd))
+			rejected := d.Dispatch(context.Background(), makeEnvelope("order.update", cmd)).Wait()
+
+			if len(rejected) != 1 || !errors.Is(rejected[0].Error, cqrs.ErrAggregateQueueFull) {
+				t.Errorf("third command results = %+v, want a single ErrAggregateQueueFull", rejected)
+			}
+			close(release)
+			running.Wait()
+			waiting.Wait()
+			if got := calls.Load(); got != 2 {
+				t.Errorf("receiver calls = %d, want 2", got)
+			}
+		})
+
+		t.Run(name+"/cancelled waiter is skipped without breaking the queue", func(t *testing.T) {
+			t.Parallel()
+			d := newDispatcher(cqrs.WithAggregateSerialization(0))
+
+			release := make(chan struct{})
+			var order []int
+			var mu sync.Mutex
+			receiver := func(_ context.Context, env cqrs.CommandEnvelope[targetedCommand]) (any, error) {
+				if env.Payload.Seq == 0 {
+					<-release
+				}
+				mu.Lock()
+				order = append(order, env.Payload.Seq)
+				mu.Unlock()
+				return nil, nil
+			}
+			if err := cqrs.RegisterReceiver(d, "order.update", cqrs.CommandReceiver[targetedCommand](receiver)); err != nil {
+				t.Fatalf("RegisterReceiver() error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			first := d.Dispatch(context.Background(), makeEnvelope("order.update", targetedCommand{Seq: 0, Aggregates: []string{"order-1"}}))
+			cancelled := d.Dispatch(ctx, makeEnvelope("order.update", targetedCommand{Seq: 1, Aggregates: []string{"order-1"}}))
+			last := d.Dispatch(context.Background(), makeEnvelope("order.update", targetedCommand{Seq: 2, Aggregates: []string{"order-1"}}))
+
+			cancel()
+			results := cancelled.Wait()
+			if len(results) != 1 || !errors.Is(results[0].Error, context.Canceled) {
+				t.Errorf("cancelled command results = %+v, want a single context.Canceled", results)
+			}
+			close(release)
+			first.Wait()
+			last.Wait()
+			if want := []int{0, 2}; !slices.Equal(order, want) {
+				t.Errorf("order = %v, want %v", order, want)
+			}
+		})
+	}
+}
+
+func TestWithAggregateSerializationIgnoresUntargetedCommands(t *testing.T) {
+	t.Parallel()
+	d := cqrs.NewAsyncCommandDispatcher(cqrs.WithAggregateSerialization(0))
+
+	var started sync.WaitGroup
+	started.Add(2)
+	receiver := func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+		started.Done()
+		started.Wait()
+		return nil, nil
+	}
+	if err := cqrs.RegisterReceiver(d, "user.create", cqrs.CommandReceiver[any](receiver)); err != nil {
+		t.Fatalf("RegisterReceiver() error = %v", err)
+	}
+
+	w1 := d.Dispatch(context.Background(), makeEnvelope("user.create", CommandDispatcherTestCreateUser{}))
+	w2 := d.Dispatch(context.Background(), makeEnvelope("user.create", targetedCommand{}))
+	select {
+	case <-w1.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("commands without target aggregates were serialized")
+	}
+	<-w2.Done()
+}
@@ -0,0 +1,68 @@
+package cqrs
+
+import (
+	"context"
+	"fmt"
+)
+
+// Validatable is implemented by command payloads that can check themselves
+// before being handled. ValidationMiddleware calls Validate on every such
+// payload before the receiver runs; payloads that do not implement it are
+// dispatched unvalidated.
+//
+// Validate should check only the command's own shape (required fields,
+// formats, ranges) and never consult state or the caller's identity, so that
+// a malformed command can be rejected as invalid before, and without
+// revealing, whether the caller could have performed it.
+type Validatable interface {
+	Validate() error
+}
+
+// ValidationError reports a command rejected by its payload's Validate
+// method. The receiver does not run and its CommandResult carries the
+// ValidationError instead. Use errors.As to tell it apart from receiver
+// failures.
+type ValidationError struct {
+	CommandType string
+	Err         error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid command %q: %v", e.CommandType, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationMiddleware rejects commands whose payload implements Validatable
+// and fails Validate with a ValidationError, without calling the rest of the
+// chain. Middlewares run in the order they were added, so Use it before any
+// authorization middleware to validate first:
+//
+//	d.Use(cqrs.ValidationMiddleware())
+//	d.Use(authorize)
+//
+// Validation runs once per matching receiver, so each receiver's result
+// carries the ValidationError.
+func ValidationMiddleware() CommandMiddleware {
+	return func(next ReceiverFunc) ReceiverFunc {
+		return func(ctx context.Context, env CommandEnvelope[any]) (any, error) {
+			if v, ok := env.Payload.(Validatable); ok {
+				if err := v.Validate(); err != nil {
+					return nil, &ValidationError{CommandType: env.CommandType, Err: err}
+				}
+			}
+			return next(ctx, env)
+		}
+	}
+}
+
+// rejectCommand completes w with err as its only result, without running
+// any receiver.
+func rejectCommand(w *Watchable, envelope CommandEnvelope[any], err error) {
+	w.rejected = true
+	w.results <- CommandResult{Error: err, CommandType: envelope.CommandType}
+	close(w.results)
+	close(w.done)
+}
@@ -0,0 +1,90 @@
+package cqrs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+)
+
+var errEmailRequired = errors.New("email is required")
+
+type validatedCreateUser struct {
+	Email string
+}
+
+func (c validatedCreateUser) Validate() error {
+	if c.Email == "" {
+		return errEmailRequired
+	}
+	return nil
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	t.Parallel()
+
+	dispatchers := map[string]func() cqrs.CommandDispatcher{
+		"AsyncCommandDispatcher":  func() cqrs.CommandDispatcher { return cqrs.NewAsyncCommandDispatcher() },
+		"QueuedCommandDispatcher": func() cqrs.CommandDispatcher { return cqrs.NewQueuedCommandDispatcher() },
+	}
+	tests := []struct {
+		name      string
+		payload   any
+		wantCalls int32
+		wantErr   error
+	}{
+		{name: "valid command reaches receivers", payload: validatedCreateUser{Email: "a@example.com"}, wantCalls: 2},
+		{name: "invalid command is rejected before each receiver", payload: validatedCreateUser{}, wantErr: errEmailRequired},
+		{name: "non-validatable command passes through", payload: CommandDispatcherTestCreateUser{}, wantCalls: 2},
+	}
+
+	for dispatcherName, newDispatcher := range dispatchers {
+		for _, tt := range tests {
+			t.Run(dispatcherName+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				d := newDispatcher()
+				d.Use(cqrs.ValidationMiddleware())
+				var calls atomic.Int32
+				receiver := func(context.Context, cqrs.CommandEnvelope[any]) (any, error) {
+					calls.Add(1)
+					return "ok", nil
+				}
+				if err := cqrs.RegisterReceiver(d, "user.create", cqrs.CommandReceiver[any](receiver)); err != nil {
+					t.Fatalf("RegisterReceiver() error = %v", err)
+				}
+				if err := d.RegisterWildcardReceiver(receiver); err != nil {
+					t.Fatalf("RegisterWildcardReceiver() error = %v", err)
+				}
+
+				results := d.Dispatch(context.Background(), makeEnvelope("user.create", tt.payload)).Wait()
+
+				if got := calls.Load(); got != tt.wantCalls {
+					t.Errorf("receiver calls = %d, want %d", got, tt.wantCalls)
+				}
+				if tt.wantErr == nil {
+					for _, r := range results {
+						if r.Error != nil {
+							t.Errorf("unexpected result error: %v", r.Error)
+						}
+					}
+					return
+				}
+				if len(results) != 2 {
+					t.Fatalf("got %d results, want a validation result per receiver", len(results))
+				}
+				for _, r := range results {
+					var validationErr *cqrs.ValidationError
+					if !errors.As(r.Error, &validationErr) {
+						t.Fatalf("expected a ValidationError, got %v", r.Error)
+					}
+					if validationErr.CommandType != "user.create" || !errors.Is(r.Error, tt.wantErr) {
+						t.Errorf("ValidationError = %+v, want command type user.create wrapping %v", validationErr, tt.wantErr)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package ddd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// ErrNoApplier is returned when an Aggregate records or applies an event of a
+// type it has no applier registered for.
+var ErrNoApplier = errors.New("no applier registered for event type")
+
+// Applier mutates an aggregate's state to reflect one event.
+type Applier[S any] func(state *S, event domain.EventEnvelope[any]) error
+
+// ApplyPayload adapts fn, which takes the event payload as P, to an Applier.
+// Recorded events carry the payload as given, but events read back from an
+// event store usually carry it as map[string]any; those payloads are
+// converted to P through JSON, so P must round-trip through encoding/json.
+func ApplyPayload[S, P any](fn func(state *S, payload P)) Applier[S] {
+	return func(state *S, event domain.EventEnvelope[any]) error {
+		payload, ok := event.Payload.(P)
+		if !ok {
+			data, err := json.Marshal(event.Payload)
+			if err != nil {
+				return fmt.Errorf("failed to encode %T payload: %w", event.Payload, err)
+			}
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return fmt.Errorf("failed to decode payload as %T: %w", payload, err)
+			}
+		}
+		fn(state, payload)
+		return nil
+	}
+}
+
+// Aggregate is an aggregate root whose state S changes only by applying
+// events, through the applier registered for each event type. RecordEvent
+// applies a new event before recording it and ApplyEvent applies a stored
+// one, so commands and replays (LoadFromHistory, LoadFromSnapshot) run the
+// same code and cannot drift apart.
+//
+// Embed *Aggregate[S] in place of *BaseEntity and register the appliers in
+// the constructor:
+//
+//	type User struct {
+//		*ddd.Aggregate[UserState]
+//	}
+//
+//	func NewUser(id string) *User {
+//		u := &User{Aggregate: ddd.NewAggregate[UserState](id)}
+//		u.RegisterApplier("user.renamed", ddd.ApplyPayload(func(s *UserState, e UserRenamed) {
+//			s.Name = e.Name
+//		}))
+//		return u
+//	}
+//
+//	func (u *User) Rename(name string) error {
+//		return u.RecordEvent(UserRenamed{Name: name}, "user.renamed")
+//	}
+type Aggregate[S any] struct {
+	*BaseEntity
+
+	// stateMu serializes applying events, so state and the sequence
+	// number advance together.
+	stateMu  sync.RWMutex
+	state    S
+	appliers map[string]Applier[S]
+}
+
+// NewAggregate creates an Aggregate with the given aggregate ID and the zero
+// state, starting at sequence number 0.
+func NewAggregate[S any](aggregateID string) *Aggregate[S] {
+	return &Aggregate[S]{
+		BaseEntity: NewBaseEntity(aggregateID),
+		appliers:   make(map[string]Applier[S]),
+	}
+}
+
+// RestoreAggregate creates an Aggregate with a known sequence number and
+// state, for use when loading an aggregate from a projection or read model.
+func RestoreAggregate[S any](aggregateID string, sequenceNo int, state S) *Aggregate[S] {
+	return &Aggregate[S]{
+		BaseEntity: RestoreBaseEntity(aggregateID, sequenceNo),
+		state:      state,
+		appliers:   make(map[string]Applier[S]),
+	}
+}
+
+// RegisterApplier registers fn to apply events of eventType, replacing any
+// applier registered for it before. It panics when eventType is empty or fn
+// is nil.
+func (a *Aggregate[S]) RegisterApplier(eventType string, fn Applier[S]) {
+	if eventType == "" {
+		panic("event type must not be empty")
+	}
+	if fn == nil {
+		panic("applier must not be nil")
+	}
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	a.appliers[eventType] = fn
+}
+
+// State returns a copy of the aggregate's state. Maps, slices and pointers in
+// S are shared with the aggregate and must not be modified.
+func (a *Aggregate[S]) State() S {
+	a.stateMu.RLock()
+	defer a.stateMu.RUnlock()
+	return a.state
+}
+
+// RecordEvent records a new event and applies it to the state. Nothing is
+// recorded and the state is left as it was when the event type has no
+// applier (ErrNoApplier), the applier fails or the event cannot be recorded.
+// The applier works on a shallow copy of the state, so maps, slices and
+// pointers it changes in place are not rolled back. As with BaseEntity.RecordEvent, the invariant set with SetInvariant
+// is checked afterwards, against the new state.
+func (a *Aggregate[S]) RecordEvent(payload any, eventType string) error {
+	if err := a.recordEvent(payload, eventType); err != nil {
+		return err
+	}
+	a.checkInvariant()
+	return nil
+}
+
+// recordEvent applies and records a new event without checking the
+// invariant, which may read the state.
+func (a *Aggregate[S]) recordEvent(payload any, eventType string) error {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	applier, ok := a.appliers[eventType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoApplier, eventType)
+	}
+	envelope := domain.NewEventEnvelope(payload, a.GetID(), eventType, a.GetSequenceNo()+1)
+	if envelope.AggregateID != a.GetID() {
+		return fmt.Errorf("%w: expected %s, got %s", ErrWrongAggregate, a.GetID(), envelope.AggregateID)
+	}
+	next := a.state
+	if err := applier(&next, envelope); err != nil {
+		return fmt.Errorf("apply %s: %w", eventType, err)
+	}
+	if err := a.recordEnvelope(envelope); err != nil {
+		return err
+	}
+	a.state = next
+	return nil
+}
+
+// ApplyEvent applies a stored event to the state once BaseEntity.ApplyEvent
+// has validated it and advanced the sequence number. When the applier fails,
+// neither the state nor the sequence number changes, as for RecordEvent.
+func (a *Aggregate[S]) ApplyEvent(ctx context.Context, event domain.EventEnvelope[any]) error {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	applier, ok := a.appliers[event.EventType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoApplier, event.EventType)
+	}
+	if err := a.validateEvent(event); err != nil {
+		return err
+	}
+	next := a.state
+	if err := applier(&next, event); err != nil {
+		return fmt.Errorf("apply %s: %w", event.EventType, err)
+	}
+	if err := a.BaseEntity.ApplyEvent(ctx, event); err != nil {
+		return err
+	}
+	a.state = next
+	return nil
+}
@@ -0,0 +1,284 @@
+package ddd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+type userState struct {
+	Name   string
+	Email  string
+	Active bool
+}
+
+type userRegistered struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type userRenamed struct {
+	Name string `json:"name"`
+}
+
+type userDeactivated struct{}
+
+// user is a test aggregate built on Aggregate, as an application's would be.
+type user struct {
+	*Aggregate[userState]
+}
+
+func newUser(id string) *user {
+	u := &user{Aggregate: NewAggregate[userState](id)}
+	u.RegisterApplier("user.registered", ApplyPayload(func(s *userState, e userRegistered) {
+		s.Name = e.Name
+		s.Email = e.Email
+		s.Active = true
+	}))
+	u.RegisterApplier("user.renamed", ApplyPayload(func(s *userState, e userRenamed) {
+		s.Name = e.Name
+	}))
+	u.RegisterApplier("user.deactivated", ApplyPayload(func(s *userState, _ userDeactivated) {
+		s.Active = false
+	}))
+	return u
+}
+
+func (u *user) Register(name, email string) error {
+	return u.RecordEvent(userRegistered{Name: name, Email: email}, "user.registered")
+}
+
+func (u *user) Rename(name string) error {
+	return u.RecordEvent(userRenamed{Name: name}, "user.renamed")
+}
+
+func (u *user) Deactivate() error {
+	return u.RecordEvent(userDeactivated{}, "user.deactivated")
+}
+
+// throughJSON returns events as an event store reads them back, with their
+// payloads decoded into map[string]any.
+func throughJSON(t *testing.T, events []domain.EventEnvelope[any]) []domain.EventEnvelope[any] {
+	t.Helper()
+	decoded := make([]domain.EventEnvelope[any], len(events))
+	for i, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("failed to encode event: %v", err)
+		}
+		if err := json.Unmarshal(data, &decoded[i]); err != nil {
+			t.Fatalf("failed to decode event: %v", err)
+		}
+	}
+	return decoded
+}
+
+func TestAggregate_ReplayMatchesLiveState(t *testing.T) {
+	t.Parallel()
+
+	live := newUser("user-1")
+	if err := live.Register("Ada", "ada@example.com"); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	if err := live.Rename("Ada Lovelace"); err != nil {
+		t.Fatalf("Rename() error: %v", err)
+	}
+	if err := live.Deactivate(); err != nil {
+		t.Fatalf("Deactivate() error: %v", err)
+	}
+
+	want := userState{Name: "Ada Lovelace", Email: "ada@example.com", Active: false}
+	if got := live.State(); got != want {
+		t.Fatalf("live state = %+v, want %+v", got, want)
+	}
+
+	events := live.GetUncommittedEvents()
+	tests := []struct {
+		name   string
+		events []domain.EventEnvelope[any]
+	}{
+		{name: "typed payloads", events: events},
+		{name: "payloads read back from a store", events: throughJSON(t, events)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			replayed := newUser("user-1")
+			if err := LoadFromHistory(context.Background(), replayed, tt.events); err != nil {
+				t.Fatalf("LoadFromHistory() error: %v", err)
+			}
+			if got := replayed.State(); got != live.State() {
+				t.Errorf("replayed state = %+v, want the live state %+v", got, live.State())
+			}
+			if got := replayed.GetSequenceNo(); got != live.GetSequenceNo() {
+				t.Errorf("replayed sequence = %d, want %d", got, live.GetSequenceNo())
+			}
+			if got := len(replayed.GetUncommittedEvents()); got != 0 {
+				t.Errorf("replay recorded %d events, want none", got)
+			}
+		})
+	}
+}
+
+func TestAggregate_RecordEventErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		payload   any
+		eventType string
+		wantErr   error
+	}{
+		{name: "event type without applier", payload: userRenamed{Name: "Ada"}, eventType: "user.promoted", wantErr: ErrNoApplier},
+		{name: "payload the applier cannot decode", payload: "Ada", eventType: "user.renamed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			u := newUser("user-1")
+			err := u.RecordEvent(tt.payload, tt.eventType)
+			if err == nil {
+				t.Fatal("RecordEvent() succeeded, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("RecordEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := len(u.GetUncommittedEvents()); got != 0 {
+				t.Errorf("recorded %d events, want none", got)
+			}
+			if got := u.GetSequenceNo(); got != 0 {
+				t.Errorf("sequence = %d, want 0", got)
+			}
+			if got := u.State(); got != (userState{}) {
+				t.Errorf("state = %+v, want the zero state", got)
+			}
+		})
+	}
+}
+
+func TestAggregate_ApplyEventWithoutApplier(t *testing.T) {
+	t.Parallel()
+
+	u := newUser("user-1")
+	event := toAnyEvent(domain.NewEventEnvelope[any](map[string]any{}, "user-1", "user.promoted", 1))
+	if err := u.ApplyEvent(context.Background(), event); !errors.Is(err, ErrNoApplier) {
+		t.Fatalf("ApplyEvent() error = %v, want ErrNoApplier", err)
+	}
+	if got := u.GetSequenceNo(); got != 0 {
+		t.Errorf("sequence = %d, want 0", got)
+	}
+}
+
+func TestAggregate_FailedApplierLeavesStateAndSequence(t *testing.T) {
+	t.Parallel()
+
+	errHalfway := errors.New("failed halfway")
+	newHalfUser := func() *user {
+		u := newUser("user-1")
+		u.RegisterApplier("user.renamed", func(s *userState, _ domain.EventEnvelope[any]) error {
+			s.Name = "half-applied"
+			return errHalfway
+		})
+		return u
+	}
+
+	recorded := newHalfUser()
+	if err := recorded.Register("Ada", "ada@example.com"); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	if err := recorded.Rename("Ada Lovelace"); !errors.Is(err, errHalfway) {
+		t.Fatalf("Rename() error = %v, want %v", err, errHalfway)
+	}
+	if got := recorded.State().Name; got != "Ada" {
+		t.Errorf("state name after failed RecordEvent = %q, want %q", got, "Ada")
+	}
+	if got := recorded.GetSequenceNo(); got != 1 {
+		t.Errorf("sequence after failed RecordEvent = %d, want 1", got)
+	}
+
+	events := newUser("user-1")
+	if err := events.Register("Ada", "ada@example.com"); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	if err := events.Rename("Ada Lovelace"); err != nil {
+		t.Fatalf("Rename() error: %v", err)
+	}
+	history := events.GetUncommittedEvents()
+
+	replayed := newHalfUser()
+	ctx := context.Background()
+	if err := replayed.ApplyEvent(ctx, history[0]); err != nil {
+		t.Fatalf("ApplyEvent(registered) error: %v", err)
+	}
+	if err := replayed.ApplyEvent(ctx, history[1]); !errors.Is(err, errHalfway) {
+		t.Fatalf("ApplyEvent(renamed) error = %v, want %v", err, errHalfway)
+	}
+	if got := replayed.State().Name; got != "Ada" {
+		t.Errorf("state name after failed ApplyEvent = %q, want %q", got, "Ada")
+	}
+	if got := replayed.GetSequenceNo(); got != 1 {
+		t.Errorf("sequence after failed ApplyEvent = %d, want 1", got)
+	}
+
+	// The event was not marked applied, so it can be applied once fixed.
+	replayed.RegisterApplier("user.renamed", ApplyPayload(func(s *userState, e userRenamed) {
+		s.Name = e.Name
+	}))
+	if err := replayed.ApplyEvent(ctx, history[1]); err != nil {
+		t.Fatalf("ApplyEvent(renamed) retry error: %v", err)
+	}
+	if got := replayed.State().Name; got != "Ada Lovelace" {
+		t.Errorf("state name after retry = %q, want %q", got, "Ada Lovelace")
+	}
+}
+
+func TestRestoreAggregate(t *testing.T) {
+	t.Parallel()
+
+	u := &user{Aggregate: RestoreAggregate("user-1", 4, userState{Name: "Ada", Active: true})}
+	u.RegisterApplier("user.renamed", ApplyPayload(func(s *userState, e userRenamed) {
+		s.Name = e.Name
+	}))
+	if err := u.Rename("Ada Lovelace"); err != nil {
+		t.Fatalf("Rename() error: %v", err)
+	}
+
+	if got, want := u.State(), (userState{Name: "Ada Lovelace", Active: true}); got != want {
+		t.Errorf("state = %+v, want %+v", got, want)
+	}
+	events := u.GetUncommittedEvents()
+	if len(events) != 1 || events[0].SequenceNo != 5 {
+		t.Errorf("uncommitted events = %+v, want one at sequence 5", events)
+	}
+}
+
+func TestAggregate_InvariantSeesNewState(t *testing.T) {
+	t.Parallel()
+
+	u := newUser("user-1")
+	u.SetInvariant(func() error {
+		if u.State().Name == "" {
+			return errors.New("name is required")
+		}
+		return nil
+	})
+
+	if err := u.Register("Ada", "ada@example.com"); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	if !u.IsValid() {
+		t.Fatalf("IsValid() = false after a valid registration, errors %v", u.Errors())
+	}
+	if err := u.Rename(""); err != nil {
+		t.Fatalf("Rename() error: %v", err)
+	}
+	if u.IsValid() {
+		t.Error("IsValid() = true after renaming to an empty name")
+	}
+	if got := len(u.GetUncommittedEvents()); got != 2 {
+		t.Errorf("recorded %d events, want 2", got)
+	}
+}
@@ -0,0 +1,292 @@
+package ddd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// ChangeKind classifies a FieldChange.
+type ChangeKind string
+
+const (
+	// ChangeAdded marks a field, element or map entry present only in the later state.
+	ChangeAdded ChangeKind = "added"
+
+	// ChangeRemoved marks a field, element or map entry present only in the earlier state.
+	ChangeRemoved ChangeKind = "removed"
+
+	// ChangeModified marks a value present in both states that differs.
+	ChangeModified ChangeKind = "changed"
+)
+
+// RedactedValue replaces the before/after values of redacted fields.
+const RedactedValue = "[REDACTED]"
+
+// FieldChange is one leaf-level difference between two aggregate states.
+// Path addresses the value from the aggregate root, e.g. "Address.City",
+// "LineItems[2].Quantity", "LineItems[SKU=A-1].Quantity" or "Tags[gold]".
+type FieldChange struct {
+	Path   string
+	Kind   ChangeKind
+	Before any
+	After  any
+}
+
+// DiffOption configures Diff.
+type DiffOption func(*diffConfig)
+
+type diffConfig struct {
+	redact    map[string]bool
+	sliceKeys map[string]string
+}
+
+// WithRedactedFields redacts the values of the named fields wherever they
+// appear (matched by Go field name, at any depth). The change itself is still
+// reported — an auditor sees that the password hash changed, not what it is.
+// Fields tagged `audit:"redact"` are always redacted; fields tagged
+// `audit:"-"` are skipped entirely.
+func WithRedactedFields(names ...string) DiffOption {
+	return func(c *diffConfig) {
+		for _, n := range names {
+			c.redact[n] = true
+		}
+	}
+}
+
+// WithSliceKey matches the elements of the slice field at path (e.g.
+// "LineItems") by the value of their keyField instead of by index, so
+// inserting or removing a line item reports that item rather than a cascade
+// of shifted-index changes.
+func WithSliceKey(path, keyField string) DiffOption {
+	return func(c *diffConfig) {
+		c.sliceKeys[path] = keyField
+	}
+}
+
+// Diff compares two states of the same aggregate type — typically the
+// aggregate rebuilt from its history up to version N and up to version M via
+// EventStore.GetEventsRange — and returns a field-level change list ordered by
+// path. Unexported fields are compared as well, since aggregates usually keep
+// their state private; the embedded BaseEntity is skipped because its event
+// bookkeeping is not domain state.
+//
+// Nested structs, pointers, slices and maps are walked so a changed line item
+// quantity is reported as that one leaf, not as the whole aggregate. Values
+// are compared with reflect.DeepEqual at the leaves, except time.Time which is
+// compared with Equal.
+func Diff(before, after any, opts ...DiffOption) ([]FieldChange, error) {
+	cfg := &diffConfig{redact: map[string]bool{}, sliceKeys: map[string]string{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	b, a := addressable(before), addressable(after)
+	if b.IsValid() && a.IsValid() && b.Type() != a.Type() {
+		return nil, fmt.Errorf("cannot diff %s against %s", b.Type(), a.Type())
+	}
+
+	var changes []FieldChange
+	cfg.walk(&changes, "", b, a, false)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+var (
+	baseEntityType = reflect.TypeOf(BaseEntity{})
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// addressable copies v into a new addressable value so unexported fields can
+// be read through reflect.NewAt.
+func addressable(v any) reflect.Value {
+	if v == nil {
+		return reflect.Value{}
+	}
+	rv := reflect.ValueOf(v)
+	cp := reflect.New(rv.Type()).Elem()
+	cp.Set(rv)
+	return cp
+}
+
+func (c *diffConfig) walk(changes *[]FieldChange, path string, before, after reflect.Value, redacted bool) {
+	before, after = deref(before), deref(after)
+
+	switch {
+	case !before.IsValid() && !after.IsValid():
+		return
+	case !before.IsValid():
+		c.emit(changes, path, ChangeAdded, nil, exportable(after), redacted)
+		return
+	case !after.IsValid():
+		c.emit(changes, path, ChangeRemoved, exportable(before), nil, redacted)
+		return
+	case before.Type() != after.Type():
+		c.emit(changes, path, ChangeModified, exportable(before), exportable(after), redacted)
+		return
+	}
+
+	switch before.Kind() {
+	case reflect.Struct:
+		if before.Type() == timeType {
+			bt, at := exportable(before).(time.Time), exportable(after).(time.Time)
+			if !bt.Equal(at) {
+				c.emit(changes, path, ChangeModified, bt, at, redacted)
+			}
+			return
+		}
+		c.walkStruct(changes, path, before, after, redacted)
+	case reflect.Slice, reflect.Array:
+		if key, ok := c.sliceKeys[path]; ok && before.Kind() == reflect.Slice {
+			c.walkKeyedSlice(changes, path, key, before, after, redacted)
+			return
+		}
+		c.walkSlice(changes, path, before, after, redacted)
+	case reflect.Map:
+		c.walkMap(changes, path, before, after, redacted)
+	default:
+		bv, av := exportable(before), exportable(after)
+		if !reflect.DeepEqual(bv, av) {
+			c.emit(changes, path, ChangeModified, bv, av, redacted)
+		}
+	}
+}
+
+func (c *diffConfig) walkStruct(changes *[]FieldChange, path string, before, after reflect.Value, redacted bool) {
+	t := before.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("audit")
+		if tag == "-" {
+			continue
+		}
+		if ft := f.Type; ft == baseEntityType || (ft.Kind() == reflect.Pointer && ft.Elem() == baseEntityType) {
+			continue
+		}
+		if isSyncPrimitive(f.Type) {
+			continue
+		}
+		fieldRedacted := redacted || tag == "redact" || c.redact[f.Name]
+		c.walk(changes, joinPath(path, f.Name), before.Field(i), after.Field(i), fieldRedacted)
+	}
+}
+
+func (c *diffConfig) walkSlice(changes *[]FieldChange, path string, before, after reflect.Value, redacted bool) {
+	n := max(before.Len(), after.Len())
+	for i := 0; i < n; i++ {
+		var b, a reflect.Value
+		if i < before.Len() {
+			b = before.Index(i)
+		}
+		if i < after.Len() {
+			a = after.Index(i)
+		}
+		c.walk(changes, fmt.Sprintf("%s[%d]", path, i), b, a, redacted)
+	}
+}
+
+func (c *diffConfig) walkKeyedSlice(changes *[]FieldChange, path, keyField string, before, after reflect.Value, redacted bool) {
+	index := func(s reflect.Value) (map[string]reflect.Value, []string) {
+		m := make(map[string]reflect.Value, s.Len())
+		var order []string
+		for i := 0; i < s.Len(); i++ {
+			el := deref(s.Index(i))
+			k := fmt.Sprintf("%s[%d]", path, i)
+			if el.IsValid() && el.Kind() == reflect.Struct {
+				if kf := el.FieldByName(keyField); kf.IsValid() {
+					k = fmt.Sprintf("%s[%s=%v]", path, keyField, exportable(kf))
+				}
+			}
+			if _, dup := m[k]; !dup {
+				order = append(order, k)
+			}
+			m[k] = s.Index(i)
+		}
+		return m, order
+	}
+
+	bm, border := index(before)
+	am, aorder := index(after)
+	seen := make(map[string]bool, len(bm)+len(am))
+	for _, k := range append(border, aorder...) {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		c.walk(changes, k, bm[k], am[k], redacted)
+	}
+}
+
+func (c *diffConfig) walkMap(changes *[]FieldChange, path string, before, after reflect.Value, redacted bool) {
+	keys := make(map[string]reflect.Value)
+	for _, k := range before.MapKeys() {
+		keys[fmt.Sprint(exportable(k))] = k
+	}
+	for _, k := range after.MapKeys() {
+		keys[fmt.Sprint(exportable(k))] = k
+	}
+	for name, k := range keys {
+		c.walk(changes, fmt.Sprintf("%s[%s]", path, name), before.MapIndex(k), after.MapIndex(k), redacted)
+	}
+}
+
+func (c *diffConfig) emit(changes *[]FieldChange, path string, kind ChangeKind, before, after any, redacted bool) {
+	if redacted {
+		if before != nil {
+			before = RedactedValue
+		}
+		if after != nil {
+			after = RedactedValue
+		}
+	}
+	*changes = append(*changes, FieldChange{Path: path, Kind: kind, Before: before, After: after})
+}
+
+// deref follows pointers and interfaces; a nil pointer or interface becomes
+// the invalid Value so it compares as absent.
+func deref(v reflect.Value) reflect.Value {
+	v = unlock(v)
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = unlock(v.Elem())
+	}
+	return v
+}
+
+// unlock makes a value read from an unexported field usable with Interface,
+// MapIndex and friends by re-deriving it from its address. Diff only reads
+// through the result.
+func unlock(v reflect.Value) reflect.Value {
+	if v.IsValid() && !v.CanInterface() && v.CanAddr() {
+		return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+	return v
+}
+
+// exportable returns v as an interface value.
+func exportable(v reflect.Value) any {
+	v = unlock(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return fmt.Sprint(v)
+}
+
+func isSyncPrimitive(t reflect.Type) bool {
+	return t.PkgPath() == "sync" || t.PkgPath() == "sync/atomic"
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return strings.Join([]string{prefix, name}, ".")
+}
@@ -0,0 +1,148 @@
+package ddd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type diffTestAddress struct {
+	Street string
+	City   string
+}
+
+type diffTestLineItem struct {
+	SKU      string
+	Quantity int
+}
+
+type diffTestOrder struct {
+	*BaseEntity
+	status       string
+	placedAt     time.Time
+	shipTo       *diffTestAddress
+	lineItems    []diffTestLineItem
+	tags         map[string]string
+	PasswordHash string
+	Secret       string `audit:"redact"`
+	Ignored      string `audit:"-"`
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	placed := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	before := diffTestOrder{
+		BaseEntity: NewBaseEntity("order-1"),
+		status:     "draft",
+		placedAt:   placed,
+		shipTo:     &diffTestAddress{Street: "1 Main St", City: "Kingston"},
+		lineItems: []diffTestLineItem{
+			{SKU: "A-1", Quantity: 1},
+			{SKU: "B-2", Quantity: 2},
+		},
+		tags:         map[string]string{"channel": "web"},
+		PasswordHash: "old-hash",
+		Secret:       "s1",
+		Ignored:      "x",
+	}
+
+	t.Run("identical states have no changes", func(t *testing.T) {
+		t.Parallel()
+		changes, err := Diff(before, before)
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("expected no changes, got %+v", changes)
+		}
+	})
+
+	t.Run("nested fields, slices and maps", func(t *testing.T) {
+		t.Parallel()
+		after := before
+		after.BaseEntity = NewBaseEntity("order-1")
+		after.status = "placed"
+		after.placedAt = placed.In(time.FixedZone("EST", -5*3600)) // same instant
+		after.shipTo = &diffTestAddress{Street: "1 Main St", City: "Montego Bay"}
+		after.lineItems = []diffTestLineItem{
+			{SKU: "A-1", Quantity: 3},
+			{SKU: "B-2", Quantity: 2},
+			{SKU: "C-3", Quantity: 1},
+		}
+		after.tags = map[string]string{"priority": "high"}
+		after.Ignored = "y"
+
+		changes, err := Diff(before, after)
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		want := []FieldChange{
+			{Path: "lineItems[0].Quantity", Kind: ChangeModified, Before: 1, After: 3},
+			{Path: "lineItems[2]", Kind: ChangeAdded, After: diffTestLineItem{SKU: "C-3", Quantity: 1}},
+			{Path: "shipTo.City", Kind: ChangeModified, Before: "Kingston", After: "Montego Bay"},
+			{Path: "status", Kind: ChangeModified, Before: "draft", After: "placed"},
+			{Path: "tags[channel]", Kind: ChangeRemoved, Before: "web"},
+			{Path: "tags[priority]", Kind: ChangeAdded, After: "high"},
+		}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("Diff() =\n%+v\nwant\n%+v", changes, want)
+		}
+	})
+
+	t.Run("keyed slices match elements by key", func(t *testing.T) {
+		t.Parallel()
+		after := before
+		after.lineItems = []diffTestLineItem{
+			{SKU: "B-2", Quantity: 5},
+		}
+
+		changes, err := Diff(before, after, WithSliceKey("lineItems", "SKU"))
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		want := []FieldChange{
+			{Path: "lineItems[SKU=A-1]", Kind: ChangeRemoved, Before: diffTestLineItem{SKU: "A-1", Quantity: 1}},
+			{Path: "lineItems[SKU=B-2].Quantity", Kind: ChangeModified, Before: 2, After: 5},
+		}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("Diff() =\n%+v\nwant\n%+v", changes, want)
+		}
+	})
+
+	t.Run("redaction", func(t *testing.T) {
+		t.Parallel()
+		after := before
+		after.PasswordHash = "new-hash"
+		after.Secret = "s2"
+
+		changes, err := Diff(before, after, WithRedactedFields("PasswordHash"))
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		want := []FieldChange{
+			{Path: "PasswordHash", Kind: ChangeModified, Before: RedactedValue, After: RedactedValue},
+			{Path: "Secret", Kind: ChangeModified, Before: RedactedValue, After: RedactedValue},
+		}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("Diff() =\n%+v\nwant\n%+v", changes, want)
+		}
+	})
+
+	t.Run("pointer states and type mismatch", func(t *testing.T) {
+		t.Parallel()
+		after := before
+		after.status = "cancelled"
+		changes, err := Diff(&before, &after)
+		if err != nil {
+			t.Fatalf("Diff: %v", err)
+		}
+		if len(changes) != 1 || changes[0].Path != "status" {
+			t.Errorf("expected a single status change, got %+v", changes)
+		}
+
+		if _, err := Diff(before, diffTestAddress{}); err == nil {
+			t.Error("expected an error diffing different types")
+		}
+	})
+}
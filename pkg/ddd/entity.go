@@ -37,6 +37,12 @@ type BaseEntity struct {
 	// appliedEventIDs tracks event IDs that have already been applied to prevent duplicates.
 	appliedEventIDs map[string]bool
 
+	// invariant is checked after every recorded event; see SetInvariant.
+	invariant func() error
+
+	// errors holds the errors recorded by AddError and failed invariant checks.
+	errors []error
+
 	// mu protects concurrent access to the entity state.
 	mu sync.RWMutex
 }
@@ -98,6 +104,30 @@ func (e *BaseEntity) ClearUncommittedEvents() {
 // applyEventInternal performs the actual event application logic.
 // It assumes the caller holds the lock.
 func (e *BaseEntity) applyEventInternal(event domain.EventEnvelope[any]) error {
+	if err := e.validateEventLocked(event); err != nil {
+		return err
+	}
+
+	// Mark event as applied
+	e.appliedEventIDs[event.ID] = true
+
+	// Update sequence number
+	e.sequenceNo = event.SequenceNo
+
+	return nil
+}
+
+// validateEvent reports the error ApplyEvent would return for event, without
+// applying it.
+func (e *BaseEntity) validateEvent(event domain.EventEnvelope[any]) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.validateEventLocked(event)
+}
+
+// validateEventLocked checks that event is the next new event of this
+// aggregate. It assumes the caller holds the lock.
+func (e *BaseEntity) validateEventLocked(event domain.EventEnvelope[any]) error {
 	// Validate event belongs to this aggregate
 	if event.AggregateID != e.aggregateID {
 		return fmt.Errorf("%w: expected %s, got %s", ErrWrongAggregate, e.aggregateID, event.AggregateID)
@@ -114,12 +144,6 @@ func (e *BaseEntity) applyEventInternal(event domain.EventEnvelope[any]) error {
 		return fmt.Errorf("%w: expected %d, got %d", ErrInvalidEventSequenceNo, expectedSequenceNo, event.SequenceNo)
 	}
 
-	// Mark event as applied
-	e.appliedEventIDs[event.ID] = true
-
-	// Update sequence number
-	e.sequenceNo = event.SequenceNo
-
 	return nil
 }
 
@@ -142,7 +166,17 @@ func (e *BaseEntity) ApplyEvent(ctx context.Context, event domain.EventEnvelope[
 // RecordEvent records a new event by creating an EventEnvelope internally.
 // The payload can be any type and will be stored in the event envelope.
 // This method is thread-safe and validates that the event belongs to this aggregate.
+// Once the event is recorded, the invariant set with SetInvariant is checked.
 func (e *BaseEntity) RecordEvent(payload any, eventType string) error {
+	if err := e.recordEvent(payload, eventType); err != nil {
+		return err
+	}
+	e.checkInvariant()
+	return nil
+}
+
+// recordEvent records a new event without checking the invariant.
+func (e *BaseEntity) recordEvent(payload any, eventType string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -166,3 +200,73 @@ func (e *BaseEntity) RecordEvent(payload any, eventType string) error {
 
 	return nil
 }
+
+// recordEnvelope records an envelope built by the caller, validating it as
+// ApplyEvent does before adding it to the uncommitted events.
+func (e *BaseEntity) recordEnvelope(envelope domain.EventEnvelope[any]) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.applyEventInternal(envelope); err != nil {
+		return err
+	}
+	e.uncommittedEvents = append(e.uncommittedEvents, envelope)
+	return nil
+}
+
+// SetInvariant sets a check of the aggregate's invariants, run after every
+// RecordEvent once the event is recorded. A non-nil error does not undo the
+// event: it is recorded with AddError, so IsValid turns false and the command
+// handler can discard the aggregate instead of committing it
+// (SimpleUnitOfWork.DryRun reports it too). The check runs without the
+// entity's lock held, so it may call the aggregate's methods. Pass nil to
+// remove it.
+func (e *BaseEntity) SetInvariant(invariant func() error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.invariant = invariant
+}
+
+// AddError records a validation error, making IsValid false.
+func (e *BaseEntity) AddError(err error) {
+	if err == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors = append(e.errors, err)
+}
+
+// Errors returns a copy of the recorded validation errors.
+func (e *BaseEntity) Errors() []error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]error(nil), e.errors...)
+}
+
+// IsValid reports whether no validation errors have been recorded.
+func (e *BaseEntity) IsValid() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.errors) == 0
+}
+
+// CheckInvariant runs the invariant set with SetInvariant and returns its
+// error, without recording it. It returns nil when no invariant is set.
+// LoadFromHistory calls it in strict mode, since replaying events does not
+// run the invariant.
+func (e *BaseEntity) CheckInvariant() error {
+	e.mu.RLock()
+	invariant := e.invariant
+	e.mu.RUnlock()
+	if invariant == nil {
+		return nil
+	}
+	return invariant()
+}
+
+// checkInvariant runs the invariant set with SetInvariant, recording its
+// error.
+func (e *BaseEntity) checkInvariant() {
+	e.AddError(e.CheckInvariant())
+}
@@ -744,3 +744,46 @@ func TestBaseEntity_ApplyThenRecord(t *testing.T) {
 		t.Errorf("Uncommitted event payload = %v, want payload2", events[0].Payload)
 	}
 }
+
+func TestBaseEntity_Invariant(t *testing.T) {
+	t.Parallel()
+
+	errTooManyEvents := errors.New("at most two events allowed")
+	entity := NewBaseEntity("agg-1")
+	entity.SetInvariant(func() error {
+		if len(entity.GetUncommittedEvents()) > 2 {
+			return errTooManyEvents
+		}
+		return nil
+	})
+
+	for i := range 2 {
+		if err := entity.RecordEvent(i, "test.recorded"); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+	}
+	if !entity.IsValid() {
+		t.Fatalf("IsValid() = false after events satisfying the invariant, errors %v", entity.Errors())
+	}
+
+	if err := entity.RecordEvent(2, "test.recorded"); err != nil {
+		t.Fatalf("RecordEvent() error = %v, want the violation recorded instead", err)
+	}
+	if got := len(entity.GetUncommittedEvents()); got != 3 {
+		t.Errorf("GetUncommittedEvents() length = %d, want the violating event recorded too", got)
+	}
+	if entity.IsValid() {
+		t.Error("IsValid() = true after the invariant failed")
+	}
+	if errs := entity.Errors(); len(errs) != 1 || !errors.Is(errs[0], errTooManyEvents) {
+		t.Errorf("Errors() = %v, want the invariant's error", errs)
+	}
+
+	entity.SetInvariant(nil)
+	if err := entity.RecordEvent(3, "test.recorded"); err != nil {
+		t.Fatalf("RecordEvent() error = %v", err)
+	}
+	if got := len(entity.Errors()); got != 1 {
+		t.Errorf("Errors() length = %d after removing the invariant, want 1", got)
+	}
+}
@@ -0,0 +1,112 @@
+package ddd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// ErrInvariantViolation is returned by LoadFromHistory in strict mode when the
+// reconstructed aggregate does not satisfy its invariants.
+var ErrInvariantViolation = errors.New("aggregate invariant violated")
+
+// EventApplier is implemented by aggregates that can be rebuilt from their
+// event history. Entities embedding BaseEntity satisfy it, and typically
+// override ApplyEvent to mutate their own state after delegating to
+// BaseEntity.ApplyEvent.
+type EventApplier interface {
+	ApplyEvent(ctx context.Context, event domain.EventEnvelope[any]) error
+}
+
+// Validator is implemented by aggregates that can check their own
+// invariants. LoadFromHistory consults it in strict mode.
+type Validator interface {
+	IsValid() bool
+}
+
+// InvariantChecker is implemented by aggregates that carry their own
+// invariant check, such as entities embedding BaseEntity with an invariant
+// set by SetInvariant. LoadFromHistory runs it in strict mode.
+type InvariantChecker interface {
+	CheckInvariant() error
+}
+
+// InvariantFunc checks an invariant of a reconstructed aggregate, returning a
+// descriptive error when it does not hold.
+type InvariantFunc func(aggregate any) error
+
+// LoadOption configures LoadFromHistory.
+type LoadOption func(*loadConfig)
+
+type loadConfig struct {
+	strict     bool
+	invariants []InvariantFunc
+}
+
+// WithStrictInvariants enables strict mode: once every event has been
+// applied, the aggregate's IsValid (when it implements Validator), its
+// CheckInvariant (when it implements InvariantChecker) and each of the given
+// invariants are evaluated against the final state. Intermediate
+// states during replay are never checked, since they may legitimately be
+// incomplete.
+func WithStrictInvariants(invariants ...InvariantFunc) LoadOption {
+	return func(c *loadConfig) {
+		c.strict = true
+		c.invariants = append(c.invariants, invariants...)
+	}
+}
+
+// LoadFromHistory replays events onto aggregate in order. Without options it
+// only applies the events; in strict mode (WithStrictInvariants) a final state
+// that violates invariants is reported as ErrInvariantViolation instead of
+// being handed back as a quietly broken aggregate, which surfaces corrupted or
+// tampered events at load time.
+func LoadFromHistory(ctx context.Context, aggregate EventApplier, events []domain.EventEnvelope[any], opts ...LoadOption) error {
+	if aggregate == nil {
+		return errors.New("aggregate must not be nil")
+	}
+
+	cfg := &loadConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	for _, event := range events {
+		if err := aggregate.ApplyEvent(ctx, event); err != nil {
+			return fmt.Errorf("apply event %s (sequence %d): %w", event.ID, event.SequenceNo, err)
+		}
+	}
+
+	if !cfg.strict {
+		return nil
+	}
+
+	var violations []error
+	if v, ok := aggregate.(Validator); ok && !v.IsValid() {
+		violations = append(violations, errors.New("IsValid reported false"))
+	}
+	if c, ok := aggregate.(InvariantChecker); ok {
+		if err := c.CheckInvariant(); err != nil {
+			violations = append(violations, err)
+		}
+	}
+	for _, invariant := range cfg.invariants {
+		if invariant == nil {
+			continue
+		}
+		if err := invariant(aggregate); err != nil {
+			violations = append(violations, err)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	id := ""
+	if e, ok := aggregate.(interface{ GetID() string }); ok {
+		id = e.GetID()
+	}
+	return fmt.Errorf("%w: aggregate %s after %d events: %w", ErrInvariantViolation, id, len(events), errors.Join(violations...))
+}
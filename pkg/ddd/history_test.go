@@ -0,0 +1,130 @@
+package ddd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// ledger is a test aggregate whose balance must never be negative once
+// fully loaded, although a replay may pass through a negative balance.
+type ledger struct {
+	*BaseEntity
+	balance int
+}
+
+func (l *ledger) ApplyEvent(ctx context.Context, event domain.EventEnvelope[any]) error {
+	if err := l.BaseEntity.ApplyEvent(ctx, event); err != nil {
+		return err
+	}
+	l.balance += event.Payload.(int)
+	return nil
+}
+
+func (l *ledger) IsValid() bool { return l.balance >= 0 }
+
+func ledgerHistory(amounts ...int) []domain.EventEnvelope[any] {
+	events := make([]domain.EventEnvelope[any], len(amounts))
+	for i, amount := range amounts {
+		events[i] = toAnyEvent(domain.NewEventEnvelope[any](amount, "ledger-1", "ledger.posted", i+1))
+	}
+	return events
+}
+
+func TestLoadFromHistory(t *testing.T) {
+	t.Parallel()
+
+	noOverdraftOver := func(limit int) InvariantFunc {
+		return func(aggregate any) error {
+			if aggregate.(*ledger).balance > limit {
+				return errors.New("balance over limit")
+			}
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name        string
+		amounts     []int
+		opts        []LoadOption
+		wantBalance int
+		wantErr     error
+	}{
+		{name: "lenient load ignores invalid final state", amounts: []int{10, -30}, wantBalance: -20},
+		{name: "strict load accepts valid final state", amounts: []int{10, 5}, opts: []LoadOption{WithStrictInvariants()}, wantBalance: 15},
+		{name: "strict load tolerates invalid intermediate state", amounts: []int{-10, 25}, opts: []LoadOption{WithStrictInvariants()}, wantBalance: 15},
+		{name: "strict load rejects IsValid failure", amounts: []int{10, -30}, opts: []LoadOption{WithStrictInvariants()}, wantBalance: -20, wantErr: ErrInvariantViolation},
+		{name: "strict load rejects registered invariant", amounts: []int{100}, opts: []LoadOption{WithStrictInvariants(noOverdraftOver(50))}, wantBalance: 100, wantErr: ErrInvariantViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			agg := &ledger{BaseEntity: NewBaseEntity("ledger-1")}
+			err := LoadFromHistory(context.Background(), agg, ledgerHistory(tt.amounts...), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromHistory() error = %v, want %v", err, tt.wantErr)
+			}
+			if agg.balance != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", agg.balance, tt.wantBalance)
+			}
+			if got := agg.GetSequenceNo(); got != len(tt.amounts) {
+				t.Errorf("GetSequenceNo() = %d, want %d", got, len(tt.amounts))
+			}
+		})
+	}
+}
+
+func TestLoadFromHistory_EntityInvariant(t *testing.T) {
+	t.Parallel()
+
+	errOverLimit := errors.New("balance over limit")
+	newLedger := func() *ledger {
+		l := &ledger{BaseEntity: NewBaseEntity("ledger-1")}
+		l.SetInvariant(func() error {
+			if l.balance > 100 {
+				return errOverLimit
+			}
+			return nil
+		})
+		return l
+	}
+	// A corrupted history: no command could have recorded the second
+	// posting, since the invariant rejects it.
+	corrupted := ledgerHistory(50, 1000)
+
+	strict := newLedger()
+	err := LoadFromHistory(context.Background(), strict, corrupted, WithStrictInvariants())
+	if !errors.Is(err, ErrInvariantViolation) || !errors.Is(err, errOverLimit) {
+		t.Fatalf("strict LoadFromHistory() error = %v, want ErrInvariantViolation wrapping %v", err, errOverLimit)
+	}
+	if len(strict.Errors()) != 0 {
+		t.Errorf("Errors() = %v, want the violation returned rather than recorded", strict.Errors())
+	}
+
+	if err := LoadFromHistory(context.Background(), newLedger(), corrupted); err != nil {
+		t.Errorf("lenient LoadFromHistory() error = %v, want nil", err)
+	}
+	if err := LoadFromHistory(context.Background(), newLedger(), ledgerHistory(50, 25), WithStrictInvariants()); err != nil {
+		t.Errorf("strict LoadFromHistory() of a valid history error = %v, want nil", err)
+	}
+}
+
+func TestLoadFromHistory_ApplyError(t *testing.T) {
+	t.Parallel()
+
+	agg := &ledger{BaseEntity: NewBaseEntity("ledger-1")}
+	events := ledgerHistory(10, 5)
+	events[1].SequenceNo = 7
+
+	err := LoadFromHistory(context.Background(), agg, events, WithStrictInvariants())
+	if !errors.Is(err, ErrInvalidEventSequenceNo) {
+		t.Fatalf("expected ErrInvalidEventSequenceNo, got %v", err)
+	}
+	if errors.Is(err, ErrInvariantViolation) {
+		t.Error("apply failure must not be reported as an invariant violation")
+	}
+}
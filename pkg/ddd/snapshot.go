@@ -0,0 +1,125 @@
+package ddd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// Snapshotter is implemented by aggregates whose state can be serialized so
+// LoadFromSnapshot can skip replaying the events it already reflects.
+// Snapshot must capture every field ApplyEvent mutates; RestoreSnapshot sets
+// the aggregate's own state from it (BaseEntity's sequence number is restored
+// by LoadFromSnapshot).
+type Snapshotter interface {
+	Snapshot() ([]byte, error)
+	RestoreSnapshot(state []byte) error
+}
+
+// SchemaVersioner is implemented by Snapshotters whose snapshot format
+// changes over time. Bump SnapshotSchemaVersion whenever the serialized state
+// changes shape; snapshots written under another version are then ignored
+// and replaced instead of being restored. Snapshotters without it are
+// version 0.
+type SchemaVersioner interface {
+	SnapshotSchemaVersion() int
+}
+
+// snapshotSchemaVersion returns aggregate's current snapshot schema version.
+func snapshotSchemaVersion(aggregate Snapshotter) int {
+	if v, ok := aggregate.(SchemaVersioner); ok {
+		return v.SnapshotSchemaVersion()
+	}
+	return 0
+}
+
+// SnapshotAggregate is an aggregate that LoadFromSnapshot can rebuild. It is
+// satisfied by types embedding *BaseEntity that implement ApplyEvent and
+// Snapshotter.
+type SnapshotAggregate interface {
+	EventApplier
+	Snapshotter
+	GetID() string
+	GetSequenceNo() int
+	restoreSequenceNo(sequenceNo int)
+}
+
+// restoreSequenceNo positions the entity at sequenceNo, as if the events up
+// to it had been applied.
+func (e *BaseEntity) restoreSequenceNo(sequenceNo int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sequenceNo = sequenceNo
+}
+
+// LoadFromSnapshot rebuilds aggregate from its latest snapshot plus the
+// events recorded after it, replaying only that tail through
+// LoadFromHistory (opts apply to it). Without a snapshot it replays the
+// whole stream.
+//
+// A snapshot of a schema version other than the aggregate's (see
+// SchemaVersioner) is stale: it is skipped, the whole stream is replayed, and
+// a fresh snapshot replaces the stale one, so callers see only a slower load.
+// Failing to write the fresh snapshot does not fail the load; the next load
+// tries again. A snapshot of the current version that cannot be restored is
+// an error rather than a silent full replay, since it means the format
+// changed without a version bump.
+func LoadFromSnapshot(ctx context.Context, aggregate SnapshotAggregate, events domain.EventStore, snapshots domain.SnapshotStore, opts ...LoadOption) error {
+	if aggregate == nil {
+		return errors.New("aggregate must not be nil")
+	}
+	aggregateID := aggregate.GetID()
+
+	from := 1
+	stale := false
+	snapshot, err := snapshots.LoadSnapshot(ctx, aggregateID)
+	switch {
+	case errors.Is(err, domain.ErrSnapshotNotFound):
+	case err != nil:
+		return fmt.Errorf("load snapshot for aggregate %s: %w", aggregateID, err)
+	case snapshot.SchemaVersion != snapshotSchemaVersion(aggregate):
+		stale = true
+	default:
+		if err := aggregate.RestoreSnapshot(snapshot.State); err != nil {
+			return fmt.Errorf("restore snapshot of aggregate %s at sequence %d: %w", aggregateID, snapshot.SequenceNo, err)
+		}
+		aggregate.restoreSequenceNo(snapshot.SequenceNo)
+		from = snapshot.SequenceNo + 1
+	}
+
+	tail, err := events.GetEventsFromVersion(ctx, aggregateID, from)
+	if err != nil {
+		return fmt.Errorf("load events of aggregate %s from sequence %d: %w", aggregateID, from, err)
+	}
+	if err := LoadFromHistory(ctx, aggregate, tail, opts...); err != nil {
+		return err
+	}
+	if stale {
+		_ = SaveSnapshot(ctx, snapshots, aggregate)
+	}
+	return nil
+}
+
+// SaveSnapshot stores aggregate's current state at its current sequence
+// number and snapshot schema version. Call it after a successful commit, for
+// example every N events; an aggregate with uncommitted events is refused,
+// since its state would be ahead of the stored stream.
+func SaveSnapshot(ctx context.Context, snapshots domain.SnapshotStore, aggregate SnapshotAggregate) error {
+	if uncommitted, ok := aggregate.(interface {
+		GetUncommittedEvents() []domain.EventEnvelope[any]
+	}); ok && len(uncommitted.GetUncommittedEvents()) > 0 {
+		return fmt.Errorf("aggregate %s has uncommitted events", aggregate.GetID())
+	}
+	state, err := aggregate.Snapshot()
+	if err != nil {
+		return fmt.Errorf("snapshot aggregate %s: %w", aggregate.GetID(), err)
+	}
+	return snapshots.SaveSnapshot(ctx, domain.Snapshot{
+		AggregateID:   aggregate.GetID(),
+		SequenceNo:    aggregate.GetSequenceNo(),
+		SchemaVersion: snapshotSchemaVersion(aggregate),
+		State:         state,
+	})
+}
@@ -0,0 +1,199 @@
+package ddd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+// snapshotLedger is a ledger that can be snapshotted and counts the events
+// it replays.
+type snapshotLedger struct {
+	ledger
+	applied int
+}
+
+func newSnapshotLedger() *snapshotLedger {
+	return &snapshotLedger{ledger: ledger{BaseEntity: NewBaseEntity("ledger-1")}}
+}
+
+func (l *snapshotLedger) ApplyEvent(ctx context.Context, event domain.EventEnvelope[any]) error {
+	l.applied++
+	return l.ledger.ApplyEvent(ctx, event)
+}
+
+func (l *snapshotLedger) Snapshot() ([]byte, error) { return json.Marshal(l.balance) }
+
+func (l *snapshotLedger) RestoreSnapshot(state []byte) error {
+	return json.Unmarshal(state, &l.balance)
+}
+
+// seedLedger appends n events of amount 1 and snapshots the aggregate as of
+// sequence snapshotAt (0 means no snapshot).
+func seedLedger(tb testing.TB, n, snapshotAt int) (domain.EventStore, domain.SnapshotStore) {
+	tb.Helper()
+	ctx := context.Background()
+	events := infrastructure.NewMemoryStore()
+	amounts := make([]int, n)
+	for i := range amounts {
+		amounts[i] = 1
+	}
+	if err := events.Append(ctx, "ledger-1", 0, ledgerHistory(amounts...)...); err != nil {
+		tb.Fatalf("failed to seed events: %v", err)
+	}
+	snapshots := infrastructure.NewMemorySnapshotStore()
+	if snapshotAt > 0 {
+		state, _ := json.Marshal(snapshotAt)
+		if err := snapshots.SaveSnapshot(ctx, domain.Snapshot{AggregateID: "ledger-1", SequenceNo: snapshotAt, State: state}); err != nil {
+			tb.Fatalf("failed to seed snapshot: %v", err)
+		}
+	}
+	return events, snapshots
+}
+
+func TestLoadFromSnapshot(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		snapshotAt  int
+		wantApplied int
+	}{
+		{name: "without a snapshot replays everything", wantApplied: 5000},
+		{name: "with a snapshot replays only the tail", snapshotAt: 4900, wantApplied: 100},
+		{name: "with a current snapshot replays nothing", snapshotAt: 5000, wantApplied: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			events, snapshots := seedLedger(t, 5000, tt.snapshotAt)
+			agg := newSnapshotLedger()
+			if err := LoadFromSnapshot(context.Background(), agg, events, snapshots); err != nil {
+				t.Fatalf("LoadFromSnapshot() error = %v", err)
+			}
+			if agg.applied != tt.wantApplied {
+				t.Errorf("applied %d events, want %d", agg.applied, tt.wantApplied)
+			}
+			if agg.balance != 5000 || agg.GetSequenceNo() != 5000 {
+				t.Errorf("balance = %d at sequence %d, want 5000 at 5000", agg.balance, agg.GetSequenceNo())
+			}
+		})
+	}
+}
+
+func TestLoadFromSnapshot_CorruptSnapshot(t *testing.T) {
+	t.Parallel()
+
+	events, snapshots := seedLedger(t, 3, 0)
+	if err := snapshots.SaveSnapshot(context.Background(), domain.Snapshot{AggregateID: "ledger-1", SequenceNo: 2, State: []byte("not json")}); err != nil {
+		t.Fatalf("failed to seed snapshot: %v", err)
+	}
+	if err := LoadFromSnapshot(context.Background(), newSnapshotLedger(), events, snapshots); err == nil {
+		t.Fatal("expected an unrestorable snapshot to fail the load")
+	}
+}
+
+// ledgerV2 snapshots the ledger in a newer format than snapshotLedger.
+type ledgerV2 struct {
+	*snapshotLedger
+}
+
+func (l ledgerV2) SnapshotSchemaVersion() int { return 2 }
+
+func (l ledgerV2) Snapshot() ([]byte, error) {
+	return json.Marshal(map[string]int{"balance": l.balance})
+}
+
+func (l ledgerV2) RestoreSnapshot(state []byte) error {
+	var v struct{ Balance int }
+	if err := json.Unmarshal(state, &v); err != nil {
+		return err
+	}
+	l.balance = v.Balance
+	return nil
+}
+
+func TestLoadFromSnapshot_StaleSchemaVersion(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// The stored version-0 snapshot would not decode as version 2.
+	events, snapshots := seedLedger(t, 50, 40)
+	agg := ledgerV2{newSnapshotLedger()}
+	if err := LoadFromSnapshot(ctx, agg, events, snapshots); err != nil {
+		t.Fatalf("LoadFromSnapshot() error = %v", err)
+	}
+	if agg.applied != 50 || agg.balance != 50 || agg.GetSequenceNo() != 50 {
+		t.Errorf("applied %d events, balance %d at sequence %d; want a full replay to 50", agg.applied, agg.balance, agg.GetSequenceNo())
+	}
+
+	snapshot, err := snapshots.LoadSnapshot(ctx, "ledger-1")
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if snapshot.SchemaVersion != 2 || snapshot.SequenceNo != 50 {
+		t.Fatalf("expected the stale snapshot replaced at version 2, sequence 50; got %+v", snapshot)
+	}
+
+	reloaded := ledgerV2{newSnapshotLedger()}
+	if err := LoadFromSnapshot(ctx, reloaded, events, snapshots); err != nil {
+		t.Fatalf("LoadFromSnapshot() error = %v", err)
+	}
+	if reloaded.applied != 0 || reloaded.balance != 50 {
+		t.Errorf("applied %d events, balance %d; want the fresh snapshot restored", reloaded.applied, reloaded.balance)
+	}
+}
+
+func TestSaveSnapshot(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	events, snapshots := seedLedger(t, 10, 0)
+	agg := newSnapshotLedger()
+	if err := LoadFromSnapshot(ctx, agg, events, snapshots); err != nil {
+		t.Fatalf("LoadFromSnapshot() error = %v", err)
+	}
+	if err := SaveSnapshot(ctx, snapshots, agg); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	if snapshot, err := snapshots.LoadSnapshot(ctx, "ledger-1"); err != nil || snapshot.SequenceNo != 10 {
+		t.Fatalf("expected a snapshot at 10, got %d, %v", snapshot.SequenceNo, err)
+	}
+
+	if err := agg.RecordEvent(1, "ledger.posted"); err != nil {
+		t.Fatalf("RecordEvent() error = %v", err)
+	}
+	if err := SaveSnapshot(ctx, snapshots, agg); err == nil {
+		t.Error("expected an aggregate with uncommitted events to be refused")
+	}
+	if _, err := snapshots.LoadSnapshot(ctx, "ledger-2"); !errors.Is(err, domain.ErrSnapshotNotFound) {
+		t.Errorf("expected ErrSnapshotNotFound, got %v", err)
+	}
+}
+
+func BenchmarkLoadFromSnapshot(b *testing.B) {
+	for _, bc := range []struct {
+		name       string
+		snapshotAt int
+	}{
+		{"full replay", 0},
+		{"snapshot at 4900", 4900},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			events, snapshots := seedLedger(b, 5000, bc.snapshotAt)
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := LoadFromSnapshot(ctx, newSnapshotLedger(), events, snapshots); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package application
+
+import (
+	"context"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// WithAggregateLocks runs fn inside a single store transaction holding
+// exclusive locks on the given aggregates, for a strictly serialized
+// load-mutate-save. fn should load the aggregates and commit its unit of work
+// with the context it receives, so the appends join the locked transaction:
+//
+//	err := application.WithAggregateLocks(ctx, store, []string{from, to}, func(ctx context.Context) error {
+//		// load both ledgers from store, apply the transfer, track them ...
+//		return uow.Commit(ctx)
+//	})
+//
+// Locks are released when the transaction ends. A lock that cannot be
+// acquired in time fails with domain.ErrLockTimeout without running fn.
+func WithAggregateLocks(ctx context.Context, store domain.AggregateLocker, aggregateIDs []string, fn func(ctx context.Context) error) error {
+	return store.InTransaction(ctx, func(ctx context.Context) error {
+		if err := store.LockAggregates(ctx, aggregateIDs...); err != nil {
+			return err
+		}
+		return fn(ctx)
+	})
+}
@@ -0,0 +1,256 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/subscriptions"
+)
+
+const (
+	// DefaultOutboxBatchSize is how many pending events an OutboxRelay reads
+	// per poll unless configured with WithOutboxBatchSize.
+	DefaultOutboxBatchSize = 100
+
+	// DefaultOutboxPollInterval is how long an OutboxRelay waits after a poll
+	// that left nothing pending unless configured with
+	// WithOutboxPollInterval.
+	DefaultOutboxPollInterval = time.Second
+)
+
+// ErrRelayStarted is returned by OutboxRelay.Start on a relay that is already
+// running.
+var ErrRelayStarted = errors.New("outbox relay already started")
+
+// NewUnitOfWorkWithOutbox creates a SimpleUnitOfWork that, instead of
+// dispatching events after the commit, enqueues them in outbox within the
+// commit's transaction. An OutboxRelay over the same outbox dispatches them,
+// so events committed just before a crash are still delivered after the
+// restart. eventStore must implement domain.TransactionalEventStore;
+// otherwise Commit fails without persisting.
+func NewUnitOfWorkWithOutbox(eventStore domain.EventStore, outbox domain.Outbox, opts ...UnitOfWorkOption) *SimpleUnitOfWork {
+	uow := NewSimpleUnitOfWork(eventStore, nil, opts...)
+	uow.outbox = outbox
+	return uow
+}
+
+// OutboxRelay delivers the events of a domain.Outbox to an EventDispatcher in
+// the background. Each poll reads a batch of pending events, dispatches them
+// in order and marks the delivered ones sent; an event whose dispatch fails
+// is marked failed and retried once the outbox's backoff has passed, so
+// delivery is at-least-once and handlers must be idempotent. A failed event
+// holds back the later events of its own aggregate, keeping each aggregate's
+// events in order, but not those of other aggregates.
+type OutboxRelay struct {
+	outbox       domain.Outbox
+	dispatcher   *domain.EventDispatcher
+	batchSize    int
+	pollInterval time.Duration
+	maxIdleWait  time.Duration
+	logger       *slog.Logger
+	clock        subscriptions.Clock
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// OutboxRelayOption configures an OutboxRelay.
+type OutboxRelayOption func(*OutboxRelay)
+
+// WithOutboxBatchSize sets how many pending events are read per poll (default
+// DefaultOutboxBatchSize).
+func WithOutboxBatchSize(n int) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithOutboxPollInterval sets how long the relay waits after a poll that
+// drained the outbox, or failed, before polling again (default
+// DefaultOutboxPollInterval). A full batch is followed by the next one
+// immediately.
+func WithOutboxPollInterval(d time.Duration) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		if d > 0 {
+			r.pollInterval = d
+		}
+	}
+}
+
+// WithOutboxIdleBackoff lets an idle relay poll less often: each consecutive
+// poll that finds nothing pending doubles the wait, starting from the poll
+// interval and capped at maximum. Any delivery or failed poll resets the
+// wait to the poll interval. The default is no backoff; a maximum below the
+// poll interval is raised to it.
+func WithOutboxIdleBackoff(maximum time.Duration) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.maxIdleWait = maximum
+	}
+}
+
+// WithOutboxClock sets the time source for the relay's poll waits (default:
+// the system clock), so tests can drive the polling loop deterministically.
+func WithOutboxClock(clock subscriptions.Clock) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		if clock != nil {
+			r.clock = clock
+		}
+	}
+}
+
+// WithOutboxLogger sets the logger dispatch and outbox failures are reported
+// to (default slog.Default()).
+func WithOutboxLogger(logger *slog.Logger) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		if logger != nil {
+			r.logger = logger
+		}
+	}
+}
+
+// NewOutboxRelay creates a relay delivering outbox's events to dispatcher.
+func NewOutboxRelay(outbox domain.Outbox, dispatcher *domain.EventDispatcher, opts ...OutboxRelayOption) *OutboxRelay {
+	r := &OutboxRelay{
+		outbox:       outbox,
+		dispatcher:   dispatcher,
+		batchSize:    DefaultOutboxBatchSize,
+		pollInterval: DefaultOutboxPollInterval,
+		logger:       slog.Default(),
+		clock:        systemClock{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.maxIdleWait = max(r.maxIdleWait, r.pollInterval)
+	return r
+}
+
+// Start runs the relay in a background goroutine until ctx is done or Stop is
+// called. Starting a running relay fails with ErrRelayStarted; a stopped
+// relay can be started again.
+func (r *OutboxRelay) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done != nil {
+		return ErrRelayStarted
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	go r.run(ctx, r.done)
+	return nil
+}
+
+// Stop stops the relay and waits for the poll in flight to finish. Events it
+// dispatched but had not yet marked sent are dispatched again on the next
+// start. Stop on a relay that is not running is a no-op.
+func (r *OutboxRelay) Stop() {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.mu.Unlock()
+	if done == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// run polls until ctx is done. A full batch is followed by the next poll
+// immediately; otherwise the relay waits the poll interval, stretched by
+// WithOutboxIdleBackoff while the outbox stays empty.
+func (r *OutboxRelay) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	idlePolls := 0
+	for {
+		read, err := r.relayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("outbox relay poll failed", slog.Any("error", err))
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && read == r.batchSize {
+			idlePolls = 0
+			continue
+		}
+
+		wait := r.pollInterval
+		if err != nil || read > 0 {
+			idlePolls = 0
+		} else {
+			wait = r.idleDelay(idlePolls)
+			idlePolls++
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.clock.After(wait):
+		}
+	}
+}
+
+// idleDelay doubles the poll interval per consecutive empty poll, capped at
+// maxIdleWait.
+func (r *OutboxRelay) idleDelay(emptyPolls int) time.Duration {
+	delay := r.pollInterval
+	for range emptyPolls {
+		if delay > r.maxIdleWait/2 {
+			return r.maxIdleWait
+		}
+		delay *= 2
+	}
+	return min(delay, r.maxIdleWait)
+}
+
+// relayBatch dispatches one batch of pending events and marks the delivered
+// ones sent, returning how many events it read. A failed event is logged and
+// marked failed, and the later events of its aggregate in the batch are left
+// pending behind it.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	events, err := r.outbox.Pending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	sent := make([]string, 0, len(events))
+	failed := make(map[string]bool)
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if failed[event.AggregateID] {
+			continue
+		}
+		if err := r.dispatcher.Dispatch(ctx, event); err != nil {
+			failed[event.AggregateID] = true
+			r.logger.Warn("outbox event dispatch failed; will retry",
+				slog.String("event_id", event.ID),
+				slog.String("event_type", event.EventType),
+				slog.String("aggregate_id", event.AggregateID),
+				slog.Any("error", err))
+			if err := r.outbox.MarkFailed(context.WithoutCancel(ctx), event.ID, err); err != nil {
+				r.logger.Error("outbox relay failed to record a failed dispatch",
+					slog.String("event_id", event.ID), slog.Any("error", err))
+			}
+			continue
+		}
+		sent = append(sent, event.ID)
+	}
+	// Record deliveries even when stopping, so they are not repeated.
+	if err := r.outbox.MarkSent(context.WithoutCancel(ctx), sent...); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
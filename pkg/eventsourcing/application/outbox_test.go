@@ -0,0 +1,354 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/application"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newOutboxStore(t *testing.T, opts ...infrastructure.GormOutboxOption) (*infrastructure.GormEventStore, *infrastructure.GormOutbox) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open in-memory sqlite: %v", err)
+	}
+	// Every connection to :memory: opens its own empty database; the relay
+	// polls while the test reads the outbox, so share a single connection.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql.DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	store, err := infrastructure.NewGormEventStore(db)
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	outbox, err := infrastructure.NewGormOutbox(store, opts...)
+	if err != nil {
+		t.Fatalf("failed to create outbox: %v", err)
+	}
+	return store, outbox
+}
+
+func TestUnitOfWorkWithOutbox(t *testing.T) {
+	t.Parallel()
+
+	t.Run("commit enqueues and the relay delivers once", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		store, outbox := newOutboxStore(t)
+
+		var mu sync.Mutex
+		var delivered []string
+		dispatcher := domain.NewEventDispatcher()
+		if _, err := domain.Subscribe[any](dispatcher, "test.created", func(_ context.Context, env domain.EventEnvelope[any]) error {
+			mu.Lock()
+			delivered = append(delivered, env.ID)
+			mu.Unlock()
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		uow := application.NewUnitOfWorkWithOutbox(store, outbox)
+		entity := NewTestEntity("user-1", "Ada", "ada@example.com")
+		if err := entity.RecordEvent(map[string]string{"name": "Ada"}, "test.created"); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+		if err := uow.Track(entity); err != nil {
+			t.Fatalf("Track() error = %v", err)
+		}
+		if err := uow.Commit(ctx); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+
+		pending, err := outbox.Pending(ctx, 0)
+		if err != nil || len(pending) != 1 {
+			t.Fatalf("Pending() = %d events, %v, want 1", len(pending), err)
+		}
+
+		relay := application.NewOutboxRelay(outbox, dispatcher, application.WithOutboxPollInterval(5*time.Millisecond))
+		if err := relay.Start(ctx); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		if err := relay.Start(ctx); !errors.Is(err, application.ErrRelayStarted) {
+			t.Errorf("second Start() error = %v, want ErrRelayStarted", err)
+		}
+		waitFor(t, func() bool {
+			pending, err := outbox.Pending(ctx, 0)
+			return err == nil && len(pending) == 0
+		})
+		time.Sleep(20 * time.Millisecond)
+		relay.Stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(delivered) != 1 || delivered[0] != pending[0].ID {
+			t.Errorf("delivered = %v, want exactly %s", delivered, pending[0].ID)
+		}
+	})
+
+	t.Run("failed dispatch stays pending and is retried", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		store, outbox := newOutboxStore(t, infrastructure.WithOutboxRetryBackoff(time.Millisecond, time.Millisecond))
+
+		var mu sync.Mutex
+		attempts := 0
+		dispatcher := domain.NewEventDispatcher()
+		if _, err := domain.Subscribe[any](dispatcher, "test.created", func(context.Context, domain.EventEnvelope[any]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			if attempts < 3 {
+				return errors.New("handler down")
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		uow := application.NewUnitOfWorkWithOutbox(store, outbox)
+		entity := NewTestEntity("user-1", "Ada", "ada@example.com")
+		if err := entity.RecordEvent(map[string]string{"name": "Ada"}, "test.created"); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+		if err := uow.Track(entity); err != nil {
+			t.Fatalf("Track() error = %v", err)
+		}
+		if err := uow.Commit(ctx); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+
+		relay := application.NewOutboxRelay(outbox, dispatcher, application.WithOutboxPollInterval(time.Millisecond))
+		if err := relay.Start(ctx); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		defer relay.Stop()
+		// A failed event is not pending until its retry is due, so wait for
+		// the delivery as well as for an empty outbox.
+		waitFor(t, func() bool {
+			mu.Lock()
+			done := attempts >= 3
+			mu.Unlock()
+			pending, err := outbox.Pending(ctx, 0)
+			return done && err == nil && len(pending) == 0
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		if attempts != 3 {
+			t.Errorf("attempts = %d, want 3", attempts)
+		}
+	})
+
+	t.Run("a failing event holds back only its own aggregate", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		store, outbox := newOutboxStore(t, infrastructure.WithOutboxRetryBackoff(20*time.Millisecond, 20*time.Millisecond))
+		commitOutboxEvents(t, store, outbox, "user-1", 2)
+		commitOutboxEvents(t, store, outbox, "user-2", 1)
+
+		var mu sync.Mutex
+		var delivered []string
+		failures := 0
+		dispatcher := domain.NewEventDispatcher()
+		if _, err := domain.Subscribe[any](dispatcher, "test.created", func(_ context.Context, env domain.EventEnvelope[any]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if env.AggregateID == "user-1" && env.SequenceNo == 1 && failures < 2 {
+				failures++
+				return errors.New("handler down")
+			}
+			delivered = append(delivered, fmt.Sprintf("%s/%d", env.AggregateID, env.SequenceNo))
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		// A batch of one is the oldest pending event, so a failing head of
+		// the outbox would block everything behind it.
+		relay := application.NewOutboxRelay(outbox, dispatcher,
+			application.WithOutboxBatchSize(1), application.WithOutboxPollInterval(time.Millisecond))
+		if err := relay.Start(ctx); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		defer relay.Stop()
+		waitFor(t, func() bool {
+			pending, err := outbox.Pending(ctx, 0)
+			mu.Lock()
+			defer mu.Unlock()
+			return err == nil && len(pending) == 0 && len(delivered) == 3
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		want := []string{"user-2/1", "user-1/1", "user-1/2"}
+		if !slices.Equal(delivered, want) {
+			t.Errorf("delivered = %v, want %v", delivered, want)
+		}
+	})
+
+	t.Run("failed commit enqueues nothing", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		store, outbox := newOutboxStore(t)
+
+		hookErr := errors.New("hook failed")
+		uow := application.NewUnitOfWorkWithOutbox(store, outbox, application.WithTxHook(func(context.Context) error { return hookErr }))
+		entity := NewTestEntity("user-1", "Ada", "ada@example.com")
+		if err := entity.RecordEvent(map[string]string{"name": "Ada"}, "test.created"); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+		if err := uow.Track(entity); err != nil {
+			t.Fatalf("Track() error = %v", err)
+		}
+		if err := uow.Commit(ctx); !errors.Is(err, hookErr) {
+			t.Fatalf("Commit() error = %v, want %v", err, hookErr)
+		}
+		if pending, err := outbox.Pending(ctx, 0); err != nil || len(pending) != 0 {
+			t.Errorf("Pending() = %d events, %v, want none", len(pending), err)
+		}
+	})
+
+	t.Run("enqueue outside a transaction is refused", func(t *testing.T) {
+		t.Parallel()
+		_, outbox := newOutboxStore(t)
+		err := outbox.Enqueue(context.Background(), domain.EventEnvelope[any]{ID: "ev-1"})
+		if !errors.Is(err, domain.ErrOutboxOutsideTransaction) {
+			t.Errorf("Enqueue() error = %v, want ErrOutboxOutsideTransaction", err)
+		}
+	})
+}
+
+func TestOutboxRelay_AdaptivePolling(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store, outbox := newOutboxStore(t)
+	commitOutboxEvents(t, store, outbox, "user-1", 5)
+
+	var mu sync.Mutex
+	delivered := 0
+	dispatcher := domain.NewEventDispatcher()
+	if _, err := domain.Subscribe[any](dispatcher, "test.created", func(context.Context, domain.EventEnvelope[any]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered++
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	deliveredCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return delivered
+	}
+
+	clock := newFakeClock()
+	relay := application.NewOutboxRelay(outbox, dispatcher,
+		application.WithOutboxBatchSize(2),
+		application.WithOutboxPollInterval(time.Second),
+		application.WithOutboxIdleBackoff(4*time.Second),
+		application.WithOutboxClock(clock))
+	if err := relay.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer relay.Stop()
+
+	// Two full batches drain back to back; only the trailing partial batch
+	// waits, so the whole backlog is delivered before the first wait.
+	if got := clock.nextWait(t); got != time.Second {
+		t.Fatalf("first wait = %v, want the poll interval", got)
+	}
+	if got := deliveredCount(); got != 5 {
+		t.Fatalf("delivered %d events before the first wait, want 5", got)
+	}
+
+	// Empty polls back off from the poll interval up to the cap.
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		clock.fire <- time.Time{}
+		if got := clock.nextWait(t); got != want {
+			t.Fatalf("idle wait = %v, want %v", got, want)
+		}
+	}
+
+	// New events reset the backoff.
+	commitOutboxEvents(t, store, outbox, "user-2", 1)
+	clock.fire <- time.Time{}
+	if got := clock.nextWait(t); got != time.Second {
+		t.Fatalf("wait after new events = %v, want the poll interval", got)
+	}
+	if got := deliveredCount(); got != 6 {
+		t.Fatalf("delivered %d events, want 6", got)
+	}
+}
+
+// commitOutboxEvents commits n test.created events of aggregateID through an
+// outbox unit of work.
+func commitOutboxEvents(t *testing.T, store *infrastructure.GormEventStore, outbox *infrastructure.GormOutbox, aggregateID string, n int) {
+	t.Helper()
+	uow := application.NewUnitOfWorkWithOutbox(store, outbox)
+	entity := NewTestEntity(aggregateID, "Ada", "ada@example.com")
+	for i := range n {
+		if err := entity.RecordEvent(map[string]int{"n": i}, "test.created"); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+	}
+	if err := uow.Track(entity); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	if err := uow.Commit(context.Background()); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+}
+
+// fakeClock reports every wait the relay requests and releases it only when
+// the test fires.
+type fakeClock struct {
+	waits chan time.Duration
+	fire  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{waits: make(chan time.Duration, 16), fire: make(chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time { return time.Unix(0, 0) }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+func (c *fakeClock) nextWait(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.waits:
+		return d
+	case <-time.After(10 * time.Second):
+		t.Fatal("relay never waited")
+		return 0
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -2,8 +2,12 @@ package application
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
 	"github.com/segmentio/ksuid"
@@ -21,9 +25,20 @@ type UnitOfWork interface {
 
 	// Commit persists all uncommitted events from all tracked entities atomically.
 	// If any entity fails to persist, the entire commit fails and rollback occurs.
+	// Each event's metadata records domain.MetadataTriggeredBy from ctx (see
+	// domain.ContextWithTrigger) and the correlation and causation IDs from
+	// domain.ContextWithCorrelation unless the event already carries them,
+	// events committed under domain.ContextWithBackfill are flagged as
+	// backfill, and metadata from domain.ContextWithEventMetadata is merged in
+	// without overriding keys the event already carries.
 	// After successful persistence, events are optionally dispatched via EventDispatcher.
 	Commit(ctx context.Context) error
 
+	// DryRun runs the pre-persistence checks Commit relies on and reports any
+	// failure without persisting or dispatching. Its concurrency check is
+	// advisory: a successful DryRun does not guarantee that Commit succeeds.
+	DryRun(ctx context.Context) error
+
 	// Rollback clears the tracking of entities without clearing their uncommitted events.
 	// This allows entities to be retried in a new unit of work.
 	Rollback() error
@@ -36,19 +51,215 @@ type SimpleUnitOfWork struct {
 	dispatcher       *domain.EventDispatcher
 	entities         map[string]domain.Entity
 	expectedVersions map[string]int
+	originRegion     string
+	eventVersions    *domain.UpcasterRegistry
+	txHooks          []TxHook
 	mu               sync.RWMutex
+
+	// Clock-skew handling; see WithClockSkewDetection.
+	detectSkew bool
+	clampSkew  bool
+
+	// Stream length limits; see WithStreamLengthLimit.
+	streamLimit           StreamLengthLimit
+	streamLimitByCategory map[string]StreamLengthLimit
+
+	// Event attribution; see WithAttribution.
+	attribution      AttributionPolicy
+	defaultActorID   string
+	defaultAccountID string
+
+	// Outbox delivery; see NewUnitOfWorkWithOutbox.
+	outbox domain.Outbox
+
+	logger *slog.Logger
+}
+
+// ErrStreamTooLong is returned by Commit and DryRun when a commit would take
+// an aggregate's stream past its StreamLengthLimit.Max.
+var ErrStreamTooLong = errors.New("aggregate stream exceeds its maximum length")
+
+// ErrUnattributedEvent is returned by Commit under AttributionError when an
+// event has no actor.
+var ErrUnattributedEvent = errors.New("event has no actor")
+
+// AttributionPolicy decides what Commit does with an event that has no actor:
+// neither its own domain.MetadataActorID nor one recorded in the commit
+// context with domain.ContextWithActor.
+type AttributionPolicy int
+
+const (
+	// AttributionOff stamps nothing and checks nothing. It is the default.
+	AttributionOff AttributionPolicy = iota
+
+	// AttributionWarn commits unattributed events, logging a warning for
+	// each.
+	AttributionWarn
+
+	// AttributionError refuses the whole commit with ErrUnattributedEvent,
+	// so every stored event names who caused it.
+	AttributionError
+
+	// AttributionDefault attributes such events to the actor and account set
+	// with WithDefaultAttribution.
+	AttributionDefault
+)
+
+// StreamLengthLimit bounds how many events one aggregate's stream may hold.
+// A stream that keeps growing usually means an aggregate that should have
+// been split, and every load replays all of it. Zero disables either bound.
+type StreamLengthLimit struct {
+	// Warn logs a warning for every commit that leaves the stream longer
+	// than Warn.
+	Warn int
+
+	// Max refuses, with ErrStreamTooLong, any commit that would leave the
+	// stream longer than Max.
+	Max int
+}
+
+// TxHook is work that must commit atomically with a unit of work's events,
+// such as reserving a unique value in a relational lookup table. It runs
+// inside the event store transaction after the events are appended; ctx
+// carries that transaction (see infrastructure.TxFromContext and
+// infrastructure.GormTxHook). Returning an error rolls back the event save.
+type TxHook func(ctx context.Context) error
+
+// UnitOfWorkOption configures a SimpleUnitOfWork.
+type UnitOfWorkOption func(*SimpleUnitOfWork)
+
+// WithOriginRegion stamps domain.MetadataOriginRegion with region on every
+// committed event that does not already carry one. Replicated events keep the
+// region they were committed in, so handlers wrapped with
+// domain.LocalOriginOnly can tell them apart.
+func WithOriginRegion(region string) UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		uow.originRegion = region
+	}
+}
+
+// WithEventVersions stamps domain.MetadataEventVersion on every committed
+// event whose type has upcasters in registry, recording the current version
+// (registry.CurrentVersion) unless the event already carries one. Without
+// the stamp, an event written in the current shape would be read back as
+// version 1 and wrongly upcast; use the same registry as the dispatcher's
+// domain.WithUpcasters.
+func WithEventVersions(registry *domain.UpcasterRegistry) UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		uow.eventVersions = registry
+	}
+}
+
+// WithTxHook registers hooks that run in the same transaction as the event
+// save on every commit with events to persist. The event store must
+// implement domain.TransactionalEventStore; with any other store Commit fails
+// without persisting rather than run the hooks non-atomically.
+func WithTxHook(hooks ...TxHook) UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		uow.txHooks = append(uow.txHooks, hooks...)
+	}
+}
+
+// WithClockSkewDetection logs a warning through logger (nil means
+// slog.Default()) whenever an event's Created timestamp is earlier than the
+// aggregate's previous event — a sign the recording node's clock is skewed.
+// Ordering always follows sequence numbers, so this only flags the
+// timestamps; it costs one read of the aggregate's latest stored event per
+// commit.
+func WithClockSkewDetection(logger *slog.Logger) UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		uow.detectSkew = true
+		if logger != nil {
+			uow.logger = logger
+		}
+	}
+}
+
+// WithMonotonicTimestamps enables WithClockSkewDetection and additionally
+// clamps a skewed event's Created timestamp to its predecessor's, so
+// timestamps never go backwards within an aggregate. The original timestamp
+// is preserved under domain.MetadataRawCreatedAt. Clamping rewrites the
+// recorded business time, which some audit regimes disallow, so it is
+// opt-in.
+func WithMonotonicTimestamps() UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		uow.detectSkew = true
+		uow.clampSkew = true
+	}
+}
+
+// WithAttribution stamps domain.MetadataActorID and, when known,
+// domain.MetadataAccountID on every committed event from the actor in the
+// commit context (domain.ContextWithActor), unless the event already carries
+// them, and applies policy to events left without an actor. Warnings go
+// through logger (nil means slog.Default()). An actor without an account is
+// attributed: system events and single-tenant applications have no account.
+// Events from migrations and jobs should be committed under
+// domain.ContextWithSystemActor so AttributionError does not refuse them.
+func WithAttribution(policy AttributionPolicy, logger *slog.Logger) UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		uow.attribution = policy
+		if logger != nil {
+			uow.logger = logger
+		}
+	}
+}
+
+// WithDefaultAttribution enables WithAttribution under AttributionDefault,
+// attributing events committed without an actor to actorID in accountID
+// (which may be empty).
+func WithDefaultAttribution(actorID, accountID string) UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		uow.attribution = AttributionDefault
+		uow.defaultActorID = actorID
+		uow.defaultAccountID = accountID
+	}
+}
+
+// WithStreamLengthLimit applies limit to every aggregate without a
+// category-specific limit (see WithCategoryStreamLengthLimit), logging
+// warnings through logger (nil means slog.Default()). The check is cheap: the
+// stream length is the sequence number of the aggregate's last event, so no
+// events are counted.
+func WithStreamLengthLimit(limit StreamLengthLimit, logger *slog.Logger) UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		uow.streamLimit = limit
+		if logger != nil {
+			uow.logger = logger
+		}
+	}
+}
+
+// WithCategoryStreamLengthLimit overrides the WithStreamLengthLimit limit for
+// aggregates whose events are in category (see domain.EventCategory), for
+// aggregate types that legitimately keep long streams. A zero limit exempts
+// the category.
+func WithCategoryStreamLengthLimit(category string, limit StreamLengthLimit) UnitOfWorkOption {
+	return func(uow *SimpleUnitOfWork) {
+		if uow.streamLimitByCategory == nil {
+			uow.streamLimitByCategory = make(map[string]StreamLengthLimit)
+		}
+		uow.streamLimitByCategory[category] = limit
+	}
 }
 
 // NewSimpleUnitOfWork creates a new SimpleUnitOfWork instance.
 // eventStore is required for persisting events.
 // dispatcher is optional and can be nil if event dispatch is not needed.
-func NewSimpleUnitOfWork(eventStore domain.EventStore, dispatcher *domain.EventDispatcher) *SimpleUnitOfWork {
-	return &SimpleUnitOfWork{
+func NewSimpleUnitOfWork(eventStore domain.EventStore, dispatcher *domain.EventDispatcher, opts ...UnitOfWorkOption) *SimpleUnitOfWork {
+	uow := &SimpleUnitOfWork{
 		eventStore:       eventStore,
 		dispatcher:       dispatcher,
 		entities:         make(map[string]domain.Entity),
 		expectedVersions: make(map[string]int),
 	}
+	for _, opt := range opts {
+		opt(uow)
+	}
+	if uow.logger == nil {
+		uow.logger = slog.Default()
+	}
+	return uow
 }
 
 // Track registers one or more entities to be included in the unit of work.
@@ -100,6 +311,9 @@ func (uow *SimpleUnitOfWork) Track(entities ...domain.Entity) error {
 }
 
 // Commit persists all uncommitted events from all tracked entities atomically.
+// With a domain.TransactionalEventStore every aggregate's events and any
+// WithTxHook hooks are saved in one transaction; other stores append each
+// aggregate separately.
 func (uow *SimpleUnitOfWork) Commit(ctx context.Context) error {
 	uow.mu.Lock()
 
@@ -121,14 +335,59 @@ func (uow *SimpleUnitOfWork) Commit(ctx context.Context) error {
 		return nil
 	}
 
-	// Stamp all events with the same transaction ID, then build allEvents from the stamped slices
+	// Stamp all events with the same transaction ID and the provenance
+	// recorded in ctx. Keys an event already carries are left alone, and
+	// the built-in keys win over ones attached with ContextWithEventMetadata.
 	transactionID := ksuid.New().String()
-	var allEvents []domain.EventEnvelope[any]
+	defaults := map[string]interface{}{domain.MetadataTriggeredBy: domain.TriggerFromContext(ctx)}
+	correlationID, causationID := domain.CorrelationFromContext(ctx)
+	if correlationID != "" {
+		defaults[domain.MetadataCorrelationID] = correlationID
+	}
+	if causationID != "" {
+		defaults[domain.MetadataCausationID] = causationID
+	}
+	if domain.BackfillFromContext(ctx) {
+		defaults[domain.MetadataBackfill] = true
+	}
+	if uow.originRegion != "" {
+		defaults[domain.MetadataOriginRegion] = uow.originRegion
+	}
+	for key, value := range domain.EventMetadataFromContext(ctx) {
+		if _, exists := defaults[key]; !exists {
+			defaults[key] = value
+		}
+	}
 	for _, events := range eventsByAggregate {
 		for i := range events {
 			events[i].TransactionID = transactionID
+			// Each event gets its own copy of its metadata, keeping the
+			// entity's envelope untouched if the commit later fails; the
+			// steps below assign into that copy directly.
+			metadata := make(map[string]interface{}, len(events[i].Metadata)+len(defaults)+1)
+			for key, value := range events[i].Metadata {
+				metadata[key] = value
+			}
+			if uow.eventVersions != nil {
+				if version := uow.eventVersions.CurrentVersion(events[i].EventType); version > 1 {
+					if _, exists := metadata[domain.MetadataEventVersion]; !exists {
+						metadata[domain.MetadataEventVersion] = version
+					}
+				}
+			}
+			for key, value := range defaults {
+				if _, exists := metadata[key]; !exists {
+					metadata[key] = value
+				}
+			}
+			events[i].Metadata = metadata
 		}
-		allEvents = append(allEvents, events...)
+	}
+
+	if err := uow.attribute(ctx, eventsByAggregate); err != nil {
+		uow.mu.Unlock()
+		_ = uow.Rollback()
+		return err
 	}
 
 	// Store references before unlocking
@@ -141,19 +400,68 @@ func (uow *SimpleUnitOfWork) Commit(ctx context.Context) error {
 		expectedVersions[k] = v
 	}
 	dispatcher := uow.dispatcher
+	txHooks := uow.txHooks
+	outbox := uow.outbox
 	uow.mu.Unlock()
 
-	// Persist events for each aggregate with optimistic concurrency control
 	for aggregateID, events := range eventsByAggregate {
-		expectedVersion := expectedVersions[aggregateID]
-
-		// Append events to event store with expected version
-		if err := uow.eventStore.Append(ctx, aggregateID, expectedVersion, events...); err != nil {
-			// Rollback on failure
+		if err := uow.checkStreamLength(aggregateID, expectedVersions[aggregateID], events); err != nil {
 			_ = uow.Rollback()
-			return fmt.Errorf("failed to persist events for aggregate %q: %w", aggregateID, err)
+			return err
+		}
+	}
+	if uow.detectSkew {
+		for aggregateID, events := range eventsByAggregate {
+			uow.checkClockSkew(ctx, aggregateID, expectedVersions[aggregateID], events)
 		}
 	}
+	var allEvents []domain.EventEnvelope[any]
+	for _, events := range eventsByAggregate {
+		allEvents = append(allEvents, events...)
+	}
+
+	// Persist events for each aggregate with optimistic concurrency control,
+	// then run the transaction hooks.
+	persist := func(ctx context.Context) error {
+		for aggregateID, events := range eventsByAggregate {
+			expectedVersion := expectedVersions[aggregateID]
+
+			// Append events to event store with expected version
+			if err := uow.eventStore.Append(ctx, aggregateID, expectedVersion, events...); err != nil {
+				return fmt.Errorf("failed to persist events for aggregate %q: %w", aggregateID, err)
+			}
+		}
+		for _, hook := range txHooks {
+			if err := hook(ctx); err != nil {
+				return fmt.Errorf("transaction hook failed: %w", err)
+			}
+		}
+		if outbox != nil {
+			if err := outbox.Enqueue(ctx, allEvents...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Stores that support transactions persist every aggregate, hook and
+	// outbox row atomically; hooks and the outbox are refused on stores that
+	// cannot.
+	var err error
+	if txStore, ok := uow.eventStore.(domain.TransactionalEventStore); ok {
+		err = txStore.InTransaction(ctx, persist)
+	} else if len(txHooks) > 0 {
+		err = errors.New("transaction hooks require an event store implementing domain.TransactionalEventStore")
+	} else if outbox != nil {
+		err = errors.New("an outbox requires an event store implementing domain.TransactionalEventStore")
+	} else {
+		err = persist(ctx)
+	}
+	if err != nil {
+		// Rollback on failure
+		_ = uow.Rollback()
+		return err
+	}
 
 	// Clear uncommitted events from all entities
 	uow.mu.Lock()
@@ -165,18 +473,105 @@ func (uow *SimpleUnitOfWork) Commit(ctx context.Context) error {
 	uow.expectedVersions = make(map[string]int)
 	uow.mu.Unlock()
 
-	// Dispatch events if dispatcher is provided
+	// Dispatch events if dispatcher is provided, as one batch so batch
+	// handlers can process the whole commit in a single call
 	if dispatcher != nil && len(allEvents) > 0 {
-		for _, event := range allEvents {
-			if err := dispatcher.Dispatch(ctx, event); err != nil {
-				// Events are already persisted, so dispatch errors don't fail the commit
-				// This follows eventual consistency model
-				// Log or handle dispatch errors as needed
-				_ = err // Dispatch errors are non-fatal
+		if err := dispatcher.DispatchBatch(ctx, allEvents); err != nil {
+			// Events are already persisted, so dispatch errors don't fail the commit
+			// This follows eventual consistency model
+			// Log or handle dispatch errors as needed
+			_ = err // Dispatch errors are non-fatal
+		}
+	}
+
+	return nil
+}
+
+// DryRun validates the tracked entities and their uncommitted events as
+// Commit would, without writing, dispatching or clearing anything, so an API
+// can offer a validate-only mode. It checks that:
+//
+//   - every entity implementing IsValid() bool reports itself valid;
+//   - every event belongs to its aggregate, has an ID and a type, and the
+//     events continue the aggregate's sequence from its expected version;
+//   - every payload and metadata map can be serialized for storage;
+//   - the store's current version for each aggregate still equals the
+//     version the entity was loaded at.
+//
+// The concurrency check is advisory only. Another writer may append to an
+// aggregate between DryRun and Commit, so callers must still handle
+// domain.ErrConcurrencyConflict from Commit and must not treat a nil DryRun
+// as a guarantee. All failures are reported together.
+func (uow *SimpleUnitOfWork) DryRun(ctx context.Context) error {
+	uow.mu.RLock()
+	entities := make(map[string]domain.Entity, len(uow.entities))
+	for k, v := range uow.entities {
+		entities[k] = v
+	}
+	expectedVersions := make(map[string]int, len(uow.expectedVersions))
+	for k, v := range uow.expectedVersions {
+		expectedVersions[k] = v
+	}
+	uow.mu.RUnlock()
+
+	var errs []error
+	for aggregateID, entity := range entities {
+		if v, ok := entity.(interface{ IsValid() bool }); ok && !v.IsValid() {
+			errs = append(errs, fmt.Errorf("aggregate %q is not valid", aggregateID))
+		}
+
+		events := entity.GetUncommittedEvents()
+		if len(events) == 0 {
+			continue
+		}
+
+		expectedVersion := expectedVersions[aggregateID]
+		for i, event := range events {
+			if err := validateEvent(aggregateID, expectedVersion+i+1, event); err != nil {
+				errs = append(errs, fmt.Errorf("aggregate %q: %w", aggregateID, err))
 			}
 		}
+		if err := uow.checkStreamLength(aggregateID, expectedVersion, events); err != nil {
+			errs = append(errs, err)
+		}
+
+		currentVersion, err := uow.eventStore.GetCurrentVersion(ctx, aggregateID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read current version for aggregate %q: %w", aggregateID, err))
+			continue
+		}
+		if currentVersion != expectedVersion {
+			errs = append(errs, fmt.Errorf("aggregate %q: %w: expected version %d, got %d",
+				aggregateID, domain.ErrConcurrencyConflict, expectedVersion, currentVersion))
+		}
 	}
 
+	if len(errs) > 0 {
+		return fmt.Errorf("dry run failed: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validateEvent applies the structural checks event stores make on Append,
+// plus serializability of the payload and metadata.
+func validateEvent(aggregateID string, wantSequenceNo int, event domain.EventEnvelope[any]) error {
+	switch {
+	case event.AggregateID != aggregateID:
+		return fmt.Errorf("%w: aggregate ID mismatch on event %s", domain.ErrInvalidEvent, event.ID)
+	case event.ID == "":
+		return fmt.Errorf("%w: event ID is required", domain.ErrInvalidEvent)
+	case event.EventType == "":
+		return fmt.Errorf("%w: event type is required on event %s", domain.ErrInvalidEvent, event.ID)
+	case event.SequenceNo != wantSequenceNo:
+		return fmt.Errorf("%w: event %s has sequence number %d, expected %d",
+			domain.ErrInvalidEvent, event.ID, event.SequenceNo, wantSequenceNo)
+	}
+	if _, err := json.Marshal(event.Payload); err != nil {
+		return fmt.Errorf("%w: payload of event %s cannot be serialized: %v", domain.ErrInvalidEvent, event.ID, err)
+	}
+	if _, err := json.Marshal(event.Metadata); err != nil {
+		return fmt.Errorf("%w: metadata of event %s cannot be serialized: %v", domain.ErrInvalidEvent, event.ID, err)
+	}
 	return nil
 }
 
@@ -190,3 +585,105 @@ func (uow *SimpleUnitOfWork) Rollback() error {
 
 	return nil
 }
+
+// checkStreamLength applies the stream length limit for the aggregate's
+// category to the length the stream will have once events are appended.
+func (uow *SimpleUnitOfWork) checkStreamLength(aggregateID string, expectedVersion int, events []domain.EventEnvelope[any]) error {
+	if len(events) == 0 {
+		return nil
+	}
+	category := domain.EventCategory(events[0].EventType)
+	limit, ok := uow.streamLimitByCategory[category]
+	if !ok {
+		limit = uow.streamLimit
+	}
+	length := expectedVersion + len(events)
+	if limit.Max > 0 && length > limit.Max {
+		return fmt.Errorf("%w: aggregate %q (%s) would reach %d events, limit %d",
+			ErrStreamTooLong, aggregateID, category, length, limit.Max)
+	}
+	if limit.Warn > 0 && length > limit.Warn {
+		uow.logger.Warn("aggregate stream is unusually long; consider splitting the aggregate",
+			slog.String("aggregate_id", aggregateID),
+			slog.String("category", category),
+			slog.Int("length", length),
+			slog.Int("warn_at", limit.Warn))
+	}
+	return nil
+}
+
+// checkClockSkew compares each event's Created timestamp with its
+// predecessor's — the aggregate's latest stored event for the first one —
+// logging any that go backwards and, with WithMonotonicTimestamps, clamping
+// them in place. A failed read of the stored predecessor only narrows the
+// check to the events being committed.
+func (uow *SimpleUnitOfWork) checkClockSkew(ctx context.Context, aggregateID string, expectedVersion int, events []domain.EventEnvelope[any]) {
+	var previous time.Time
+	if expectedVersion > 0 {
+		stored, err := uow.eventStore.GetEventsRange(ctx, aggregateID, expectedVersion, expectedVersion)
+		if err == nil && len(stored) == 1 {
+			previous = stored[0].Created
+		}
+	}
+
+	for i := range events {
+		created := events[i].Created
+		if !previous.IsZero() && created.Before(previous) {
+			uow.logger.Warn("event timestamp earlier than its predecessor; clock skew suspected",
+				slog.String("aggregate_id", aggregateID),
+				slog.String("event_id", events[i].ID),
+				slog.Int("sequence_no", events[i].SequenceNo),
+				slog.Time("created", created),
+				slog.Time("previous", previous),
+				slog.Bool("clamped", uow.clampSkew))
+			if uow.clampSkew {
+				if _, exists := events[i].Metadata[domain.MetadataRawCreatedAt]; !exists {
+					events[i].Metadata[domain.MetadataRawCreatedAt] = created.Format(time.RFC3339Nano)
+				}
+				events[i].Created = previous
+				continue
+			}
+		}
+		previous = created
+	}
+}
+
+// attribute applies the attribution policy to the events about to be
+// committed, failing under AttributionError before anything is stamped.
+func (uow *SimpleUnitOfWork) attribute(ctx context.Context, eventsByAggregate map[string][]domain.EventEnvelope[any]) error {
+	if uow.attribution == AttributionOff {
+		return nil
+	}
+	actorID, accountID := domain.ActorFromContext(ctx)
+	if actorID == "" && uow.attribution == AttributionError {
+		for aggregateID, events := range eventsByAggregate {
+			for _, event := range events {
+				if _, ok := event.Metadata[domain.MetadataActorID]; !ok {
+					return fmt.Errorf("%w: %s event of aggregate %s", ErrUnattributedEvent, event.EventType, aggregateID)
+				}
+			}
+		}
+	}
+	if actorID == "" && uow.attribution == AttributionDefault {
+		actorID, accountID = uow.defaultActorID, uow.defaultAccountID
+	}
+
+	for aggregateID, events := range eventsByAggregate {
+		for i := range events {
+			if _, ok := events[i].Metadata[domain.MetadataActorID]; ok {
+				continue
+			}
+			if actorID == "" {
+				uow.logger.Warn("committing an event without an actor",
+					slog.String("aggregate_id", aggregateID),
+					slog.String("event_type", events[i].EventType))
+				continue
+			}
+			events[i].Metadata[domain.MetadataActorID] = actorID
+			if _, exists := events[i].Metadata[domain.MetadataAccountID]; !exists && accountID != "" {
+				events[i].Metadata[domain.MetadataAccountID] = accountID
+			}
+		}
+	}
+	return nil
+}
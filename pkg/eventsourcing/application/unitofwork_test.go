@@ -1,11 +1,15 @@
 package application_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/akeemphilbert/pericarp/pkg/ddd"
 	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/application"
@@ -300,7 +304,7 @@ func TestCommit(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[map[string]string](dispatcher, "test.created", handler); err != nil {
+		if _, err := domain.Subscribe[map[string]string](dispatcher, "test.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe handler: %v", err)
 		}
 
@@ -797,7 +801,7 @@ func TestCommit_TransactionID(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[map[string]string](dispatcher, "test.created", handler); err != nil {
+		if _, err := domain.Subscribe[map[string]string](dispatcher, "test.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -842,3 +846,790 @@ func (f *failingEventStore) Append(ctx context.Context, aggregateID string, expe
 	}
 	return f.MemoryStore.Append(ctx, aggregateID, expectedVersion, events...)
 }
+
+func TestCommit_EventVersions(t *testing.T) {
+	t.Parallel()
+
+	registry := domain.NewUpcasterRegistry()
+	noop := domain.UpcasterFunc(func(eventType string, _ int, payload []byte) ([]byte, string, error) {
+		return payload, eventType, nil
+	})
+	for _, from := range []int{1, 2} {
+		if err := registry.Register("test.created", from, noop); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	eventStore := infrastructure.NewMemoryStore()
+	uow := application.NewSimpleUnitOfWork(eventStore, nil, application.WithEventVersions(registry))
+	entity := NewTestEntity("entity-1", "Test", "test@example.com")
+	if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	if err := entity.RecordEvent(map[string]string{"name": "Renamed"}, "test.renamed"); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	if err := uow.Track(entity); err != nil {
+		t.Fatalf("Failed to track entity: %v", err)
+	}
+	ctx := context.Background()
+	if err := uow.Commit(ctx); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	events, err := eventStore.GetEvents(ctx, "entity-1")
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if got := domain.EventVersion(events[0].Metadata); got != 3 {
+		t.Errorf("test.created version = %d, want the current version 3", got)
+	}
+	if _, stamped := events[1].Metadata[domain.MetadataEventVersion]; stamped {
+		t.Error("expected an event type without upcasters to be left unstamped")
+	}
+}
+
+func TestCommit_OriginRegion(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stamps local region on new events", func(t *testing.T) {
+		t.Parallel()
+		eventStore := infrastructure.NewMemoryStore()
+		uow := application.NewSimpleUnitOfWork(eventStore, nil, application.WithOriginRegion("us-east-1"))
+
+		entity := NewTestEntity("entity-1", "Test", "test@example.com")
+		if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+		if err := uow.Track(entity); err != nil {
+			t.Fatalf("Failed to track entity: %v", err)
+		}
+
+		ctx := context.Background()
+		if err := uow.Commit(ctx); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		events, err := eventStore.GetEvents(ctx, "entity-1")
+		if err != nil {
+			t.Fatalf("Failed to get events: %v", err)
+		}
+		if got := domain.OriginRegion(events[0]); got != "us-east-1" {
+			t.Errorf("Expected origin region us-east-1, got %q", got)
+		}
+	})
+
+	t.Run("keeps origin region of replicated events", func(t *testing.T) {
+		t.Parallel()
+		eventStore := infrastructure.NewMemoryStore()
+		uow := application.NewSimpleUnitOfWork(eventStore, nil, application.WithOriginRegion("us-east-1"))
+
+		entity := NewTestEntity("entity-1", "Test", "test@example.com")
+		replicated := domain.NewEventEnvelope[any](map[string]string{"name": "Test"}, "entity-1", "test.created", 1)
+		replicated.Metadata[domain.MetadataOriginRegion] = "eu-west-1"
+		entity2 := &replicatingEntity{TestEntity: entity, events: []domain.EventEnvelope[any]{replicated}}
+		if err := uow.Track(entity2); err != nil {
+			t.Fatalf("Failed to track entity: %v", err)
+		}
+
+		ctx := context.Background()
+		if err := uow.Commit(ctx); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		events, err := eventStore.GetEvents(ctx, "entity-1")
+		if err != nil {
+			t.Fatalf("Failed to get events: %v", err)
+		}
+		if got := domain.OriginRegion(events[0]); got != "eu-west-1" {
+			t.Errorf("Expected origin region eu-west-1, got %q", got)
+		}
+	})
+}
+
+// replicatingEntity hands the unit of work pre-built envelopes, as a
+// replication consumer would when re-committing events from another region.
+type replicatingEntity struct {
+	*TestEntity
+	events []domain.EventEnvelope[any]
+}
+
+func (r *replicatingEntity) GetSequenceNo() int { return len(r.events) }
+
+func (r *replicatingEntity) GetUncommittedEvents() []domain.EventEnvelope[any] { return r.events }
+
+func (r *replicatingEntity) ClearUncommittedEvents() { r.events = nil }
+
+// invalidEntity reports itself invalid so DryRun's validity check trips.
+type invalidEntity struct {
+	*TestEntity
+}
+
+func (invalidEntity) IsValid() bool { return false }
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	newTracked := func(t *testing.T, store domain.EventStore, entity domain.Entity) *application.SimpleUnitOfWork {
+		t.Helper()
+		uow := application.NewSimpleUnitOfWork(store, domain.NewEventDispatcher())
+		if err := uow.Track(entity); err != nil {
+			t.Fatalf("Failed to track entity: %v", err)
+		}
+		return uow
+	}
+
+	t.Run("valid work neither persists nor clears events", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+		entity := NewTestEntity("entity-1", "Test", "test@example.com")
+		if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+		uow := newTracked(t, store, entity)
+
+		if err := uow.DryRun(ctx); err != nil {
+			t.Fatalf("DryRun failed: %v", err)
+		}
+		if version, _ := store.GetCurrentVersion(ctx, "entity-1"); version != 0 {
+			t.Errorf("Expected nothing persisted, got version %d", version)
+		}
+		if got := len(entity.GetUncommittedEvents()); got != 1 {
+			t.Errorf("Expected uncommitted event to remain, got %d", got)
+		}
+		if err := uow.Commit(ctx); err != nil {
+			t.Fatalf("Commit after DryRun failed: %v", err)
+		}
+	})
+
+	t.Run("reports a stale expected version", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+		entity := NewTestEntity("entity-1", "Test", "test@example.com")
+		if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+		uow := newTracked(t, store, entity)
+
+		concurrent := domain.NewEventEnvelope[any](map[string]string{"name": "Other"}, "entity-1", "test.created", 1)
+		if err := store.Append(ctx, "entity-1", 0, concurrent); err != nil {
+			t.Fatalf("Failed to append concurrent event: %v", err)
+		}
+
+		if err := uow.DryRun(ctx); !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Errorf("Expected ErrConcurrencyConflict, got %v", err)
+		}
+	})
+
+	t.Run("reports an invalid aggregate", func(t *testing.T) {
+		t.Parallel()
+		entity := invalidEntity{NewTestEntity("entity-1", "Test", "test@example.com")}
+		uow := newTracked(t, infrastructure.NewMemoryStore(), entity)
+
+		if err := uow.DryRun(ctx); err == nil {
+			t.Error("Expected DryRun to reject an invalid aggregate")
+		}
+	})
+
+	t.Run("reports an unserializable payload", func(t *testing.T) {
+		t.Parallel()
+		entity := NewTestEntity("entity-1", "Test", "test@example.com")
+		if err := entity.RecordEvent(make(chan int), "test.created"); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+		uow := newTracked(t, infrastructure.NewMemoryStore(), entity)
+
+		if err := uow.DryRun(ctx); !errors.Is(err, domain.ErrInvalidEvent) {
+			t.Errorf("Expected ErrInvalidEvent, got %v", err)
+		}
+	})
+}
+
+func TestCommit_TriggeredBy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "outside a command defaults to system", ctx: context.Background(), want: domain.TriggerSystem},
+		{name: "migration marker", ctx: domain.ContextWithTrigger(context.Background(), domain.TriggerMigration), want: domain.TriggerMigration},
+		{name: "command type", ctx: domain.ContextWithTrigger(context.Background(), "user.register"), want: "user.register"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			eventStore := infrastructure.NewMemoryStore()
+			uow := application.NewSimpleUnitOfWork(eventStore, nil)
+
+			entity := NewTestEntity("entity-1", "Test", "test@example.com")
+			if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+				t.Fatalf("Failed to record event: %v", err)
+			}
+			if err := uow.Track(entity); err != nil {
+				t.Fatalf("Failed to track entity: %v", err)
+			}
+			if err := uow.Commit(tt.ctx); err != nil {
+				t.Fatalf("Commit failed: %v", err)
+			}
+
+			events, err := eventStore.GetEvents(context.Background(), "entity-1")
+			if err != nil {
+				t.Fatalf("Failed to get events: %v", err)
+			}
+			if got := events[0].Metadata[domain.MetadataTriggeredBy]; got != tt.want {
+				t.Errorf("Expected %s %q, got %v", domain.MetadataTriggeredBy, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCommit_Backfill(t *testing.T) {
+	t.Parallel()
+
+	eventStore := infrastructure.NewMemoryStore()
+	dispatcher := domain.NewEventDispatcher()
+	var dispatched []bool
+	var mu sync.Mutex
+	if _, err := dispatcher.SubscribeWildcard(func(ctx context.Context, env domain.EventEnvelope[any]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		dispatched = append(dispatched, domain.IsBackfill(env))
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	commit := func(ctx context.Context, id string) {
+		t.Helper()
+		uow := application.NewSimpleUnitOfWork(eventStore, dispatcher)
+		entity := NewTestEntity(id, "Test", "test@example.com")
+		if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+		if err := uow.Track(entity); err != nil {
+			t.Fatalf("Failed to track entity: %v", err)
+		}
+		if err := uow.Commit(ctx); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	commit(ctx, "live-1")
+	commit(domain.ContextWithBackfill(ctx), "backfill-1")
+
+	for id, want := range map[string]bool{"live-1": false, "backfill-1": true} {
+		events, err := eventStore.GetEvents(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to get events: %v", err)
+		}
+		if got := domain.IsBackfill(events[0]); got != want {
+			t.Errorf("%s: persisted IsBackfill = %v, want %v", id, got, want)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(dispatched) != 2 || dispatched[0] || !dispatched[1] {
+		t.Errorf("Expected dispatched backfill flags [false true], got %v", dispatched)
+	}
+}
+
+// stagingEventStore is a TransactionalEventStore over a MemoryStore: appends
+// made inside InTransaction are staged and only reach the store if the
+// transaction function succeeds.
+type stagingEventStore struct {
+	*infrastructure.MemoryStore
+}
+
+type stagedAppend struct {
+	aggregateID     string
+	expectedVersion int
+	events          []domain.EventEnvelope[any]
+}
+
+type stagingKey struct{}
+
+func (s *stagingEventStore) Append(ctx context.Context, aggregateID string, expectedVersion int, events ...domain.EventEnvelope[any]) error {
+	if staged, ok := ctx.Value(stagingKey{}).(*[]stagedAppend); ok {
+		*staged = append(*staged, stagedAppend{aggregateID, expectedVersion, events})
+		return nil
+	}
+	return s.MemoryStore.Append(ctx, aggregateID, expectedVersion, events...)
+}
+
+func (s *stagingEventStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	var staged []stagedAppend
+	if err := fn(context.WithValue(ctx, stagingKey{}, &staged)); err != nil {
+		return err
+	}
+	for _, a := range staged {
+		if err := s.MemoryStore.Append(ctx, a.aggregateID, a.expectedVersion, a.events...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCommit_TxHook(t *testing.T) {
+	t.Parallel()
+
+	record := func(t *testing.T, id string) *TestEntity {
+		t.Helper()
+		entity := NewTestEntity(id, "Test", "test@example.com")
+		if err := entity.RecordEvent(map[string]string{"email": "test@example.com"}, "user.registered"); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+		return entity
+	}
+
+	t.Run("hook runs inside the store transaction", func(t *testing.T) {
+		t.Parallel()
+		store := &stagingEventStore{infrastructure.NewMemoryStore()}
+		var inTx bool
+		uow := application.NewSimpleUnitOfWork(store, nil, application.WithTxHook(func(ctx context.Context) error {
+			_, inTx = ctx.Value(stagingKey{}).(*[]stagedAppend)
+			return nil
+		}))
+		if err := uow.Track(record(t, "user-1")); err != nil {
+			t.Fatalf("Failed to track: %v", err)
+		}
+		if err := uow.Commit(context.Background()); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+		if !inTx {
+			t.Error("Expected hook to receive the transaction context")
+		}
+		if version, _ := store.GetCurrentVersion(context.Background(), "user-1"); version != 1 {
+			t.Errorf("Expected events persisted, got version %d", version)
+		}
+	})
+
+	t.Run("hook failure rolls back the event save", func(t *testing.T) {
+		t.Parallel()
+		store := &stagingEventStore{infrastructure.NewMemoryStore()}
+		errTaken := errors.New("email already reserved")
+		uow := application.NewSimpleUnitOfWork(store, nil, application.WithTxHook(func(ctx context.Context) error {
+			return errTaken
+		}))
+		entity := record(t, "user-1")
+		if err := uow.Track(entity); err != nil {
+			t.Fatalf("Failed to track: %v", err)
+		}
+		if err := uow.Commit(context.Background()); !errors.Is(err, errTaken) {
+			t.Fatalf("Expected hook error, got %v", err)
+		}
+		if version, _ := store.GetCurrentVersion(context.Background(), "user-1"); version != 0 {
+			t.Errorf("Expected no events persisted, got version %d", version)
+		}
+		if got := len(entity.GetUncommittedEvents()); got != 1 {
+			t.Errorf("Expected uncommitted events kept for retry, got %d", got)
+		}
+	})
+
+	t.Run("hooks are refused on a non-transactional store", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+		uow := application.NewSimpleUnitOfWork(store, nil, application.WithTxHook(func(ctx context.Context) error {
+			t.Error("hook must not run without a transaction")
+			return nil
+		}))
+		if err := uow.Track(record(t, "user-1")); err != nil {
+			t.Fatalf("Failed to track: %v", err)
+		}
+		if err := uow.Commit(context.Background()); err == nil {
+			t.Fatal("Expected Commit to fail")
+		}
+		if version, _ := store.GetCurrentVersion(context.Background(), "user-1"); version != 0 {
+			t.Errorf("Expected no events persisted, got version %d", version)
+		}
+	})
+}
+
+// lockingEventStore is a stagingEventStore that records LockAggregates calls
+// and times out on the aggregate IDs in busy.
+type lockingEventStore struct {
+	stagingEventStore
+	busy   map[string]bool
+	locked []string
+}
+
+func (s *lockingEventStore) LockAggregates(ctx context.Context, aggregateIDs ...string) error {
+	if _, ok := ctx.Value(stagingKey{}).(*[]stagedAppend); !ok {
+		return errors.New("not in a transaction")
+	}
+	for _, id := range aggregateIDs {
+		if s.busy[id] {
+			return fmt.Errorf("%w: aggregate %s", domain.ErrLockTimeout, id)
+		}
+		s.locked = append(s.locked, id)
+	}
+	return nil
+}
+
+func TestWithAggregateLocks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("commit runs under the locks", func(t *testing.T) {
+		t.Parallel()
+		store := &lockingEventStore{stagingEventStore: stagingEventStore{infrastructure.NewMemoryStore()}}
+
+		err := application.WithAggregateLocks(context.Background(), store, []string{"ledger-1", "ledger-2"}, func(ctx context.Context) error {
+			if len(store.locked) != 2 {
+				t.Errorf("Expected locks held before fn, got %v", store.locked)
+			}
+			entity := NewTestEntity("ledger-1", "Test", "test@example.com")
+			if err := entity.RecordEvent(map[string]int{"amount": 10}, "ledger.debited"); err != nil {
+				return err
+			}
+			uow := application.NewSimpleUnitOfWork(store, nil)
+			if err := uow.Track(entity); err != nil {
+				return err
+			}
+			return uow.Commit(ctx)
+		})
+		if err != nil {
+			t.Fatalf("WithAggregateLocks failed: %v", err)
+		}
+		if version, _ := store.GetCurrentVersion(context.Background(), "ledger-1"); version != 1 {
+			t.Errorf("Expected events persisted, got version %d", version)
+		}
+	})
+
+	t.Run("lock timeout skips fn", func(t *testing.T) {
+		t.Parallel()
+		store := &lockingEventStore{
+			stagingEventStore: stagingEventStore{infrastructure.NewMemoryStore()},
+			busy:              map[string]bool{"ledger-2": true},
+		}
+
+		called := false
+		err := application.WithAggregateLocks(context.Background(), store, []string{"ledger-1", "ledger-2"}, func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+		if !errors.Is(err, domain.ErrLockTimeout) {
+			t.Fatalf("Expected ErrLockTimeout, got %v", err)
+		}
+		if called {
+			t.Error("Expected fn not to run without its locks")
+		}
+	})
+}
+
+func TestCommit_ContextEventMetadata(t *testing.T) {
+	t.Parallel()
+
+	eventStore := infrastructure.NewMemoryStore()
+	uow := application.NewSimpleUnitOfWork(eventStore, nil)
+
+	entity := NewTestEntity("entity-1", "Test", "test@example.com")
+	if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	entity.GetUncommittedEvents()[0].Metadata["cohort"] = "from-event"
+	if err := uow.Track(entity); err != nil {
+		t.Fatalf("Failed to track entity: %v", err)
+	}
+
+	ctx := domain.ContextWithTrigger(context.Background(), "user.register")
+	ctx = domain.ContextWithEventMetadata(ctx, map[string]string{
+		"ab_variant":               "B",
+		"cohort":                   "from-command",
+		domain.MetadataTriggeredBy: "spoofed",
+	})
+	if err := uow.Commit(ctx); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	events, err := eventStore.GetEvents(context.Background(), "entity-1")
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	want := map[string]any{
+		"ab_variant":               "B",
+		"cohort":                   "from-event",
+		domain.MetadataTriggeredBy: "user.register",
+	}
+	for key, value := range want {
+		if got := events[0].Metadata[key]; got != value {
+			t.Errorf("metadata[%s] = %v, want %v", key, got, value)
+		}
+	}
+}
+
+func TestCommit_Correlation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		eventMetadata   map[string]any
+		wantCorrelation any
+		wantCausation   any
+	}{
+		{name: "outside a command stamps neither", ctx: context.Background()},
+		{
+			name:            "stamps the context's correlation",
+			ctx:             domain.ContextWithCorrelation(context.Background(), "corr-1", "cmd-1"),
+			wantCorrelation: "corr-1",
+			wantCausation:   "cmd-1",
+		},
+		{
+			name:            "event's own metadata wins",
+			ctx:             domain.ContextWithCorrelation(context.Background(), "corr-1", "cmd-1"),
+			eventMetadata:   map[string]any{domain.MetadataCorrelationID: "corr-event", domain.MetadataCausationID: "ev-0"},
+			wantCorrelation: "corr-event",
+			wantCausation:   "ev-0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			eventStore := infrastructure.NewMemoryStore()
+			uow := application.NewSimpleUnitOfWork(eventStore, nil)
+
+			entity := NewTestEntity("entity-1", "Test", "test@example.com")
+			if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+				t.Fatalf("Failed to record event: %v", err)
+			}
+			for key, value := range tt.eventMetadata {
+				entity.GetUncommittedEvents()[0].Metadata[key] = value
+			}
+			if err := uow.Track(entity); err != nil {
+				t.Fatalf("Failed to track entity: %v", err)
+			}
+			if err := uow.Commit(tt.ctx); err != nil {
+				t.Fatalf("Commit failed: %v", err)
+			}
+
+			events, err := eventStore.GetEvents(context.Background(), "entity-1")
+			if err != nil {
+				t.Fatalf("Failed to get events: %v", err)
+			}
+			if got := events[0].Metadata[domain.MetadataCorrelationID]; got != tt.wantCorrelation {
+				t.Errorf("Expected %s %v, got %v", domain.MetadataCorrelationID, tt.wantCorrelation, got)
+			}
+			if got := events[0].Metadata[domain.MetadataCausationID]; got != tt.wantCausation {
+				t.Errorf("Expected %s %v, got %v", domain.MetadataCausationID, tt.wantCausation, got)
+			}
+		})
+	}
+}
+
+// fixedEntity is a domain.Entity with hand-built uncommitted events, so tests
+// control their timestamps.
+type fixedEntity struct {
+	id     string
+	seq    int
+	events []domain.EventEnvelope[any]
+}
+
+func (e *fixedEntity) GetID() string      { return e.id }
+func (e *fixedEntity) GetSequenceNo() int { return e.seq }
+func (e *fixedEntity) GetUncommittedEvents() []domain.EventEnvelope[any] {
+	return append([]domain.EventEnvelope[any](nil), e.events...)
+}
+func (e *fixedEntity) ClearUncommittedEvents() { e.events = nil }
+
+func TestCommit_ClockSkew(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	skewedEvent := func(seq int, created time.Time) domain.EventEnvelope[any] {
+		event := domain.NewEventEnvelope[any](map[string]int{"seq": seq}, "ledger-1", "ledger.posted", seq)
+		event.Created = created
+		return event
+	}
+
+	tests := []struct {
+		name        string
+		opts        []application.UnitOfWorkOption
+		wantCreated time.Time
+		wantRaw     bool
+	}{
+		{name: "detection only logs", wantCreated: base.Add(-time.Hour)},
+		{name: "clamping keeps timestamps monotonic", opts: []application.UnitOfWorkOption{application.WithMonotonicTimestamps()}, wantCreated: base, wantRaw: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			eventStore := infrastructure.NewMemoryStore()
+			if err := eventStore.Append(ctx, "ledger-1", 0, skewedEvent(1, base)); err != nil {
+				t.Fatalf("failed to seed: %v", err)
+			}
+
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, nil))
+			opts := append([]application.UnitOfWorkOption{application.WithClockSkewDetection(logger)}, tt.opts...)
+			uow := application.NewSimpleUnitOfWork(eventStore, nil, opts...)
+
+			entity := &fixedEntity{id: "ledger-1", seq: 2, events: []domain.EventEnvelope[any]{skewedEvent(2, base.Add(-time.Hour))}}
+			if err := uow.Track(entity); err != nil {
+				t.Fatalf("Failed to track: %v", err)
+			}
+			if err := uow.Commit(ctx); err != nil {
+				t.Fatalf("Commit failed: %v", err)
+			}
+
+			if !strings.Contains(logs.String(), "clock skew suspected") {
+				t.Errorf("expected a skew warning, got %q", logs.String())
+			}
+			events, err := eventStore.GetEvents(ctx, "ledger-1")
+			if err != nil {
+				t.Fatalf("Failed to get events: %v", err)
+			}
+			if got := events[1].Created; !got.Equal(tt.wantCreated) {
+				t.Errorf("Created = %v, want %v", got, tt.wantCreated)
+			}
+			raw, ok := events[1].Metadata[domain.MetadataRawCreatedAt]
+			if ok != tt.wantRaw {
+				t.Fatalf("raw timestamp recorded = %v, want %v", ok, tt.wantRaw)
+			}
+			if ok && raw != base.Add(-time.Hour).Format(time.RFC3339Nano) {
+				t.Errorf("raw timestamp = %v", raw)
+			}
+		})
+	}
+}
+
+func TestCommit_StreamLengthLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := application.StreamLengthLimit{Warn: 3, Max: 5}
+	tests := []struct {
+		name      string
+		eventType string
+		stored    int
+		opts      []application.UnitOfWorkOption
+		wantWarn  bool
+		wantErr   error
+	}{
+		{name: "under both bounds", eventType: "ledger.posted", stored: 1},
+		{name: "past warn logs", eventType: "ledger.posted", stored: 3, wantWarn: true},
+		{name: "past max refuses", eventType: "ledger.posted", stored: 5, wantErr: application.ErrStreamTooLong},
+		{
+			name: "category override raises the bound", eventType: "ledger.posted", stored: 5,
+			opts: []application.UnitOfWorkOption{application.WithCategoryStreamLengthLimit("ledger", application.StreamLengthLimit{Max: 100})},
+		},
+		{
+			name: "override leaves other categories alone", eventType: "order.placed", stored: 5,
+			opts:    []application.UnitOfWorkOption{application.WithCategoryStreamLengthLimit("ledger", application.StreamLengthLimit{})},
+			wantErr: application.ErrStreamTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			eventStore := infrastructure.NewMemoryStore()
+			for seq := 1; seq <= tt.stored; seq++ {
+				if err := eventStore.Append(ctx, "agg-1", seq-1, domain.NewEventEnvelope[any](seq, "agg-1", tt.eventType, seq)); err != nil {
+					t.Fatalf("failed to seed: %v", err)
+				}
+			}
+
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, nil))
+			opts := append([]application.UnitOfWorkOption{application.WithStreamLengthLimit(limit, logger)}, tt.opts...)
+			uow := application.NewSimpleUnitOfWork(eventStore, nil, opts...)
+
+			next := domain.NewEventEnvelope[any](tt.stored+1, "agg-1", tt.eventType, tt.stored+1)
+			entity := &fixedEntity{id: "agg-1", seq: tt.stored + 1, events: []domain.EventEnvelope[any]{next}}
+			if err := uow.Track(entity); err != nil {
+				t.Fatalf("Failed to track: %v", err)
+			}
+			if err := uow.DryRun(ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DryRun() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := uow.Commit(ctx); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Commit() error = %v, want %v", err, tt.wantErr)
+			}
+
+			version, err := eventStore.GetCurrentVersion(ctx, "agg-1")
+			if err != nil {
+				t.Fatalf("Failed to get version: %v", err)
+			}
+			wantVersion := tt.stored + 1
+			if tt.wantErr != nil {
+				wantVersion = tt.stored
+			}
+			if version != wantVersion {
+				t.Errorf("version = %d, want %d", version, wantVersion)
+			}
+			if got := strings.Contains(logs.String(), "unusually long"); got != tt.wantWarn {
+				t.Errorf("warning logged = %v, want %v: %q", got, tt.wantWarn, logs.String())
+			}
+		})
+	}
+}
+
+func TestCommit_Attribution(t *testing.T) {
+	t.Parallel()
+
+	userCtx := domain.ContextWithActor(context.Background(), "user-7", "acct-1")
+	tests := []struct {
+		name        string
+		policy      application.AttributionPolicy
+		ctx         context.Context
+		wantErr     error
+		wantActor   string
+		wantAccount string
+		wantWarning bool
+	}{
+		{name: "off stamps nothing", policy: application.AttributionOff, ctx: userCtx},
+		{name: "actor from context", policy: application.AttributionError, ctx: userCtx, wantActor: "user-7", wantAccount: "acct-1"},
+		{name: "warn commits unattributed events", policy: application.AttributionWarn, ctx: context.Background(), wantWarning: true},
+		{name: "error refuses unattributed events", policy: application.AttributionError, ctx: context.Background(), wantErr: application.ErrUnattributedEvent},
+		{name: "system actor passes the error policy", policy: application.AttributionError, ctx: domain.ContextWithSystemActor(context.Background()), wantActor: domain.SystemActor},
+		{name: "default substitutes the configured actor", policy: application.AttributionDefault, ctx: context.Background(), wantActor: "svc-batch", wantAccount: "acct-0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var logs bytes.Buffer
+			opts := []application.UnitOfWorkOption{application.WithAttribution(tt.policy, slog.New(slog.NewTextHandler(&logs, nil)))}
+			if tt.policy == application.AttributionDefault {
+				opts = append(opts, application.WithDefaultAttribution("svc-batch", "acct-0"))
+			}
+			eventStore := infrastructure.NewMemoryStore()
+			uow := application.NewSimpleUnitOfWork(eventStore, nil, opts...)
+			entity := NewTestEntity("entity-1", "Test", "test@example.com")
+			if err := entity.RecordEvent(map[string]string{"name": "Test"}, "test.created"); err != nil {
+				t.Fatalf("Failed to record event: %v", err)
+			}
+			if err := uow.Track(entity); err != nil {
+				t.Fatalf("Failed to track entity: %v", err)
+			}
+
+			err := uow.Commit(tt.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Commit() error = %v, want %v", err, tt.wantErr)
+			}
+			events, _ := eventStore.GetEvents(context.Background(), "entity-1")
+			if tt.wantErr != nil {
+				if len(events) != 0 {
+					t.Errorf("expected nothing persisted, got %d events", len(events))
+				}
+				return
+			}
+			actor, _ := events[0].Metadata[domain.MetadataActorID].(string)
+			if actor != tt.wantActor || domain.AccountID(events[0]) != tt.wantAccount {
+				t.Errorf("attributed to (%q, %q), want (%q, %q)", actor, domain.AccountID(events[0]), tt.wantActor, tt.wantAccount)
+			}
+			if got := strings.Contains(logs.String(), "without an actor"); got != tt.wantWarning {
+				t.Errorf("warning logged = %v, want %v", got, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "context"
+
+// SystemActor is the actor recorded for events that no user or agent caused,
+// such as those emitted by migrations and scheduled jobs. Use
+// ContextWithSystemActor so such events count as attributed.
+const SystemActor = "system"
+
+type actorContextKey struct{}
+
+type actor struct {
+	id        string
+	accountID string
+}
+
+// ContextWithActor returns a copy of ctx recording who is causing any events
+// committed under it: actorID, the user or agent, and accountID, the account
+// (tenant) acted in, which may be empty. The unit of work stamps them as
+// MetadataActorID and MetadataAccountID when attribution is enabled (see
+// application.WithAttribution).
+func ContextWithActor(ctx context.Context, actorID, accountID string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor{id: actorID, accountID: accountID})
+}
+
+// ContextWithSystemActor returns a copy of ctx attributing its events to
+// SystemActor, in no account. Migrations, reactors and scheduled jobs use it
+// to declare that their events are legitimately system-originated.
+func ContextWithSystemActor(ctx context.Context) context.Context {
+	return ContextWithActor(ctx, SystemActor, "")
+}
+
+// ActorFromContext returns the actor and account recorded in ctx, or empty
+// strings when none was recorded.
+func ActorFromContext(ctx context.Context) (actorID, accountID string) {
+	a, _ := ctx.Value(actorContextKey{}).(actor)
+	return a.id, a.accountID
+}
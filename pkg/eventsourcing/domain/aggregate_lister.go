@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"strings"
+)
+
+// DefaultAggregateIDPageSize is the page size ListAggregateIDs uses when
+// ListAggregateIDsOptions.Limit is not positive.
+const DefaultAggregateIDPageSize = 1000
+
+// EventCategory returns the stream category of an event type: the segment
+// before the first ".", which by convention names the entity type ("Agent"
+// for "Agent.Created", "user" for "user.created"). An event type without a
+// dot is its own category.
+func EventCategory(eventType string) string {
+	category, _, _ := strings.Cut(eventType, ".")
+	return category
+}
+
+// ListAggregateIDsOptions pages and scopes ListAggregateIDs.
+type ListAggregateIDsOptions struct {
+	// AccountID restricts the result to aggregates with events recorded for
+	// this account (MetadataAccountID). Empty means every account.
+	AccountID string
+
+	// After is an exclusive cursor: only aggregate IDs that sort after it are
+	// returned. Pass the last ID of the previous page to continue.
+	After string
+
+	// Limit caps the page size; values <= 0 mean DefaultAggregateIDPageSize.
+	Limit int
+}
+
+// AggregateLister is implemented by event stores that can enumerate the
+// aggregates of an entity type, for per-aggregate rebuilds and inventory
+// tooling. It is optional — not every EventStore can answer it efficiently —
+// so callers type-assert for it.
+type AggregateLister interface {
+	// ListAggregateIDs returns one page of distinct aggregate IDs with events
+	// in category (see EventCategory), in ascending ID order. A page shorter
+	// than the limit is the last one.
+	ListAggregateIDs(ctx context.Context, category string, opts ListAggregateIDsOptions) ([]string, error)
+}
+
+// PageSize returns the effective page size for opts.
+func (opts ListAggregateIDsOptions) PageSize() int {
+	if opts.Limit <= 0 {
+		return DefaultAggregateIDPageSize
+	}
+	return opts.Limit
+}
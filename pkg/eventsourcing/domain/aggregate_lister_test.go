@@ -0,0 +1,30 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+func TestEventCategory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		eventType string
+		want      string
+	}{
+		{eventType: "Agent.Created", want: "Agent"},
+		{eventType: "user.profile.updated", want: "user"},
+		{eventType: "heartbeat", want: "heartbeat"},
+		{eventType: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			t.Parallel()
+			if got := domain.EventCategory(tt.eventType); got != tt.want {
+				t.Errorf("EventCategory(%q) = %q, want %q", tt.eventType, got, tt.want)
+			}
+		})
+	}
+}
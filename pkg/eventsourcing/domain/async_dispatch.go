@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+)
+
+// DefaultWorkerQueueSize is how many events may wait for each worker of an
+// asynchronous dispatcher unless configured with WithWorkerQueueSize.
+const DefaultWorkerQueueSize = 16
+
+// ErrDispatcherClosed is returned by Dispatch on an asynchronous dispatcher
+// after Close.
+var ErrDispatcherClosed = errors.New("event dispatcher closed")
+
+// WithDispatchErrorHandler registers fn to receive the errors of events
+// dispatched asynchronously (see NewEventDispatcherWithWorkers), which cannot
+// be returned from Dispatch. fn runs on the worker goroutine, so a slow fn
+// delays that worker's later events. Without it such errors are discarded.
+// It has no effect on a synchronous dispatcher.
+func WithDispatchErrorHandler(fn func(envelope EventEnvelope[any], err error)) DispatcherOption {
+	return func(d *EventDispatcher) {
+		d.onDispatchError = fn
+	}
+}
+
+// WithWorkerQueueSize sets how many events may wait for each worker of an
+// asynchronous dispatcher before Dispatch blocks (default
+// DefaultWorkerQueueSize).
+func WithWorkerQueueSize(n int) DispatcherOption {
+	return func(d *EventDispatcher) {
+		if n > 0 {
+			d.workerQueueSize = n
+		}
+	}
+}
+
+// NewEventDispatcherWithWorkers creates an EventDispatcher whose Dispatch
+// hands each event to one of n worker goroutines and returns without waiting
+// for its handlers, so a slow projector no longer holds up the commit that
+// produced the event.
+//
+// Events are assigned to workers by a hash of their AggregateID, so one
+// aggregate's events are handled in dispatch order while different
+// aggregates proceed in parallel. Each worker has a bounded queue
+// (WithWorkerQueueSize); when it is full Dispatch blocks until there is room
+// or ctx is done, pushing back on producers instead of buffering without
+// limit. Handler errors are passed to WithDispatchErrorHandler rather than
+// returned. Handlers receive ctx without its cancellation, since the
+// dispatching request has usually returned by the time they run.
+//
+// Close stops accepting events and waits for the queued ones to be handled.
+// ReplayDispatcher still dispatches synchronously. n below 1 is treated as 1.
+// A handler must not dispatch to its own dispatcher: once its worker's queue
+// is full, that Dispatch would wait on the very worker running the handler.
+func NewEventDispatcherWithWorkers(n int, opts ...DispatcherOption) *EventDispatcher {
+	d := NewEventDispatcher(opts...)
+	if n < 1 {
+		n = 1
+	}
+	queueSize := d.workerQueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultWorkerQueueSize
+	}
+	d.workers = &dispatchWorkers{queues: make([]chan asyncDispatch, n)}
+	for i := range d.workers.queues {
+		queue := make(chan asyncDispatch, queueSize)
+		d.workers.queues[i] = queue
+		d.workers.wg.Add(1)
+		go d.runWorker(queue)
+	}
+	return d
+}
+
+// dispatchWorkers is the worker pool of an asynchronous EventDispatcher.
+type dispatchWorkers struct {
+	// mu is held for reading while an event is enqueued and for writing
+	// while the queues are closed, so no send races the close.
+	mu     sync.RWMutex
+	closed bool
+	queues []chan asyncDispatch
+	wg     sync.WaitGroup
+}
+
+// asyncDispatch is one event waiting for a worker.
+type asyncDispatch struct {
+	ctx      context.Context
+	envelope EventEnvelope[any]
+}
+
+// enqueue puts envelope on its aggregate's worker queue, blocking while the
+// queue is full.
+func (w *dispatchWorkers) enqueue(ctx context.Context, envelope EventEnvelope[any]) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return ErrDispatcherClosed
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(envelope.AggregateID))
+	queue := w.queues[h.Sum32()%uint32(len(w.queues))]
+
+	select {
+	case queue <- asyncDispatch{ctx: context.WithoutCancel(ctx), envelope: envelope}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runWorker handles queued events until the queue is closed.
+func (d *EventDispatcher) runWorker(queue <-chan asyncDispatch) {
+	defer d.workers.wg.Done()
+	for job := range queue {
+		if err := d.dispatch(job.ctx, job.envelope, false); err != nil && d.onDispatchError != nil {
+			d.onDispatchError(job.envelope, err)
+		}
+	}
+}
+
+// Close stops an asynchronous dispatcher: later Dispatch calls fail with
+// ErrDispatcherClosed, and Close returns once every event already queued has
+// been handled. It is safe to call more than once and is a no-op on a
+// synchronous dispatcher.
+func (d *EventDispatcher) Close() {
+	if d.workers == nil {
+		return
+	}
+	d.workers.mu.Lock()
+	if !d.workers.closed {
+		d.workers.closed = true
+		for _, queue := range d.workers.queues {
+			close(queue)
+		}
+	}
+	d.workers.mu.Unlock()
+	d.workers.wg.Wait()
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "context"
+
+// MetadataBackfill is the metadata key flagging events emitted by a backfill
+// rather than by live activity. Events without the key are live events.
+const MetadataBackfill = "backfill"
+
+// IsBackfill reports whether the envelope was flagged as a backfill event.
+// Only a boolean true (or the string "true", as some stores round-trip it)
+// counts; a missing or false flag means "process normally".
+func IsBackfill[T any](env EventEnvelope[T]) bool {
+	switch v := env.Metadata[MetadataBackfill].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+type backfillContextKey struct{}
+
+// ContextWithBackfill returns a copy of ctx under which committed events are
+// flagged as backfill: the unit of work sets MetadataBackfill on every event
+// it commits with this context, so the flag is fixed at emission time and
+// travels with the event through persistence and dispatch.
+func ContextWithBackfill(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backfillContextKey{}, true)
+}
+
+// BackfillFromContext reports whether ctx was marked with ContextWithBackfill.
+func BackfillFromContext(ctx context.Context) bool {
+	backfill, _ := ctx.Value(backfillContextKey{}).(bool)
+	return backfill
+}
+
+// SkipBackfill wraps handler so it only runs for live events; events flagged
+// as backfill are skipped without error.
+//
+// Use it for notification-style reactions (emails, webhooks, alerts) that
+// must not fire for historical data. Projections that should reflect the
+// backfill subscribe unwrapped, which is how a handler opts into backfill
+// processing.
+func SkipBackfill[T any](handler EventHandler[T]) EventHandler[T] {
+	return func(ctx context.Context, env EventEnvelope[T]) error {
+		if IsBackfill(env) {
+			return nil
+		}
+		return handler(ctx, env)
+	}
+}
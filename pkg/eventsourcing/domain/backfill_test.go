@@ -0,0 +1,76 @@
+package domain_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+func TestIsBackfill(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     bool
+	}{
+		{name: "no metadata is live", metadata: nil, want: false},
+		{name: "missing flag is live", metadata: map[string]interface{}{"other": true}, want: false},
+		{name: "false flag is live", metadata: map[string]interface{}{domain.MetadataBackfill: false}, want: false},
+		{name: "true flag is backfill", metadata: map[string]interface{}{domain.MetadataBackfill: true}, want: true},
+		{name: "string flag is backfill", metadata: map[string]interface{}{domain.MetadataBackfill: "true"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			env := domain.EventEnvelope[any]{EventType: "order.placed", Metadata: tt.metadata}
+			if got := domain.IsBackfill(env); got != tt.want {
+				t.Errorf("IsBackfill() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipBackfill(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dispatcher := domain.NewEventDispatcher()
+
+	var projected, notified atomic.Int32
+	if _, err := domain.Subscribe(dispatcher, "order.placed", func(ctx context.Context, env domain.EventEnvelope[string]) error {
+		projected.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to subscribe projection: %v", err)
+	}
+	if _, err := domain.Subscribe(dispatcher, "order.placed", domain.SkipBackfill(func(ctx context.Context, env domain.EventEnvelope[string]) error {
+		notified.Add(1)
+		return nil
+	})); err != nil {
+		t.Fatalf("failed to subscribe notifier: %v", err)
+	}
+
+	live := domain.EventEnvelope[any]{EventType: "order.placed", Payload: "o-1"}
+	backfilled := domain.EventEnvelope[any]{
+		EventType: "order.placed",
+		Payload:   "o-2",
+		Metadata:  map[string]interface{}{domain.MetadataBackfill: true},
+	}
+
+	for _, env := range []domain.EventEnvelope[any]{live, backfilled} {
+		if err := dispatcher.Dispatch(ctx, env); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+	}
+
+	if got := projected.Load(); got != 2 {
+		t.Errorf("expected projection to see both events, got %d", got)
+	}
+	if got := notified.Load(); got != 1 {
+		t.Errorf("expected notifier to see only the live event, got %d", got)
+	}
+}
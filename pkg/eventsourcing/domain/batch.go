@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// BatchEventHandler is a type-safe handler that processes several events in
+// one call, such as a projector writing them with a single bulk insert. The
+// envelopes are in dispatch order.
+type BatchEventHandler[T any] func(ctx context.Context, envs []EventEnvelope[T]) error
+
+// BatchHandlerFunc is a batch handler as the dispatcher invokes it, with the
+// payloads untyped.
+type BatchHandlerFunc func(ctx context.Context, envs []EventEnvelope[any]) error
+
+// SubscribeBatch registers a batch handler for eventType, which may be a
+// pattern as with Subscribe. DispatchBatch hands it every matching envelope
+// of the batch in one call; Dispatch, and ReplayDispatcher, hand it a
+// single-envelope batch. The returned SubscriptionID removes the handler
+// again with Unsubscribe.
+//
+// A batch call is one invocation: it is not wrapped in the dispatcher's
+// middlewares and is not dead-lettered, though a panic is still recovered
+// and WithHandlerTimeout still bounds it. Single-envelope calls through
+// Dispatch behave like any other handler's.
+func SubscribeBatch[T any](d *EventDispatcher, eventType string, handler BatchEventHandler[T], opts ...SubscribeOption) (SubscriptionID, error) {
+	if eventType == "" {
+		return 0, fmt.Errorf("event type cannot be empty")
+	}
+	if handler == nil {
+		return 0, fmt.Errorf("handler cannot be nil")
+	}
+
+	batch := func(ctx context.Context, envs []EventEnvelope[any]) error {
+		typedEnvs := make([]EventEnvelope[T], len(envs))
+		for i, env := range envs {
+			typedEnv, err := typedEnvelope[T](env, eventType)
+			if err != nil {
+				return err
+			}
+			typedEnvs[i] = typedEnv
+		}
+		return handler(ctx, typedEnvs)
+	}
+	single := func(ctx context.Context, env EventEnvelope[any]) error {
+		return batch(ctx, []EventEnvelope[any]{env})
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sub := d.newSubscription(single, eventType, opts)
+	sub.handleBatch = batch
+	d.handlers[eventType] = append(d.handlers[eventType], sub)
+	registerTypeFactory[T](d, eventType)
+
+	return sub.id, nil
+}
+
+// DispatchBatch dispatches envelopes, such as the events of one commit, as a
+// batch. Each handler registered with SubscribeBatch is called once with all
+// the envelopes it matches; every other handler is called once per matching
+// envelope, as by Dispatch. Each handler sees its envelopes in the order
+// given, and different handlers run in parallel. Errors from all handlers are
+// collected and returned together.
+//
+// On a dispatcher with workers, the envelopes are queued one by one as by
+// Dispatch and batch handlers receive them singly.
+func (d *EventDispatcher) DispatchBatch(ctx context.Context, envelopes []EventEnvelope[any]) error {
+	if d.workers != nil {
+		var errs []error
+		for _, envelope := range envelopes {
+			if err := d.workers.enqueue(ctx, envelope); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	d.mu.RLock()
+	matched := make([][]subscription, len(envelopes))
+	for i, envelope := range envelopes {
+		matched[i] = d.matchingSubscriptions(envelope, false)
+	}
+	timeout := d.handlerTimeout
+	d.mu.RUnlock()
+
+	// Group the envelopes by subscription, keeping first-match order.
+	type delivery struct {
+		sub       subscription
+		envelopes []EventEnvelope[any]
+	}
+	var deliveries []*delivery
+	bySubscription := make(map[SubscriptionID]*delivery)
+	for i, envelope := range envelopes {
+		for _, sub := range d.enabledSubscriptions(ctx, matched[i], envelope) {
+			dl, ok := bySubscription[sub.id]
+			if !ok {
+				dl = &delivery{sub: sub}
+				bySubscription[sub.id] = dl
+				deliveries = append(deliveries, dl)
+			}
+			dl.envelopes = append(dl.envelopes, envelope)
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	var errsMu sync.Mutex
+	var errs []error
+	collect := func(err error) {
+		errsMu.Lock()
+		errs = append(errs, err)
+		errsMu.Unlock()
+	}
+	for _, dl := range deliveries {
+		g.Go(func() error {
+			if dl.sub.handleBatch != nil {
+				batch := func(ctx context.Context, _ EventEnvelope[any]) error {
+					return dl.sub.handleBatch(ctx, dl.envelopes)
+				}
+				if err := runHandler(gCtx, batch, dl.envelopes[0], timeout); err != nil {
+					collect(fmt.Errorf("batch handler %q error for %d events: %w", dl.sub.name, len(dl.envelopes), err))
+				}
+				return nil
+			}
+			for _, envelope := range dl.envelopes {
+				if err := d.deliver(gCtx, dl.sub, envelope, timeout); err != nil {
+					collect(fmt.Errorf("handler error for event type %q: %w", envelope.EventType, err))
+				}
+			}
+			return nil
+		})
+	}
+	_ = g.Wait() // handlers report through collect, never to the group
+
+	if len(errs) > 0 {
+		return fmt.Errorf("dispatch errors: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+// CategoryFeedReader is implemented by event stores that can filter the
+// global ordered feed by event category (see EventCategory) at the storage
+// layer, so a projector rebuilding only "user" and "order" read models never
+// loads "payment" events.
+type CategoryFeedReader interface {
+	// ReadAfterInCategories is ReadAfter restricted to events whose category
+	// is in categories. An empty categories slice means every category — it
+	// never means "match nothing".
+	ReadAfterInCategories(ctx context.Context, afterPosition int64, limit int, categories []string) ([]EventEnvelope[any], error)
+}
+
+// ReadAfterInCategories reads store's global feed after afterPosition,
+// keeping only events in categories, up to limit (limit <= 0 means no
+// limit). Stores implementing CategoryFeedReader filter in the query; for
+// others the feed is paged through ReadAfter and filtered in memory. An empty
+// categories slice means every category.
+func ReadAfterInCategories(ctx context.Context, store EventStore, afterPosition int64, limit int, categories []string) ([]EventEnvelope[any], error) {
+	if len(categories) == 0 {
+		return store.ReadAfter(ctx, afterPosition, limit)
+	}
+	if reader, ok := store.(CategoryFeedReader); ok {
+		return reader.ReadAfterInCategories(ctx, afterPosition, limit, categories)
+	}
+
+	result := make([]EventEnvelope[any], 0)
+	for {
+		page, err := store.ReadAfter(ctx, afterPosition, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range page {
+			if slices.Contains(categories, EventCategory(event.EventType)) {
+				result = append(result, event)
+				if limit > 0 && len(result) == limit {
+					return result, nil
+				}
+			}
+		}
+		if limit <= 0 || len(page) < limit {
+			return result, nil
+		}
+		afterPosition = page[len(page)-1].Position
+	}
+}
@@ -0,0 +1,64 @@
+package domain_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+// plainFeedStore hides the MemoryStore's CategoryFeedReader so the
+// ReadAfter fallback is exercised.
+type plainFeedStore struct {
+	domain.EventStore
+}
+
+func TestReadAfterInCategories(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := infrastructure.NewMemoryStore()
+	for i, eventType := range []string{"user.created", "payment.settled", "order.placed", "payment.refunded", "user.renamed", "order.shipped"} {
+		aggregateID := domain.EventCategory(eventType) + "-1"
+		version, _ := store.GetCurrentVersion(ctx, aggregateID)
+		event := domain.NewEventEnvelope[any](i, aggregateID, eventType, version+1)
+		if err := store.Append(ctx, aggregateID, -1, event); err != nil {
+			t.Fatalf("append %s: %v", eventType, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		after      int64
+		limit      int
+		categories []string
+		want       []string
+	}{
+		{name: "empty set means all", categories: nil, want: []string{"user.created", "payment.settled", "order.placed", "payment.refunded", "user.renamed", "order.shipped"}},
+		{name: "selected categories", categories: []string{"user", "order"}, want: []string{"user.created", "order.placed", "user.renamed", "order.shipped"}},
+		{name: "limit counts matching events", limit: 3, categories: []string{"user", "order"}, want: []string{"user.created", "order.placed", "user.renamed"}},
+		{name: "after position", after: 3, categories: []string{"user", "order"}, want: []string{"user.renamed", "order.shipped"}},
+		{name: "unknown category", categories: []string{"invoice"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		for storeName, s := range map[string]domain.EventStore{"category reader": store, "fallback": plainFeedStore{store}} {
+			t.Run(tt.name+"/"+storeName, func(t *testing.T) {
+				t.Parallel()
+				events, err := domain.ReadAfterInCategories(ctx, s, tt.after, tt.limit, tt.categories)
+				if err != nil {
+					t.Fatalf("ReadAfterInCategories failed: %v", err)
+				}
+				got := make([]string, len(events))
+				for i, event := range events {
+					got[i] = event.EventType
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package domain
+
+import "context"
+
+// MetadataTriggeredBy is the metadata key recording what produced an event:
+// the command type for events emitted while handling a command, or a marker
+// such as TriggerSystem or TriggerMigration otherwise.
+const MetadataTriggeredBy = "triggered_by_command"
+
+const (
+	// TriggerSystem marks events emitted outside any command, e.g. by a
+	// reactor or scheduled job. It is the default when a context carries no
+	// trigger.
+	TriggerSystem = "system"
+
+	// TriggerMigration marks events emitted by data migrations.
+	TriggerMigration = "migration"
+)
+
+type triggerContextKey struct{}
+
+// ContextWithTrigger returns a copy of ctx recording what is causing any
+// events committed under it. The command dispatcher sets the command type;
+// reactors and migrations may set TriggerSystem, TriggerMigration or their
+// own marker.
+func ContextWithTrigger(ctx context.Context, trigger string) context.Context {
+	return context.WithValue(ctx, triggerContextKey{}, trigger)
+}
+
+// TriggerFromContext returns the trigger recorded in ctx, or TriggerSystem
+// when none was recorded, so provenance is never empty.
+func TriggerFromContext(ctx context.Context) string {
+	if trigger, ok := ctx.Value(triggerContextKey{}).(string); ok && trigger != "" {
+		return trigger
+	}
+	return TriggerSystem
+}
+
+const (
+	// MetadataCorrelationID is the metadata key correlating the events of
+	// one request or workflow.
+	MetadataCorrelationID = "correlation_id"
+
+	// MetadataCausationID is the metadata key naming the message that caused
+	// an event.
+	MetadataCausationID = "causation_id"
+
+	// MetadataActorID is the metadata key naming the user or agent that
+	// issued a command.
+	MetadataActorID = "actor_id"
+
+	// MetadataRawCreatedAt preserves, in RFC 3339 form, the original Created
+	// timestamp of an event whose timestamp was clamped for clock skew.
+	MetadataRawCreatedAt = "raw_created_at"
+)
+
+type correlationContextKey struct{}
+
+type correlation struct {
+	correlationID string
+	causationID   string
+}
+
+// ContextWithCorrelation returns a copy of ctx recording the correlation ID
+// of the request or workflow under way and the ID of the message causing any
+// events committed under it. The unit of work stamps them as
+// MetadataCorrelationID and MetadataCausationID. The command dispatcher sets
+// them for every command: the correlation ID from the command's metadata, or
+// the command ID when it has none, and the command ID as the cause.
+func ContextWithCorrelation(ctx context.Context, correlationID, causationID string) context.Context {
+	return context.WithValue(ctx, correlationContextKey{}, correlation{correlationID: correlationID, causationID: causationID})
+}
+
+// ContextWithCausingEvent returns a copy of ctx continuing env's workflow, for
+// handlers that react to env by committing events of their own: those events
+// carry env's correlation ID, or env's ID when it has none, and env's ID as
+// their cause.
+func ContextWithCausingEvent[T any](ctx context.Context, env EventEnvelope[T]) context.Context {
+	correlationID := CorrelationID(env)
+	if correlationID == "" {
+		correlationID = env.ID
+	}
+	return ContextWithCorrelation(ctx, correlationID, env.ID)
+}
+
+// CorrelationFromContext returns the correlation and causation IDs recorded
+// in ctx, or empty strings when none were recorded.
+func CorrelationFromContext(ctx context.Context) (correlationID, causationID string) {
+	c, _ := ctx.Value(correlationContextKey{}).(correlation)
+	return c.correlationID, c.causationID
+}
+
+// reservedMetadataKeys are the keys the framework owns. Caller-supplied event
+// metadata (ContextWithEventMetadata) can never set them.
+var reservedMetadataKeys = map[string]bool{
+	MetadataCorrelationID: true,
+	MetadataCausationID:   true,
+	MetadataActorID:       true,
+	MetadataTriggeredBy:   true,
+	MetadataBackfill:      true,
+	MetadataOriginRegion:  true,
+	MetadataAccountID:     true,
+	MetadataRawCreatedAt:  true,
+	MetadataEventVersion:  true,
+	MetadataForgotten:     true,
+}
+
+// IsReservedMetadataKey reports whether key is owned by the framework and
+// therefore cannot be supplied through ContextWithEventMetadata.
+func IsReservedMetadataKey(key string) bool {
+	return reservedMetadataKeys[key]
+}
+
+type eventMetadataContextKey struct{}
+
+// ContextWithEventMetadata returns a copy of ctx carrying metadata to merge
+// into every event committed under it, such as a feature-flag cohort or A/B
+// variant taken from the command being handled. Reserved keys (see
+// IsReservedMetadataKey) are dropped, and at commit an event's own metadata
+// wins over these values, so the precedence is: framework keys, then the
+// event's own metadata, then context metadata. Metadata from an enclosing
+// context is kept unless overridden.
+func ContextWithEventMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	merged := make(map[string]string, len(metadata))
+	for k, v := range EventMetadataFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range metadata {
+		if !IsReservedMetadataKey(k) {
+			merged[k] = v
+		}
+	}
+	return context.WithValue(ctx, eventMetadataContextKey{}, merged)
+}
+
+// EventMetadataFromContext returns the metadata recorded by
+// ContextWithEventMetadata, or nil. The map must not be modified.
+func EventMetadataFromContext(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(eventMetadataContextKey{}).(map[string]string)
+	return metadata
+}
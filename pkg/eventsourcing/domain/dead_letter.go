@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ErrHandlerNotFound is returned by Redeliver when no handler with the given
+// name matches the event.
+var ErrHandlerNotFound = errors.New("event handler not found")
+
+// DeadLetterStore durably records events a handler kept failing on, so the
+// dispatcher can move on to later events while the poison one waits for a
+// fix and a replay.
+type DeadLetterStore interface {
+	// Store records that the handler named handlerName failed on envelope
+	// with lastErr after exhausting its retries.
+	Store(ctx context.Context, envelope EventEnvelope[any], handlerName string, lastErr error) error
+}
+
+// DispatcherBinder is implemented by dead-letter stores that replay their
+// letters through the dispatcher that filled them. WithDeadLetterStore binds
+// such a store to the dispatcher it configures.
+type DispatcherBinder interface {
+	BindDispatcher(d *EventDispatcher)
+}
+
+// HandlerName names a subscription, identifying its handler in dead letters
+// and to Redeliver. Unnamed handlers are named after the event type they
+// subscribe to and their registration order under it, such as
+// "user.created#0"; account-scoped handlers add the account, as in
+// "user.created@acct-1#0", and wildcard handlers use "*wildcard*#0". Name
+// handlers explicitly when dead letters must survive a change in
+// registration order.
+func HandlerName(name string) SubscribeOption {
+	return func(s *subscription) {
+		s.name = name
+	}
+}
+
+// WithDeadLetterStore retries a failing handler up to retries more times
+// within the same Dispatch and then records the event in store instead of
+// reporting the failure, so one poison event no longer fails every dispatch
+// that carries it. The retries run immediately, one after the other; they
+// absorb brief glitches, not outages. Only when store itself fails is the
+// handler's error returned, joined with the store's. A handler whose ctx is
+// done is reported as before and not dead-lettered.
+//
+// A store implementing DispatcherBinder is bound to the dispatcher, so its
+// letters can be replayed through Redeliver. Negative retries are treated
+// as 0.
+func WithDeadLetterStore(store DeadLetterStore, retries int) DispatcherOption {
+	return func(d *EventDispatcher) {
+		d.deadLetters = store
+		d.deadLetterRetries = max(retries, 0)
+		if binder, ok := store.(DispatcherBinder); ok {
+			binder.BindDispatcher(d)
+		}
+	}
+}
+
+// Redeliver runs the handler named handlerName on envelope once, as when
+// replaying a dead letter, and returns its error. The handler must be
+// registered for a pattern matching the event's type; otherwise Redeliver
+// returns ErrHandlerNotFound. A failure is returned as is, not dead-lettered
+// again.
+func (d *EventDispatcher) Redeliver(ctx context.Context, envelope EventEnvelope[any], handlerName string) error {
+	d.mu.RLock()
+	var handler HandlerFunc
+	for _, sub := range d.matchingSubscriptions(envelope, false) {
+		if sub.name == handlerName {
+			handler = sub.handle
+			break
+		}
+	}
+	timeout := d.handlerTimeout
+	d.mu.RUnlock()
+
+	if handler == nil {
+		return fmt.Errorf("%w: %q for event type %q", ErrHandlerNotFound, handlerName, envelope.EventType)
+	}
+	return runHandler(ctx, handler, envelope, timeout)
+}
+
+// deliver runs sub's handler on envelope, retrying and dead-lettering it when
+// a DeadLetterStore is configured.
+func (d *EventDispatcher) deliver(ctx context.Context, sub subscription, envelope EventEnvelope[any], timeout time.Duration) error {
+	err := runHandler(ctx, sub.handle, envelope, timeout)
+	if err == nil || d.deadLetters == nil {
+		return err
+	}
+	for attempt := 0; attempt < d.deadLetterRetries && ctx.Err() == nil; attempt++ {
+		if err = runHandler(ctx, sub.handle, envelope, timeout); err == nil {
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+	if storeErr := d.deadLetters.Store(ctx, envelope, sub.name, err); storeErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to dead-letter event %s for handler %q: %w", envelope.ID, sub.name, storeErr))
+	}
+	return nil
+}
+
+// runHandler runs handler directly, or isolated under timeout when one is
+// configured. Either way a panic is recovered and returned as an error, so
+// the handlers dispatched alongside it still run.
+func runHandler(ctx context.Context, handler HandlerFunc, envelope EventEnvelope[any], timeout time.Duration) (err error) {
+	if timeout > 0 {
+		return runIsolated(ctx, handler, envelope, timeout)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = handlerPanicError(r)
+		}
+	}()
+	return handler(ctx, envelope)
+}
+
+// handlerPanicError converts a recovered handler panic into an error wrapping
+// ErrHandlerPanic that carries the panic value and the handler's stack. It
+// must be called from the deferred function that recovered.
+func handlerPanicError(r any) error {
+	return fmt.Errorf("%w: %v\n%s", ErrHandlerPanic, r, debug.Stack())
+}
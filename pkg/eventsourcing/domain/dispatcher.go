@@ -3,9 +3,12 @@ package domain
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -15,95 +18,299 @@ import (
 // The type parameter T represents the strongly-typed event payload.
 type EventHandler[T any] func(ctx context.Context, env EventEnvelope[T]) error
 
-// handlerFunc is the internal representation of a handler that accepts EventEnvelope[any].
-type handlerFunc func(ctx context.Context, env EventEnvelope[any]) error
+// HandlerFunc is a handler as the dispatcher invokes it, with the payload
+// untyped. Subscribe wraps typed handlers into one; DispatchMiddleware wraps
+// it further.
+type HandlerFunc func(ctx context.Context, env EventEnvelope[any]) error
+
+// subscription is a registered handler together with its delivery options.
+type subscription struct {
+	id         SubscriptionID
+	handle     HandlerFunc
+	name       string
+	replaySafe bool
+
+	// flag gates the handler on the dispatcher's FeatureFlags; empty means
+	// always enabled. See EnabledByFlag.
+	flag string
+
+	// handleBatch is set for handlers registered with SubscribeBatch, which
+	// DispatchBatch hands all their envelopes at once; handle then wraps it
+	// for single events.
+	handleBatch BatchHandlerFunc
+}
+
+// SubscribeOption configures a single subscription.
+type SubscribeOption func(*subscription)
+
+// ReplaySafe marks a handler as safe to run during a bulk replay, typically
+// because it is an idempotent projection. Handlers without it (notifications,
+// integrations) are skipped by a ReplayDispatcher.
+func ReplaySafe() SubscribeOption {
+	return func(s *subscription) {
+		s.replaySafe = true
+	}
+}
+
+// wildcardPattern names SubscribeWildcard registrations in default handler
+// names.
+const wildcardPattern = "*wildcard*"
+
+// newSubscription issues a subscription for handle under pattern, named after
+// the pattern and its registration count unless opts name it. d.mu must be
+// held for writing.
+func (d *EventDispatcher) newSubscription(handle HandlerFunc, pattern string, opts []SubscribeOption) subscription {
+	d.lastID++
+	s := subscription{
+		id:     d.lastID,
+		handle: handle,
+		name:   fmt.Sprintf("%s#%d", pattern, d.registrations[pattern]),
+	}
+	d.registrations[pattern]++
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
 
 // typeFactory is a function that creates a new instance of an event payload type.
 type typeFactory func() interface{}
 
+var (
+	// ErrHandlerTimeout is reported for a handler that did not return within
+	// the handler timeout configured with WithHandlerTimeout.
+	ErrHandlerTimeout = errors.New("event handler timed out")
+
+	// ErrHandlerPanic is reported for a handler that panicked. The error
+	// carries the panic value and the handler's stack.
+	ErrHandlerPanic = errors.New("event handler panicked")
+
+	// ErrReplayExited is returned by ReplayDispatcher.Dispatch after Exit.
+	ErrReplayExited = errors.New("replay dispatcher exited")
+
+	// ErrSubscriptionNotFound is returned by Unsubscribe for an ID that is
+	// not, or no longer, subscribed.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// SubscriptionID identifies one registration made with Subscribe or
+// SubscribeWildcard, for passing to Unsubscribe. It is opaque and only
+// meaningful to the dispatcher that issued it.
+type SubscriptionID uint64
+
 // EventDispatcher is responsible for registering event handlers and dispatching events to them.
 // It acts as both a handler registry and event dispatcher.
 type EventDispatcher struct {
 	mu               sync.RWMutex
-	handlers         map[string][]handlerFunc
-	wildcardHandlers []handlerFunc
+	handlers         map[string][]subscription
+	wildcardHandlers []subscription
 	typeRegistry     map[string]typeFactory
+
+	// accountHandlers holds the SubscribeForAccount registrations by account
+	// and then pattern, so an event only consults its own account's.
+	accountHandlers map[string]map[string][]subscription
+
+	// lastID is the most recently issued SubscriptionID. registrations
+	// counts the subscriptions ever made per pattern (wildcardPattern for
+	// SubscribeWildcard), so default handler names stay unique after an
+	// Unsubscribe.
+	lastID        SubscriptionID
+	registrations map[string]int
+
+	// handlerTimeout bounds each handler when isolation is enabled; zero
+	// means handlers run to completion.
+	handlerTimeout time.Duration
+
+	// upcasters bring old payloads to their current version in
+	// UnmarshalEvent; nil disables upcasting.
+	upcasters *UpcasterRegistry
+
+	// Asynchronous dispatch; see NewEventDispatcherWithWorkers. workers is
+	// nil for a synchronous dispatcher.
+	workers         *dispatchWorkers
+	workerQueueSize int
+	onDispatchError func(envelope EventEnvelope[any], err error)
+
+	// Dead-lettering; see WithDeadLetterStore. deadLetters is nil when
+	// failing handlers are reported rather than dead-lettered.
+	deadLetters       DeadLetterStore
+	deadLetterRetries int
+
+	// flags enables handlers subscribed with EnabledByFlag; nil runs them
+	// all.
+	flags FeatureFlags
+
+	// middlewares wrap every handler invocation, outermost first; see Use.
+	middlewares []DispatchMiddleware
+}
+
+// DispatcherOption configures an EventDispatcher.
+type DispatcherOption func(*EventDispatcher)
+
+// WithHandlerTimeout isolates handlers from each other within one Dispatch:
+// each handler runs in its own goroutine with a context that expires after
+// timeout. A handler still running at the deadline is reported as
+// ErrHandlerTimeout and Dispatch returns without waiting for it, so one
+// blocking handler cannot stall its siblings or the caller. A handler that
+// panics is reported as ErrHandlerPanic instead of crashing the process.
+//
+// Handlers should honour ctx cancellation: a handler that ignores it keeps
+// running in the background after its timeout has been reported. Isolated
+// handlers run concurrently with no ordering between them, so handlers that
+// depend on running in a particular order must not rely on this option.
+func WithHandlerTimeout(timeout time.Duration) DispatcherOption {
+	return func(d *EventDispatcher) {
+		d.handlerTimeout = timeout
+	}
+}
+
+// WithUpcasters makes UnmarshalEvent run each payload through registry's
+// upcaster chain, from the version in its MetadataEventVersion (1 when
+// absent) to the current version, before decoding it into the type
+// registered for the resulting event type. Handlers then only ever see the
+// current payload shape, however old the stored event.
+func WithUpcasters(registry *UpcasterRegistry) DispatcherOption {
+	return func(d *EventDispatcher) {
+		d.upcasters = registry
+	}
 }
 
 // NewEventDispatcher creates a new EventDispatcher instance.
-func NewEventDispatcher() *EventDispatcher {
-	return &EventDispatcher{
-		handlers:         make(map[string][]handlerFunc),
-		wildcardHandlers: make([]handlerFunc, 0),
+func NewEventDispatcher(opts ...DispatcherOption) *EventDispatcher {
+	d := &EventDispatcher{
+		handlers:         make(map[string][]subscription),
+		wildcardHandlers: make([]subscription, 0),
 		typeRegistry:     make(map[string]typeFactory),
+		accountHandlers:  make(map[string]map[string][]subscription),
+		registrations:    make(map[string]int),
 	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
 // Subscribe registers a typed event handler for a specific event type.
 // The handler will be called when events of the specified type are dispatched.
 // Multiple handlers can be registered for the same event type.
+// The returned SubscriptionID removes the handler again with Unsubscribe.
 // This is a generic function (not a method) because Go doesn't support generic methods on non-generic types.
-func Subscribe[T any](d *EventDispatcher, eventType string, handler EventHandler[T]) error {
+func Subscribe[T any](d *EventDispatcher, eventType string, handler EventHandler[T], opts ...SubscribeOption) (SubscriptionID, error) {
 	if eventType == "" {
-		return fmt.Errorf("event type cannot be empty")
+		return 0, fmt.Errorf("event type cannot be empty")
 	}
 	if handler == nil {
-		return fmt.Errorf("handler cannot be nil")
+		return 0, fmt.Errorf("handler cannot be nil")
 	}
 
-	// Wrap the typed handler to accept EventEnvelope[any]
-	wrappedHandler := func(ctx context.Context, env EventEnvelope[any]) error {
-		// Type assert the payload to T
-		payload, ok := env.Payload.(T)
-		if !ok {
-			return fmt.Errorf("type assertion failed: expected %T, got %T for event type %q", *new(T), env.Payload, eventType)
-		}
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-		// Reconstruct EventEnvelope[T] with the typed payload
-		typedEnv := EventEnvelope[T]{
-			ID:            env.ID,
-			AggregateID:   env.AggregateID,
-			EventType:     env.EventType,
-			Payload:       payload,
-			Created:       env.Created,
-			SequenceNo:    env.SequenceNo,
-			TransactionID: env.TransactionID,
-			Metadata:      env.Metadata,
-		}
+	// Store handler in dispatcher's internal map (dispatcher acts as registry)
+	sub := d.newSubscription(untypedHandler(eventType, handler), eventType, opts)
+	d.handlers[eventType] = append(d.handlers[eventType], sub)
+	registerTypeFactory[T](d, eventType)
+
+	return sub.id, nil
+}
 
-		// Call the typed handler
+// untypedHandler wraps handler, registered for eventType, to accept
+// EventEnvelope[any].
+func untypedHandler[T any](eventType string, handler EventHandler[T]) HandlerFunc {
+	return func(ctx context.Context, env EventEnvelope[any]) error {
+		typedEnv, err := typedEnvelope[T](env, eventType)
+		if err != nil {
+			return err
+		}
 		return handler(ctx, typedEnv)
 	}
+}
 
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
-	// Store handler in dispatcher's internal map (dispatcher acts as registry)
-	d.handlers[eventType] = append(d.handlers[eventType], wrappedHandler)
+// typedEnvelope reconstructs env with its payload asserted to T.
+func typedEnvelope[T any](env EventEnvelope[any], eventType string) (EventEnvelope[T], error) {
+	payload, ok := env.Payload.(T)
+	if !ok {
+		return EventEnvelope[T]{}, fmt.Errorf("type assertion failed: expected %T, got %T for event type %q", *new(T), env.Payload, eventType)
+	}
+	return EventEnvelope[T]{
+		ID:            env.ID,
+		AggregateID:   env.AggregateID,
+		EventType:     env.EventType,
+		Payload:       payload,
+		Created:       env.Created,
+		SequenceNo:    env.SequenceNo,
+		TransactionID: env.TransactionID,
+		Metadata:      env.Metadata,
+	}, nil
+}
 
-	// Register type factory for deserialization support
-	// Only register if not already registered for this event type
+// registerTypeFactory registers T as eventType's payload type for
+// deserialization support, unless one is already registered. d.mu must be
+// held for writing.
+func registerTypeFactory[T any](d *EventDispatcher, eventType string) {
 	if _, exists := d.typeRegistry[eventType]; !exists {
 		d.typeRegistry[eventType] = func() interface{} {
 			return new(T)
 		}
 	}
-
-	return nil
 }
 
 // SubscribeWildcard registers a catch-all handler that will be called for all event types.
 // Wildcard handlers are executed in parallel with pattern-matched handlers.
-func (d *EventDispatcher) SubscribeWildcard(handler func(context.Context, EventEnvelope[any]) error) error {
+// The returned SubscriptionID removes the handler again with Unsubscribe.
+func (d *EventDispatcher) SubscribeWildcard(handler func(context.Context, EventEnvelope[any]) error, opts ...SubscribeOption) (SubscriptionID, error) {
 	if handler == nil {
-		return fmt.Errorf("handler cannot be nil")
+		return 0, fmt.Errorf("handler cannot be nil")
 	}
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	d.wildcardHandlers = append(d.wildcardHandlers, handler)
-	return nil
+	sub := d.newSubscription(handler, wildcardPattern, opts)
+	d.wildcardHandlers = append(d.wildcardHandlers, sub)
+	return sub.id, nil
+}
+
+// Unsubscribe removes the handler registered under id, so later dispatches
+// no longer invoke it. It is safe to call concurrently with Dispatch; a
+// dispatch that had already resolved its handlers when Unsubscribe was
+// called may still invoke the handler once. The event type stays registered
+// for UnmarshalEvent. Unsubscribe returns ErrSubscriptionNotFound for an id
+// that is not subscribed, including one already unsubscribed.
+func (d *EventDispatcher) Unsubscribe(id SubscriptionID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	matches := func(sub subscription) bool { return sub.id == id }
+	for pattern, subs := range d.handlers {
+		if i := slices.IndexFunc(subs, matches); i >= 0 {
+			if len(subs) == 1 {
+				delete(d.handlers, pattern)
+			} else {
+				d.handlers[pattern] = slices.Delete(subs, i, i+1)
+			}
+			return nil
+		}
+	}
+	if i := slices.IndexFunc(d.wildcardHandlers, matches); i >= 0 {
+		d.wildcardHandlers = slices.Delete(d.wildcardHandlers, i, i+1)
+		return nil
+	}
+	for account, byPattern := range d.accountHandlers {
+		for pattern, subs := range byPattern {
+			if i := slices.IndexFunc(subs, matches); i >= 0 {
+				if len(subs) > 1 {
+					byPattern[pattern] = slices.Delete(subs, i, i+1)
+				} else if len(byPattern) > 1 {
+					delete(byPattern, pattern)
+				} else {
+					delete(d.accountHandlers, account)
+				}
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %d", ErrSubscriptionNotFound, id)
 }
 
 // splitEventType splits an event type by dot, handling edge cases.
@@ -166,6 +373,13 @@ func getMatchingPatterns(eventType string) []string {
 	return patterns
 }
 
+// MatchesEventType reports whether a subscription pattern matches eventType
+// with the same semantics as Subscribe: the exact type, the type with any one
+// of its segments replaced by "*", or "*" for every segment.
+func MatchesEventType(pattern, eventType string) bool {
+	return slices.Contains(getMatchingPatterns(eventType), pattern)
+}
+
 // joinEventParts joins event parts with dots.
 func joinEventParts(parts []string) string {
 	if len(parts) == 0 {
@@ -182,22 +396,23 @@ func joinEventParts(parts []string) string {
 // Handlers are executed in parallel using goroutines.
 // If any handler returns an error, it is collected and returned after all handlers complete.
 // Pattern matching: "user.created" triggers handlers for "user.created", "user.*", "*.created", and "*.*"
+// On a dispatcher created with NewEventDispatcherWithWorkers, Dispatch only
+// queues the event and returns; handler errors go to WithDispatchErrorHandler.
 func (d *EventDispatcher) Dispatch(ctx context.Context, envelope EventEnvelope[any]) error {
-	d.mu.RLock()
-
-	// Get all matching patterns for this event type
-	patterns := getMatchingPatterns(envelope.EventType)
-
-	// Collect all handlers that match any pattern
-	// Note: If a handler is registered for multiple matching patterns, it will be called multiple times
-	var allHandlers []handlerFunc
-	for _, pattern := range patterns {
-		allHandlers = append(allHandlers, d.handlers[pattern]...)
+	if d.workers != nil {
+		return d.workers.enqueue(ctx, envelope)
 	}
+	return d.dispatch(ctx, envelope, false)
+}
 
-	// Add wildcard handlers to the same slice
-	allHandlers = append(allHandlers, d.wildcardHandlers...)
+// dispatch delivers envelope to the matching handlers, restricted to those
+// marked ReplaySafe when replayOnly is set.
+func (d *EventDispatcher) dispatch(ctx context.Context, envelope EventEnvelope[any], replayOnly bool) error {
+	d.mu.RLock()
+	allHandlers := d.matchingSubscriptions(envelope, replayOnly)
+	timeout := d.handlerTimeout
 	d.mu.RUnlock()
+	allHandlers = d.enabledSubscriptions(ctx, allHandlers, envelope)
 
 	// If no handlers, return early
 	if len(allHandlers) == 0 {
@@ -217,7 +432,7 @@ func (d *EventDispatcher) Dispatch(ctx context.Context, envelope EventEnvelope[a
 	for i := range allHandlers {
 		idx := i // Capture loop variable
 		g.Go(func() error {
-			if err := allHandlers[idx](gCtx, envelope); err != nil {
+			if err := d.deliver(gCtx, allHandlers[idx], envelope, timeout); err != nil {
 				errsMu.Lock()
 				errs = append(errs, fmt.Errorf("handler error for event type %q: %w", envelope.EventType, err))
 				errsMu.Unlock()
@@ -237,12 +452,126 @@ func (d *EventDispatcher) Dispatch(ctx context.Context, envelope EventEnvelope[a
 
 	// Return all collected errors
 	if len(errs) > 0 {
-		return fmt.Errorf("dispatch errors: %v", errs)
+		return fmt.Errorf("dispatch errors: %w", errors.Join(errs...))
 	}
 
 	return nil
 }
 
+// matchingSubscriptions returns the subscriptions for envelope's event type
+// and its matching patterns, then those of envelope's account, then the
+// wildcard ones, restricted to those marked ReplaySafe when replayOnly is
+// set, with their handlers wrapped in the middleware chain. A handler
+// registered for several matching patterns appears once per pattern. d.mu
+// must be held.
+func (d *EventDispatcher) matchingSubscriptions(envelope EventEnvelope[any], replayOnly bool) []subscription {
+	var subs []subscription
+	collect := func(candidates []subscription) {
+		for _, sub := range candidates {
+			if replayOnly && !sub.replaySafe {
+				continue
+			}
+			sub.handle = d.applyMiddlewares(sub.handle)
+			subs = append(subs, sub)
+		}
+	}
+	patterns := getMatchingPatterns(envelope.EventType)
+	for _, pattern := range patterns {
+		collect(d.handlers[pattern])
+	}
+	if byPattern := d.accountHandlers[AccountID(envelope)]; byPattern != nil {
+		for _, pattern := range patterns {
+			collect(byPattern[pattern])
+		}
+	}
+	collect(d.wildcardHandlers)
+	return subs
+}
+
+// enabledSubscriptions drops the flagged subscriptions the dispatcher's
+// FeatureFlags disable for envelope.
+func (d *EventDispatcher) enabledSubscriptions(ctx context.Context, subs []subscription, envelope EventEnvelope[any]) []subscription {
+	if d.flags == nil {
+		return subs
+	}
+	enabled := subs[:0]
+	for _, sub := range subs {
+		if sub.flag == "" || d.flags.Enabled(ctx, sub.flag, envelope) {
+			enabled = append(enabled, sub)
+		}
+	}
+	return enabled
+}
+
+// ReplayDispatcher delivers replayed events to the ReplaySafe handlers of an
+// EventDispatcher and suppresses the rest. It is a separate dispatch path
+// rather than a mode on the dispatcher, so events committed by live traffic
+// while a replay is running still reach every handler through
+// EventDispatcher.Dispatch.
+type ReplayDispatcher struct {
+	dispatcher *EventDispatcher
+	exited     atomic.Bool
+}
+
+// EnterReplay starts a replay against d's handlers. Feed the historical events
+// to the returned ReplayDispatcher's Dispatch and call Exit when the replay is
+// done:
+//
+//	replay := dispatcher.EnterReplay()
+//	defer replay.Exit()
+//	for _, env := range history {
+//		if err := replay.Dispatch(ctx, env); err != nil { ... }
+//	}
+func (d *EventDispatcher) EnterReplay() *ReplayDispatcher {
+	return &ReplayDispatcher{dispatcher: d}
+}
+
+// Dispatch delivers envelope to the matching handlers registered with
+// ReplaySafe, with the same pattern matching and error collection as
+// EventDispatcher.Dispatch. It returns ErrReplayExited once Exit was called,
+// so a replay loop that outlives its window fails instead of silently
+// reaching no one.
+func (r *ReplayDispatcher) Dispatch(ctx context.Context, envelope EventEnvelope[any]) error {
+	if r.exited.Load() {
+		return ErrReplayExited
+	}
+	return r.dispatcher.dispatch(ctx, envelope, true)
+}
+
+// Exit ends the replay. It is safe to call more than once.
+func (r *ReplayDispatcher) Exit() {
+	r.exited.Store(true)
+}
+
+// runIsolated runs handler in its own goroutine under a timeout, converting a
+// panic into ErrHandlerPanic and an overrun into ErrHandlerTimeout. The result
+// channel is buffered so an abandoned handler can still finish without leaking
+// a blocked send.
+func runIsolated(ctx context.Context, handler HandlerFunc, envelope EventEnvelope[any], timeout time.Duration) error {
+	hctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- handlerPanicError(r)
+			}
+		}()
+		done <- handler(hctx, envelope)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-hctx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w after %v", ErrHandlerTimeout, timeout)
+	}
+}
+
 // RegisterType registers a type factory for an event type to enable type-safe deserialization.
 // This is separate from handler registration and is used when unmarshaling events from storage.
 func RegisterType[T any](d *EventDispatcher, eventType string, factory func() T) error {
@@ -266,18 +595,11 @@ func RegisterType[T any](d *EventDispatcher, eventType string, factory func() T)
 
 // UnmarshalEvent unmarshals an event from JSON using the registered type factory.
 // It looks up the type factory from the registry and reconstructs EventEnvelope[any] with the typed payload.
+// With WithUpcasters, the payload is first upcast to its current version and
+// the factory is looked up for the resulting event type; the returned
+// envelope carries that type and records the version reached in
+// MetadataEventVersion.
 func (d *EventDispatcher) UnmarshalEvent(ctx context.Context, data []byte, eventType string) (EventEnvelope[any], error) {
-	d.mu.RLock()
-	factory, exists := d.typeRegistry[eventType]
-	d.mu.RUnlock()
-
-	if !exists {
-		return EventEnvelope[any]{}, fmt.Errorf("type not registered for event type %q", eventType)
-	}
-
-	// Create a new instance of the event type
-	payload := factory()
-
 	// Unmarshal the JSON into a temporary struct to extract metadata
 	var temp struct {
 		ID            string                 `json:"id"`
@@ -294,8 +616,37 @@ func (d *EventDispatcher) UnmarshalEvent(ctx context.Context, data []byte, event
 		return EventEnvelope[any]{}, fmt.Errorf("failed to unmarshal event envelope: %w", err)
 	}
 
+	rawPayload := []byte(temp.Payload)
+	if d.upcasters != nil {
+		upcast, upcastType, version, err := d.upcasters.Upcast(eventType, EventVersion(temp.Metadata), rawPayload)
+		if err != nil {
+			return EventEnvelope[any]{}, fmt.Errorf("failed to upcast event payload: %w", err)
+		}
+		if upcastType != eventType {
+			eventType, temp.EventType = upcastType, upcastType
+		}
+		if version > 1 {
+			if temp.Metadata == nil {
+				temp.Metadata = make(map[string]interface{})
+			}
+			temp.Metadata[MetadataEventVersion] = version
+		}
+		rawPayload = upcast
+	}
+
+	d.mu.RLock()
+	factory, exists := d.typeRegistry[eventType]
+	d.mu.RUnlock()
+
+	if !exists {
+		return EventEnvelope[any]{}, fmt.Errorf("type not registered for event type %q", eventType)
+	}
+
+	// Create a new instance of the event type
+	payload := factory()
+
 	// Unmarshal the payload into the typed instance
-	if err := json.Unmarshal(temp.Payload, payload); err != nil {
+	if err := json.Unmarshal(rawPayload, payload); err != nil {
 		return EventEnvelope[any]{}, fmt.Errorf("failed to unmarshal event payload: %w", err)
 	}
 
@@ -1,10 +1,16 @@
 package domain_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -58,7 +64,7 @@ func TestSubscribe(t *testing.T) {
 			return nil
 		}
 
-		err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler)
+		_, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler)
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -71,7 +77,7 @@ func TestSubscribe(t *testing.T) {
 			return nil
 		}
 
-		err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "", handler)
+		_, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "", handler)
 		if err == nil {
 			t.Error("Expected error for empty event type")
 		}
@@ -84,7 +90,7 @@ func TestSubscribe(t *testing.T) {
 		t.Parallel()
 		d := domain.NewEventDispatcher()
 
-		err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", nil)
+		_, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", nil)
 		if err == nil {
 			t.Error("Expected error for nil handler")
 		}
@@ -109,10 +115,10 @@ func TestSubscribe(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler1); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler1); err != nil {
 			t.Fatalf("Failed to register handler1: %v", err)
 		}
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler2); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler2); err != nil {
 			t.Fatalf("Failed to register handler2: %v", err)
 		}
 
@@ -159,7 +165,7 @@ func TestDispatch(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -204,7 +210,7 @@ func TestDispatch(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -249,10 +255,10 @@ func TestDispatch(t *testing.T) {
 			return errors.New("handler2 error")
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler1); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler1); err != nil {
 			t.Fatalf("Failed to subscribe handler1: %v", err)
 		}
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler2); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler2); err != nil {
 			t.Fatalf("Failed to subscribe handler2: %v", err)
 		}
 
@@ -314,7 +320,7 @@ func TestDispatch(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -360,7 +366,7 @@ func TestSubscribeWildcard(t *testing.T) {
 			return nil
 		}
 
-		if err := d.SubscribeWildcard(wildcardHandler); err != nil {
+		if _, err := d.SubscribeWildcard(wildcardHandler); err != nil {
 			t.Fatalf("Failed to subscribe wildcard: %v", err)
 		}
 
@@ -422,10 +428,10 @@ func TestSubscribeWildcard(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", specificHandler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", specificHandler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
-		if err := d.SubscribeWildcard(wildcardHandler); err != nil {
+		if _, err := d.SubscribeWildcard(wildcardHandler); err != nil {
 			t.Fatalf("Failed to subscribe wildcard: %v", err)
 		}
 
@@ -460,7 +466,7 @@ func TestSubscribeWildcard(t *testing.T) {
 		t.Parallel()
 		d := domain.NewEventDispatcher()
 
-		err := d.SubscribeWildcard(nil)
+		_, err := d.SubscribeWildcard(nil)
 		if err == nil {
 			t.Error("Expected error for nil handler")
 		}
@@ -486,7 +492,7 @@ func TestConcurrentDispatch(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -540,7 +546,7 @@ func TestConcurrentDispatch(t *testing.T) {
 				handler := func(ctx context.Context, env domain.EventEnvelope[DispatcherTestUserCreatedEvent]) error {
 					return nil
 				}
-				if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
+				if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
 					t.Errorf("Failed to subscribe: %v", err)
 				}
 			}(i)
@@ -727,7 +733,7 @@ func TestTypeRegistryFromSubscribe(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -769,7 +775,7 @@ func TestPatternMatching(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -805,7 +811,7 @@ func TestPatternMatching(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.*", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.*", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -841,7 +847,7 @@ func TestPatternMatching(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "*.created", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "*.created", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -877,7 +883,7 @@ func TestPatternMatching(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "*.*", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "*.*", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -929,16 +935,16 @@ func TestPatternMatching(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", exactHandler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.created", exactHandler); err != nil {
 			t.Fatalf("Failed to subscribe exact: %v", err)
 		}
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.*", entityWildcardHandler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.*", entityWildcardHandler); err != nil {
 			t.Fatalf("Failed to subscribe entity.*: %v", err)
 		}
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "*.created", actionWildcardHandler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "*.created", actionWildcardHandler); err != nil {
 			t.Fatalf("Failed to subscribe *.created: %v", err)
 		}
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "*.*", allWildcardHandler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "*.*", allWildcardHandler); err != nil {
 			t.Fatalf("Failed to subscribe *.*: %v", err)
 		}
 
@@ -983,7 +989,7 @@ func TestPatternMatching(t *testing.T) {
 			return nil
 		}
 
-		if err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.*", handler); err != nil {
+		if _, err := domain.Subscribe[DispatcherTestUserCreatedEvent](d, "user.*", handler); err != nil {
 			t.Fatalf("Failed to subscribe: %v", err)
 		}
 
@@ -1010,3 +1016,941 @@ func TestPatternMatching(t *testing.T) {
 		}
 	})
 }
+
+func TestDispatchHandlerIsolation(t *testing.T) {
+	t.Parallel()
+
+	event := domain.EventEnvelope[any]{ID: "evt-1", EventType: "order.placed", Payload: DispatcherTestOrderPlacedEvent{OrderID: "o-1"}}
+
+	t.Run("blocking handler times out without stalling siblings", func(t *testing.T) {
+		t.Parallel()
+
+		d := domain.NewEventDispatcher(domain.WithHandlerTimeout(50 * time.Millisecond))
+		release := make(chan struct{})
+		defer close(release)
+
+		var fastRan sync.WaitGroup
+		fastRan.Add(1)
+		if _, err := domain.Subscribe(d, "order.placed", func(ctx context.Context, env domain.EventEnvelope[DispatcherTestOrderPlacedEvent]) error {
+			<-release // ignores ctx on purpose
+			return nil
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+		if _, err := domain.Subscribe(d, "order.placed", func(ctx context.Context, env domain.EventEnvelope[DispatcherTestOrderPlacedEvent]) error {
+			fastRan.Done()
+			return nil
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		start := time.Now()
+		err := d.Dispatch(context.Background(), event)
+		if !errors.Is(err, domain.ErrHandlerTimeout) {
+			t.Fatalf("expected ErrHandlerTimeout, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("dispatch waited %v for a blocking handler", elapsed)
+		}
+		fastRan.Wait()
+	})
+
+	t.Run("handler honouring ctx is cancelled at the deadline", func(t *testing.T) {
+		t.Parallel()
+
+		d := domain.NewEventDispatcher(domain.WithHandlerTimeout(50 * time.Millisecond))
+		cancelled := make(chan error, 1)
+		if _, err := domain.Subscribe(d, "order.placed", func(ctx context.Context, env domain.EventEnvelope[DispatcherTestOrderPlacedEvent]) error {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return ctx.Err()
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		if err := d.Dispatch(context.Background(), event); !errors.Is(err, domain.ErrHandlerTimeout) {
+			t.Fatalf("expected ErrHandlerTimeout, got %v", err)
+		}
+		select {
+		case err := <-cancelled:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("handler ctx error = %v, want context.DeadlineExceeded", err)
+			}
+		case <-time.After(time.Second):
+			t.Error("handler's ctx was not cancelled at the deadline")
+		}
+	})
+
+	t.Run("panic is collected as an error", func(t *testing.T) {
+		t.Parallel()
+
+		d := domain.NewEventDispatcher(domain.WithHandlerTimeout(time.Second))
+		var siblingCalled bool
+		var mu sync.Mutex
+		if _, err := d.SubscribeWildcard(func(ctx context.Context, env domain.EventEnvelope[any]) error {
+			panic("boom")
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+		if _, err := d.SubscribeWildcard(func(ctx context.Context, env domain.EventEnvelope[any]) error {
+			mu.Lock()
+			siblingCalled = true
+			mu.Unlock()
+			return nil
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		err := d.Dispatch(context.Background(), event)
+		if !errors.Is(err, domain.ErrHandlerPanic) {
+			t.Fatalf("expected ErrHandlerPanic, got %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !siblingCalled {
+			t.Error("expected sibling handler to run")
+		}
+	})
+
+	t.Run("handler errors are still reported", func(t *testing.T) {
+		t.Parallel()
+
+		d := domain.NewEventDispatcher(domain.WithHandlerTimeout(time.Second))
+		sentinel := errors.New("handler failed")
+		if _, err := d.SubscribeWildcard(func(ctx context.Context, env domain.EventEnvelope[any]) error {
+			return sentinel
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		if err := d.Dispatch(context.Background(), event); !errors.Is(err, sentinel) {
+			t.Fatalf("expected wrapped handler error, got %v", err)
+		}
+	})
+}
+
+func TestDispatchRecoversPanics(t *testing.T) {
+	t.Parallel()
+
+	d := domain.NewEventDispatcher()
+	var calls atomic.Int32
+	if _, err := domain.Subscribe[any](d, "user.created", func(ctx context.Context, env domain.EventEnvelope[any]) error {
+		var projection *DispatcherTestUserCreatedEvent
+		projection.Name = "nil pointer"
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if _, err := domain.Subscribe[any](d, "user.created", func(ctx context.Context, env domain.EventEnvelope[any]) error {
+		calls.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	err := d.Dispatch(context.Background(), domain.EventEnvelope[any]{ID: "ev-1", EventType: "user.created", Payload: map[string]any{}})
+	if err == nil || !strings.Contains(err.Error(), "panic") {
+		t.Fatalf("Dispatch() error = %v, want a panic error", err)
+	}
+	if !errors.Is(err, domain.ErrHandlerPanic) || !strings.Contains(err.Error(), "goroutine") {
+		t.Errorf("Dispatch() error = %v, want ErrHandlerPanic with the stack", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("counter = %d, want the other handler to run once", got)
+	}
+}
+
+func TestDispatchBatch(t *testing.T) {
+	t.Parallel()
+
+	envelopes := []domain.EventEnvelope[any]{
+		{ID: "ev-1", EventType: "order.placed", Payload: DispatcherTestOrderPlacedEvent{OrderID: "o-1"}},
+		{ID: "ev-2", EventType: "user.created", Payload: DispatcherTestUserCreatedEvent{UserID: "u-1"}},
+		{ID: "ev-3", EventType: "order.placed", Payload: DispatcherTestOrderPlacedEvent{OrderID: "o-2"}},
+		{ID: "ev-4", EventType: "order.placed", Payload: DispatcherTestOrderPlacedEvent{OrderID: "o-3"}},
+	}
+
+	t.Run("batch handler receives all matching envelopes in one call", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+
+		var calls [][]string
+		if _, err := domain.SubscribeBatch(d, "order.placed", func(ctx context.Context, envs []domain.EventEnvelope[DispatcherTestOrderPlacedEvent]) error {
+			var orders []string
+			for _, env := range envs {
+				orders = append(orders, env.Payload.OrderID)
+			}
+			calls = append(calls, orders)
+			return nil
+		}); err != nil {
+			t.Fatalf("SubscribeBatch() error = %v", err)
+		}
+		var singles atomic.Int32
+		if _, err := domain.Subscribe(d, "order.placed", func(ctx context.Context, env domain.EventEnvelope[DispatcherTestOrderPlacedEvent]) error {
+			singles.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		if err := d.DispatchBatch(context.Background(), envelopes); err != nil {
+			t.Fatalf("DispatchBatch() error = %v", err)
+		}
+		if len(calls) != 1 || strings.Join(calls[0], ",") != "o-1,o-2,o-3" {
+			t.Errorf("batch calls = %v, want one call with o-1,o-2,o-3", calls)
+		}
+		if got := singles.Load(); got != 3 {
+			t.Errorf("single handler calls = %d, want 3", got)
+		}
+
+		// Dispatch hands a batch handler a batch of one.
+		if err := d.Dispatch(context.Background(), envelopes[0]); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		if len(calls) != 2 || len(calls[1]) != 1 {
+			t.Errorf("batch calls = %v, want a single-envelope call from Dispatch", calls)
+		}
+	})
+
+	t.Run("batch handler errors are reported", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+
+		sentinel := errors.New("bulk insert failed")
+		if _, err := domain.SubscribeBatch(d, "order.*", func(ctx context.Context, envs []domain.EventEnvelope[DispatcherTestOrderPlacedEvent]) error {
+			return sentinel
+		}); err != nil {
+			t.Fatalf("SubscribeBatch() error = %v", err)
+		}
+		if err := d.DispatchBatch(context.Background(), envelopes); !errors.Is(err, sentinel) {
+			t.Errorf("DispatchBatch() error = %v, want %v", err, sentinel)
+		}
+	})
+}
+
+func TestReplayDispatcher(t *testing.T) {
+	t.Parallel()
+
+	event := domain.EventEnvelope[any]{ID: "evt-1", EventType: "order.placed", Payload: DispatcherTestOrderPlacedEvent{OrderID: "o-1"}}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	record := func(name string) {
+		mu.Lock()
+		calls[name]++
+		mu.Unlock()
+	}
+
+	d := domain.NewEventDispatcher()
+	if _, err := domain.Subscribe(d, "order.placed", func(ctx context.Context, env domain.EventEnvelope[DispatcherTestOrderPlacedEvent]) error {
+		record("projection")
+		return nil
+	}, domain.ReplaySafe()); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if _, err := domain.Subscribe(d, "order.*", func(ctx context.Context, env domain.EventEnvelope[DispatcherTestOrderPlacedEvent]) error {
+		record("notification")
+		return nil
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if _, err := d.SubscribeWildcard(func(ctx context.Context, env domain.EventEnvelope[any]) error {
+		record("audit")
+		return nil
+	}, domain.ReplaySafe()); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	replay := d.EnterReplay()
+	if err := replay.Dispatch(context.Background(), event); err != nil {
+		t.Fatalf("replay dispatch: %v", err)
+	}
+	// Live traffic during the replay still reaches every handler.
+	if err := d.Dispatch(context.Background(), event); err != nil {
+		t.Fatalf("live dispatch: %v", err)
+	}
+	replay.Exit()
+	replay.Exit()
+
+	if err := replay.Dispatch(context.Background(), event); !errors.Is(err, domain.ErrReplayExited) {
+		t.Fatalf("expected ErrReplayExited after Exit, got %v", err)
+	}
+
+	want := map[string]int{"projection": 2, "audit": 2, "notification": 1}
+	mu.Lock()
+	defer mu.Unlock()
+	for name, n := range want {
+		if calls[name] != n {
+			t.Errorf("%s called %d times, want %d", name, calls[name], n)
+		}
+	}
+}
+
+func TestValidateRegistrations(t *testing.T) {
+	t.Parallel()
+
+	noop := func(ctx context.Context, env domain.EventEnvelope[any]) error { return nil }
+	expected := []string{"user.created", "user.deleted", "order.placed", "order.shipped"}
+
+	tests := []struct {
+		name             string
+		setup            func(t *testing.T, d *domain.EventDispatcher)
+		wantErr          bool
+		wantInErr        []string
+		wantUnsubscribed []string
+	}{
+		{
+			name: "all subscriptions match",
+			setup: func(t *testing.T, d *domain.EventDispatcher) {
+				mustSubscribe(t, d, "user.created", noop)
+				mustSubscribe(t, d, "order.*", noop)
+			},
+			wantUnsubscribed: []string{"user.deleted"},
+		},
+		{
+			name: "misspelled event type is orphaned",
+			setup: func(t *testing.T, d *domain.EventDispatcher) {
+				mustSubscribe(t, d, "user.craeted", noop)
+				mustSubscribe(t, d, "order.placed", noop)
+			},
+			wantErr:          true,
+			wantInErr:        []string{`"user.craeted"`},
+			wantUnsubscribed: []string{"order.shipped", "user.created", "user.deleted"},
+		},
+		{
+			name: "pattern matching nothing is orphaned",
+			setup: func(t *testing.T, d *domain.EventDispatcher) {
+				mustSubscribe(t, d, "usr.*", noop)
+			},
+			wantErr:          true,
+			wantInErr:        []string{`"usr.*"`},
+			wantUnsubscribed: expectedSorted(expected),
+		},
+		{
+			name: "registered type under the wrong string is orphaned",
+			setup: func(t *testing.T, d *domain.EventDispatcher) {
+				if err := domain.RegisterType(d, "user.create", func() DispatcherTestUserCreatedEvent { return DispatcherTestUserCreatedEvent{} }); err != nil {
+					t.Fatalf("register: %v", err)
+				}
+			},
+			wantErr:          true,
+			wantInErr:        []string{`"user.create"`},
+			wantUnsubscribed: expectedSorted(expected),
+		},
+		{
+			name: "account-scoped subscriptions receive their types",
+			setup: func(t *testing.T, d *domain.EventDispatcher) {
+				if _, err := domain.SubscribeForAccount(d, "acct-1", "user.*", noop); err != nil {
+					t.Fatalf("subscribe for account: %v", err)
+				}
+			},
+			wantUnsubscribed: []string{"order.placed", "order.shipped"},
+		},
+		{
+			name: "wildcards are neither orphans nor subscribers",
+			setup: func(t *testing.T, d *domain.EventDispatcher) {
+				if _, err := d.SubscribeWildcard(noop); err != nil {
+					t.Fatalf("subscribe wildcard: %v", err)
+				}
+				mustSubscribe(t, d, "*.*", noop)
+				mustSubscribe(t, d, "user.*", noop)
+			},
+			wantUnsubscribed: []string{"order.placed", "order.shipped"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := domain.NewEventDispatcher()
+			tt.setup(t, d)
+
+			unsubscribed, err := d.ValidateRegistrations(expected...)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ValidateRegistrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, domain.ErrOrphanedSubscription) {
+					t.Errorf("expected ErrOrphanedSubscription, got %v", err)
+				}
+				for _, s := range tt.wantInErr {
+					if !strings.Contains(err.Error(), s) {
+						t.Errorf("expected error to name %s, got %v", s, err)
+					}
+				}
+			}
+			if strings.Join(unsubscribed, ",") != strings.Join(tt.wantUnsubscribed, ",") {
+				t.Errorf("unsubscribed = %v, want %v", unsubscribed, tt.wantUnsubscribed)
+			}
+		})
+	}
+
+	if _, err := domain.NewEventDispatcher().ValidateRegistrations(); err == nil {
+		t.Error("expected an error without expected event types")
+	}
+}
+
+func mustSubscribe(t *testing.T, d *domain.EventDispatcher, eventType string, handler domain.EventHandler[any]) {
+	t.Helper()
+	if _, err := domain.Subscribe(d, eventType, handler); err != nil {
+		t.Fatalf("subscribe %s: %v", eventType, err)
+	}
+}
+
+func expectedSorted(types []string) []string {
+	sorted := append([]string(nil), types...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func TestEventDispatcherWithWorkers(t *testing.T) {
+	t.Parallel()
+
+	eventFor := func(aggregateID string, seq int) domain.EventEnvelope[any] {
+		return domain.EventEnvelope[any]{ID: fmt.Sprintf("%s-%d", aggregateID, seq), AggregateID: aggregateID, EventType: "order.placed", SequenceNo: seq}
+	}
+
+	t.Run("preserves order per aggregate", func(t *testing.T) {
+		t.Parallel()
+
+		d := domain.NewEventDispatcherWithWorkers(4)
+		var mu sync.Mutex
+		seen := make(map[string][]int)
+		if _, err := d.SubscribeWildcard(func(_ context.Context, env domain.EventEnvelope[any]) error {
+			mu.Lock()
+			seen[env.AggregateID] = append(seen[env.AggregateID], env.SequenceNo)
+			mu.Unlock()
+			return nil
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		for seq := 1; seq <= 50; seq++ {
+			for _, id := range []string{"order-1", "order-2", "order-3"} {
+				if err := d.Dispatch(context.Background(), eventFor(id, seq)); err != nil {
+					t.Fatalf("Dispatch() error = %v", err)
+				}
+			}
+		}
+		d.Close()
+
+		for id, seqs := range seen {
+			if len(seqs) != 50 || !sort.IntsAreSorted(seqs) {
+				t.Errorf("%s handled %v, want 1..50 in order", id, seqs)
+			}
+		}
+		if err := d.Dispatch(context.Background(), eventFor("order-1", 51)); !errors.Is(err, domain.ErrDispatcherClosed) {
+			t.Errorf("Dispatch() after Close error = %v, want ErrDispatcherClosed", err)
+		}
+	})
+
+	t.Run("returns before handlers run and reports their errors", func(t *testing.T) {
+		t.Parallel()
+
+		handlerErr := errors.New("projector down")
+		reported := make(chan error, 1)
+		d := domain.NewEventDispatcherWithWorkers(2, domain.WithDispatchErrorHandler(func(_ domain.EventEnvelope[any], err error) {
+			reported <- err
+		}))
+		release := make(chan struct{})
+		if _, err := d.SubscribeWildcard(func(context.Context, domain.EventEnvelope[any]) error {
+			<-release
+			return handlerErr
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		if err := d.Dispatch(context.Background(), eventFor("order-1", 1)); err != nil {
+			t.Fatalf("Dispatch() error = %v, want nil while the handler is still running", err)
+		}
+		close(release)
+		select {
+		case err := <-reported:
+			if !errors.Is(err, handlerErr) {
+				t.Errorf("reported error = %v, want %v", err, handlerErr)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("handler error was not reported")
+		}
+		d.Close()
+	})
+
+	t.Run("blocks when the worker queue is full", func(t *testing.T) {
+		t.Parallel()
+
+		d := domain.NewEventDispatcherWithWorkers(1, domain.WithWorkerQueueSize(1))
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		if _, err := d.SubscribeWildcard(func(context.Context, domain.EventEnvelope[any]) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		if err := d.Dispatch(context.Background(), eventFor("order-1", 1)); err != nil {
+			t.Fatalf("Dispatch(1) error = %v", err)
+		}
+		<-started // the worker holds event 1
+		if err := d.Dispatch(context.Background(), eventFor("order-1", 2)); err != nil {
+			t.Fatalf("Dispatch(2) error = %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := d.Dispatch(ctx, eventFor("order-1", 3)); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Dispatch(3) error = %v, want it to block until the deadline", err)
+		}
+
+		close(release)
+		<-started
+		d.Close()
+	})
+}
+
+// recordingDeadLetters is a DeadLetterStore that keeps letters in memory.
+type recordingDeadLetters struct {
+	mu      sync.Mutex
+	letters []string
+	err     error
+}
+
+func (r *recordingDeadLetters) Store(_ context.Context, envelope domain.EventEnvelope[any], handlerName string, lastErr error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.letters = append(r.letters, fmt.Sprintf("%s/%s: %v", envelope.ID, handlerName, lastErr))
+	return nil
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	errPoison := errors.New("poison")
+	event := domain.EventEnvelope[any]{ID: "ev-1", AggregateID: "user-1", EventType: "user.created", Payload: map[string]any{"name": "Ada"}}
+
+	t.Run("exhausted handler is dead-lettered and dispatch succeeds", func(t *testing.T) {
+		t.Parallel()
+		letters := &recordingDeadLetters{}
+		d := domain.NewEventDispatcher(domain.WithDeadLetterStore(letters, 2))
+
+		var attempts, healthy int
+		var mu sync.Mutex
+		if _, err := domain.Subscribe[any](d, "user.created", func(context.Context, domain.EventEnvelope[any]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			return errPoison
+		}, domain.HandlerName("projector")); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+		if _, err := d.SubscribeWildcard(func(context.Context, domain.EventEnvelope[any]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			healthy++
+			return nil
+		}); err != nil {
+			t.Fatalf("SubscribeWildcard() error = %v", err)
+		}
+
+		if err := d.Dispatch(context.Background(), event); err != nil {
+			t.Fatalf("Dispatch() error = %v, want the failure dead-lettered", err)
+		}
+		if attempts != 3 || healthy != 1 {
+			t.Errorf("attempts = %d, healthy calls = %d, want 3 and 1", attempts, healthy)
+		}
+		if len(letters.letters) != 1 || letters.letters[0] != "ev-1/projector: poison" {
+			t.Errorf("letters = %v, want one for projector", letters.letters)
+		}
+	})
+
+	t.Run("retry that succeeds is not dead-lettered", func(t *testing.T) {
+		t.Parallel()
+		letters := &recordingDeadLetters{}
+		d := domain.NewEventDispatcher(domain.WithDeadLetterStore(letters, 1))
+
+		attempts := 0
+		if _, err := domain.Subscribe[any](d, "user.created", func(context.Context, domain.EventEnvelope[any]) error {
+			attempts++
+			if attempts == 1 {
+				return errPoison
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		if err := d.Dispatch(context.Background(), event); err != nil || len(letters.letters) != 0 {
+			t.Errorf("Dispatch() error = %v, letters = %v, want success and no letters", err, letters.letters)
+		}
+	})
+
+	t.Run("failing store reports the handler error", func(t *testing.T) {
+		t.Parallel()
+		errStore := errors.New("store down")
+		d := domain.NewEventDispatcher(domain.WithDeadLetterStore(&recordingDeadLetters{err: errStore}, 0))
+		if _, err := domain.Subscribe[any](d, "user.created", func(context.Context, domain.EventEnvelope[any]) error {
+			return errPoison
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		err := d.Dispatch(context.Background(), event)
+		if !errors.Is(err, errPoison) || !errors.Is(err, errStore) {
+			t.Errorf("Dispatch() error = %v, want both the handler and store errors", err)
+		}
+	})
+
+	t.Run("redeliver runs only the named handler", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		var calls []string
+		for _, name := range []string{"first", "second"} {
+			if _, err := domain.Subscribe[any](d, "user.*", func(context.Context, domain.EventEnvelope[any]) error {
+				calls = append(calls, name)
+				return nil
+			}, domain.HandlerName(name)); err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+		}
+		if _, err := domain.Subscribe[any](d, "user.created", func(context.Context, domain.EventEnvelope[any]) error {
+			calls = append(calls, "unnamed")
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		if err := d.Redeliver(context.Background(), event, "second"); err != nil {
+			t.Fatalf("Redeliver() error = %v", err)
+		}
+		if err := d.Redeliver(context.Background(), event, "user.created#0"); err != nil {
+			t.Fatalf("Redeliver() by default name error = %v", err)
+		}
+		if strings.Join(calls, ",") != "second,unnamed" {
+			t.Errorf("calls = %v, want second then unnamed", calls)
+		}
+		if err := d.Redeliver(context.Background(), event, "missing"); !errors.Is(err, domain.ErrHandlerNotFound) {
+			t.Errorf("Redeliver() of unknown handler error = %v, want ErrHandlerNotFound", err)
+		}
+	})
+}
+
+func TestDispatcherFeatureFlags(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	enabled := map[string]bool{"new-projector": false}
+	flags := domain.FeatureFlagsFunc(func(_ context.Context, flag string, _ domain.EventEnvelope[any]) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return enabled[flag]
+	})
+	d := domain.NewEventDispatcher(domain.WithFeatureFlags(flags))
+
+	var calls []string
+	record := func(name string) domain.EventHandler[any] {
+		return func(context.Context, domain.EventEnvelope[any]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	if _, err := domain.Subscribe(d, "user.created", record("flagged"), domain.EnabledByFlag("new-projector"), domain.HandlerName("flagged")); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if _, err := domain.Subscribe(d, "user.created", record("plain")); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	event := domain.EventEnvelope[any]{ID: "ev-1", AggregateID: "user-1", EventType: "user.created", Payload: map[string]any{}}
+	if err := d.Dispatch(context.Background(), event); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	mu.Lock()
+	enabled["new-projector"] = true
+	mu.Unlock()
+	if err := d.Dispatch(context.Background(), event); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+
+	sort.Strings(calls)
+	if strings.Join(calls, ",") != "flagged,plain,plain" {
+		t.Errorf("calls = %v, want the flagged handler only once enabled", calls)
+	}
+}
+
+func TestInRollout(t *testing.T) {
+	t.Parallel()
+
+	in := 0
+	for i := range 1000 {
+		event := domain.EventEnvelope[any]{AggregateID: fmt.Sprintf("agg-%d", i)}
+		if domain.InRollout(event, 25) {
+			in++
+		}
+		if domain.InRollout(event, 25) && !domain.InRollout(event, 50) {
+			t.Fatalf("aggregate %s in a 25%% rollout but not a 50%% one", event.AggregateID)
+		}
+	}
+	if in < 180 || in > 320 {
+		t.Errorf("%d of 1000 aggregates in a 25%% rollout, want about 250", in)
+	}
+	if domain.InRollout(domain.EventEnvelope[any]{AggregateID: "x"}, 0) || !domain.InRollout(domain.EventEnvelope[any]{AggregateID: "x"}, 100) {
+		t.Error("0% must include nothing and 100% everything")
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	event := domain.EventEnvelope[any]{ID: "ev-1", AggregateID: "user-1", EventType: "user.created", Payload: map[string]any{}}
+
+	t.Run("handler is not invoked after unsubscribe", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		var typed, wildcard int
+		typedID, err := domain.Subscribe[any](d, "user.created", func(context.Context, domain.EventEnvelope[any]) error {
+			typed++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+		wildcardID, err := d.SubscribeWildcard(func(context.Context, domain.EventEnvelope[any]) error {
+			wildcard++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("SubscribeWildcard() error = %v", err)
+		}
+
+		if err := d.Dispatch(context.Background(), event); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		if err := d.Unsubscribe(typedID); err != nil {
+			t.Fatalf("Unsubscribe() error = %v", err)
+		}
+		if err := d.Unsubscribe(wildcardID); err != nil {
+			t.Fatalf("Unsubscribe() of wildcard error = %v", err)
+		}
+		if err := d.Dispatch(context.Background(), event); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+
+		if typed != 1 || wildcard != 1 {
+			t.Errorf("calls = %d typed, %d wildcard, want 1 each", typed, wildcard)
+		}
+		if err := d.Unsubscribe(typedID); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+			t.Errorf("second Unsubscribe() error = %v, want ErrSubscriptionNotFound", err)
+		}
+	})
+
+	t.Run("other handlers for the pattern keep running", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		var mu sync.Mutex
+		var calls []string
+		record := func(name string) domain.EventHandler[any] {
+			return func(context.Context, domain.EventEnvelope[any]) error {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, name)
+				return nil
+			}
+		}
+		var ids []domain.SubscriptionID
+		for _, name := range []string{"first", "second", "third"} {
+			id, err := domain.Subscribe(d, "user.created", record(name))
+			if err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			ids = append(ids, id)
+		}
+		if err := d.Unsubscribe(ids[1]); err != nil {
+			t.Fatalf("Unsubscribe() error = %v", err)
+		}
+		if _, err := domain.Subscribe(d, "user.created", record("fourth")); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		if err := d.Dispatch(context.Background(), event); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		sort.Strings(calls)
+		if strings.Join(calls, ",") != "first,fourth,third" {
+			t.Errorf("calls = %v, want first, third and fourth", calls)
+		}
+		if err := d.Redeliver(context.Background(), event, "user.created#3"); err != nil {
+			t.Errorf("Redeliver() of the fourth registration by default name error = %v", err)
+		}
+	})
+
+	t.Run("concurrent unsubscribe during dispatch is safe", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		var ids []domain.SubscriptionID
+		for range 20 {
+			id, err := domain.Subscribe[any](d, "user.*", func(context.Context, domain.EventEnvelope[any]) error { return nil })
+			if err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			ids = append(ids, id)
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				if err := d.Dispatch(context.Background(), event); err != nil {
+					t.Errorf("Dispatch() error = %v", err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, id := range ids {
+				if err := d.Unsubscribe(id); err != nil {
+					t.Errorf("Unsubscribe() error = %v", err)
+				}
+			}
+		}()
+		wg.Wait()
+	})
+}
+
+func TestDispatcherMiddleware(t *testing.T) {
+	t.Parallel()
+
+	event := domain.EventEnvelope[any]{ID: "ev-1", AggregateID: "user-1", EventType: "user.created", Payload: map[string]any{}}
+
+	t.Run("middlewares wrap specific and wildcard handlers outermost first", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		var mu sync.Mutex
+		var trace []string
+		record := func(step string) {
+			mu.Lock()
+			defer mu.Unlock()
+			trace = append(trace, step)
+		}
+		tag := func(name string) domain.DispatchMiddleware {
+			return func(next domain.HandlerFunc) domain.HandlerFunc {
+				return func(ctx context.Context, env domain.EventEnvelope[any]) error {
+					record(name + ">")
+					err := next(ctx, env)
+					record("<" + name)
+					return err
+				}
+			}
+		}
+		if _, err := domain.Subscribe(d, "user.created", func(context.Context, domain.EventEnvelope[any]) error {
+			record("handler")
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+		d.Use(tag("outer"))
+		d.Use(tag("inner"))
+
+		if err := d.Dispatch(context.Background(), event); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		if got := strings.Join(trace, " "); got != "outer> inner> handler <inner <outer" {
+			t.Errorf("trace = %q, want outer wrapping inner wrapping the handler", got)
+		}
+
+		trace = nil
+		if _, err := d.SubscribeWildcard(func(context.Context, domain.EventEnvelope[any]) error { return nil }); err != nil {
+			t.Fatalf("SubscribeWildcard() error = %v", err)
+		}
+		if err := d.Dispatch(context.Background(), event); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		if len(trace) != 9 {
+			t.Errorf("trace = %v, want both handlers wrapped", trace)
+		}
+	})
+
+	t.Run("recovery turns a panic into an error", func(t *testing.T) {
+		t.Parallel()
+		var logs bytes.Buffer
+		d := domain.NewEventDispatcher()
+		d.Use(domain.LoggingMiddleware(slog.New(slog.NewTextHandler(&logs, nil))))
+		d.Use(domain.RecoveryMiddleware())
+		healthy := false
+		if _, err := domain.Subscribe(d, "user.created", func(context.Context, domain.EventEnvelope[any]) error {
+			panic("boom")
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+		if _, err := d.SubscribeWildcard(func(context.Context, domain.EventEnvelope[any]) error {
+			healthy = true
+			return nil
+		}); err != nil {
+			t.Fatalf("SubscribeWildcard() error = %v", err)
+		}
+
+		err := d.Dispatch(context.Background(), event)
+		if !errors.Is(err, domain.ErrHandlerPanic) || !strings.Contains(err.Error(), "boom") {
+			t.Errorf("Dispatch() error = %v, want ErrHandlerPanic with the panic value", err)
+		}
+		if !healthy {
+			t.Error("the healthy handler did not run")
+		}
+		if out := logs.String(); !strings.Contains(out, "event handler failed") || !strings.Contains(out, "event_id=ev-1") {
+			t.Errorf("logs = %q, want the failure logged with the event ID", out)
+		}
+	})
+
+	t.Run("scoped logger tags handler logs with the event's context", func(t *testing.T) {
+		t.Parallel()
+		var logs bytes.Buffer
+		d := domain.NewEventDispatcher()
+		d.Use(domain.ScopedLoggerMiddleware(slog.New(slog.NewJSONHandler(&logs, nil))))
+		if _, err := domain.Subscribe(d, "user.created", func(ctx context.Context, _ domain.EventEnvelope[any]) error {
+			domain.LoggerFromContext(ctx).InfoContext(ctx, "welcome email sent")
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		tagged := event
+		tagged.Metadata = map[string]any{
+			domain.MetadataActorID:       "agent-7",
+			domain.MetadataAccountID:     "acct-1",
+			domain.MetadataCorrelationID: "corr-1",
+		}
+		if err := d.Dispatch(context.Background(), tagged); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+
+		var line map[string]any
+		if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+			t.Fatalf("log line is not JSON: %v\n%s", err, logs.String())
+		}
+		want := map[string]any{
+			"msg":            "welcome email sent",
+			"event_type":     "user.created",
+			"event_id":       "ev-1",
+			"aggregate_id":   "user-1",
+			"actor_id":       "agent-7",
+			"account_id":     "acct-1",
+			"correlation_id": "corr-1",
+		}
+		for key, value := range want {
+			if line[key] != value {
+				t.Errorf("%s = %v, want %v", key, line[key], value)
+			}
+		}
+		if _, ok := line["causation_id"]; ok {
+			t.Errorf("causation_id logged without being in the metadata: %v", line)
+		}
+	})
+}
@@ -18,18 +18,37 @@ var (
 	// ErrGlobalOrderingNotSupported is returned by ReadAfter on stores that
 	// cannot provide a global, cross-aggregate ordering of events.
 	ErrGlobalOrderingNotSupported = errors.New("event store does not support a global ordered feed")
+
+	// ErrLockTimeout is returned by AggregateLocker.LockAggregates when an
+	// aggregate lock could not be acquired within the store's lock timeout.
+	ErrLockTimeout = errors.New("timed out waiting for aggregate lock")
+
+	// ErrStreamExists is returned by SaveIfNotExists when the aggregate
+	// already has events.
+	ErrStreamExists = errors.New("event stream already exists")
+
+	// ErrCreationRaceLost is returned together with ErrStreamExists when the
+	// stream did not exist at the version check but a concurrent writer
+	// created it before this append landed.
+	ErrCreationRaceLost = errors.New("concurrent stream creation won")
 )
 
 // EventStore defines the interface for persisting and retrieving events.
 // Implementations should be thread-safe and handle concurrent access.
 // Events are stored as EventEnvelope[any] to allow storing events with different payload types together.
+//
+// Ordering is defined by sequence numbers, never by timestamps: within an
+// aggregate, events are ordered by SequenceNo; across aggregates, by the
+// store-assigned Position. Created is informational only — events recorded in
+// a tight loop routinely share a timestamp. Within one Append call, positions
+// are assigned in SequenceNo order regardless of the order events are passed.
 type EventStore interface {
 	// Append appends one or more events to the event store for a given aggregate.
 	// It returns an error if the expected version doesn't match (optimistic concurrency control).
 	// If expectedVersion is -1, no version check is performed.
 	Append(ctx context.Context, aggregateID string, expectedVersion int, events ...EventEnvelope[any]) error
 
-	// GetEvents retrieves all events for a given aggregate ID.
+	// GetEvents retrieves all events for a given aggregate ID, ordered by SequenceNo.
 	// Returns an empty slice if no events are found.
 	GetEvents(ctx context.Context, aggregateID string) ([]EventEnvelope[any], error)
 
@@ -84,6 +103,34 @@ type EventStore interface {
 	Close() error
 }
 
+// TransactionalEventStore is implemented by event stores that can group
+// several appends, together with caller-supplied work against the same
+// database, into one atomic transaction.
+type TransactionalEventStore interface {
+	EventStore
+
+	// InTransaction runs fn with a context bound to a single store
+	// transaction. Appends made with that context join the transaction, which
+	// commits only if fn returns nil; any error rolls back every append made
+	// within it.
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// AggregateLocker is implemented by transactional stores that can serialize
+// access to individual aggregate streams with a pessimistic lock, for
+// aggregates that cannot tolerate the retry loops of optimistic concurrency.
+type AggregateLocker interface {
+	TransactionalEventStore
+
+	// LockAggregates blocks until it holds an exclusive lock on each of the
+	// given aggregate streams. It must be called with a context from
+	// InTransaction; the locks are held until that transaction ends. Locks are
+	// taken in sorted aggregate ID order, so commands locking overlapping sets
+	// of aggregates cannot deadlock. A lock that is not granted within the
+	// store's lock timeout fails with ErrLockTimeout.
+	LockAggregates(ctx context.Context, aggregateIDs ...string) error
+}
+
 // ToAnyEnvelope converts an EventEnvelope[T] to EventEnvelope[any] for storage.
 // This allows storing events with different payload types together in the event store.
 func ToAnyEnvelope[T any](envelope EventEnvelope[T]) EventEnvelope[any] {
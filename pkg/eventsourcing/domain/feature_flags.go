@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// FeatureFlags decides at runtime whether a flagged handler runs for an
+// event, so a new projector can be rolled out to a share of events or
+// switched off during an incident without a redeploy. Enabled is called once
+// per flagged handler per dispatched event and should answer from memory.
+type FeatureFlags interface {
+	Enabled(ctx context.Context, flag string, envelope EventEnvelope[any]) bool
+}
+
+// FeatureFlagsFunc adapts a function to FeatureFlags.
+type FeatureFlagsFunc func(ctx context.Context, flag string, envelope EventEnvelope[any]) bool
+
+// Enabled calls f.
+func (f FeatureFlagsFunc) Enabled(ctx context.Context, flag string, envelope EventEnvelope[any]) bool {
+	return f(ctx, flag, envelope)
+}
+
+// WithFeatureFlags sets the FeatureFlags consulted for handlers subscribed
+// with EnabledByFlag. Without it flagged handlers always run.
+func WithFeatureFlags(flags FeatureFlags) DispatcherOption {
+	return func(d *EventDispatcher) {
+		d.flags = flags
+	}
+}
+
+// EnabledByFlag makes the handler run only for events the dispatcher's
+// FeatureFlags enable flag for. A disabled handler misses those events for
+// good: EventDispatcher keeps no checkpoint, so nothing is caught up when the
+// flag is turned back on. Rebuild the projection after re-enabling it (see
+// RebuildProjection), or run it as a subscriptions.Subscriber, whose
+// WithFeatureFlag can pause at the checkpoint instead. Redeliver ignores the
+// flag.
+func EnabledByFlag(flag string) SubscribeOption {
+	return func(s *subscription) {
+		s.flag = flag
+	}
+}
+
+// InRollout reports whether envelope falls within a percent rollout, for use
+// in FeatureFlags implementations. Events are bucketed by a hash of their
+// AggregateID, so an aggregate is either wholly in or wholly out of the
+// rollout and a projection never sees half of an aggregate's history.
+func InRollout(envelope EventEnvelope[any], percent int) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(envelope.AggregateID))
+	return int(h.Sum32()%100) < percent
+}
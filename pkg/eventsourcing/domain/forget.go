@@ -0,0 +1,21 @@
+package domain
+
+// MetadataForgotten is the metadata key flagging an event whose payload was
+// erased by a data-subject deletion. The event keeps its type, sequence
+// number and timestamp, so the stream stays intact for auditing, but its
+// payload is ForgottenPayload.
+const MetadataForgotten = "forgotten"
+
+// ForgottenPayload returns the tombstone that replaces the payload of a
+// forgotten event.
+func ForgottenPayload() map[string]any {
+	return map[string]any{MetadataForgotten: true}
+}
+
+// IsForgotten reports whether the envelope's payload was erased. Handlers and
+// ApplyEvent implementations check it to skip tombstones instead of failing
+// to decode them.
+func IsForgotten[T any](env EventEnvelope[T]) bool {
+	forgotten, _ := env.Metadata[MetadataForgotten].(bool)
+	return forgotten
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+)
+
+// loggedMetadataKeys are event metadata keys copied onto the logger
+// ScopedLoggerMiddleware installs when present, so every log line in a
+// handler carries them.
+var loggedMetadataKeys = []string{MetadataCorrelationID, MetadataCausationID, MetadataAccountID, MetadataActorID}
+
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying logger.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFromContext returns the logger installed in ctx, or slog.Default()
+// when ctx carries none. The command dispatcher installs a command-scoped
+// logger for receivers (cqrs.LoggerFromContext reads the same one), and
+// ScopedLoggerMiddleware an event-scoped one for event handlers.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// ScopedLoggerMiddleware installs a child of base in every handler's context,
+// tagged with the event's type, ID and aggregate ID and with the correlation,
+// causation, account and actor IDs from its metadata, so handlers logging
+// through LoggerFromContext need not add them. It replaces any logger already
+// in the context, such as the command-scoped one when a unit of work
+// dispatches while a command is handled. A nil base means slog.Default().
+func ScopedLoggerMiddleware(base *slog.Logger) DispatchMiddleware {
+	if base == nil {
+		base = slog.Default()
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, env EventEnvelope[any]) error {
+			attrs := []any{
+				slog.String("event_type", env.EventType),
+				slog.String("event_id", env.ID),
+				slog.String("aggregate_id", env.AggregateID),
+			}
+			for _, key := range loggedMetadataKeys {
+				if v, ok := env.Metadata[key]; ok {
+					attrs = append(attrs, slog.Any(key, v))
+				}
+			}
+			return next(ContextWithLogger(ctx, base.With(attrs...)), env)
+		}
+	}
+}
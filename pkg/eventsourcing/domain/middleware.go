@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DispatchMiddleware wraps every handler invocation of an EventDispatcher,
+// for cross-cutting concerns such as metrics, logging and tracing that
+// should not be repeated in each handler.
+type DispatchMiddleware func(next HandlerFunc) HandlerFunc
+
+// Use adds middleware around every handler, specific and wildcard alike,
+// including handlers subscribed before the call. Middlewares run in the order
+// they were added, the first outermost. Each retry under WithDeadLetterStore
+// and each Redeliver is a separate invocation, so it passes through the chain
+// again; with WithHandlerTimeout the chain runs inside the isolated goroutine.
+func (d *EventDispatcher) Use(middleware DispatchMiddleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.middlewares = append(d.middlewares, middleware)
+}
+
+// applyMiddlewares wraps handler in d's middleware chain. d.mu must be held.
+func (d *EventDispatcher) applyMiddlewares(handler HandlerFunc) HandlerFunc {
+	for i := len(d.middlewares) - 1; i >= 0; i-- {
+		handler = d.middlewares[i](handler)
+	}
+	return handler
+}
+
+// LoggingMiddleware logs every handler invocation to logger: failures at
+// error level and successes at debug level, each with the event's type, ID
+// and aggregate ID and the handler's duration.
+func LoggingMiddleware(logger *slog.Logger) DispatchMiddleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, env EventEnvelope[any]) error {
+			start := time.Now()
+			err := next(ctx, env)
+			attrs := []any{
+				"event_type", env.EventType,
+				"event_id", env.ID,
+				"aggregate_id", env.AggregateID,
+				"duration_ms", time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "event handler failed", append(attrs, "error", err)...)
+			} else {
+				logger.DebugContext(ctx, "event handler completed", attrs...)
+			}
+			return err
+		}
+	}
+}
+
+// RecoveryMiddleware turns a handler panic into an error wrapping
+// ErrHandlerPanic, so one faulty handler cannot crash the process that
+// dispatches to it.
+func RecoveryMiddleware() DispatchMiddleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, env EventEnvelope[any]) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+				}
+			}()
+			return next(ctx, env)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrOutboxOutsideTransaction is returned by Outbox.Enqueue when it is called
+// without a context from the event store's InTransaction, where the outbox
+// rows could not commit atomically with the events.
+var ErrOutboxOutsideTransaction = errors.New("outbox enqueue requires an event store transaction")
+
+// Outbox records committed events for delivery after the commit, so a crash
+// between persisting events and dispatching them cannot lose the dispatch.
+// Events are enqueued in the same transaction that appends them, and a relay
+// later reads the pending ones, dispatches them and marks them sent. Delivery
+// is at-least-once: an event dispatched just before a crash is dispatched
+// again after the restart.
+type Outbox interface {
+	// Enqueue records events as pending delivery. It must be called with a
+	// context from the event store's InTransaction so the records commit or
+	// roll back with the events; otherwise it fails with
+	// ErrOutboxOutsideTransaction.
+	Enqueue(ctx context.Context, events ...EventEnvelope[any]) error
+
+	// Pending returns up to limit events not yet marked sent that are due
+	// for delivery, oldest first. An event whose delivery failed is not due
+	// until its retry time (see MarkFailed), and neither are the later
+	// events of its aggregate, so each aggregate's events are delivered in
+	// order.
+	Pending(ctx context.Context, limit int) ([]EventEnvelope[any], error)
+
+	// MarkSent records the events with the given IDs as delivered, so
+	// Pending no longer returns them.
+	MarkSent(ctx context.Context, eventIDs ...string) error
+
+	// MarkFailed records a failed delivery of the event with the given ID:
+	// it counts the attempt, keeps cause for inspection and schedules a
+	// retry after a backoff that grows with the attempts, so a failing event
+	// neither blocks the events of other aggregates nor is retried on every
+	// poll.
+	MarkFailed(ctx context.Context, eventID string, cause error) error
+}
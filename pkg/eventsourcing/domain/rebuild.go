@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultRebuildBatchSize is the page size RebuildProjection reads the feed
+// in when given a non-positive batch size.
+const DefaultRebuildBatchSize = 500
+
+// RebuildProjection feeds handler every event in store after afterPosition
+// (0 for the whole history), in global Position order, paging through
+// ReadAfter batchSize events at a time until the feed is exhausted. It
+// returns the Position of the last event handled, which the caller can
+// persist as the projection's checkpoint and later pass back as
+// afterPosition to resume.
+//
+// The order is stable across pages even while events are appended
+// concurrently, since ReadAfter never returns an event positioned before one
+// it has already returned; events committed during the rebuild are picked up
+// by the final pages. Handling stops at the first handler error, which is
+// returned together with the Position of the last event handled before it.
+// To deliver through an EventDispatcher, pass a ReplayDispatcher's Dispatch.
+func RebuildProjection(ctx context.Context, store EventStore, afterPosition int64, batchSize int, handler EventHandler[any]) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultRebuildBatchSize
+	}
+	for {
+		page, err := store.ReadAfter(ctx, afterPosition, batchSize)
+		if err != nil {
+			return afterPosition, err
+		}
+		for _, event := range page {
+			if err := handler(ctx, event); err != nil {
+				return afterPosition, fmt.Errorf("rebuild handler failed at position %d: %w", event.Position, err)
+			}
+			afterPosition = event.Position
+		}
+		if len(page) < batchSize {
+			return afterPosition, nil
+		}
+	}
+}
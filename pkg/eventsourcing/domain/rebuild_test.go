@@ -0,0 +1,66 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+func TestRebuildProjection(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := infrastructure.NewMemoryStore()
+	for i := 1; i <= 7; i++ {
+		aggregateID := fmt.Sprintf("agg-%d", i%3)
+		version, _ := store.GetCurrentVersion(ctx, aggregateID)
+		if err := store.Append(ctx, aggregateID, version, domain.NewEventEnvelope[any](i, aggregateID, "test.recorded", version+1)); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name      string
+		after     int64
+		batchSize int
+		failAt    int64
+		wantSeen  []int64
+		wantLast  int64
+		wantErr   error
+	}{
+		{name: "whole history across pages", batchSize: 3, wantSeen: []int64{1, 2, 3, 4, 5, 6, 7}, wantLast: 7},
+		{name: "page size dividing the feed", batchSize: 7, wantSeen: []int64{1, 2, 3, 4, 5, 6, 7}, wantLast: 7},
+		{name: "default batch size", wantSeen: []int64{1, 2, 3, 4, 5, 6, 7}, wantLast: 7},
+		{name: "resume after position", after: 5, batchSize: 2, wantSeen: []int64{6, 7}, wantLast: 7},
+		{name: "handler error stops at last handled", batchSize: 2, failAt: 4, wantSeen: []int64{1, 2, 3}, wantLast: 3, wantErr: errStop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var seen []int64
+			last, err := domain.RebuildProjection(ctx, store, tt.after, tt.batchSize, func(_ context.Context, env domain.EventEnvelope[any]) error {
+				if env.Position == tt.failAt {
+					return errStop
+				}
+				seen = append(seen, env.Position)
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RebuildProjection() error = %v, want %v", err, tt.wantErr)
+			}
+			if last != tt.wantLast {
+				t.Errorf("last position = %d, want %d", last, tt.wantLast)
+			}
+			if fmt.Sprint(seen) != fmt.Sprint(tt.wantSeen) {
+				t.Errorf("handled positions %v, want %v", seen, tt.wantSeen)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrOrphanedSubscription is returned by ValidateRegistrations for handlers
+// or registered types whose event type no producer emits — usually a
+// misspelled event type string.
+var ErrOrphanedSubscription = errors.New("subscription to an event type no producer emits")
+
+// ValidateRegistrations cross-checks the dispatcher's registrations against
+// expected, the event types the application's producers emit (for example
+// the generated event catalog). Call it once at startup, after wiring every
+// handler, so wiring mistakes fail the boot instead of silently never
+// matching in production.
+//
+// It reports an error wrapping ErrOrphanedSubscription naming every event
+// type that is subscribed to (Subscribe, SubscribeForAccount) or registered
+// (RegisterType) but not in expected, and every pattern such as "user.*" that
+// matches no expected type. Wildcard handlers (SubscribeWildcard) and
+// all-wildcard patterns such as "*.*" are excluded, since they legitimately
+// subscribe to nothing specific.
+//
+// It also returns, sorted, the expected types that no exact or pattern
+// subscription receives. Those are warnings rather than errors — an event may
+// be emitted purely for the record — and wildcard handlers do not count as
+// subscribers for them, since a catch-all audit handler says nothing about
+// whether anything acts on the event.
+func (d *EventDispatcher) ValidateRegistrations(expected ...string) (unsubscribed []string, err error) {
+	if len(expected) == 0 {
+		return nil, errors.New("no expected event types declared")
+	}
+	known := make(map[string]bool, len(expected))
+	for _, eventType := range expected {
+		known[eventType] = true
+	}
+
+	d.mu.RLock()
+	subscribed := make(map[string]bool, len(d.handlers)+len(d.typeRegistry))
+	for eventType, subs := range d.handlers {
+		if len(subs) > 0 {
+			subscribed[eventType] = true
+		}
+	}
+	for _, byPattern := range d.accountHandlers {
+		for eventType := range byPattern {
+			subscribed[eventType] = true
+		}
+	}
+	registered := make([]string, 0, len(d.typeRegistry))
+	for eventType := range d.typeRegistry {
+		registered = append(registered, eventType)
+	}
+	d.mu.RUnlock()
+
+	// Dispatching an expected type consults its matching patterns: each
+	// subscribed one is used, and makes the type received.
+	received := make(map[string]bool)
+	usedPatterns := make(map[string]bool)
+	for eventType := range known {
+		for _, pattern := range getMatchingPatterns(eventType) {
+			if subscribed[pattern] && !isAllWildcard(pattern) {
+				received[eventType] = true
+				usedPatterns[pattern] = true
+			}
+		}
+	}
+
+	orphaned := make(map[string]bool)
+	for eventType := range subscribed {
+		if isAllWildcard(eventType) {
+			continue
+		}
+		if strings.Contains(eventType, "*") {
+			if !usedPatterns[eventType] {
+				orphaned[eventType] = true
+			}
+		} else if !known[eventType] {
+			orphaned[eventType] = true
+		}
+	}
+	for _, eventType := range registered {
+		// Subscribe registers patterns too; those were checked above.
+		if !strings.Contains(eventType, "*") && !known[eventType] {
+			orphaned[eventType] = true
+		}
+	}
+
+	for eventType := range known {
+		if !received[eventType] {
+			unsubscribed = append(unsubscribed, eventType)
+		}
+	}
+	sort.Strings(unsubscribed)
+
+	if len(orphaned) > 0 {
+		names := make([]string, 0, len(orphaned))
+		for eventType := range orphaned {
+			names = append(names, fmt.Sprintf("%q", eventType))
+		}
+		sort.Strings(names)
+		err = fmt.Errorf("%w: %s", ErrOrphanedSubscription, strings.Join(names, ", "))
+	}
+	return unsubscribed, err
+}
+
+// isAllWildcard reports whether every segment of pattern is "*".
+func isAllWildcard(pattern string) bool {
+	parts := splitEventType(pattern)
+	for _, part := range parts {
+		if part != "*" {
+			return false
+		}
+	}
+	return len(parts) > 0
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "context"
+
+// MetadataOriginRegion is the metadata key recording the region in which an
+// event was originally committed. Replicated copies keep the value from their
+// home region, which lets consumers tell local events from replicated ones.
+const MetadataOriginRegion = "origin_region"
+
+// OriginRegion returns the origin region recorded in the envelope's metadata,
+// or "" if none was recorded.
+func OriginRegion[T any](env EventEnvelope[T]) string {
+	region, _ := env.Metadata[MetadataOriginRegion].(string)
+	return region
+}
+
+// IsLocalOrigin reports whether the envelope originated in localRegion.
+// Events without an origin region (single-region deployments, or events stored
+// before regions were recorded) are treated as local.
+func IsLocalOrigin[T any](env EventEnvelope[T], localRegion string) bool {
+	region := OriginRegion(env)
+	return region == "" || region == localRegion
+}
+
+// LocalOriginOnly wraps handler so it only runs for events that originated in
+// localRegion; replicated events are skipped without error.
+//
+// Use it for integration publishers (message buses, webhooks, outbound sync)
+// so that an event is published once, in its home region, and replication does
+// not loop. Domain projections should subscribe unwrapped so that a passive
+// region's read models still apply replicated events.
+//
+// The returned handler is an EventHandler[T]; with T = any it can be passed to
+// SubscribeWildcard as well as Subscribe.
+func LocalOriginOnly[T any](localRegion string, handler EventHandler[T]) EventHandler[T] {
+	return func(ctx context.Context, env EventEnvelope[T]) error {
+		if !IsLocalOrigin(env, localRegion) {
+			return nil
+		}
+		return handler(ctx, env)
+	}
+}
@@ -0,0 +1,77 @@
+package domain_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+func TestIsLocalOrigin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     bool
+	}{
+		{name: "no metadata is local", metadata: nil, want: true},
+		{name: "missing key is local", metadata: map[string]interface{}{"other": "x"}, want: true},
+		{name: "same region is local", metadata: map[string]interface{}{domain.MetadataOriginRegion: "us-east-1"}, want: true},
+		{name: "other region is replicated", metadata: map[string]interface{}{domain.MetadataOriginRegion: "eu-west-1"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			env := domain.EventEnvelope[any]{EventType: "order.placed", Metadata: tt.metadata}
+			if got := domain.IsLocalOrigin(env, "us-east-1"); got != tt.want {
+				t.Errorf("IsLocalOrigin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalOriginOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dispatcher := domain.NewEventDispatcher()
+
+	var projected, published atomic.Int32
+	if _, err := dispatcher.SubscribeWildcard(func(ctx context.Context, env domain.EventEnvelope[any]) error {
+		projected.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to subscribe projection: %v", err)
+	}
+	if _, err := dispatcher.SubscribeWildcard(domain.LocalOriginOnly("us-east-1", func(ctx context.Context, env domain.EventEnvelope[any]) error {
+		published.Add(1)
+		return nil
+	})); err != nil {
+		t.Fatalf("failed to subscribe publisher: %v", err)
+	}
+
+	local := domain.EventEnvelope[any]{
+		EventType: "order.placed",
+		Metadata:  map[string]interface{}{domain.MetadataOriginRegion: "us-east-1"},
+	}
+	replicated := domain.EventEnvelope[any]{
+		EventType: "order.placed",
+		Metadata:  map[string]interface{}{domain.MetadataOriginRegion: "eu-west-1"},
+	}
+
+	for _, env := range []domain.EventEnvelope[any]{local, replicated} {
+		if err := dispatcher.Dispatch(ctx, env); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+	}
+
+	if got := projected.Load(); got != 2 {
+		t.Errorf("expected projection to see both events, got %d", got)
+	}
+	if got := published.Load(); got != 1 {
+		t.Errorf("expected publisher to see only the local event, got %d", got)
+	}
+}
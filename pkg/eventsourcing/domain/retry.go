@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// PermanentError marks a handler error that retrying cannot fix, such as a
+// payload that fails validation. WithRetry returns it at once instead of
+// retrying.
+type PermanentError struct {
+	Err error
+}
+
+// Permanent wraps err in a PermanentError; it returns nil for a nil err.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// RetryPolicy configures WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls, including the first; values
+	// below 1 mean a single call.
+	MaxAttempts int
+
+	// BaseDelay is the wait before the second attempt.
+	BaseDelay time.Duration
+
+	// Multiplier scales the wait after each further failure; values below 1
+	// keep the wait at BaseDelay.
+	Multiplier float64
+
+	// MaxDelay caps the wait between attempts; zero means no cap.
+	MaxDelay time.Duration
+}
+
+// WithRetry wraps handler so a failing call is retried with exponential
+// backoff under policy, absorbing transient failures such as database
+// timeouts instead of failing the whole Dispatch. Errors wrapping a
+// PermanentError are returned without retrying. If ctx is done while waiting
+// between attempts, the wrapper stops and returns the last error joined with
+// ctx's. The last attempt's error is returned when every attempt fails.
+//
+// Retries hold up the Dispatch that called the handler; keep MaxAttempts and
+// the delays short for dispatchers on the commit path.
+func WithRetry[T any](handler EventHandler[T], policy RetryPolicy) EventHandler[T] {
+	attempts := max(policy.MaxAttempts, 1)
+	multiplier := max(policy.Multiplier, 1)
+	return func(ctx context.Context, env EventEnvelope[T]) error {
+		delay := policy.BaseDelay
+		for attempt := 1; ; attempt++ {
+			err := handler(ctx, env)
+			var permanent *PermanentError
+			if err == nil || attempt >= attempts || errors.As(err, &permanent) {
+				return err
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errors.Join(err, ctx.Err())
+			case <-timer.C:
+			}
+			delay = time.Duration(float64(delay) * multiplier)
+			if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+				delay = policy.MaxDelay
+			}
+		}
+	}
+}
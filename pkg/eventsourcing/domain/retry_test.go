@@ -0,0 +1,84 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	errTransient := errors.New("timeout")
+	env := domain.EventEnvelope[string]{ID: "ev-1", Payload: "data"}
+
+	t.Run("fails twice then succeeds with increasing delays", func(t *testing.T) {
+		t.Parallel()
+		var calls []time.Time
+		handler := domain.WithRetry(func(context.Context, domain.EventEnvelope[string]) error {
+			calls = append(calls, time.Now())
+			if len(calls) < 3 {
+				return errTransient
+			}
+			return nil
+		}, domain.RetryPolicy{MaxAttempts: 3, BaseDelay: 20 * time.Millisecond, Multiplier: 2})
+
+		if err := handler(context.Background(), env); err != nil {
+			t.Fatalf("handler error = %v", err)
+		}
+		if len(calls) != 3 {
+			t.Fatalf("calls = %d, want 3", len(calls))
+		}
+		first, second := calls[1].Sub(calls[0]), calls[2].Sub(calls[1])
+		if first < 20*time.Millisecond || second < 40*time.Millisecond || second <= first {
+			t.Errorf("delays = %v then %v, want at least 20ms then 40ms, increasing", first, second)
+		}
+	})
+
+	t.Run("returns the last error when attempts run out", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		handler := domain.WithRetry(func(context.Context, domain.EventEnvelope[string]) error {
+			calls++
+			return errTransient
+		}, domain.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+		if err := handler(context.Background(), env); !errors.Is(err, errTransient) || calls != 2 {
+			t.Errorf("handler error = %v after %d calls, want the transient error after 2", err, calls)
+		}
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		handler := domain.WithRetry(func(context.Context, domain.EventEnvelope[string]) error {
+			calls++
+			return domain.Permanent(errTransient)
+		}, domain.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond})
+
+		var permanent *domain.PermanentError
+		err := handler(context.Background(), env)
+		if calls != 1 || !errors.As(err, &permanent) || !errors.Is(err, errTransient) {
+			t.Errorf("handler error = %v after %d calls, want the permanent error after 1", err, calls)
+		}
+	})
+
+	t.Run("cancellation stops the wait between attempts", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		handler := domain.WithRetry(func(context.Context, domain.EventEnvelope[string]) error {
+			calls++
+			cancel()
+			return errTransient
+		}, domain.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour})
+
+		err := handler(ctx, env)
+		if calls != 1 || !errors.Is(err, context.Canceled) || !errors.Is(err, errTransient) {
+			t.Errorf("handler error = %v after %d calls, want cancellation after 1", err, calls)
+		}
+	})
+}
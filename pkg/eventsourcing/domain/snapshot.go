@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSnapshotNotFound is returned by SnapshotStore.LoadSnapshot when the
+// aggregate has no snapshot.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Snapshot is an aggregate's serialized state as of the event at SequenceNo.
+// SchemaVersion identifies the shape of State, so a snapshot written before
+// the aggregate's state struct changed can be recognized and discarded
+// rather than mis-decoded.
+type Snapshot struct {
+	AggregateID   string
+	SequenceNo    int
+	SchemaVersion int
+	State         []byte
+}
+
+// SnapshotStore persists serialized aggregate state so a load can start from
+// the latest snapshot and replay only the events recorded after it, instead
+// of the whole stream. Snapshots are a cache: the events stay the source of
+// truth, and a missing or discarded snapshot only costs a longer replay.
+type SnapshotStore interface {
+	// SaveSnapshot stores snapshot as its aggregate's only snapshot,
+	// replacing the stored one when snapshot is at a higher sequence number
+	// or has a different schema version. Saving an older snapshot of the
+	// same schema is a no-op, so concurrent snapshotters cannot regress it,
+	// while a snapshot of a new schema always supersedes a stale one.
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+
+	// LoadSnapshot returns the aggregate's snapshot, or ErrSnapshotNotFound.
+	LoadSnapshot(ctx context.Context, aggregateID string) (Snapshot, error)
+}
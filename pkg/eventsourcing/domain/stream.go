@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SaveIfNotExists appends the first events of a new aggregate, failing with
+// ErrStreamExists if any event has ever been stored for aggregateID. The
+// events must start at sequence number 1, so beyond the expected-version-0
+// check the store's uniqueness on (aggregate_id, sequence_no) rejects a
+// concurrent creator that passed the same check. Together with
+// ddd.DeterministicID this gives idempotent creation keyed on a natural key,
+// with no separate existence check to race against.
+//
+// A stream that already existed and a creation race lost to a concurrent
+// writer are both ErrStreamExists; the latter additionally matches
+// ErrCreationRaceLost, so callers can log the two apart.
+func SaveIfNotExists(ctx context.Context, store EventStore, aggregateID string, events ...EventEnvelope[any]) error {
+	if len(events) == 0 {
+		return fmt.Errorf("%w: no events to save", ErrInvalidEvent)
+	}
+	first := events[0]
+	for _, event := range events[1:] {
+		if event.SequenceNo < first.SequenceNo {
+			first = event
+		}
+	}
+	if first.SequenceNo != 1 {
+		return fmt.Errorf("%w: a new stream must start at sequence 1, got %d", ErrInvalidEvent, first.SequenceNo)
+	}
+
+	err := store.Append(ctx, aggregateID, 0, events...)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrConcurrencyConflict) {
+		return fmt.Errorf("%w: aggregate %s: %w", ErrStreamExists, aggregateID, err)
+	}
+
+	// The version check passed but the insert failed: if the stream exists
+	// now, a concurrent creator's first event claimed sequence 1 first.
+	if version, verr := store.GetCurrentVersion(ctx, aggregateID); verr == nil && version > 0 {
+		return fmt.Errorf("%w: %w: aggregate %s: %w", ErrStreamExists, ErrCreationRaceLost, aggregateID, err)
+	}
+	return err
+}
+
+// EventStreamer is implemented by event stores that can yield an aggregate's
+// events one at a time instead of materializing the whole history, which
+// caps memory when loading very long-lived aggregates.
+type EventStreamer interface {
+	// StreamEvents sends the aggregate's events, ordered by SequenceNo, on
+	// the returned event channel and closes it after the last one. The error
+	// channel then yields at most one error (a failed read, or the context's
+	// error if ctx ended first) and is closed. Consumers that stop reading
+	// early must cancel ctx, which stops the read and releases its resources.
+	StreamEvents(ctx context.Context, aggregateID string) (<-chan EventEnvelope[any], <-chan error)
+}
+
+// StreamEvents streams aggregateID's events from store with the semantics of
+// EventStreamer.StreamEvents. Stores implementing EventStreamer stream from
+// storage; for others the history is read with GetEvents and then sent one
+// event at a time, so callers can use one code path for every store.
+func StreamEvents(ctx context.Context, store EventStore, aggregateID string) (<-chan EventEnvelope[any], <-chan error) {
+	if streamer, ok := store.(EventStreamer); ok {
+		return streamer.StreamEvents(ctx, aggregateID)
+	}
+
+	events := make(chan EventEnvelope[any])
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		history, err := store.GetEvents(ctx, aggregateID)
+		if err != nil {
+			errs <- err
+			return
+		}
+		for _, event := range history {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return events, errs
+}
+
+// MultiAggregateReader is implemented by event stores that can read the
+// events of several aggregates in one round trip, for callers such as saga
+// coordinators that rebuild many related aggregates per message.
+type MultiAggregateReader interface {
+	// GetEventsForAggregates returns the events of each aggregate in
+	// aggregateIDs, keyed by aggregate ID and ordered by SequenceNo within
+	// each. Aggregates without events are absent from the map rather than an
+	// error.
+	GetEventsForAggregates(ctx context.Context, aggregateIDs []string) (map[string][]EventEnvelope[any], error)
+}
+
+// GetEventsForAggregates reads the events of several aggregates with the
+// semantics of MultiAggregateReader. Stores implementing it answer in one
+// query; for others GetEvents is called per aggregate.
+func GetEventsForAggregates(ctx context.Context, store EventStore, aggregateIDs []string) (map[string][]EventEnvelope[any], error) {
+	if reader, ok := store.(MultiAggregateReader); ok {
+		return reader.GetEventsForAggregates(ctx, aggregateIDs)
+	}
+	result := make(map[string][]EventEnvelope[any], len(aggregateIDs))
+	for _, aggregateID := range aggregateIDs {
+		if _, seen := result[aggregateID]; seen {
+			continue
+		}
+		events, err := store.GetEvents(ctx, aggregateID)
+		if err != nil {
+			return nil, fmt.Errorf("load events of aggregate %s: %w", aggregateID, err)
+		}
+		if len(events) > 0 {
+			result[aggregateID] = events
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,128 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+// raceStore simulates losing a creation race: its version check passes, but
+// a concurrent writer's events land first and the insert is rejected.
+type raceStore struct {
+	*infrastructure.MemoryStore
+	winner []domain.EventEnvelope[any]
+}
+
+func (s *raceStore) Append(ctx context.Context, aggregateID string, expectedVersion int, events ...domain.EventEnvelope[any]) error {
+	if err := s.MemoryStore.Append(ctx, aggregateID, expectedVersion, s.winner...); err != nil {
+		return err
+	}
+	return errors.New("duplicate key value violates unique constraint")
+}
+
+func TestSaveIfNotExists(t *testing.T) {
+	t.Parallel()
+
+	newEvent := func(aggregateID string, seq int) domain.EventEnvelope[any] {
+		return domain.NewEventEnvelope[any](map[string]string{"name": "acme"}, aggregateID, "customer.created", seq)
+	}
+
+	t.Run("creates a new stream", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+		if err := domain.SaveIfNotExists(context.Background(), store, "cust-1", newEvent("cust-1", 1), newEvent("cust-1", 2)); err != nil {
+			t.Fatalf("SaveIfNotExists failed: %v", err)
+		}
+		if version, _ := store.GetCurrentVersion(context.Background(), "cust-1"); version != 2 {
+			t.Errorf("version = %d, want 2", version)
+		}
+	})
+
+	t.Run("existing stream", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+		if err := store.Append(context.Background(), "cust-1", -1, newEvent("cust-1", 1)); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+		err := domain.SaveIfNotExists(context.Background(), store, "cust-1", newEvent("cust-1", 1))
+		if !errors.Is(err, domain.ErrStreamExists) {
+			t.Fatalf("expected ErrStreamExists, got %v", err)
+		}
+		if errors.Is(err, domain.ErrCreationRaceLost) {
+			t.Error("a stream that already existed must not be reported as a lost race")
+		}
+	})
+
+	t.Run("lost creation race", func(t *testing.T) {
+		t.Parallel()
+		store := &raceStore{MemoryStore: infrastructure.NewMemoryStore(), winner: []domain.EventEnvelope[any]{newEvent("cust-1", 1)}}
+		err := domain.SaveIfNotExists(context.Background(), store, "cust-1", newEvent("cust-1", 1))
+		if !errors.Is(err, domain.ErrStreamExists) || !errors.Is(err, domain.ErrCreationRaceLost) {
+			t.Fatalf("expected ErrStreamExists and ErrCreationRaceLost, got %v", err)
+		}
+	})
+
+	t.Run("rejects a stream not starting at sequence 1", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+		if err := domain.SaveIfNotExists(context.Background(), store, "cust-1", newEvent("cust-1", 2)); !errors.Is(err, domain.ErrInvalidEvent) {
+			t.Fatalf("expected ErrInvalidEvent, got %v", err)
+		}
+	})
+}
+
+func TestStreamEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := infrastructure.NewMemoryStore()
+	for i := 1; i <= 5; i++ {
+		if err := store.Append(ctx, "agg-1", i-1, domain.NewEventEnvelope[any](i, "agg-1", "test.recorded", i)); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	t.Run("yields every event in sequence order", func(t *testing.T) {
+		t.Parallel()
+		events, errs := domain.StreamEvents(ctx, store, "agg-1")
+		var got []int
+		for event := range events {
+			got = append(got, event.SequenceNo)
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		if len(got) != 5 || got[0] != 1 || got[4] != 5 {
+			t.Errorf("got sequence numbers %v, want 1..5", got)
+		}
+	})
+
+	t.Run("cancellation stops the stream", func(t *testing.T) {
+		t.Parallel()
+		cctx, cancel := context.WithCancel(ctx)
+		events, errs := domain.StreamEvents(cctx, store, "agg-1")
+		<-events
+		cancel()
+		// With no reader the producer can only observe the cancellation.
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if _, open := <-events; open {
+			t.Error("expected the event channel closed after cancellation")
+		}
+	})
+
+	t.Run("unknown aggregate closes without error", func(t *testing.T) {
+		t.Parallel()
+		events, errs := domain.StreamEvents(ctx, store, "missing")
+		for range events {
+			t.Error("unexpected event")
+		}
+		if err := <-errs; err != nil {
+			t.Errorf("stream error: %v", err)
+		}
+	})
+}
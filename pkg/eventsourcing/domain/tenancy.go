@@ -0,0 +1,49 @@
+package domain
+
+import "errors"
+
+// MetadataAccountID is the metadata key recording the account (tenant) an
+// event belongs to. Events without it are system events that belong to no
+// account.
+const MetadataAccountID = "account_id"
+
+// AccountID returns the account recorded in the envelope's metadata, or ""
+// for system events.
+func AccountID[T any](env EventEnvelope[T]) string {
+	account, _ := env.Metadata[MetadataAccountID].(string)
+	return account
+}
+
+// SubscribeForAccount registers a typed event handler, as Subscribe does, that
+// only runs for events of eventType (or matching it, for a pattern) recorded
+// for accountID. System events, which have no account, never reach it.
+//
+// Registrations are indexed by account, so dispatching an event only consults
+// the handlers of its own account: subscribing the same handler for many
+// accounts adds nothing to the cost of dispatching another account's events.
+// Each registration has its own SubscriptionID.
+func SubscribeForAccount[T any](d *EventDispatcher, accountID, eventType string, handler EventHandler[T], opts ...SubscribeOption) (SubscriptionID, error) {
+	if accountID == "" {
+		return 0, errors.New("account ID cannot be empty")
+	}
+	if eventType == "" {
+		return 0, errors.New("event type cannot be empty")
+	}
+	if handler == nil {
+		return 0, errors.New("handler cannot be nil")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	byPattern := d.accountHandlers[accountID]
+	if byPattern == nil {
+		byPattern = make(map[string][]subscription)
+		d.accountHandlers[accountID] = byPattern
+	}
+	sub := d.newSubscription(untypedHandler(eventType, handler), eventType+"@"+accountID, opts)
+	byPattern[eventType] = append(byPattern[eventType], sub)
+	registerTypeFactory[T](d, eventType)
+
+	return sub.id, nil
+}
@@ -0,0 +1,147 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+func TestSubscribeForAccount(t *testing.T) {
+	t.Parallel()
+
+	forAccount := func(account string) domain.EventEnvelope[any] {
+		event := domain.EventEnvelope[any]{ID: "ev-1", AggregateID: "user-1", EventType: "user.created", Payload: map[string]any{}}
+		if account != "" {
+			event.Metadata = map[string]any{domain.MetadataAccountID: account}
+		}
+		return event
+	}
+
+	t.Run("handler only sees its account's events", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		var mu sync.Mutex
+		var calls []string
+		record := func(name string) domain.EventHandler[any] {
+			return func(_ context.Context, env domain.EventEnvelope[any]) error {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, name+":"+domain.AccountID(env))
+				return nil
+			}
+		}
+		if _, err := domain.SubscribeForAccount(d, "acct-1", "user.created", record("exact")); err != nil {
+			t.Fatalf("SubscribeForAccount() error = %v", err)
+		}
+		if _, err := domain.SubscribeForAccount(d, "acct-1", "user.*", record("pattern")); err != nil {
+			t.Fatalf("SubscribeForAccount() error = %v", err)
+		}
+		if _, err := domain.Subscribe(d, "user.created", record("global")); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		for _, account := range []string{"acct-1", "acct-2", ""} {
+			if err := d.Dispatch(context.Background(), forAccount(account)); err != nil {
+				t.Fatalf("Dispatch() for %q error = %v", account, err)
+			}
+		}
+
+		sort.Strings(calls)
+		want := "exact:acct-1,global:,global:acct-1,global:acct-2,pattern:acct-1"
+		if got := strings.Join(calls, ","); got != want {
+			t.Errorf("calls = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("one handler for many accounts", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		var calls atomic.Int32
+		handler := func(context.Context, domain.EventEnvelope[any]) error {
+			calls.Add(1)
+			return nil
+		}
+		ids := make(map[string]domain.SubscriptionID)
+		for i := range 1000 {
+			account := fmt.Sprintf("acct-%d", i)
+			id, err := domain.SubscribeForAccount(d, account, "user.created", handler)
+			if err != nil {
+				t.Fatalf("SubscribeForAccount() error = %v", err)
+			}
+			ids[account] = id
+		}
+
+		for _, account := range []string{"acct-7", "acct-999", "acct-1000", ""} {
+			if err := d.Dispatch(context.Background(), forAccount(account)); err != nil {
+				t.Fatalf("Dispatch() error = %v", err)
+			}
+		}
+		if got := calls.Load(); got != 2 {
+			t.Errorf("calls = %d, want one each for acct-7 and acct-999", got)
+		}
+
+		if err := d.Unsubscribe(ids["acct-7"]); err != nil {
+			t.Fatalf("Unsubscribe() error = %v", err)
+		}
+		if err := d.Dispatch(context.Background(), forAccount("acct-7")); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		if err := d.Dispatch(context.Background(), forAccount("acct-8")); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("calls = %d, want the unsubscribed account skipped", got)
+		}
+		if err := d.Unsubscribe(ids["acct-7"]); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+			t.Errorf("second Unsubscribe() error = %v, want ErrSubscriptionNotFound", err)
+		}
+	})
+
+	t.Run("redelivery by default name", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		var calls atomic.Int32
+		handler := func(context.Context, domain.EventEnvelope[any]) error {
+			calls.Add(1)
+			return nil
+		}
+		if _, err := domain.Subscribe(d, "user.created", handler); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+		if _, err := domain.SubscribeForAccount(d, "acct-1", "user.created", handler); err != nil {
+			t.Fatalf("SubscribeForAccount() error = %v", err)
+		}
+
+		if err := d.Redeliver(context.Background(), forAccount("acct-1"), "user.created@acct-1#0"); err != nil {
+			t.Fatalf("Redeliver() error = %v", err)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("calls = %d, want only the account-scoped handler redelivered", got)
+		}
+		if err := d.Redeliver(context.Background(), forAccount("acct-2"), "user.created@acct-1#0"); !errors.Is(err, domain.ErrHandlerNotFound) {
+			t.Errorf("Redeliver() for another account error = %v, want ErrHandlerNotFound", err)
+		}
+	})
+
+	t.Run("invalid registrations are rejected", func(t *testing.T) {
+		t.Parallel()
+		d := domain.NewEventDispatcher()
+		handler := func(context.Context, domain.EventEnvelope[any]) error { return nil }
+		if _, err := domain.SubscribeForAccount[any](d, "", "user.created", handler); err == nil {
+			t.Error("SubscribeForAccount() with no account succeeded, want an error")
+		}
+		if _, err := domain.SubscribeForAccount[any](d, "acct-1", "", handler); err == nil {
+			t.Error("SubscribeForAccount() with no event type succeeded, want an error")
+		}
+		if _, err := domain.SubscribeForAccount[any](d, "acct-1", "user.created", nil); err == nil {
+			t.Error("SubscribeForAccount() with a nil handler succeeded, want an error")
+		}
+	})
+}
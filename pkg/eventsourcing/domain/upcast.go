@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// MetadataEventVersion is the metadata key recording the schema version of an
+// event's payload. Events without it are version 1, so payloads stored before
+// versioning was introduced are upcast from the start of their chain.
+const MetadataEventVersion = "event_version"
+
+// maxUpcastSteps bounds an upcast chain so a registration cycle fails instead
+// of looping forever.
+const maxUpcastSteps = 1000
+
+// ErrUpcastCycle is returned when an upcaster chain does not terminate.
+var ErrUpcastCycle = errors.New("upcaster chain does not terminate")
+
+// Upcaster transforms a stored event payload of the given type and version
+// into the next version's payload, optionally renaming the event type. It
+// works on raw JSON, so the structs of old versions need not be kept around.
+type Upcaster interface {
+	Upcast(eventType string, version int, payload []byte) (newPayload []byte, newType string, err error)
+}
+
+// UpcasterFunc adapts a function to Upcaster.
+type UpcasterFunc func(eventType string, version int, payload []byte) ([]byte, string, error)
+
+// Upcast calls f.
+func (f UpcasterFunc) Upcast(eventType string, version int, payload []byte) ([]byte, string, error) {
+	return f(eventType, version, payload)
+}
+
+// UpcasterRegistry chains upcasters by event type and version. An upcaster
+// registered for (eventType, fromVersion) produces version fromVersion+1 of
+// the event type it returns, and the chain continues with the upcaster
+// registered for that type and version, until none is left. An event type's
+// current version is one past its highest registered fromVersion.
+type UpcasterRegistry struct {
+	mu        sync.RWMutex
+	upcasters map[string]map[int]Upcaster
+}
+
+// NewUpcasterRegistry creates an empty UpcasterRegistry.
+func NewUpcasterRegistry() *UpcasterRegistry {
+	return &UpcasterRegistry{upcasters: make(map[string]map[int]Upcaster)}
+}
+
+// Register adds the upcaster that turns version fromVersion of eventType into
+// version fromVersion+1. Each (eventType, fromVersion) takes one upcaster.
+func (r *UpcasterRegistry) Register(eventType string, fromVersion int, upcaster Upcaster) error {
+	if eventType == "" {
+		return fmt.Errorf("event type cannot be empty")
+	}
+	if fromVersion < 1 {
+		return fmt.Errorf("from version must be at least 1, got %d", fromVersion)
+	}
+	if upcaster == nil {
+		return fmt.Errorf("upcaster cannot be nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byVersion, ok := r.upcasters[eventType]
+	if !ok {
+		byVersion = make(map[int]Upcaster)
+		r.upcasters[eventType] = byVersion
+	}
+	if _, exists := byVersion[fromVersion]; exists {
+		return fmt.Errorf("upcaster for %q version %d is already registered", eventType, fromVersion)
+	}
+	byVersion[fromVersion] = upcaster
+	return nil
+}
+
+// CurrentVersion returns the version new payloads of eventType are written
+// at: one past its highest registered upcaster, or 1 when it has none.
+func (r *UpcasterRegistry) CurrentVersion(eventType string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	current := 1
+	for fromVersion := range r.upcasters[eventType] {
+		current = max(current, fromVersion+1)
+	}
+	return current
+}
+
+// Upcast runs payload, stored as version of eventType, through the chain and
+// returns the resulting payload, event type and version. A payload already at
+// the end of its chain is returned unchanged.
+func (r *UpcasterRegistry) Upcast(eventType string, version int, payload []byte) ([]byte, string, int, error) {
+	for step := 0; ; step++ {
+		r.mu.RLock()
+		upcaster, ok := r.upcasters[eventType][version]
+		r.mu.RUnlock()
+		if !ok {
+			return payload, eventType, version, nil
+		}
+		if step == maxUpcastSteps {
+			return nil, "", 0, fmt.Errorf("%w: %q at version %d", ErrUpcastCycle, eventType, version)
+		}
+
+		newPayload, newType, err := upcaster.Upcast(eventType, version, payload)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("upcast %q from version %d: %w", eventType, version, err)
+		}
+		if newType == "" {
+			newType = eventType
+		}
+		payload, eventType, version = newPayload, newType, version+1
+	}
+}
+
+// EventVersion returns the payload schema version recorded in metadata under
+// MetadataEventVersion, or 1 when it is absent or unreadable. It accepts the
+// number types metadata holds in memory and after a JSON round trip.
+func EventVersion(metadata map[string]any) int {
+	var version int
+	switch v := metadata[MetadataEventVersion].(type) {
+	case int:
+		version = v
+	case int64:
+		version = int(v)
+	case float64:
+		version = int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		version = int(n)
+	case string:
+		version, _ = strconv.Atoi(v)
+	}
+	if version < 1 {
+		return 1
+	}
+	return version
+}
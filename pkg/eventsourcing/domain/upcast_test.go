@@ -0,0 +1,133 @@
+package domain_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// userRegisteredV3 is the current shape of user.registered: v1 stored "name",
+// v2 renamed it to "full_name", and v3 split out "email_address".
+type userRegisteredV3 struct {
+	FullName     string `json:"full_name"`
+	EmailAddress string `json:"email_address"`
+}
+
+// renameField returns an upcaster moving payload field from to field to.
+func renameField(from, to string) domain.Upcaster {
+	return domain.UpcasterFunc(func(eventType string, _ int, payload []byte) ([]byte, string, error) {
+		var fields map[string]any
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, "", err
+		}
+		fields[to] = fields[from]
+		delete(fields, from)
+		out, err := json.Marshal(fields)
+		return out, eventType, err
+	})
+}
+
+func newUpcastingDispatcher(t *testing.T) *domain.EventDispatcher {
+	t.Helper()
+	registry := domain.NewUpcasterRegistry()
+	if err := registry.Register("user.registered", 1, renameField("name", "full_name")); err != nil {
+		t.Fatalf("Register(1) failed: %v", err)
+	}
+	if err := registry.Register("user.registered", 2, renameField("email", "email_address")); err != nil {
+		t.Fatalf("Register(2) failed: %v", err)
+	}
+	d := domain.NewEventDispatcher(domain.WithUpcasters(registry))
+	if err := domain.RegisterType(d, "user.registered", func() userRegisteredV3 { return userRegisteredV3{} }); err != nil {
+		t.Fatalf("RegisterType failed: %v", err)
+	}
+	return d
+}
+
+func TestUnmarshalEvent_Upcasts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		stored  string
+		version int
+	}{
+		{
+			name:   "unversioned payload is version 1",
+			stored: `{"id":"ev-1","aggregate_id":"user-1","event_type":"user.registered","payload":{"name":"Ada Lovelace","email":"ada@example.com"},"timestamp":"2024-01-02T03:04:05Z","sequence_no":1}`,
+		},
+		{
+			name:   "version 2 payload runs the rest of the chain",
+			stored: `{"id":"ev-1","aggregate_id":"user-1","event_type":"user.registered","payload":{"full_name":"Ada Lovelace","email":"ada@example.com"},"timestamp":"2024-01-02T03:04:05Z","sequence_no":1,"metadata":{"event_version":2}}`,
+		},
+		{
+			name:   "current payload is untouched",
+			stored: `{"id":"ev-1","aggregate_id":"user-1","event_type":"user.registered","payload":{"full_name":"Ada Lovelace","email_address":"ada@example.com"},"timestamp":"2024-01-02T03:04:05Z","sequence_no":1,"metadata":{"event_version":3}}`,
+		},
+	}
+
+	d := newUpcastingDispatcher(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := d.UnmarshalEvent(context.Background(), []byte(tt.stored), "user.registered")
+			if err != nil {
+				t.Fatalf("UnmarshalEvent failed: %v", err)
+			}
+			payload, ok := env.Payload.(*userRegisteredV3)
+			if !ok {
+				t.Fatalf("payload type = %T, want *userRegisteredV3", env.Payload)
+			}
+			want := userRegisteredV3{FullName: "Ada Lovelace", EmailAddress: "ada@example.com"}
+			if *payload != want {
+				t.Errorf("payload = %+v, want %+v", *payload, want)
+			}
+			if got := domain.EventVersion(env.Metadata); got != 3 {
+				t.Errorf("EventVersion = %d, want 3", got)
+			}
+		})
+	}
+}
+
+func TestUpcasterRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := domain.NewUpcasterRegistry()
+	if got := registry.CurrentVersion("order.placed"); got != 1 {
+		t.Errorf("CurrentVersion without upcasters = %d, want 1", got)
+	}
+
+	// A rename hands the chain over to the new type at the next version.
+	rename := domain.UpcasterFunc(func(_ string, _ int, payload []byte) ([]byte, string, error) {
+		return payload, "order.submitted", nil
+	})
+	if err := registry.Register("order.placed", 1, rename); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := registry.Register("order.placed", 1, rename); err == nil {
+		t.Error("expected a duplicate registration to fail")
+	}
+	if err := registry.Register("order.submitted", 2, renameField("total", "amount")); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	payload, eventType, version, err := registry.Upcast("order.placed", 1, []byte(`{"total":5}`))
+	if err != nil {
+		t.Fatalf("Upcast failed: %v", err)
+	}
+	if eventType != "order.submitted" || version != 3 || string(payload) != `{"amount":5}` {
+		t.Errorf("Upcast = (%s, %s, %d), want ({\"amount\":5}, order.submitted, 3)", payload, eventType, version)
+	}
+
+	failing := domain.UpcasterFunc(func(string, int, []byte) ([]byte, string, error) {
+		return nil, "", errors.New("bad payload")
+	})
+	if err := registry.Register("order.cancelled", 1, failing); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if _, _, _, err := registry.Upcast("order.cancelled", 1, []byte(`{}`)); err == nil {
+		t.Error("expected an upcaster error to be returned")
+	}
+}
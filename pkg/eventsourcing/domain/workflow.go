@@ -0,0 +1,24 @@
+package domain
+
+import "context"
+
+// CorrelationID returns the correlation ID recorded in the envelope's
+// metadata (MetadataCorrelationID), or "".
+func CorrelationID[T any](env EventEnvelope[T]) string {
+	id, _ := env.Metadata[MetadataCorrelationID].(string)
+	return id
+}
+
+// WorkflowTracer is implemented by event stores that can reconstruct a
+// workflow: every event sharing a correlation ID, across aggregates. It is
+// optional, since answering it efficiently needs an index on the correlation
+// key, so callers type-assert for it.
+type WorkflowTracer interface {
+	// TraceWorkflow returns the events of accountID whose
+	// MetadataCorrelationID is correlationID, ordered by global Position, so
+	// the causal chain reads top to bottom. The trace is always scoped to
+	// one account: an empty accountID matches only system events, which
+	// belong to no account, never every account, so one tenant's trace
+	// cannot pull in another's events.
+	TraceWorkflow(ctx context.Context, accountID, correlationID string) ([]EventEnvelope[any], error)
+}
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
 )
@@ -17,6 +18,23 @@ func compareEnvelopes(a, b domain.EventEnvelope[any]) int {
 	return cmp.Compare(a.SequenceNo, b.SequenceNo)
 }
 
+// sortedBySequence returns events ordered by SequenceNo, keeping the caller's
+// slice untouched. Stores apply it before assigning positions so the global
+// order agrees with each aggregate's sequence order even when a batch is
+// passed out of order; timestamps are never consulted.
+func sortedBySequence(events []domain.EventEnvelope[any]) []domain.EventEnvelope[any] {
+	if slices.IsSortedFunc(events, compareSequence) {
+		return events
+	}
+	sorted := slices.Clone(events)
+	slices.SortStableFunc(sorted, compareSequence)
+	return sorted
+}
+
+func compareSequence(a, b domain.EventEnvelope[any]) int {
+	return cmp.Compare(a.SequenceNo, b.SequenceNo)
+}
+
 // toAnyMap converts a value to map[string]any using JSON round-trip.
 // Used by multiple EventStore implementations to normalize typed payloads.
 func toAnyMap(v any) (map[string]any, error) {
@@ -37,3 +55,26 @@ func toAnyMap(v any) (map[string]any, error) {
 		return m, nil
 	}
 }
+
+// listAggregateIDs pages the IDs of aggregates in byAggregate with at least
+// one event in category (and account, when opts.AccountID is set). It backs
+// the in-process stores, which have no index to consult.
+func listAggregateIDs(byAggregate map[string][]domain.EventEnvelope[any], category string, opts domain.ListAggregateIDsOptions) []string {
+	ids := make([]string, 0)
+	for aggregateID, events := range byAggregate {
+		if opts.After != "" && aggregateID <= opts.After {
+			continue
+		}
+		if slices.ContainsFunc(events, func(e domain.EventEnvelope[any]) bool {
+			return domain.EventCategory(e.EventType) == category &&
+				(opts.AccountID == "" || domain.AccountID(e) == opts.AccountID)
+		}) {
+			ids = append(ids, aggregateID)
+		}
+	}
+	slices.Sort(ids)
+	if limit := opts.PageSize(); len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
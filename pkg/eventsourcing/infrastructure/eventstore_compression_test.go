@@ -0,0 +1,155 @@
+package infrastructure_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+// largePayload returns a representative ~4KB order payload.
+func largePayload() map[string]any {
+	lines := make([]any, 0, 24)
+	for i := range 24 {
+		lines = append(lines, map[string]any{
+			"sku":         fmt.Sprintf("SKU-%05d", i),
+			"description": "Organic cotton t-shirt, crew neck, regular fit",
+			"quantity":    float64(i%5 + 1),
+			"unit_price":  19.99,
+			"warehouse":   "eu-west-fulfilment-centre",
+		})
+	}
+	return map[string]any{
+		"order_id":         "order-8f14e45f",
+		"customer_id":      "cust-c9f0f895",
+		"shipping_address": "221B Baker Street, London NW1 6XE, United Kingdom",
+		"lines":            lines,
+	}
+}
+
+func TestGormStore_Compression(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	cipher, err := infrastructure.NewAESGCMCipher(key)
+	if err != nil {
+		t.Fatalf("NewAESGCMCipher() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts []infrastructure.GormEventStoreOption
+	}{
+		{name: "gzip", opts: []infrastructure.GormEventStoreOption{infrastructure.WithCompression(infrastructure.CompressionGzip)}},
+		{name: "gzip with cipher", opts: []infrastructure.GormEventStoreOption{infrastructure.WithCompression(infrastructure.CompressionGzip), infrastructure.WithEventCipher(cipher)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := newTestGormDB(t)
+			ctx := context.Background()
+
+			// Written before compression is enabled, and read back as-is after.
+			plain, err := infrastructure.NewGormEventStore(db)
+			if err != nil {
+				t.Fatalf("failed to create gorm event store: %v", err)
+			}
+			if err := plain.Append(ctx, "order-1", 0, createTestEvent("order-1", "ev-1", "order.created", 1)); err != nil {
+				t.Fatalf("append uncompressed: %v", err)
+			}
+
+			store, err := infrastructure.NewGormEventStore(db, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create gorm event store: %v", err)
+			}
+			event := createTestEvent("order-1", "ev-2", "order.updated", 2)
+			event.Payload = largePayload()
+			if err := store.Append(ctx, "order-1", 1, event); err != nil {
+				t.Fatalf("append compressed: %v", err)
+			}
+
+			var row infrastructure.GormEventModel
+			if err := db.Where("id = ?", "ev-2").First(&row).Error; err != nil {
+				t.Fatalf("load row: %v", err)
+			}
+			if _, ok := row.Payload["order_id"]; ok {
+				t.Errorf("payload stored uncompressed: %v", row.Payload)
+			}
+
+			events, err := store.GetEvents(ctx, "order-1")
+			if err != nil {
+				t.Fatalf("GetEvents() error = %v", err)
+			}
+			if len(events) != 2 {
+				t.Fatalf("got %d events, want 2", len(events))
+			}
+			if got := events[0].Payload.(map[string]any)["test"]; got != "data" {
+				t.Errorf("uncompressed payload = %v, want it read as-is", events[0].Payload)
+			}
+			want, _ := json.Marshal(largePayload())
+			got, _ := json.Marshal(events[1].Payload)
+			if string(got) != string(want) {
+				t.Errorf("payload = %s, want %s", got, want)
+			}
+
+			// Turning compression off again still reads the compressed rows.
+			if _, ok := row.Payload["$compressed"]; ok {
+				reader, err := infrastructure.NewGormEventStore(db)
+				if err != nil {
+					t.Fatalf("failed to create gorm event store: %v", err)
+				}
+				back, err := reader.GetEventByID(ctx, "ev-2")
+				if err != nil {
+					t.Fatalf("GetEventByID() error = %v", err)
+				}
+				if !reflect.DeepEqual(back.Payload, events[1].Payload) {
+					t.Errorf("payload without the option = %v, want the decompressed payload", back.Payload)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGormStore_Compression appends a ~4KB payload with and without gzip
+// and reports the bytes stored in the payload column per event.
+func BenchmarkGormStore_Compression(b *testing.B) {
+	for _, bc := range []struct {
+		name        string
+		compression infrastructure.Compression
+	}{
+		{name: "none", compression: infrastructure.CompressionNone},
+		{name: "gzip", compression: infrastructure.CompressionGzip},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			ctx := context.Background()
+			db := newTestGormDB(b)
+			store, err := infrastructure.NewGormEventStore(db, infrastructure.WithCompression(bc.compression))
+			if err != nil {
+				b.Fatalf("failed to create gorm event store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			payload := largePayload()
+			seq := 0
+			for b.Loop() {
+				seq++
+				event := createTestEvent("order-1", fmt.Sprintf("ev-%d", seq), "order.updated", seq)
+				event.Payload = payload
+				if err := store.Append(ctx, "order-1", -1, event); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			var stored int64
+			if err := db.Raw("SELECT COALESCE(SUM(LENGTH(payload)), 0) FROM events").Scan(&stored).Error; err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(stored)/float64(seq), "stored-bytes/event")
+		})
+	}
+}
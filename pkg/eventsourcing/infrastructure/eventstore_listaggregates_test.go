@@ -0,0 +1,83 @@
+package infrastructure_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+func TestEventStore_ListAggregateIDs(t *testing.T) {
+	t.Parallel()
+
+	stores := []struct {
+		name       string
+		setupStore func(t *testing.T) domain.EventStore
+	}{
+		{name: "memory", setupStore: setupMemoryStore},
+		{name: "gorm", setupStore: setupGormStore},
+		{name: "file", setupStore: setupFileStore},
+	}
+
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			store := st.setupStore(t)
+			defer func() { _ = store.Close() }()
+
+			lister, ok := store.(domain.AggregateLister)
+			if !ok {
+				t.Fatalf("%T does not implement domain.AggregateLister", store)
+			}
+
+			appendOne := func(aggregateID, eventType, account string) {
+				t.Helper()
+				e := createTestEvent(aggregateID, aggregateID+"-1", eventType, 1)
+				if account != "" {
+					e.Metadata[domain.MetadataAccountID] = account
+				}
+				if err := store.Append(ctx, aggregateID, 0, e); err != nil {
+					t.Fatalf("failed to append %s: %v", aggregateID, err)
+				}
+			}
+			appendOne("user-3", "user.created", "acme")
+			appendOne("user-1", "user.created", "acme")
+			appendOne("user-2", "user.created", "globex")
+			appendOne("user-4", "user.created", "")
+			appendOne("order-1", "order.placed", "acme")
+
+			list := func(opts domain.ListAggregateIDsOptions) []string {
+				t.Helper()
+				ids, err := lister.ListAggregateIDs(ctx, "user", opts)
+				if err != nil {
+					t.Fatalf("ListAggregateIDs failed: %v", err)
+				}
+				return ids
+			}
+
+			if got, want := list(domain.ListAggregateIDsOptions{}), []string{"user-1", "user-2", "user-3", "user-4"}; !slices.Equal(got, want) {
+				t.Errorf("all users = %v, want %v", got, want)
+			}
+			if got, want := list(domain.ListAggregateIDsOptions{AccountID: "acme"}), []string{"user-1", "user-3"}; !slices.Equal(got, want) {
+				t.Errorf("acme users = %v, want %v", got, want)
+			}
+
+			// Page through two at a time using the last ID as the cursor.
+			var paged []string
+			opts := domain.ListAggregateIDsOptions{Limit: 2}
+			for {
+				page := list(opts)
+				paged = append(paged, page...)
+				if len(page) < opts.Limit {
+					break
+				}
+				opts.After = page[len(page)-1]
+			}
+			if want := []string{"user-1", "user-2", "user-3", "user-4"}; !slices.Equal(paged, want) {
+				t.Errorf("paged users = %v, want %v", paged, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package infrastructure_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+func TestEventStore_GetEventsForAggregates(t *testing.T) {
+	t.Parallel()
+
+	stores := []struct {
+		name       string
+		setupStore func(t *testing.T) domain.EventStore
+	}{
+		{name: "memory", setupStore: setupMemoryStore},
+		{name: "gorm", setupStore: setupGormStore},
+	}
+
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			store := st.setupStore(t)
+			defer func() { _ = store.Close() }()
+
+			for _, aggregateID := range []string{"order-1", "order-2", "order-3"} {
+				for seq := 1; seq <= 3; seq++ {
+					e := createTestEvent(aggregateID, fmt.Sprintf("%s-ev-%d", aggregateID, seq), "order.updated", seq)
+					if err := store.Append(ctx, aggregateID, seq-1, e); err != nil {
+						t.Fatalf("failed to append: %v", err)
+					}
+				}
+			}
+
+			if _, ok := store.(domain.MultiAggregateReader); !ok {
+				t.Fatalf("%T does not implement domain.MultiAggregateReader", store)
+			}
+			got, err := domain.GetEventsForAggregates(ctx, store, []string{"order-3", "order-1", "missing"})
+			if err != nil {
+				t.Fatalf("GetEventsForAggregates failed: %v", err)
+			}
+
+			if len(got) != 2 {
+				t.Fatalf("got %d aggregates, want 2 (missing aggregates are absent)", len(got))
+			}
+			if _, ok := got["missing"]; ok {
+				t.Error("an aggregate without events must be absent from the result")
+			}
+			for _, aggregateID := range []string{"order-1", "order-3"} {
+				events := got[aggregateID]
+				if len(events) != 3 {
+					t.Fatalf("%s: got %d events, want 3", aggregateID, len(events))
+				}
+				for i, event := range events {
+					if event.AggregateID != aggregateID || event.SequenceNo != i+1 {
+						t.Errorf("%s: event %d = %s seq %d, want seq %d", aggregateID, i, event.AggregateID, event.SequenceNo, i+1)
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGormStore_LoadAggregates compares loading a saga's aggregates one
+// query at a time against a single GetEventsForAggregates call.
+func BenchmarkGormStore_LoadAggregates(b *testing.B) {
+	const aggregates = 20
+	ctx := context.Background()
+	store, err := infrastructure.NewGormEventStore(newTestGormDB(b))
+	if err != nil {
+		b.Fatalf("failed to create gorm event store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ids := make([]string, aggregates)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("order-%d", i)
+		for seq := 1; seq <= 5; seq++ {
+			e := createTestEvent(ids[i], fmt.Sprintf("%s-ev-%d", ids[i], seq), "order.updated", seq)
+			if err := store.Append(ctx, ids[i], seq-1, e); err != nil {
+				b.Fatalf("failed to append: %v", err)
+			}
+		}
+	}
+
+	b.Run("per aggregate", func(b *testing.B) {
+		for b.Loop() {
+			for _, id := range ids {
+				if _, err := store.GetEvents(ctx, id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batched", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := store.GetEventsForAggregates(ctx, ids); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
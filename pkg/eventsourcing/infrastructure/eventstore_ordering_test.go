@@ -0,0 +1,77 @@
+package infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// TestEventStore_OrderingIgnoresTimestamps pins the ordering contract: events
+// that share a Created timestamp (common in bulk operations) are ordered by
+// SequenceNo within an aggregate and by Position across aggregates, even when
+// a batch is handed to Append out of sequence order.
+func TestEventStore_OrderingIgnoresTimestamps(t *testing.T) {
+	t.Parallel()
+
+	stores := []struct {
+		name       string
+		setupStore func(t *testing.T) domain.EventStore
+	}{
+		{name: "memory", setupStore: setupMemoryStore},
+		{name: "gorm", setupStore: setupGormStore},
+		{name: "file", setupStore: setupFileStore},
+		{name: "redis", setupStore: setupRedisStore},
+	}
+
+	sameInstant := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	event := func(aggregateID, eventID string, seq int) domain.EventEnvelope[any] {
+		e := createTestEvent(aggregateID, eventID, "test.updated", seq)
+		e.Created = sameInstant
+		return e
+	}
+
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			store := st.setupStore(t)
+			defer func() { _ = store.Close() }()
+
+			if err := store.Append(ctx, "agg-a", -1,
+				event("agg-a", "a-3", 3),
+				event("agg-a", "a-1", 1),
+				event("agg-a", "a-2", 2),
+			); err != nil {
+				t.Fatalf("failed to append agg-a: %v", err)
+			}
+			if err := store.Append(ctx, "agg-b", -1,
+				event("agg-b", "b-2", 2),
+				event("agg-b", "b-1", 1),
+			); err != nil {
+				t.Fatalf("failed to append agg-b: %v", err)
+			}
+
+			events, err := store.GetEvents(ctx, "agg-a")
+			if err != nil {
+				t.Fatalf("GetEvents failed: %v", err)
+			}
+			assertEventIDs(t, events, []string{"a-1", "a-2", "a-3"})
+
+			version, err := store.GetCurrentVersion(ctx, "agg-a")
+			if err != nil {
+				t.Fatalf("GetCurrentVersion failed: %v", err)
+			}
+			if version != 3 {
+				t.Errorf("expected current version 3, got %d", version)
+			}
+
+			feed, err := store.ReadAfter(ctx, 0, 0)
+			if err != nil {
+				t.Fatalf("ReadAfter failed: %v", err)
+			}
+			assertEventIDs(t, feed, []string{"a-1", "a-2", "a-3", "b-1", "b-2"})
+		})
+	}
+}
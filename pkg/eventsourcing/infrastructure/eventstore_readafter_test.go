@@ -107,6 +107,7 @@ func TestEventStore_ReadAfter(t *testing.T) {
 		{name: "memory", setupStore: setupMemoryStore},
 		{name: "gorm", setupStore: setupGormStore},
 		{name: "file", setupStore: setupFileStore},
+		{name: "redis", setupStore: setupRedisStore},
 	}
 
 	for _, st := range stores {
@@ -222,6 +223,7 @@ func TestEventStore_HeadPosition(t *testing.T) {
 		{name: "memory", setupStore: setupMemoryStore},
 		{name: "gorm", setupStore: setupGormStore},
 		{name: "file", setupStore: setupFileStore},
+		{name: "redis", setupStore: setupRedisStore},
 	}
 
 	for _, st := range stores {
@@ -0,0 +1,101 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+func TestEventStore_TraceWorkflow(t *testing.T) {
+	t.Parallel()
+
+	stores := []struct {
+		name       string
+		setupStore func(t *testing.T) domain.EventStore
+	}{
+		{name: "memory", setupStore: setupMemoryStore},
+		{name: "gorm", setupStore: func(t *testing.T) domain.EventStore {
+			store, err := infrastructure.NewGormEventStore(newTestGormDB(t), infrastructure.WithWorkflowTracing())
+			if err != nil {
+				t.Fatalf("failed to create gorm event store: %v", err)
+			}
+			return store
+		}},
+	}
+
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			store := st.setupStore(t)
+			defer func() { _ = store.Close() }()
+
+			tracer, ok := store.(domain.WorkflowTracer)
+			if !ok {
+				t.Fatalf("%T does not implement domain.WorkflowTracer", store)
+			}
+
+			appendOne := func(aggregateID, eventID, correlationID, account string) {
+				t.Helper()
+				version, _ := store.GetCurrentVersion(ctx, aggregateID)
+				e := createTestEvent(aggregateID, eventID, "test.recorded", version+1)
+				e.Metadata[domain.MetadataCorrelationID] = correlationID
+				if account != "" {
+					e.Metadata[domain.MetadataAccountID] = account
+				}
+				if err := store.Append(ctx, aggregateID, version, e); err != nil {
+					t.Fatalf("failed to append %s: %v", eventID, err)
+				}
+			}
+			appendOne("order-1", "ev-1", "wf-1", "acme")
+			appendOne("payment-1", "ev-2", "wf-1", "acme")
+			appendOne("order-2", "ev-3", "wf-2", "acme")
+			appendOne("order-9", "ev-4", "wf-1", "globex")
+			appendOne("order-1", "ev-5", "wf-1", "acme")
+			appendOne("job-1", "ev-6", "wf-1", "")
+
+			tests := []struct {
+				name    string
+				account string
+				want    []string
+			}{
+				{name: "events of the workflow in position order", account: "acme", want: []string{"ev-1", "ev-2", "ev-5"}},
+				{name: "other tenants are isolated", account: "globex", want: []string{"ev-4"}},
+				{name: "empty account matches only system events", account: "", want: []string{"ev-6"}},
+			}
+			for _, tt := range tests {
+				events, err := tracer.TraceWorkflow(ctx, tt.account, "wf-1")
+				if err != nil {
+					t.Fatalf("%s: TraceWorkflow failed: %v", tt.name, err)
+				}
+				got := make([]string, len(events))
+				for i, event := range events {
+					got[i] = event.ID
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				}
+			}
+
+			if _, err := tracer.TraceWorkflow(ctx, "acme", ""); !errors.Is(err, domain.ErrInvalidEvent) {
+				t.Errorf("expected ErrInvalidEvent for an empty correlation ID, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGormStore_TraceWorkflowRequiresOption(t *testing.T) {
+	t.Parallel()
+
+	store, err := infrastructure.NewGormEventStore(newTestGormDB(t))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	if _, err := store.TraceWorkflow(context.Background(), "acme", "wf-1"); !errors.Is(err, infrastructure.ErrWorkflowTracingDisabled) {
+		t.Errorf("expected ErrWorkflowTracingDisabled, got %v", err)
+	}
+}
@@ -239,9 +239,10 @@ func (f *FileStore) Append(ctx context.Context, aggregateID string, expectedVers
 		return fmt.Errorf("%w: expected version %d, got %d", domain.ErrConcurrencyConflict, expectedVersion, currentVersion)
 	}
 
-	// Append events preserving their SequenceNo as set by the domain.
-	// Each event is copied so the store-assigned global Position does not
-	// mutate the caller's envelopes.
+	// Append events preserving their SequenceNo as set by the domain, in
+	// sequence order. Each event is copied so the store-assigned global
+	// Position does not mutate the caller's envelopes.
+	events = sortedBySequence(events)
 	for _, event := range events {
 		f.lastPos++
 		event.Position = f.lastPos
@@ -465,6 +466,18 @@ func (f *FileStore) ReadAfter(ctx context.Context, afterPosition int64, limit in
 	return result, nil
 }
 
+// ListAggregateIDs returns a page of the aggregate IDs with events in
+// category, in ascending order.
+func (f *FileStore) ListAggregateIDs(ctx context.Context, category string, opts domain.ListAggregateIDsOptions) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.loadUncachedLocked(); err != nil {
+		return nil, err
+	}
+	return listAggregateIDs(f.cache, category, opts), nil
+}
+
 // HeadPosition returns the highest position assigned so far.
 func (f *FileStore) HeadPosition(ctx context.Context) (int64, error) {
 	f.mu.RLock()
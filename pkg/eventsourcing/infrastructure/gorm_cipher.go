@@ -0,0 +1,93 @@
+package infrastructure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// EventCipher encrypts event payloads at rest. The store passes the same
+// associated data, derived from the event's ID, to Encrypt and Decrypt;
+// implementations must authenticate it so a ciphertext copied into another
+// event's row fails to decrypt. Implementations must be safe for concurrent
+// use.
+type EventCipher interface {
+	Encrypt(plaintext, associatedData []byte) ([]byte, error)
+	Decrypt(ciphertext, associatedData []byte) ([]byte, error)
+}
+
+// ErrCipherNotConfigured is returned when a GormEventStore reads an encrypted
+// payload or field without a cipher configured to decrypt it.
+var ErrCipherNotConfigured = errors.New("payload is encrypted but no cipher is configured")
+
+// WithEventCipher encrypts each event's payload with c before Append writes
+// it and decrypts it on every read. Only the payload is encrypted: the event
+// type, category, account, metadata and the other columns stay in the clear
+// so they can still be indexed and queried.
+//
+// Rows whose payload is not in the encrypted form, such as events written
+// before the cipher was configured or payloads tombstoned by ForgetAggregate,
+// are read as they are. A payload that fails to decrypt fails the read, as
+// does an encrypted payload read by a store without the option, with
+// ErrCipherNotConfigured. The payload is sealed with the event ID as
+// associated data, so it cannot be moved to another event's row.
+func WithEventCipher(c EventCipher) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		s.cipher = c
+	}
+}
+
+// AESGCMCipher is an EventCipher using AES-256 in GCM mode. Each ciphertext
+// is prefixed with the random nonce it was sealed with.
+type AESGCMCipher struct {
+	aead cipher.AEAD
+}
+
+var _ EventCipher = (*AESGCMCipher)(nil)
+
+// ErrCiphertextTooShort is returned by AESGCMCipher.Decrypt for input shorter
+// than a nonce.
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// NewAESGCMCipher creates an AESGCMCipher from a 32-byte key.
+func NewAESGCMCipher(key []byte) (*AESGCMCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("AES-GCM key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return &AESGCMCipher{aead: aead}, nil
+}
+
+// Encrypt seals plaintext and associatedData under a fresh random nonce and
+// returns the nonce followed by the ciphertext.
+func (c *AESGCMCipher) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// Decrypt splits the nonce off ciphertext and opens the rest, failing if it
+// was tampered with, sealed under another key or sealed with other associated
+// data.
+func (c *AESGCMCipher) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, ErrCiphertextTooShort
+	}
+	plaintext, err := c.aead.Open(nil, ciphertext[:size], ciphertext[size:], associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ciphertext: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,171 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"gorm.io/gorm"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+var (
+	_ domain.DeadLetterStore  = (*GormDeadLetterStore)(nil)
+	_ domain.DispatcherBinder = (*GormDeadLetterStore)(nil)
+)
+
+var (
+	// ErrDeadLetterNotFound is returned by Replay for an unknown dead-letter ID.
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	// ErrDeadLetterUnbound is returned by Replay on a store that was never
+	// passed to domain.WithDeadLetterStore, so has no dispatcher to replay
+	// through.
+	ErrDeadLetterUnbound = errors.New("dead-letter store is not bound to a dispatcher")
+)
+
+// GormDeadLetterModel is one event a handler gave up on. The event is copied
+// in full, so the letter can be replayed even when the dispatcher is fed from
+// somewhere other than an event store.
+type GormDeadLetterModel struct {
+	ID             string    `gorm:"primaryKey;column:id"`
+	HandlerName    string    `gorm:"column:handler_name;index"`
+	EventID        string    `gorm:"column:event_id;index"`
+	AggregateID    string    `gorm:"column:aggregate_id"`
+	EventType      string    `gorm:"column:event_type"`
+	SequenceNo     int       `gorm:"column:sequence_no"`
+	TransactionID  string    `gorm:"column:transaction_id"`
+	Payload        JSONB     `gorm:"column:payload;type:jsonb"`
+	Metadata       JSONB     `gorm:"column:metadata;type:jsonb"`
+	EventCreatedAt time.Time `gorm:"column:event_created_at"`
+	LastError      string    `gorm:"column:last_error"`
+	Attempts       int       `gorm:"column:attempts"`
+	CreatedAt      time.Time `gorm:"column:created_at;index"`
+	UpdatedAt      time.Time `gorm:"column:updated_at"`
+}
+
+// TableName returns the table name for the dead-letter model.
+func (GormDeadLetterModel) TableName() string {
+	return "dead_letters"
+}
+
+// Envelope rebuilds the event the letter was recorded for.
+func (m GormDeadLetterModel) Envelope() domain.EventEnvelope[any] {
+	return modelToEnvelope(GormEventModel{
+		ID:            m.EventID,
+		AggregateID:   m.AggregateID,
+		EventType:     m.EventType,
+		SequenceNo:    m.SequenceNo,
+		TransactionID: m.TransactionID,
+		Payload:       m.Payload,
+		Metadata:      m.Metadata,
+		CreatedAt:     m.EventCreatedAt,
+	})
+}
+
+// GormDeadLetterStore is a domain.DeadLetterStore kept in a dead_letters
+// table. Payloads are stored as plain JSON, without the event store's
+// encryption or compression, so grant access to the table accordingly.
+type GormDeadLetterStore struct {
+	db         *gorm.DB
+	dispatcher *domain.EventDispatcher
+}
+
+// NewGormDeadLetterStore creates a dead-letter store on db and auto-migrates
+// its table. Pass it to domain.WithDeadLetterStore, which also binds it to
+// the dispatcher Replay re-dispatches through.
+func NewGormDeadLetterStore(db *gorm.DB) (*GormDeadLetterStore, error) {
+	if err := db.AutoMigrate(&GormDeadLetterModel{}); err != nil {
+		return nil, fmt.Errorf("failed to auto-migrate dead-letter table: %w", err)
+	}
+	return &GormDeadLetterStore{db: db}, nil
+}
+
+// BindDispatcher sets the dispatcher Replay re-dispatches through.
+func (s *GormDeadLetterStore) BindDispatcher(d *domain.EventDispatcher) {
+	s.dispatcher = d
+}
+
+// Store records envelope as dead for handlerName.
+func (s *GormDeadLetterStore) Store(ctx context.Context, envelope domain.EventEnvelope[any], handlerName string, lastErr error) error {
+	model, err := envelopeToModel(envelope)
+	if err != nil {
+		return err
+	}
+	letter := GormDeadLetterModel{
+		ID:             ksuid.New().String(),
+		HandlerName:    handlerName,
+		EventID:        model.ID,
+		AggregateID:    model.AggregateID,
+		EventType:      model.EventType,
+		SequenceNo:     model.SequenceNo,
+		TransactionID:  model.TransactionID,
+		Payload:        model.Payload,
+		Metadata:       model.Metadata,
+		EventCreatedAt: model.CreatedAt,
+		LastError:      errorText(lastErr),
+		Attempts:       1,
+	}
+	if err := s.db.WithContext(ctx).Create(&letter).Error; err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit dead letters, oldest first (limit <= 0 means no
+// limit).
+func (s *GormDeadLetterStore) List(ctx context.Context, limit int) ([]GormDeadLetterModel, error) {
+	query := s.db.WithContext(ctx).Order("created_at ASC").Order("id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var letters []GormDeadLetterModel
+	if err := query.Find(&letters).Error; err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+// Replay re-dispatches the dead letter id to the handler that gave up on it,
+// via domain.EventDispatcher.Redeliver. On success the letter is deleted; on
+// failure its attempt count and last error are updated and the handler's
+// error is returned.
+func (s *GormDeadLetterStore) Replay(ctx context.Context, id string) error {
+	if s.dispatcher == nil {
+		return ErrDeadLetterUnbound
+	}
+	var letter GormDeadLetterModel
+	if err := s.db.WithContext(ctx).First(&letter, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+		}
+		return fmt.Errorf("failed to load dead letter %s: %w", id, err)
+	}
+
+	if err := s.dispatcher.Redeliver(ctx, letter.Envelope(), letter.HandlerName); err != nil {
+		update := s.db.WithContext(ctx).Model(&GormDeadLetterModel{}).Where("id = ?", id).Updates(map[string]any{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": errorText(err),
+			"updated_at": time.Now(),
+		})
+		if update.Error != nil {
+			return errors.Join(err, fmt.Errorf("failed to record replay failure of dead letter %s: %w", id, update.Error))
+		}
+		return err
+	}
+	if err := s.db.WithContext(ctx).Delete(&GormDeadLetterModel{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete replayed dead letter %s: %w", id, err)
+	}
+	return nil
+}
+
+// errorText returns err's message, or "" for nil.
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,72 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+func TestGormDeadLetterStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	letters, err := infrastructure.NewGormDeadLetterStore(newTestGormDB(t))
+	if err != nil {
+		t.Fatalf("NewGormDeadLetterStore() error = %v", err)
+	}
+	if err := letters.Replay(ctx, "any"); !errors.Is(err, infrastructure.ErrDeadLetterUnbound) {
+		t.Errorf("Replay() before binding error = %v, want ErrDeadLetterUnbound", err)
+	}
+
+	healthy := false
+	var received []domain.EventEnvelope[any]
+	d := domain.NewEventDispatcher(domain.WithDeadLetterStore(letters, 1))
+	if _, err := domain.Subscribe[any](d, "user.created", func(_ context.Context, env domain.EventEnvelope[any]) error {
+		if !healthy {
+			return errors.New("downstream unavailable")
+		}
+		received = append(received, env)
+		return nil
+	}, domain.HandlerName("projector")); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	event := createTestEvent("user-1", "ev-1", "user.created", 1)
+	event.Metadata["account_id"] = "acct-1"
+	if err := d.Dispatch(ctx, event); err != nil {
+		t.Fatalf("Dispatch() error = %v, want the failure dead-lettered", err)
+	}
+
+	stored, err := letters.List(ctx, 0)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("List() = %d letters, %v, want 1", len(stored), err)
+	}
+	letter := stored[0]
+	if letter.HandlerName != "projector" || letter.EventID != "ev-1" || letter.LastError != "downstream unavailable" {
+		t.Errorf("letter = %+v, want projector's failure on ev-1", letter)
+	}
+
+	if err := letters.Replay(ctx, letter.ID); err == nil {
+		t.Fatal("Replay() while the handler still fails error = nil")
+	}
+	if stored, _ := letters.List(ctx, 0); len(stored) != 1 || stored[0].Attempts != 2 {
+		t.Errorf("after failed replay letters = %+v, want one with 2 attempts", stored)
+	}
+
+	healthy = true
+	if err := letters.Replay(ctx, letter.ID); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(received) != 1 || received[0].ID != "ev-1" || received[0].Payload.(map[string]any)["test"] != "data" || received[0].Metadata["account_id"] != "acct-1" {
+		t.Errorf("received = %+v, want ev-1 with its payload and metadata", received)
+	}
+	if stored, _ := letters.List(ctx, 0); len(stored) != 0 {
+		t.Errorf("after replay letters = %+v, want none", stored)
+	}
+	if err := letters.Replay(ctx, letter.ID); !errors.Is(err, infrastructure.ErrDeadLetterNotFound) {
+		t.Errorf("second Replay() error = %v, want ErrDeadLetterNotFound", err)
+	}
+}
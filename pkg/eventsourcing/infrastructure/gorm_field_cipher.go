@@ -0,0 +1,282 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"gorm.io/gorm"
+)
+
+// fieldRewrapBatchSize is how many events RewrapFields reads per query.
+const fieldRewrapBatchSize = 500
+
+// WithFieldCipher encrypts individual payload fields with c instead of the
+// whole payload, so a single sensitive field such as a social security number
+// is protected while the rest of the payload stays in the clear and can still
+// be queried. A field is encrypted when its struct field carries the tag
+// `pericarp:"encrypted"`, or when it is named for the event's type with
+// WithEncryptedFields; only top-level payload fields are considered.
+//
+// An encrypted field is stored as a JSON object holding its sealed value
+// under "$encrypted", and reads restore the original value. Encrypted fields
+// therefore cannot be used in payload queries: a filter or index on them
+// sees ciphertext, which differs for every event. Fields stored in the clear,
+// such as a field written before it was marked encrypted, are read as they
+// are, so old events keep decoding after a field is added to the encrypted
+// set. A field that fails to decrypt fails the read, as does an encrypted
+// field read by a store without the option, with ErrCipherNotConfigured.
+// Each field is sealed with its event ID and field name as associated data,
+// so it cannot be moved to another field or event.
+//
+// WithFieldCipher composes with WithCompression and WithEventCipher: fields
+// are encrypted first, then the whole payload is compressed and encrypted.
+func WithFieldCipher(c EventCipher) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		s.fieldCipher = c
+	}
+}
+
+// WithEncryptedFields marks the named top-level payload fields of events of
+// eventType as encrypted, for payloads without struct tags such as maps. It
+// has no effect without WithFieldCipher.
+func WithEncryptedFields(eventType string, fields ...string) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		if s.encryptedFields == nil {
+			s.encryptedFields = make(map[string][]string)
+		}
+		s.encryptedFields[eventType] = append(s.encryptedFields[eventType], fields...)
+	}
+}
+
+// RewrapFields re-encrypts every encrypted payload field sealed with previous
+// under the store's current field cipher, for rotating the field key: make
+// the new key current with WithFieldCipher, call RewrapFields with the old
+// one, then retire it. Fields the current cipher already opens are left
+// alone, so an interrupted rotation can simply be run again. It returns the
+// number of events rewritten.
+//
+// Like ForgetAggregate, RewrapFields updates stored events in place; each
+// batch is committed in its own transaction.
+func (s *GormEventStore) RewrapFields(ctx context.Context, previous EventCipher) (int, error) {
+	if s.fieldCipher == nil {
+		return 0, fmt.Errorf("failed to rewrap fields: no field cipher configured")
+	}
+
+	rewritten := 0
+	var after int64
+	for {
+		var models []GormEventModel
+		err := s.db.WithContext(ctx).Select("id", "position", "payload").
+			Where("position > ?", after).Order("position").Limit(fieldRewrapBatchSize).
+			Find(&models).Error
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to read events to rewrap: %w", err)
+		}
+		if len(models) == 0 {
+			return rewritten, nil
+		}
+		after = models[len(models)-1].Position
+
+		var changed []GormEventModel
+		for _, m := range models {
+			if err := s.openSealedPayload(&m); err != nil {
+				return rewritten, err
+			}
+			ok, err := s.rewrapFields(&m, previous)
+			if err != nil {
+				return rewritten, err
+			}
+			if !ok {
+				continue
+			}
+			if err := s.sealPayload(&m); err != nil {
+				return rewritten, err
+			}
+			changed = append(changed, m)
+		}
+
+		err = s.transaction(ctx, func(tx *gorm.DB) error {
+			for _, m := range changed {
+				if err := tx.Model(&GormEventModel{}).Where("id = ?", m.ID).Update("payload", m.Payload).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to rewrap fields: %w", err)
+		}
+		rewritten += len(changed)
+	}
+}
+
+// sealFields encrypts the fields of m's payload that are marked encrypted for
+// env. It is a no-op without a field cipher.
+func (s *GormEventStore) sealFields(env domain.EventEnvelope[any], m *GormEventModel) error {
+	if s.fieldCipher == nil || m.Payload == nil {
+		return nil
+	}
+	fields := slices.Concat(taggedEncryptedFields(reflect.TypeOf(env.Payload)), s.encryptedFields[env.EventType])
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// Copy before sealing so a map payload the caller still holds is not
+	// modified.
+	payload := make(JSONB, len(m.Payload))
+	for k, v := range m.Payload {
+		payload[k] = v
+	}
+	for _, field := range fields {
+		value, ok := payload[field]
+		if !ok || isSealedField(value) {
+			continue
+		}
+		sealed, err := sealField(s.fieldCipher, value, fieldAssociatedData(m.ID, field))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt field %q of event %q: %w", field, m.ID, err)
+		}
+		payload[field] = sealed
+	}
+	m.Payload = payload
+	return nil
+}
+
+// openFields decrypts the encrypted fields of m's payload. Without a field
+// cipher an encrypted field fails with ErrCipherNotConfigured.
+func (s *GormEventStore) openFields(m *GormEventModel) error {
+	for field, value := range m.Payload {
+		if !isSealedField(value) {
+			continue
+		}
+		if s.fieldCipher == nil {
+			return fmt.Errorf("failed to read field %q of event %q: %w", field, m.ID, ErrCipherNotConfigured)
+		}
+		opened, err := openField(s.fieldCipher, value, fieldAssociatedData(m.ID, field))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt field %q of event %q: %w", field, m.ID, err)
+		}
+		m.Payload[field] = opened
+	}
+	return nil
+}
+
+// rewrapFields re-encrypts the fields of m's payload that only previous can
+// open under the current field cipher, reporting whether any changed.
+func (s *GormEventStore) rewrapFields(m *GormEventModel, previous EventCipher) (bool, error) {
+	changed := false
+	for field, value := range m.Payload {
+		if !isSealedField(value) {
+			continue
+		}
+		ad := fieldAssociatedData(m.ID, field)
+		if _, err := openField(s.fieldCipher, value, ad); err == nil {
+			continue
+		}
+		opened, err := openField(previous, value, ad)
+		if err != nil {
+			return false, fmt.Errorf("failed to decrypt field %q of event %q with the previous key: %w", field, m.ID, err)
+		}
+		if m.Payload[field], err = sealField(s.fieldCipher, opened, ad); err != nil {
+			return false, fmt.Errorf("failed to encrypt field %q of event %q: %w", field, m.ID, err)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// fieldAssociatedData binds a sealed field to the event and field it was
+// written for, so it cannot be moved to another field or event.
+func fieldAssociatedData(eventID, field string) []byte {
+	return []byte(eventID + "\x00" + field)
+}
+
+// sealField returns value in its encrypted form.
+func sealField(c EventCipher, value any, associatedData []byte) (map[string]any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = c.Encrypt(data, associatedData); err != nil {
+		return nil, err
+	}
+	return map[string]any{encryptedPayloadKey: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+// openField restores a value sealed by sealField.
+func openField(c EventCipher, sealed any, associatedData []byte) (any, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed.(map[string]any)[encryptedPayloadKey].(string))
+	if err != nil {
+		return nil, err
+	}
+	if data, err = c.Decrypt(data, associatedData); err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// isSealedField reports whether value is in the form sealField produces.
+func isSealedField(value any) bool {
+	m, ok := value.(map[string]any)
+	if !ok || len(m) != 1 {
+		return false
+	}
+	_, ok = m[encryptedPayloadKey].(string)
+	return ok
+}
+
+// encryptedFieldsByType caches taggedEncryptedFields per payload type.
+var encryptedFieldsByType sync.Map
+
+// taggedEncryptedFields returns the JSON names of t's fields tagged
+// `pericarp:"encrypted"`. t may be a struct or a pointer to one.
+func taggedEncryptedFields(t reflect.Type) []string {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	if cached, ok := encryptedFieldsByType.Load(t); ok {
+		return cached.([]string)
+	}
+
+	var fields []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() || !hasTagOption(f.Tag.Get("pericarp"), "encrypted") {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+	encryptedFieldsByType.Store(t, fields)
+	return fields
+}
+
+// hasTagOption reports whether the comma-separated tag lists option.
+func hasTagOption(tag, option string) bool {
+	for part := range strings.SplitSeq(tag, ",") {
+		if strings.TrimSpace(part) == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"gorm.io/gorm"
+)
+
+// ForgetOption configures ForgetAggregate.
+type ForgetOption func(*forgetConfig)
+
+type forgetConfig struct {
+	hardDelete bool
+}
+
+// WithHardDelete makes ForgetAggregate delete the aggregate's events outright
+// instead of tombstoning them. The stream then vanishes entirely: its
+// sequence numbers and feed positions leave gaps, and the aggregate ID can be
+// reused. Prefer the default soft mode wherever an audit trail must survive.
+func WithHardDelete() ForgetOption {
+	return func(c *forgetConfig) {
+		c.hardDelete = true
+	}
+}
+
+// ForgetAggregate erases the personal data in an aggregate's events for a
+// data-subject deletion request. By default each event keeps its row, ID,
+// type, sequence number, position, timestamps and transaction, but its
+// payload is replaced with domain.ForgottenPayload, its metadata loses the
+// actor (domain.MetadataActorID) and gains domain.MetadataForgotten, so later
+// reads return tombstones in an otherwise intact stream. WithHardDelete
+// removes the rows instead.
+//
+// Forgetting runs in one transaction (joining InTransaction's when called
+// within it) and is idempotent; an unknown aggregate is a no-op. Snapshots,
+// projections and other copies of the aggregate's data are not touched and
+// must be erased separately.
+func (s *GormEventStore) ForgetAggregate(ctx context.Context, aggregateID string, opts ...ForgetOption) error {
+	cfg := &forgetConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	forget := func(tx *gorm.DB) error {
+		if cfg.hardDelete {
+			return tx.Where("aggregate_id = ?", aggregateID).Delete(&GormEventModel{}).Error
+		}
+
+		var models []GormEventModel
+		if err := tx.Select("id", "metadata").Where("aggregate_id = ?", aggregateID).Find(&models).Error; err != nil {
+			return err
+		}
+		for _, m := range models {
+			metadata := JSONB{}
+			for k, v := range m.Metadata {
+				metadata[k] = v
+			}
+			delete(metadata, domain.MetadataActorID)
+			metadata[domain.MetadataForgotten] = true
+
+			err := tx.Model(&GormEventModel{}).Where("id = ?", m.ID).Updates(map[string]any{
+				"payload":  JSONB(domain.ForgottenPayload()),
+				"metadata": metadata,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if tx := s.joinedTx(ctx); tx != nil {
+		err = forget(tx)
+	} else {
+		err = s.transaction(ctx, forget)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to forget aggregate %q: %w", aggregateID, err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultGroupCommitSize caps how many units one group commit runs when
+// WithGroupCommit is given a non-positive size.
+const DefaultGroupCommitSize = 64
+
+// ErrStoreClosed is returned by a group-committing GormEventStore for writes
+// submitted after Close.
+var ErrStoreClosed = errors.New("event store closed")
+
+// WithGroupCommit batches writes to absorb bursts: InTransaction units, and
+// Appends made outside one, that arrive within window of the first pending
+// unit (up to maxUnits of them) run back to back in a single database
+// transaction and commit together. Each unit runs inside its own savepoint,
+// so a unit that fails — a concurrency conflict, a failing hook — rolls back
+// alone and its caller gets its own error while the rest of the group still
+// commits.
+//
+// Durability is unchanged: a call returns only after the transaction holding
+// its writes has committed (or failed), never when the write is merely
+// queued. The cost is latency — up to window per write, plus the time to run
+// the units queued ahead of it — in exchange for far fewer, larger commits.
+// Units run one at a time in arrival order, each seeing the writes of the
+// units before it, so version checks stay correct within a group. Keep units
+// short: a slow unit delays every unit grouped with it. Cancelling a caller's
+// context does not withdraw a unit once it has been queued; the call still
+// waits for the outcome, and a unit whose context is done by the time it runs
+// is skipped with the context error.
+//
+// Group commit is off by default. Close stops it after the group in flight.
+func WithGroupCommit(window time.Duration, maxUnits int) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		if maxUnits <= 0 {
+			maxUnits = DefaultGroupCommitSize
+		}
+		s.group = &groupCommitter{
+			store:    s,
+			window:   window,
+			maxUnits: maxUnits,
+			units:    make(chan *groupUnit),
+			stop:     make(chan struct{}),
+			stopped:  make(chan struct{}),
+		}
+	}
+}
+
+// groupUnit is one caller's transactional work waiting for a group commit.
+type groupUnit struct {
+	ctx  context.Context
+	fn   func(ctx context.Context) error
+	done chan groupResult
+}
+
+// groupResult is a unit's outcome; panicked carries a recovered panic so it
+// can be re-raised in the caller's goroutine.
+type groupResult struct {
+	err      error
+	panicked any
+}
+
+type groupCommitter struct {
+	store    *GormEventStore
+	window   time.Duration
+	maxUnits int
+	units    chan *groupUnit
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// submit queues fn and blocks until the group containing it has committed
+// or failed.
+func (g *groupCommitter) submit(ctx context.Context, fn func(ctx context.Context) error) error {
+	unit := &groupUnit{ctx: ctx, fn: fn, done: make(chan groupResult, 1)}
+	select {
+	case g.units <- unit:
+	case <-g.stop:
+		return ErrStoreClosed
+	}
+	result := <-unit.done
+	if result.panicked != nil {
+		panic(result.panicked)
+	}
+	return result.err
+}
+
+// run collects units into groups and commits them until close.
+func (g *groupCommitter) run() {
+	defer close(g.stopped)
+	for {
+		var first *groupUnit
+		select {
+		case first = <-g.units:
+		case <-g.stop:
+			return
+		}
+
+		units := []*groupUnit{first}
+		timer := time.NewTimer(g.window)
+	collect:
+		for len(units) < g.maxUnits {
+			select {
+			case unit := <-g.units:
+				units = append(units, unit)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+		g.commit(units)
+	}
+}
+
+// commit runs units in one transaction, each inside a savepoint, and reports
+// every unit's outcome once the transaction has ended. A serialization
+// failure, in a unit or at commit, re-runs the whole group.
+func (g *groupCommitter) commit(units []*groupUnit) {
+	results := make([]groupResult, len(units))
+	err := g.store.transaction(context.Background(), func(tx *gorm.DB) error {
+		clear(results)
+		for i, unit := range units {
+			if err := unit.ctx.Err(); err != nil {
+				results[i].err = err
+				continue
+			}
+			savepoint := fmt.Sprintf("pericarp_group_unit_%d", i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return fmt.Errorf("failed to create savepoint: %w", err)
+			}
+			results[i] = g.runUnit(tx, unit)
+			if isSerializationFailure(results[i].err) {
+				// The whole group is re-run, not just this unit.
+				return results[i].err
+			}
+			if results[i].err != nil || results[i].panicked != nil {
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return fmt.Errorf("failed to roll back to savepoint: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	for i, unit := range units {
+		failedWithGroup := (results[i].err == nil && results[i].panicked == nil) || isSerializationFailure(results[i].err)
+		if err != nil && failedWithGroup {
+			results[i].err = fmt.Errorf("group commit failed: %w", err)
+		}
+		unit.done <- results[i]
+	}
+}
+
+// runUnit runs one unit against the group transaction, recovering a panic so
+// it fails only that unit.
+func (g *groupCommitter) runUnit(tx *gorm.DB, unit *groupUnit) (result groupResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = groupResult{panicked: r}
+		}
+	}()
+	ctx := context.WithValue(unit.ctx, storeTxContextKey{}, storeTx{store: g.store, tx: tx})
+	return groupResult{err: unit.fn(ctx)}
+}
+
+// close stops accepting units and waits for the group in flight.
+func (g *groupCommitter) close() {
+	g.stopOnce.Do(func() { close(g.stop) })
+	<-g.stopped
+}
@@ -0,0 +1,96 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"gorm.io/gorm"
+)
+
+// DefaultSerializationRetries is how many times a GormEventStore re-runs a
+// write transaction that failed with a serialization failure, unless
+// configured otherwise with WithSerializationRetries.
+const DefaultSerializationRetries = 3
+
+// serializationRetryBackoff is the delay before the first re-run of a
+// transaction after a serialization failure; it doubles per attempt and is
+// jittered so colliding writers do not collide again in lockstep.
+const serializationRetryBackoff = 5 * time.Millisecond
+
+// WithIsolationLevel sets the isolation level of every transaction the store
+// opens to write: Append, InTransaction, ForgetAggregate and group commits.
+// The default, sql.LevelDefault, leaves the connection's level in place
+// (READ COMMITTED on Postgres). Aggregates that need stronger guarantees,
+// such as ledgers, can be given a store of their own opened with
+// sql.LevelSerializable, so the throughput cost is paid only where it is
+// needed.
+//
+// Under REPEATABLE READ or SERIALIZABLE, Postgres aborts transactions that
+// conflict with a concurrent one (SQLSTATE 40001). The store re-runs such a
+// transaction from the start, up to WithSerializationRetries times, so a
+// losing Append re-checks its expected version and fails with
+// domain.ErrConcurrencyConflict instead of a driver error. The function
+// passed to InTransaction is therefore run again on retry and must be safe
+// to repeat. A transaction still failing once retries are exhausted fails
+// with domain.ErrConcurrencyConflict.
+func WithIsolationLevel(level sql.IsolationLevel) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		s.isolation = level
+	}
+}
+
+// WithSerializationRetries sets how many times a write transaction that hit a
+// serialization failure is re-run before the store gives up (default
+// DefaultSerializationRetries). Zero disables retrying; a negative n keeps the
+// default.
+func WithSerializationRetries(n int) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		if n >= 0 {
+			s.serializationRetries = n
+		}
+	}
+}
+
+// transaction runs fn in a write transaction at the store's isolation level,
+// re-running it after serialization failures up to the configured number of
+// retries.
+func (s *GormEventStore) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	var opts []*sql.TxOptions
+	if s.isolation != sql.LevelDefault {
+		opts = append(opts, &sql.TxOptions{Isolation: s.isolation})
+	}
+
+	backoff := serializationRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.db.WithContext(ctx).Transaction(fn, opts...)
+		if !isSerializationFailure(err) {
+			return err
+		}
+		if attempt >= s.serializationRetries {
+			return fmt.Errorf("%w: serialization failure after %d attempts: %v",
+				domain.ErrConcurrencyConflict, attempt+1, err)
+		}
+
+		timer := time.NewTimer(backoff/2 + rand.N(backoff))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// isSerializationFailure reports whether err is a Postgres serialization
+// failure (SQLSTATE 40001), which is safe to resolve by re-running the
+// transaction.
+func isSerializationFailure(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "40001"
+}
@@ -103,3 +103,59 @@ func migrateEventPositions(db *gorm.DB) error {
 		return nil
 	})
 }
+
+// migrateEventCategories backfills the category and account_id columns for
+// rows that predate them (AutoMigrate adds both as NULL on existing rows; new
+// rows always carry a value, possibly empty). Category is the event type's
+// prefix before the first "."; account_id comes from the metadata JSON. The
+// UPDATEs only touch NULL rows, so the migration is idempotent and cheap once
+// complete.
+func migrateEventCategories(db *gorm.DB) error {
+	var categoryExpr, accountExpr string
+	switch db.Name() {
+	case "postgres":
+		categoryExpr = "split_part(event_type, '.', 1)"
+		accountExpr = "COALESCE(metadata->>'account_id', '')"
+	case "sqlite":
+		categoryExpr = "CASE WHEN instr(event_type, '.') > 0 THEN substr(event_type, 1, instr(event_type, '.') - 1) ELSE event_type END"
+		accountExpr = "COALESCE(json_extract(metadata, '$.account_id'), '')"
+	default:
+		return fmt.Errorf("%w: category backfill supports postgres and sqlite, got dialect %q",
+			domain.ErrGlobalOrderingNotSupported, db.Name())
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("UPDATE events SET category = " + categoryExpr + " WHERE category IS NULL").Error; err != nil {
+			return fmt.Errorf("failed to backfill event categories: %w", err)
+		}
+		if err := tx.Exec("UPDATE events SET account_id = " + accountExpr + " WHERE account_id IS NULL").Error; err != nil {
+			return fmt.Errorf("failed to backfill event account IDs: %w", err)
+		}
+		return nil
+	})
+}
+
+// correlationExpr is the SQL expression extracting the correlation ID from an
+// event's metadata on dialect.
+func correlationExpr(dialect string) (string, error) {
+	switch dialect {
+	case "postgres":
+		return "(metadata->>'correlation_id')", nil
+	case "sqlite":
+		return "json_extract(metadata, '$.correlation_id')", nil
+	default:
+		return "", fmt.Errorf("workflow tracing supports postgres and sqlite, got dialect %q", dialect)
+	}
+}
+
+// migrateCorrelationIndex creates the expression index on (correlation ID,
+// account_id, position) that serves TraceWorkflow. It is idempotent. On a
+// large Postgres table the first run locks out writes while it builds; create
+// the index CONCURRENTLY by hand beforehand to avoid that.
+func migrateCorrelationIndex(db *gorm.DB) error {
+	expr, err := correlationExpr(db.Name())
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_events_correlation ON events (" + expr + ", account_id, position)").Error
+}
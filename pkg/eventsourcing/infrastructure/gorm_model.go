@@ -47,13 +47,20 @@ func (j *JSONB) Scan(value any) error {
 // carry an xact_id xid8 column (managed by raw migration SQL, not by this
 // struct) used to withhold rows whose inserting transaction may not have
 // committed yet.
+//
+// Category (the entity-type prefix of EventType) and AccountID (from the
+// account_id metadata key) are denormalized into indexed columns so
+// ListAggregateIDs can enumerate aggregates per type and tenant, and
+// ReadAfterInCategories can read the feed of selected types by position.
 type GormEventModel struct {
 	ID            string    `gorm:"primaryKey;column:id"`
-	AggregateID   string    `gorm:"column:aggregate_id;index;uniqueIndex:idx_aggregate_sequence"`
+	AggregateID   string    `gorm:"column:aggregate_id;index;uniqueIndex:idx_aggregate_sequence;index:idx_events_category_aggregate,priority:2;index:idx_events_account_category,priority:3"`
 	EventType     string    `gorm:"column:event_type"`
 	SequenceNo    int       `gorm:"column:sequence_no;uniqueIndex:idx_aggregate_sequence"`
 	TransactionID string    `gorm:"column:transaction_id;index"`
-	Position      int64     `gorm:"column:position;uniqueIndex:idx_events_position"`
+	Category      string    `gorm:"column:category;index:idx_events_category_aggregate,priority:1;index:idx_events_account_category,priority:2;index:idx_events_category_position,priority:1"`
+	AccountID     string    `gorm:"column:account_id;index:idx_events_account_category,priority:1"`
+	Position      int64     `gorm:"column:position;uniqueIndex:idx_events_position;index:idx_events_category_position,priority:2"`
 	Payload       JSONB     `gorm:"column:payload;type:jsonb"`
 	Metadata      JSONB     `gorm:"column:metadata;type:jsonb"`
 	CreatedAt     time.Time `gorm:"column:created_at"`
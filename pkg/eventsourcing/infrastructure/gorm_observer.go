@@ -0,0 +1,68 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// EventStoreObserver receives measurements of a GormEventStore's writes and
+// reads, typically to export them as metrics (see metrics.PrometheusObserver).
+// Its methods are called on the caller's goroutine once the operation has
+// succeeded, so they must be quick and safe for concurrent use. Failed
+// operations are not observed.
+type EventStoreObserver interface {
+	// OnSave is called after Append has saved count events of aggregateID,
+	// whose payloads take bytes as stored (after any encryption or
+	// compression), taking duration. With WithGroupCommit it is called once
+	// the shared transaction has committed; inside InTransaction the events
+	// are written but not yet committed.
+	OnSave(aggregateID string, count, bytes int, duration time.Duration)
+
+	// OnLoad is called after GetEvents, GetEventsFromVersion or
+	// GetEventsRange has read count events of aggregateID, taking duration.
+	OnLoad(aggregateID string, count int, duration time.Duration)
+}
+
+// WithObserver reports every save and load to o. Without an observer the
+// store takes no measurements.
+func WithObserver(o EventStoreObserver) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		s.observer = o
+	}
+}
+
+// storedPayloadSize returns the size of m's payload as written to the
+// events table.
+func storedPayloadSize(m GormEventModel) int {
+	if m.Payload == nil {
+		return 0
+	}
+	data, err := json.Marshal(m.Payload)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// loadEvents converts the models read by read, reporting the load of
+// aggregateID to the observer.
+func (s *GormEventStore) loadEvents(aggregateID string, read func() ([]GormEventModel, error)) ([]domain.EventEnvelope[any], error) {
+	var start time.Time
+	if s.observer != nil {
+		start = time.Now()
+	}
+	models, err := read()
+	if err != nil {
+		return nil, err
+	}
+	envelopes, err := s.toEnvelopes(models)
+	if err != nil {
+		return nil, err
+	}
+	if s.observer != nil {
+		s.observer.OnLoad(aggregateID, len(envelopes), time.Since(start))
+	}
+	return envelopes, nil
+}
@@ -0,0 +1,189 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultOutboxRetryBackoff is how long a GormOutbox holds back an event
+	// after its first failed delivery unless configured with
+	// WithOutboxRetryBackoff. The wait doubles with each further failure.
+	DefaultOutboxRetryBackoff = time.Second
+
+	// DefaultOutboxMaxRetryBackoff caps the wait between a failing event's
+	// deliveries unless configured with WithOutboxRetryBackoff.
+	DefaultOutboxMaxRetryBackoff = 5 * time.Minute
+)
+
+var _ domain.Outbox = (*GormOutbox)(nil)
+
+// GormOutboxModel is one event awaiting (or past) outbox delivery. Rows
+// reference the event by ID rather than copying it, so the payload is stored
+// once and stays under the store's encryption and forgetting. Attempts,
+// NextAttemptAt and LastError track failed deliveries; see
+// GormOutbox.MarkFailed.
+type GormOutboxModel struct {
+	EventID       string     `gorm:"primaryKey;column:event_id"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+	SentAt        *time.Time `gorm:"column:sent_at;index"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt *time.Time `gorm:"column:next_attempt_at;index"`
+	LastError     string     `gorm:"column:last_error;type:text"`
+}
+
+// TableName returns the table name for the outbox model.
+func (GormOutboxModel) TableName() string {
+	return "event_outbox"
+}
+
+// GormOutbox is a domain.Outbox kept in an event_outbox table beside a
+// GormEventStore's events, so outbox rows commit in the same transaction as
+// the events they announce.
+type GormOutbox struct {
+	store        *GormEventStore
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+}
+
+// GormOutboxOption configures a GormOutbox.
+type GormOutboxOption func(*GormOutbox)
+
+// WithOutboxRetryBackoff sets how long an event is held back after a failed
+// delivery: initial after the first failure, doubling with each further one
+// up to maximum (defaults DefaultOutboxRetryBackoff and
+// DefaultOutboxMaxRetryBackoff). Non-positive values keep the defaults.
+func WithOutboxRetryBackoff(initial, maximum time.Duration) GormOutboxOption {
+	return func(o *GormOutbox) {
+		if initial > 0 {
+			o.retryBackoff = initial
+		}
+		if maximum > 0 {
+			o.maxBackoff = maximum
+		}
+	}
+}
+
+// NewGormOutbox creates an outbox for store and auto-migrates its table.
+func NewGormOutbox(store *GormEventStore, opts ...GormOutboxOption) (*GormOutbox, error) {
+	if err := store.db.AutoMigrate(&GormOutboxModel{}); err != nil {
+		return nil, fmt.Errorf("failed to auto-migrate outbox table: %w", err)
+	}
+	o := &GormOutbox{
+		store:        store,
+		retryBackoff: DefaultOutboxRetryBackoff,
+		maxBackoff:   DefaultOutboxMaxRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.maxBackoff = max(o.maxBackoff, o.retryBackoff)
+	return o, nil
+}
+
+// Enqueue inserts a pending outbox row per event inside the store transaction
+// carried by ctx.
+func (o *GormOutbox) Enqueue(ctx context.Context, events ...domain.EventEnvelope[any]) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx := o.store.joinedTx(ctx)
+	if tx == nil {
+		return domain.ErrOutboxOutsideTransaction
+	}
+	now := time.Now()
+	rows := make([]GormOutboxModel, len(events))
+	for i, event := range events {
+		rows[i] = GormOutboxModel{EventID: event.ID, CreatedAt: now}
+	}
+	if err := tx.WithContext(ctx).Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to enqueue events in outbox: %w", err)
+	}
+	return nil
+}
+
+// Pending returns up to limit unsent events that are due for delivery, in
+// feed position order (limit <= 0 means no limit). An event waiting for its
+// retry after a failed delivery, and every later unsent event of its
+// aggregate, is skipped. Rows whose event has been hard-deleted by
+// ForgetAggregate are skipped too.
+func (o *GormOutbox) Pending(ctx context.Context, limit int) ([]domain.EventEnvelope[any], error) {
+	now := time.Now()
+	query := o.store.db.WithContext(ctx).Model(&GormEventModel{}).
+		Select("events.*").
+		Joins("JOIN event_outbox ON event_outbox.event_id = events.id").
+		Where("event_outbox.sent_at IS NULL").
+		Where("(event_outbox.next_attempt_at IS NULL OR event_outbox.next_attempt_at <= ?)", now).
+		Where(`NOT EXISTS (SELECT 1 FROM event_outbox waiting
+			JOIN events earlier ON earlier.id = waiting.event_id
+			WHERE waiting.sent_at IS NULL AND waiting.next_attempt_at > ?
+			AND earlier.aggregate_id = events.aggregate_id AND earlier.position < events.position)`, now).
+		Order("events.position ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var models []GormEventModel
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to read pending outbox events: %w", err)
+	}
+	return o.store.toEnvelopes(models)
+}
+
+// MarkSent stamps the outbox rows of eventIDs with the time they were sent.
+func (o *GormOutbox) MarkSent(ctx context.Context, eventIDs ...string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+	err := o.store.db.WithContext(ctx).Model(&GormOutboxModel{}).
+		Where("event_id IN ?", eventIDs).
+		Update("sent_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox events sent: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed counts a failed delivery of the event eventID and holds it back,
+// with the later events of its aggregate, until its retry time: the retry
+// backoff after the first failure, doubling with each further one up to the
+// maximum (see WithOutboxRetryBackoff).
+func (o *GormOutbox) MarkFailed(ctx context.Context, eventID string, cause error) error {
+	err := o.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var row GormOutboxModel
+		if err := tx.Where("event_id = ?", eventID).First(&row).Error; err != nil {
+			return err
+		}
+		attempts := row.Attempts + 1
+		next := time.Now().Add(o.backoff(attempts))
+		lastError := ""
+		if cause != nil {
+			lastError = cause.Error()
+		}
+		return tx.Model(&GormOutboxModel{}).Where("event_id = ?", eventID).Updates(map[string]any{
+			"attempts":        attempts,
+			"next_attempt_at": next,
+			"last_error":      lastError,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record failed delivery of outbox event %q: %w", eventID, err)
+	}
+	return nil
+}
+
+// backoff returns how long to hold back an event after its attempts-th failed
+// delivery.
+func (o *GormOutbox) backoff(attempts int) time.Duration {
+	delay := o.retryBackoff
+	for range attempts - 1 {
+		if delay > o.maxBackoff/2 {
+			return o.maxBackoff
+		}
+		delay *= 2
+	}
+	return min(delay, o.maxBackoff)
+}
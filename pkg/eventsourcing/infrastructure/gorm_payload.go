@@ -0,0 +1,152 @@
+package infrastructure
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Compression selects how a GormEventStore compresses payloads at rest. Its
+// value is also the one-byte codec header written in front of each
+// compressed payload.
+type Compression byte
+
+const (
+	// CompressionNone stores payloads uncompressed.
+	CompressionNone Compression = 0
+	// CompressionGzip stores payloads gzip-compressed.
+	CompressionGzip Compression = 1
+)
+
+// WithCompression compresses each event's payload before Append writes it and
+// decompresses it on every read. Compressed payloads carry a one-byte codec
+// header, so rows written before compression was enabled (or after it is
+// turned off again) still read back correctly; reads never depend on the
+// option. Combined with WithEventCipher, payloads are compressed before they
+// are encrypted.
+//
+// The payload column must hold JSON, so a compressed payload is stored
+// base64-encoded, which costs a third of its size back; compression pays off
+// on large, repetitive payloads rather than small ones.
+func WithCompression(c Compression) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		s.compression = c
+	}
+}
+
+// A sealed payload is stored as a JSON object with a single one of these keys
+// whose value is the base64-encoded sealed bytes.
+const (
+	encryptedPayloadKey  = "$encrypted"
+	compressedPayloadKey = "$compressed"
+)
+
+// sealPayload replaces m's payload with its compressed and/or encrypted form.
+// It is a no-op when neither is configured or for a nil payload.
+func (s *GormEventStore) sealPayload(m *GormEventModel) error {
+	if (s.cipher == nil && s.compression == CompressionNone) || m.Payload == nil {
+		return nil
+	}
+	data, err := json.Marshal(m.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	key := compressedPayloadKey
+	if s.compression != CompressionNone {
+		if data, err = compressPayload(s.compression, data); err != nil {
+			return err
+		}
+	}
+	if s.cipher != nil {
+		if data, err = s.cipher.Encrypt(data, []byte(m.ID)); err != nil {
+			return fmt.Errorf("failed to encrypt payload: %w", err)
+		}
+		key = encryptedPayloadKey
+	}
+	m.Payload = JSONB{key: base64.StdEncoding.EncodeToString(data)}
+	return nil
+}
+
+// openPayload restores m's payload from its sealed form and decrypts its
+// encrypted fields.
+func (s *GormEventStore) openPayload(m *GormEventModel) error {
+	if err := s.openSealedPayload(m); err != nil {
+		return err
+	}
+	return s.openFields(m)
+}
+
+// openSealedPayload restores m's payload from its sealed form. Payloads that
+// were not sealed are left untouched; encrypted ones fail with
+// ErrCipherNotConfigured when no cipher is configured.
+func (s *GormEventStore) openSealedPayload(m *GormEventModel) error {
+	if len(m.Payload) != 1 {
+		return nil
+	}
+	encrypted, isEncrypted := m.Payload[encryptedPayloadKey].(string)
+	compressed, isCompressed := m.Payload[compressedPayloadKey].(string)
+
+	var data []byte
+	var err error
+	switch {
+	case isEncrypted && s.cipher == nil:
+		return fmt.Errorf("failed to read payload of event %q: %w", m.ID, ErrCipherNotConfigured)
+	case isEncrypted:
+		if data, err = base64.StdEncoding.DecodeString(encrypted); err != nil {
+			return fmt.Errorf("failed to decode payload of event %q: %w", m.ID, err)
+		}
+		if data, err = s.cipher.Decrypt(data, []byte(m.ID)); err != nil {
+			return fmt.Errorf("failed to decrypt payload of event %q: %w", m.ID, err)
+		}
+	case isCompressed:
+		if data, err = base64.StdEncoding.DecodeString(compressed); err != nil {
+			return fmt.Errorf("failed to decode payload of event %q: %w", m.ID, err)
+		}
+	default:
+		return nil
+	}
+
+	if data, err = decompressPayload(data); err != nil {
+		return fmt.Errorf("failed to decompress payload of event %q: %w", m.ID, err)
+	}
+	var payload JSONB
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload of event %q: %w", m.ID, err)
+	}
+	m.Payload = payload
+	return nil
+}
+
+// compressPayload compresses data with c and prefixes the codec header.
+func compressPayload(c Compression, data []byte) ([]byte, error) {
+	if c != CompressionGzip {
+		return nil, fmt.Errorf("unsupported payload compression %d", c)
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(byte(c))
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressPayload reverses compressPayload. Data without a codec header is
+// returned as is: plain JSON always starts with '{', never a codec byte.
+func decompressPayload(data []byte) ([]byte, error) {
+	if len(data) == 0 || Compression(data[0]) != CompressionGzip {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data[1:]))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
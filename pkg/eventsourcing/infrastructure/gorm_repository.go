@@ -79,7 +79,17 @@ func (r *GormEventRepository) insertEventsTx(tx *gorm.DB, events []GormEventMode
 // safe to hand out once every transaction that could hold a smaller position
 // has finished (xact_id < pg_snapshot_xmin(pg_current_snapshot())).
 func (r *GormEventRepository) GetEventsAfterPosition(ctx context.Context, afterPosition int64, limit int) ([]GormEventModel, error) {
+	return r.GetEventsAfterPositionInCategories(ctx, afterPosition, limit, nil)
+}
+
+// GetEventsAfterPositionInCategories is GetEventsAfterPosition restricted to
+// the given categories (served by idx_events_category_position); an empty
+// slice means every category.
+func (r *GormEventRepository) GetEventsAfterPositionInCategories(ctx context.Context, afterPosition int64, limit int, categories []string) ([]GormEventModel, error) {
 	query := r.db.WithContext(ctx).Where("position > ?", afterPosition)
+	if len(categories) > 0 {
+		query = query.Where("category IN ?", categories)
+	}
 	if r.postgres {
 		query = query.Where("xact_id < pg_snapshot_xmin(pg_current_snapshot())")
 	}
@@ -105,6 +115,26 @@ func (r *GormEventRepository) GetHeadPosition(ctx context.Context) (int64, error
 	return head, err
 }
 
+// ListAggregateIDs returns up to limit distinct aggregate IDs with events in
+// category (and accountID, when non-empty) sorting after the exclusive cursor
+// after, in ascending order.
+func (r *GormEventRepository) ListAggregateIDs(ctx context.Context, category, accountID, after string, limit int) ([]string, error) {
+	query := r.db.WithContext(ctx).Model(&GormEventModel{}).Where("category = ?", category)
+	if accountID != "" {
+		query = query.Where("account_id = ?", accountID)
+	}
+	if after != "" {
+		query = query.Where("aggregate_id > ?", after)
+	}
+
+	var ids []string
+	err := query.Distinct("aggregate_id").
+		Order("aggregate_id ASC").
+		Limit(limit).
+		Pluck("aggregate_id", &ids).Error
+	return ids, err
+}
+
 // GetEventsByAggregateID retrieves all events for a given aggregate, ordered by sequence number.
 func (r *GormEventRepository) GetEventsByAggregateID(ctx context.Context, aggregateID string) ([]GormEventModel, error) {
 	var events []GormEventModel
@@ -115,6 +145,56 @@ func (r *GormEventRepository) GetEventsByAggregateID(ctx context.Context, aggreg
 	return events, err
 }
 
+// ScanEventsByAggregateID calls fn for each of an aggregate's events in
+// sequence order, scanning one row at a time rather than loading the whole
+// result. It stops at the first error from fn or the context.
+func (r *GormEventRepository) ScanEventsByAggregateID(ctx context.Context, aggregateID string, fn func(GormEventModel) error) error {
+	db := r.db.WithContext(ctx)
+	rows, err := db.Model(&GormEventModel{}).
+		Where("aggregate_id = ?", aggregateID).
+		Order("sequence_no ASC").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event GormEventModel
+		if err := db.ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// maxAggregateIDsPerQuery bounds the IN list of GetEventsByAggregateIDs so
+// large batches stay under the bind-parameter limits of every dialect.
+const maxAggregateIDsPerQuery = 500
+
+// GetEventsByAggregateIDs retrieves the events of several aggregates ordered
+// by aggregate ID and sequence number, in one query per
+// maxAggregateIDsPerQuery IDs.
+func (r *GormEventRepository) GetEventsByAggregateIDs(ctx context.Context, aggregateIDs []string) ([]GormEventModel, error) {
+	var events []GormEventModel
+	for start := 0; start < len(aggregateIDs); start += maxAggregateIDsPerQuery {
+		chunk := aggregateIDs[start:min(start+maxAggregateIDsPerQuery, len(aggregateIDs))]
+		var batch []GormEventModel
+		err := r.db.WithContext(ctx).
+			Where("aggregate_id IN ?", chunk).
+			Order("aggregate_id ASC, sequence_no ASC").
+			Find(&batch).Error
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, batch...)
+	}
+	return events, nil
+}
+
 // GetEventsByAggregateIDRange retrieves events for an aggregate within a sequence number range.
 func (r *GormEventRepository) GetEventsByAggregateIDRange(ctx context.Context, aggregateID string, fromSeq, toSeq int) ([]GormEventModel, error) {
 	var events []GormEventModel
@@ -126,6 +206,22 @@ func (r *GormEventRepository) GetEventsByAggregateIDRange(ctx context.Context, a
 	return events, err
 }
 
+// GetEventsByCorrelationID retrieves an account's events whose metadata
+// carries correlationID, ordered by position. The filter expression matches
+// the one migrateCorrelationIndex indexes.
+func (r *GormEventRepository) GetEventsByCorrelationID(ctx context.Context, accountID, correlationID string) ([]GormEventModel, error) {
+	expr, err := correlationExpr(r.db.Name())
+	if err != nil {
+		return nil, err
+	}
+	var events []GormEventModel
+	err = r.db.WithContext(ctx).
+		Where(expr+" = ? AND account_id = ?", correlationID, accountID).
+		Order("position ASC").
+		Find(&events).Error
+	return events, err
+}
+
 // GetEventByID retrieves a single event by its ID.
 func (r *GormEventRepository) GetEventByID(ctx context.Context, eventID string) (*GormEventModel, error) {
 	var event GormEventModel
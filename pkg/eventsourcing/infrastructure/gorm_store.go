@@ -2,68 +2,199 @@ package infrastructure
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
 	"gorm.io/gorm"
 )
 
-var _ domain.EventStore = (*GormEventStore)(nil)
+var (
+	_ domain.EventStore              = (*GormEventStore)(nil)
+	_ domain.AggregateLister         = (*GormEventStore)(nil)
+	_ domain.TransactionalEventStore = (*GormEventStore)(nil)
+	_ domain.AggregateLocker         = (*GormEventStore)(nil)
+	_ domain.CategoryFeedReader      = (*GormEventStore)(nil)
+	_ domain.EventStreamer           = (*GormEventStore)(nil)
+	_ domain.WorkflowTracer          = (*GormEventStore)(nil)
+	_ domain.MultiAggregateReader    = (*GormEventStore)(nil)
+)
+
+// DefaultLockTimeout is how long LockAggregates waits for an aggregate lock
+// unless configured otherwise with WithLockTimeout.
+const DefaultLockTimeout = 10 * time.Second
 
 // GormEventStore is a GORM-based implementation of EventStore.
 type GormEventStore struct {
-	repo *GormEventRepository
-	db   *gorm.DB
+	repo        *GormEventRepository
+	db          *gorm.DB
+	lockTimeout time.Duration
+
+	// group batches writes into shared transactions; see WithGroupCommit.
+	group *groupCommitter
+
+	// tracing enables TraceWorkflow; see WithWorkflowTracing.
+	tracing bool
+
+	// cipher encrypts payloads at rest; see WithEventCipher.
+	cipher EventCipher
+
+	// fieldCipher and encryptedFields encrypt individual payload fields at
+	// rest; see WithFieldCipher.
+	fieldCipher     EventCipher
+	encryptedFields map[string][]string
+
+	// compression compresses payloads at rest; see WithCompression.
+	compression Compression
+
+	// Write transaction isolation and serialization-failure retries; see
+	// WithIsolationLevel.
+	isolation            sql.IsolationLevel
+	serializationRetries int
+
+	// observer receives save and load measurements; see WithObserver.
+	observer EventStoreObserver
+}
+
+// GormEventStoreOption configures a GormEventStore.
+type GormEventStoreOption func(*GormEventStore)
+
+// WithLockTimeout sets how long LockAggregates waits for each aggregate lock
+// before failing with domain.ErrLockTimeout. Zero disables the store's own
+// timeout, leaving only the context deadline.
+func WithLockTimeout(timeout time.Duration) GormEventStoreOption {
+	return func(s *GormEventStore) {
+		s.lockTimeout = timeout
+	}
+}
+
+// ErrWorkflowTracingDisabled is returned by TraceWorkflow on a GormEventStore
+// created without WithWorkflowTracing.
+var ErrWorkflowTracingDisabled = errors.New("workflow tracing is not enabled on this event store")
+
+// WithWorkflowTracing enables TraceWorkflow. NewGormEventStore then creates
+// the expression index on the metadata correlation ID that the trace query
+// needs; stores that never trace are spared its write cost.
+func WithWorkflowTracing() GormEventStoreOption {
+	return func(s *GormEventStore) {
+		s.tracing = true
+	}
 }
 
 // NewGormEventStore creates a new GORM-based event store and auto-migrates the
 // events table, including the global position column used by ReadAfter (and,
 // on Postgres, the xact_id commit-visibility guard).
-func NewGormEventStore(db *gorm.DB) (*GormEventStore, error) {
+func NewGormEventStore(db *gorm.DB, opts ...GormEventStoreOption) (*GormEventStore, error) {
 	if err := db.AutoMigrate(&GormEventModel{}); err != nil {
 		return nil, fmt.Errorf("failed to auto-migrate events table: %w", err)
 	}
 	if err := migrateEventPositions(db); err != nil {
 		return nil, fmt.Errorf("failed to migrate event positions: %w", err)
 	}
-	return &GormEventStore{
-		repo: NewGormEventRepository(db),
-		db:   db,
-	}, nil
+	if err := migrateEventCategories(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate event categories: %w", err)
+	}
+	s := &GormEventStore{
+		repo:                 NewGormEventRepository(db),
+		db:                   db,
+		lockTimeout:          DefaultLockTimeout,
+		serializationRetries: DefaultSerializationRetries,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.tracing {
+		if err := migrateCorrelationIndex(db); err != nil {
+			return nil, fmt.Errorf("failed to create correlation index: %w", err)
+		}
+	}
+	if s.group != nil {
+		go s.group.run()
+	}
+	return s, nil
 }
 
 // Append appends events to the store for the given aggregate.
 // If expectedVersion is not -1, optimistic concurrency control is enforced within a transaction.
+// Inside InTransaction the append joins the surrounding transaction instead.
 func (s *GormEventStore) Append(ctx context.Context, aggregateID string, expectedVersion int, events ...domain.EventEnvelope[any]) error {
 	if len(events) == 0 {
 		return nil
 	}
+	if s.observer == nil {
+		_, err := s.appendEvents(ctx, aggregateID, expectedVersion, events)
+		return err
+	}
+	start := time.Now()
+	size, err := s.appendEvents(ctx, aggregateID, expectedVersion, events)
+	if err != nil {
+		return err
+	}
+	s.observer.OnSave(aggregateID, len(events), size, time.Since(start))
+	return nil
+}
+
+// appendEvents implements Append, returning the stored size of the events'
+// payloads when the store has an observer.
+func (s *GormEventStore) appendEvents(ctx context.Context, aggregateID string, expectedVersion int, events []domain.EventEnvelope[any]) (int, error) {
+	if s.group != nil && s.joinedTx(ctx) == nil {
+		var size int
+		err := s.group.submit(ctx, func(ctx context.Context) error {
+			var err error
+			size, err = s.appendEvents(ctx, aggregateID, expectedVersion, events)
+			return err
+		})
+		return size, err
+	}
 
 	for _, event := range events {
 		if event.AggregateID != aggregateID {
-			return fmt.Errorf("%w: aggregate ID mismatch", domain.ErrInvalidEvent)
+			return 0, fmt.Errorf("%w: aggregate ID mismatch", domain.ErrInvalidEvent)
 		}
 		if event.ID == "" {
-			return fmt.Errorf("%w: event ID is required", domain.ErrInvalidEvent)
+			return 0, fmt.Errorf("%w: event ID is required", domain.ErrInvalidEvent)
 		}
 	}
 
+	// Insert in sequence order so positions follow each aggregate's sequence.
+	events = sortedBySequence(events)
 	models := make([]GormEventModel, len(events))
+	size := 0
 	for i, event := range events {
 		m, err := envelopeToModel(event)
 		if err != nil {
-			return fmt.Errorf("%w: %v", domain.ErrInvalidEvent, err)
+			return 0, fmt.Errorf("%w: %v", domain.ErrInvalidEvent, err)
+		}
+		if err := s.sealFields(event, &m); err != nil {
+			return 0, err
+		}
+		if err := s.sealPayload(&m); err != nil {
+			return 0, err
+		}
+		if s.observer != nil {
+			size += storedPayloadSize(m)
 		}
 		models[i] = m
 	}
 
-	if expectedVersion == -1 {
-		return s.repo.SaveEvents(ctx, models)
+	if tx := s.joinedTx(ctx); tx != nil {
+		return size, s.appendTx(tx, aggregateID, expectedVersion, models)
 	}
 
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return size, s.transaction(ctx, func(tx *gorm.DB) error {
+		return s.appendTx(tx, aggregateID, expectedVersion, models)
+	})
+}
+
+// appendTx checks the expected version (unless it is -1) and inserts models
+// inside tx.
+func (s *GormEventStore) appendTx(tx *gorm.DB, aggregateID string, expectedVersion int, models []GormEventModel) error {
+	if expectedVersion != -1 {
 		var maxSeq *int
 		if err := tx.Model(&GormEventModel{}).
 			Where("aggregate_id = ?", aggregateID).
@@ -81,11 +212,165 @@ func (s *GormEventStore) Append(ctx context.Context, aggregateID string, expecte
 			return fmt.Errorf("%w: expected version %d, got %d",
 				domain.ErrConcurrencyConflict, expectedVersion, currentVersion)
 		}
+	}
+
+	return translateSequenceConflict(aggregateID, s.repo.insertEventsTx(tx, models))
+}
+
+// translateSequenceConflict reports a violation of the unique
+// (aggregate_id, sequence_no) index as ErrConcurrencyConflict. The version
+// check in appendTx reads before it writes, so two writers can both pass it
+// under READ COMMITTED; the index is what actually rejects the loser.
+func translateSequenceConflict(aggregateID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	// Postgres names the index; SQLite names its columns.
+	if strings.Contains(msg, "idx_aggregate_sequence") ||
+		strings.Contains(msg, "UNIQUE constraint failed: events.aggregate_id, events.sequence_no") {
+		return fmt.Errorf("%w: a concurrent writer appended to aggregate %q first: %v",
+			domain.ErrConcurrencyConflict, aggregateID, err)
+	}
+	return err
+}
 
-		return s.repo.insertEventsTx(tx, models)
+type storeTxContextKey struct{}
+
+// storeTx is the transaction InTransaction attaches to its context, tagged
+// with the store that opened it so another store never joins it.
+type storeTx struct {
+	store *GormEventStore
+	tx    *gorm.DB
+}
+
+// InTransaction runs fn inside one database transaction. Appends to this
+// store made with the context passed to fn join the transaction, and fn can
+// write to other tables through TxFromContext — for example to reserve a
+// unique email in a lookup table atomically with the event that claims it.
+// If fn returns an error, or panics, everything rolls back. With
+// WithGroupCommit, fn runs in a transaction shared with other callers but is
+// rolled back on its own.
+func (s *GormEventStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.joinedTx(ctx) != nil {
+		return fn(ctx)
+	}
+	if s.group != nil {
+		return s.group.submit(ctx, fn)
+	}
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, storeTxContextKey{}, storeTx{store: s, tx: tx}))
 	})
 }
 
+// joinedTx returns the transaction this store opened for ctx, if any.
+func (s *GormEventStore) joinedTx(ctx context.Context) *gorm.DB {
+	if st, ok := ctx.Value(storeTxContextKey{}).(storeTx); ok && st.store == s {
+		return st.tx
+	}
+	return nil
+}
+
+// LockAggregates takes an exclusive, transaction-scoped lock on each
+// aggregate's stream inside the transaction opened by InTransaction, so a
+// load-mutate-save of those aggregates is serialized against every other
+// caller locking them. IDs are de-duplicated and locked in sorted order to
+// avoid deadlocks between commands touching overlapping aggregates.
+//
+// On Postgres each stream maps to a transaction-level advisory lock, bounded
+// by the store's lock timeout and the context deadline. SQLite only supports
+// one writer per database, so there the transaction's write lock is taken up
+// front instead (bounded by the connection's busy_timeout), which serializes
+// the whole database rather than a single stream.
+func (s *GormEventStore) LockAggregates(ctx context.Context, aggregateIDs ...string) error {
+	tx := s.joinedTx(ctx)
+	if tx == nil {
+		return errors.New("LockAggregates must be called inside InTransaction")
+	}
+	ids := slices.Clone(aggregateIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if len(ids) == 0 {
+		return nil
+	}
+	tx = tx.WithContext(ctx)
+
+	switch s.db.Name() {
+	case "postgres":
+		return s.lockPostgres(ctx, tx, ids)
+	case "sqlite":
+		if err := tx.Exec("UPDATE events SET id = id WHERE 0 = 1").Error; err != nil {
+			if strings.Contains(err.Error(), "database is locked") {
+				return fmt.Errorf("%w: %v", domain.ErrLockTimeout, err)
+			}
+			return fmt.Errorf("failed to lock aggregates: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("aggregate locking supports postgres and sqlite, got dialect %q", s.db.Name())
+	}
+}
+
+// lockPostgres acquires an advisory lock per aggregate, with lock_timeout
+// set only for the duration of the lock statements and restored afterwards
+// so the rest of the transaction keeps the session's setting.
+func (s *GormEventStore) lockPostgres(ctx context.Context, tx *gorm.DB, ids []string) error {
+	if s.lockTimeout > 0 {
+		var previous string
+		if err := tx.Raw("SELECT current_setting('lock_timeout')").Scan(&previous).Error; err != nil {
+			return fmt.Errorf("failed to read lock_timeout: %w", err)
+		}
+		timeout := fmt.Sprintf("%dms", s.lockTimeout.Milliseconds())
+		if err := tx.Exec("SELECT set_config('lock_timeout', ?, true)", timeout).Error; err != nil {
+			return fmt.Errorf("failed to set lock_timeout: %w", err)
+		}
+		defer tx.Exec("SELECT set_config('lock_timeout', ?, true)", previous)
+	}
+
+	for _, id := range ids {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", id).Error; err != nil {
+			if isLockTimeout(ctx, err) {
+				return fmt.Errorf("%w: aggregate %s: %v", domain.ErrLockTimeout, id, err)
+			}
+			return fmt.Errorf("failed to lock aggregate %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// isLockTimeout reports whether err is Postgres lock_not_available (raised
+// when lock_timeout expires) or the context deadline expiring mid-wait.
+func isLockTimeout(ctx context.Context, err error) bool {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && pgErr.SQLState() == "55P03" {
+		return true
+	}
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// TxFromContext returns the transaction attached by
+// GormEventStore.InTransaction, or nil outside one. Work written through it
+// commits or rolls back together with the events appended in the same
+// transaction.
+func TxFromContext(ctx context.Context) *gorm.DB {
+	st, _ := ctx.Value(storeTxContextKey{}).(storeTx)
+	return st.tx
+}
+
+// GormTxHook adapts fn to run against the GormEventStore transaction a unit
+// of work commits in (see application.WithTxHook). It fails if the context
+// carries no such transaction, so a misconfigured store can never silently
+// run the hook outside the event save.
+func GormTxHook(fn func(tx *gorm.DB) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		tx := TxFromContext(ctx)
+		if tx == nil {
+			return errors.New("no event store transaction in context")
+		}
+		return fn(tx)
+	}
+}
+
 // ReadAfter returns committed events with Position > afterPosition across all
 // aggregates, ordered by Position ascending, up to limit (limit <= 0 means no
 // limit). On Postgres, rows whose inserting transaction may still be in
@@ -96,25 +381,77 @@ func (s *GormEventStore) ReadAfter(ctx context.Context, afterPosition int64, lim
 	if err != nil {
 		return nil, err
 	}
-	return modelsToEnvelopes(models), nil
+	return s.toEnvelopes(models)
+}
+
+// ReadAfterInCategories is ReadAfter restricted to events whose category is
+// in categories, filtered in the query; an empty slice means every category.
+func (s *GormEventStore) ReadAfterInCategories(ctx context.Context, afterPosition int64, limit int, categories []string) ([]domain.EventEnvelope[any], error) {
+	models, err := s.repo.GetEventsAfterPositionInCategories(ctx, afterPosition, limit, categories)
+	if err != nil {
+		return nil, err
+	}
+	return s.toEnvelopes(models)
 }
 
 // GetEvents retrieves all events for the given aggregate ID.
 func (s *GormEventStore) GetEvents(ctx context.Context, aggregateID string) ([]domain.EventEnvelope[any], error) {
-	models, err := s.repo.GetEventsByAggregateID(ctx, aggregateID)
+	return s.loadEvents(aggregateID, func() ([]GormEventModel, error) {
+		return s.repo.GetEventsByAggregateID(ctx, aggregateID)
+	})
+}
+
+// GetEventsForAggregates implements domain.MultiAggregateReader with a
+// single IN query (chunked for very large batches) instead of one query per
+// aggregate.
+func (s *GormEventStore) GetEventsForAggregates(ctx context.Context, aggregateIDs []string) (map[string][]domain.EventEnvelope[any], error) {
+	models, err := s.repo.GetEventsByAggregateIDs(ctx, aggregateIDs)
 	if err != nil {
 		return nil, err
 	}
-	return modelsToEnvelopes(models), nil
+	envelopes, err := s.toEnvelopes(models)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]domain.EventEnvelope[any])
+	for _, envelope := range envelopes {
+		result[envelope.AggregateID] = append(result[envelope.AggregateID], envelope)
+	}
+	return result, nil
+}
+
+// StreamEvents implements domain.EventStreamer, scanning the aggregate's rows
+// one at a time so memory stays flat however long the stream is. Cancelling
+// ctx stops the scan and closes the underlying rows.
+func (s *GormEventStore) StreamEvents(ctx context.Context, aggregateID string) (<-chan domain.EventEnvelope[any], <-chan error) {
+	events := make(chan domain.EventEnvelope[any])
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		err := s.repo.ScanEventsByAggregateID(ctx, aggregateID, func(m GormEventModel) error {
+			if err := s.openPayload(&m); err != nil {
+				return err
+			}
+			select {
+			case events <- modelToEnvelope(m):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return events, errs
 }
 
 // GetEventsFromVersion retrieves events starting from the specified version.
 func (s *GormEventStore) GetEventsFromVersion(ctx context.Context, aggregateID string, fromVersion int) ([]domain.EventEnvelope[any], error) {
-	models, err := s.repo.GetEventsByAggregateIDRange(ctx, aggregateID, fromVersion, -1)
-	if err != nil {
-		return nil, err
-	}
-	return modelsToEnvelopes(models), nil
+	return s.loadEvents(aggregateID, func() ([]GormEventModel, error) {
+		return s.repo.GetEventsByAggregateIDRange(ctx, aggregateID, fromVersion, -1)
+	})
 }
 
 // GetEventsRange retrieves events within a version range.
@@ -123,11 +460,9 @@ func (s *GormEventStore) GetEventsRange(ctx context.Context, aggregateID string,
 	if fromVersion == -1 {
 		fromVersion = 1
 	}
-	models, err := s.repo.GetEventsByAggregateIDRange(ctx, aggregateID, fromVersion, toVersion)
-	if err != nil {
-		return nil, err
-	}
-	return modelsToEnvelopes(models), nil
+	return s.loadEvents(aggregateID, func() ([]GormEventModel, error) {
+		return s.repo.GetEventsByAggregateIDRange(ctx, aggregateID, fromVersion, toVersion)
+	})
 }
 
 // GetEventByID retrieves a specific event by its ID.
@@ -139,6 +474,9 @@ func (s *GormEventStore) GetEventByID(ctx context.Context, eventID string) (doma
 		}
 		return domain.EventEnvelope[any]{}, err
 	}
+	if err := s.openPayload(model); err != nil {
+		return domain.EventEnvelope[any]{}, err
+	}
 	return modelToEnvelope(*model), nil
 }
 
@@ -152,7 +490,7 @@ func (s *GormEventStore) GetEventsByTransactionID(ctx context.Context, transacti
 	if err != nil {
 		return nil, err
 	}
-	return modelsToEnvelopes(models), nil
+	return s.toEnvelopes(models)
 }
 
 // GetCurrentVersion returns the current version for the aggregate.
@@ -160,6 +498,36 @@ func (s *GormEventStore) GetCurrentVersion(ctx context.Context, aggregateID stri
 	return s.repo.GetCurrentVersion(ctx, aggregateID)
 }
 
+// ListAggregateIDs returns a page of the aggregate IDs with events in
+// category, in ascending order. The query is served by the (category,
+// aggregate_id) and (account_id, category, aggregate_id) indexes, so paging
+// through a large store never scans the events table.
+func (s *GormEventStore) ListAggregateIDs(ctx context.Context, category string, opts domain.ListAggregateIDsOptions) ([]string, error) {
+	ids, err := s.repo.ListAggregateIDs(ctx, category, opts.AccountID, opts.After, opts.PageSize())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aggregate IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// TraceWorkflow returns the account's events with the given correlation ID in
+// position order. It requires WithWorkflowTracing, whose index serves the
+// query; without it TraceWorkflow fails with ErrWorkflowTracingDisabled
+// rather than scan the events table.
+func (s *GormEventStore) TraceWorkflow(ctx context.Context, accountID, correlationID string) ([]domain.EventEnvelope[any], error) {
+	if !s.tracing {
+		return nil, ErrWorkflowTracingDisabled
+	}
+	if correlationID == "" {
+		return nil, fmt.Errorf("%w: correlation ID must not be empty", domain.ErrInvalidEvent)
+	}
+	models, err := s.repo.GetEventsByCorrelationID(ctx, accountID, correlationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trace workflow %q: %w", correlationID, err)
+	}
+	return s.toEnvelopes(models)
+}
+
 // HeadPosition returns the highest position ReadAfter could currently
 // deliver. On Postgres the same commit-visibility guard as ReadAfter applies,
 // so lag measured against it reaches zero when a consumer is caught up.
@@ -169,6 +537,9 @@ func (s *GormEventStore) HeadPosition(ctx context.Context) (int64, error) {
 
 // Close closes the GORM event store (no-op since GORM connection is managed externally).
 func (s *GormEventStore) Close() error {
+	if s.group != nil {
+		s.group.close()
+	}
 	return nil
 }
 
@@ -189,6 +560,8 @@ func envelopeToModel(env domain.EventEnvelope[any]) (GormEventModel, error) {
 		EventType:     env.EventType,
 		SequenceNo:    env.SequenceNo,
 		TransactionID: env.TransactionID,
+		Category:      domain.EventCategory(env.EventType),
+		AccountID:     domain.AccountID(env),
 		Position:      env.Position,
 		Payload:       payload,
 		Metadata:      metadata,
@@ -239,10 +612,15 @@ func modelToEnvelope(m GormEventModel) domain.EventEnvelope[any] {
 	}
 }
 
-func modelsToEnvelopes(models []GormEventModel) []domain.EventEnvelope[any] {
+// toEnvelopes converts models read from the events table, decrypting their
+// payloads when a cipher is configured.
+func (s *GormEventStore) toEnvelopes(models []GormEventModel) ([]domain.EventEnvelope[any], error) {
 	envelopes := make([]domain.EventEnvelope[any], len(models))
 	for i, m := range models {
+		if err := s.openPayload(&m); err != nil {
+			return nil, err
+		}
 		envelopes[i] = modelToEnvelope(m)
 	}
-	return envelopes
+	return envelopes, nil
 }
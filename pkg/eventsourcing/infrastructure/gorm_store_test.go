@@ -2,7 +2,11 @@ package infrastructure_test
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,7 +17,7 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-func newTestGormDB(t *testing.T) *gorm.DB {
+func newTestGormDB(t testing.TB) *gorm.DB {
 	t.Helper()
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
@@ -414,3 +418,579 @@ func TestGormStore_GetEventsRange(t *testing.T) {
 		}
 	})
 }
+
+// emailReservation is a non-event-sourced lookup table enforcing unique
+// emails, written atomically with the events that claim them.
+type emailReservation struct {
+	Email  string `gorm:"primaryKey"`
+	UserID string
+}
+
+func TestGormStore_InTransaction(t *testing.T) {
+	t.Parallel()
+
+	db := newTestGormDB(t)
+	store, err := infrastructure.NewGormEventStore(db)
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	if err := db.AutoMigrate(&emailReservation{}); err != nil {
+		t.Fatalf("failed to migrate reservations: %v", err)
+	}
+	ctx := context.Background()
+
+	reserve := func(email, userID string) func(ctx context.Context) error {
+		return infrastructure.GormTxHook(func(tx *gorm.DB) error {
+			return tx.Create(&emailReservation{Email: email, UserID: userID}).Error
+		})
+	}
+	register := func(userID, email string) error {
+		return store.InTransaction(ctx, func(ctx context.Context) error {
+			if err := store.Append(ctx, userID, 0, createTestEvent(userID, userID+"-registered", "user.registered", 1)); err != nil {
+				return err
+			}
+			return reserve(email, userID)(ctx)
+		})
+	}
+
+	if err := register("user-1", "a@example.com"); err != nil {
+		t.Fatalf("first registration failed: %v", err)
+	}
+	if err := register("user-2", "a@example.com"); err == nil {
+		t.Fatal("expected duplicate email to fail the transaction")
+	}
+
+	// The failed reservation rolled back user-2's event too.
+	if version, _ := store.GetCurrentVersion(ctx, "user-2"); version != 0 {
+		t.Errorf("expected user-2 event rolled back, got version %d", version)
+	}
+	if version, _ := store.GetCurrentVersion(ctx, "user-1"); version != 1 {
+		t.Errorf("expected user-1 event committed, got version %d", version)
+	}
+
+	if err := reserve("b@example.com", "user-3")(ctx); err == nil {
+		t.Error("expected GormTxHook to fail outside a store transaction")
+	}
+}
+
+func TestGormStore_SequenceConflictIsConcurrencyConflict(t *testing.T) {
+	t.Parallel()
+
+	store := setupGormStore(t)
+	ctx := context.Background()
+	if err := store.Append(ctx, "agg-1", 0, createTestEvent("agg-1", "ev-1", "test.created", 1)); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	// An unchecked append that collides on the sequence number is rejected
+	// by the unique index, exactly as the loser of a race would be.
+	err := store.Append(ctx, "agg-1", -1, createTestEvent("agg-1", "ev-2", "test.updated", 1))
+	if !errors.Is(err, domain.ErrConcurrencyConflict) {
+		t.Fatalf("expected ErrConcurrencyConflict, got %v", err)
+	}
+
+	// A duplicate event ID is a different failure.
+	err = store.Append(ctx, "agg-1", 1, createTestEvent("agg-1", "ev-1", "test.updated", 2))
+	if err == nil || errors.Is(err, domain.ErrConcurrencyConflict) {
+		t.Fatalf("expected a non-concurrency error for a duplicate event ID, got %v", err)
+	}
+}
+
+func TestGormStore_GroupCommit(t *testing.T) {
+	t.Parallel()
+
+	store, err := infrastructure.NewGormEventStore(newTestGormDB(t), infrastructure.WithGroupCommit(20*time.Millisecond, 8))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	ctx := context.Background()
+
+	// A burst of appends to distinct aggregates, plus one stale append that
+	// conflicts; only the conflicting caller fails.
+	if err := store.Append(ctx, "agg-0", 0, createTestEvent("agg-0", "ev-0", "test.created", 1)); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+	const writers = 10
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("agg-%d", i+1)
+			errs[i] = store.Append(ctx, id, 0, createTestEvent(id, id+"-created", "test.created", 1))
+		}(i)
+	}
+	conflict := store.Append(ctx, "agg-0", 0, createTestEvent("agg-0", "ev-stale", "test.created", 1))
+	wg.Wait()
+
+	if !errors.Is(conflict, domain.ErrConcurrencyConflict) {
+		t.Errorf("expected ErrConcurrencyConflict for the stale append, got %v", conflict)
+	}
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("append %d failed: %v", i+1, err)
+		}
+		// Acknowledged means committed: the event is readable immediately.
+		if version, _ := store.GetCurrentVersion(ctx, fmt.Sprintf("agg-%d", i+1)); version != 1 {
+			t.Errorf("agg-%d version = %d, want 1", i+1, version)
+		}
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	err = store.Append(ctx, "agg-99", 0, createTestEvent("agg-99", "ev-99", "test.created", 1))
+	if !errors.Is(err, infrastructure.ErrStoreClosed) {
+		t.Errorf("expected ErrStoreClosed after Close, got %v", err)
+	}
+}
+
+func TestGormStore_StreamEvents(t *testing.T) {
+	t.Parallel()
+
+	store, err := infrastructure.NewGormEventStore(newTestGormDB(t))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		if err := store.Append(ctx, "agg-1", i-1, createTestEvent("agg-1", fmt.Sprintf("ev-%d", i), "test.recorded", i)); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	events, errs := store.StreamEvents(ctx, "agg-1")
+	want := 1
+	for event := range events {
+		if event.SequenceNo != want || event.ID != fmt.Sprintf("ev-%d", want) {
+			t.Errorf("event %d = %s (sequence %d)", want, event.ID, event.SequenceNo)
+		}
+		want++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("stream error: %v", err)
+	}
+	if want != 5 {
+		t.Errorf("streamed %d events, want 4", want-1)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	events, errs = store.StreamEvents(cctx, "agg-1")
+	<-events
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled after cancelling, got %v", err)
+	}
+}
+
+func TestGormStore_ForgetAggregate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []infrastructure.ForgetOption
+		wantRows int64
+	}{
+		{name: "soft mode tombstones in place", wantRows: 3},
+		{name: "hard delete removes the stream", opts: []infrastructure.ForgetOption{infrastructure.WithHardDelete()}, wantRows: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := newTestGormDB(t)
+			store, err := infrastructure.NewGormEventStore(db)
+			if err != nil {
+				t.Fatalf("failed to create gorm event store: %v", err)
+			}
+			ctx := context.Background()
+			for i := 1; i <= 3; i++ {
+				event := createTestEvent("user-1", fmt.Sprintf("ev-%d", i), "user.updated", i)
+				event.Payload = map[string]any{"email": "ada@example.com"}
+				event.Metadata[domain.MetadataActorID] = "user-1"
+				if err := store.Append(ctx, "user-1", i-1, event); err != nil {
+					t.Fatalf("append %d: %v", i, err)
+				}
+			}
+			if err := store.Append(ctx, "user-2", 0, createTestEvent("user-2", "other", "user.updated", 1)); err != nil {
+				t.Fatalf("append other: %v", err)
+			}
+
+			if err := store.ForgetAggregate(ctx, "user-1", tt.opts...); err != nil {
+				t.Fatalf("ForgetAggregate() error = %v", err)
+			}
+
+			var rows int64
+			if err := db.Model(&infrastructure.GormEventModel{}).Where("aggregate_id = ?", "user-1").Count(&rows).Error; err != nil {
+				t.Fatalf("count failed: %v", err)
+			}
+			if rows != tt.wantRows {
+				t.Fatalf("rows = %d, want %d", rows, tt.wantRows)
+			}
+			events, err := store.GetEvents(ctx, "user-1")
+			if err != nil {
+				t.Fatalf("GetEvents() error = %v", err)
+			}
+			for i, event := range events {
+				if event.SequenceNo != i+1 || event.EventType != "user.updated" {
+					t.Errorf("event %d: sequence %d type %s, want an intact stream", i, event.SequenceNo, event.EventType)
+				}
+				if !domain.IsForgotten(event) || event.Payload.(map[string]any)["email"] != nil {
+					t.Errorf("event %d payload not tombstoned: %v", i, event.Payload)
+				}
+				if _, ok := event.Metadata[domain.MetadataActorID]; ok {
+					t.Errorf("event %d still names its actor", i)
+				}
+			}
+			if other, _ := store.GetEvents(ctx, "user-2"); len(other) != 1 || domain.IsForgotten(other[0]) {
+				t.Error("expected other aggregates untouched")
+			}
+		})
+	}
+}
+
+func TestGormStore_EventCipher(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	cipher, err := infrastructure.NewAESGCMCipher(key)
+	if err != nil {
+		t.Fatalf("NewAESGCMCipher() error = %v", err)
+	}
+	db := newTestGormDB(t)
+	ctx := context.Background()
+
+	// Written before the cipher is configured, and read back as-is after.
+	plain, err := infrastructure.NewGormEventStore(db)
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	if err := plain.Append(ctx, "user-1", 0, createTestEvent("user-1", "ev-1", "user.created", 1)); err != nil {
+		t.Fatalf("append plaintext: %v", err)
+	}
+
+	store, err := infrastructure.NewGormEventStore(db, infrastructure.WithEventCipher(cipher))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	event := createTestEvent("user-1", "ev-2", "user.updated", 2)
+	event.Payload = map[string]any{"email": "ada@example.com"}
+	event.Metadata[domain.MetadataAccountID] = "acct-1"
+	if err := store.Append(ctx, "user-1", 1, event); err != nil {
+		t.Fatalf("append encrypted: %v", err)
+	}
+
+	var row infrastructure.GormEventModel
+	if err := db.Where("id = ?", "ev-2").First(&row).Error; err != nil {
+		t.Fatalf("load row: %v", err)
+	}
+	if _, ok := row.Payload["email"]; ok {
+		t.Errorf("payload stored in the clear: %v", row.Payload)
+	}
+	if row.EventType != "user.updated" || row.AccountID != "acct-1" || row.Metadata[domain.MetadataAccountID] != "acct-1" {
+		t.Errorf("row = %+v, want event type and metadata unencrypted", row)
+	}
+
+	events, err := store.GetEvents(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if got := events[0].Payload.(map[string]any)["test"]; got != "data" {
+		t.Errorf("plaintext payload = %v, want it read as-is", events[0].Payload)
+	}
+	if got := events[1].Payload.(map[string]any)["email"]; got != "ada@example.com" {
+		t.Errorf("decrypted payload = %v, want the original", events[1].Payload)
+	}
+	if fetched, err := store.GetEventByID(ctx, "ev-2"); err != nil || fetched.Payload.(map[string]any)["email"] != "ada@example.com" {
+		t.Errorf("GetEventByID() = %v, %v, want the decrypted payload", fetched.Payload, err)
+	}
+
+	otherKey, _ := infrastructure.NewAESGCMCipher([]byte("fedcba9876543210fedcba9876543210"))
+	wrongKey, err := infrastructure.NewGormEventStore(db, infrastructure.WithEventCipher(otherKey))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	if _, err := wrongKey.GetEvents(ctx, "user-1"); err == nil {
+		t.Error("expected reading with the wrong key to fail")
+	}
+
+	// Without a cipher the sealed payload must not be handed out as if it
+	// were the payload.
+	if _, err := plain.GetEvents(ctx, "user-1"); !errors.Is(err, infrastructure.ErrCipherNotConfigured) {
+		t.Errorf("GetEvents() without a cipher error = %v, want ErrCipherNotConfigured", err)
+	}
+
+	// A ciphertext copied into another event's row does not open there.
+	if err := store.Append(ctx, "user-1", 2, createTestEvent("user-1", "ev-3", "user.updated", 3)); err != nil {
+		t.Fatalf("append encrypted: %v", err)
+	}
+	if err := db.Model(&infrastructure.GormEventModel{}).Where("id = ?", "ev-3").Update("payload", row.Payload).Error; err != nil {
+		t.Fatalf("copy payload: %v", err)
+	}
+	if _, err := store.GetEventByID(ctx, "ev-3"); err == nil {
+		t.Error("expected a payload moved to another event to fail to decrypt")
+	}
+}
+
+func TestNewAESGCMCipher(t *testing.T) {
+	t.Parallel()
+
+	if _, err := infrastructure.NewAESGCMCipher(make([]byte, 16)); err == nil {
+		t.Error("expected a 16-byte key to be rejected")
+	}
+	c, err := infrastructure.NewAESGCMCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewAESGCMCipher() error = %v", err)
+	}
+	a, _ := c.Encrypt([]byte("secret"), []byte("ev-1"))
+	b, _ := c.Encrypt([]byte("secret"), []byte("ev-1"))
+	if string(a) == string(b) {
+		t.Error("expected a fresh nonce per encryption")
+	}
+	if got, err := c.Decrypt(a, []byte("ev-1")); err != nil || string(got) != "secret" {
+		t.Errorf("Decrypt() = %q, %v, want %q", got, err, "secret")
+	}
+	if _, err := c.Decrypt(a, []byte("ev-2")); err == nil {
+		t.Error("expected Decrypt with other associated data to fail")
+	}
+	if _, err := c.Decrypt([]byte("short"), nil); !errors.Is(err, infrastructure.ErrCiphertextTooShort) {
+		t.Errorf("Decrypt(short) error = %v, want ErrCiphertextTooShort", err)
+	}
+}
+
+// serializationFailure mimics a Postgres serialization failure.
+type serializationFailure struct{}
+
+func (serializationFailure) Error() string {
+	return "could not serialize access due to concurrent update"
+}
+func (serializationFailure) SQLState() string { return "40001" }
+
+func TestGormStore_SerializationRetries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      []infrastructure.GormEventStoreOption
+		failures  int
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "retried until it commits", failures: 2, wantCalls: 3},
+		{name: "bounded by the default", failures: 100, wantCalls: infrastructure.DefaultSerializationRetries + 1, wantErr: domain.ErrConcurrencyConflict},
+		{name: "disabled", opts: []infrastructure.GormEventStoreOption{infrastructure.WithSerializationRetries(0)}, failures: 1, wantCalls: 1, wantErr: domain.ErrConcurrencyConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]infrastructure.GormEventStoreOption{infrastructure.WithIsolationLevel(sql.LevelSerializable)}, tt.opts...)
+			store, err := infrastructure.NewGormEventStore(newTestGormDB(t), opts...)
+			if err != nil {
+				t.Fatalf("failed to create gorm event store: %v", err)
+			}
+			ctx := context.Background()
+
+			calls := 0
+			err = store.InTransaction(ctx, func(ctx context.Context) error {
+				calls++
+				if err := store.Append(ctx, "ledger-1", 0, createTestEvent("ledger-1", "ev-1", "ledger.opened", 1)); err != nil {
+					return err
+				}
+				if calls <= tt.failures {
+					return fmt.Errorf("append posting: %w", serializationFailure{})
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InTransaction() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+
+			wantVersion := 1
+			if tt.wantErr != nil {
+				wantVersion = 0
+			}
+			if version, err := store.GetCurrentVersion(ctx, "ledger-1"); err != nil || version != wantVersion {
+				t.Errorf("GetCurrentVersion() = %d, %v, want %d", version, err, wantVersion)
+			}
+		})
+	}
+}
+
+// customerRegistered is a payload with one field marked for field-level
+// encryption.
+type customerRegistered struct {
+	Name string `json:"name"`
+	SSN  string `json:"ssn" pericarp:"encrypted"`
+}
+
+func TestGormStore_FieldCipher(t *testing.T) {
+	t.Parallel()
+
+	oldKey, _ := infrastructure.NewAESGCMCipher([]byte("0123456789abcdef0123456789abcdef"))
+	newKey, _ := infrastructure.NewAESGCMCipher([]byte("fedcba9876543210fedcba9876543210"))
+	db := newTestGormDB(t)
+	ctx := context.Background()
+
+	// Written in the clear before the field was marked encrypted.
+	plain, err := infrastructure.NewGormEventStore(db)
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	legacy := createTestEvent("customer-1", "ev-1", "customer.registered", 1)
+	legacy.Payload = customerRegistered{Name: "Ada", SSN: "123-45-6789"}
+	if err := plain.Append(ctx, "customer-1", 0, legacy); err != nil {
+		t.Fatalf("append plaintext: %v", err)
+	}
+
+	store, err := infrastructure.NewGormEventStore(db,
+		infrastructure.WithFieldCipher(oldKey),
+		infrastructure.WithEncryptedFields("customer.moved", "address"))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	registered := createTestEvent("customer-1", "ev-2", "customer.registered", 2)
+	registered.Payload = customerRegistered{Name: "Ada", SSN: "987-65-4321"}
+	moved := createTestEvent("customer-1", "ev-3", "customer.moved", 3)
+	moved.Payload = map[string]any{"address": "1 Main St", "city": "Kingston"}
+	if err := store.Append(ctx, "customer-1", 1, registered, moved); err != nil {
+		t.Fatalf("append encrypted: %v", err)
+	}
+	if moved.Payload.(map[string]any)["address"] != "1 Main St" {
+		t.Error("expected the caller's map payload left unmodified")
+	}
+
+	// Unmarked fields stay in the clear and queryable.
+	var rows []infrastructure.GormEventModel
+	if err := db.Order("position").Find(&rows).Error; err != nil {
+		t.Fatalf("load rows: %v", err)
+	}
+	if rows[1].Payload["name"] != "Ada" || rows[2].Payload["city"] != "Kingston" {
+		t.Errorf("payloads = %v, %v, want unmarked fields in the clear", rows[1].Payload, rows[2].Payload)
+	}
+	if rows[1].Payload["ssn"] == "987-65-4321" || rows[2].Payload["address"] == "1 Main St" {
+		t.Errorf("payloads = %v, %v, want marked fields encrypted", rows[1].Payload, rows[2].Payload)
+	}
+
+	want := []map[string]any{
+		{"name": "Ada", "ssn": "123-45-6789"},
+		{"name": "Ada", "ssn": "987-65-4321"},
+		{"address": "1 Main St", "city": "Kingston"},
+	}
+	assertPayloads := func(store *infrastructure.GormEventStore) {
+		t.Helper()
+		events, err := store.GetEvents(ctx, "customer-1")
+		if err != nil {
+			t.Fatalf("GetEvents() error = %v", err)
+		}
+		for i, event := range events {
+			if !reflect.DeepEqual(event.Payload, want[i]) {
+				t.Errorf("event %d payload = %v, want %v", i, event.Payload, want[i])
+			}
+		}
+	}
+	assertPayloads(store)
+
+	// Without a field cipher the sealed fields must not be handed out as if
+	// they were the values.
+	if _, err := plain.GetEvents(ctx, "customer-1"); !errors.Is(err, infrastructure.ErrCipherNotConfigured) {
+		t.Errorf("GetEvents() without a field cipher error = %v, want ErrCipherNotConfigured", err)
+	}
+
+	// Rotate to the new key and rewrap; a second run has nothing left to do.
+	rotated, err := infrastructure.NewGormEventStore(db, infrastructure.WithFieldCipher(newKey))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	if _, err := rotated.GetEvents(ctx, "customer-1"); err == nil {
+		t.Error("expected reading with the new key before rewrapping to fail")
+	}
+	if n, err := rotated.RewrapFields(ctx, oldKey); err != nil || n != 2 {
+		t.Fatalf("RewrapFields() = %d, %v, want 2 events rewritten", n, err)
+	}
+	if n, err := rotated.RewrapFields(ctx, oldKey); err != nil || n != 0 {
+		t.Errorf("second RewrapFields() = %d, %v, want 0", n, err)
+	}
+	assertPayloads(rotated)
+}
+
+// recordingObserver records the calls a GormEventStore makes to its
+// EventStoreObserver.
+type recordingObserver struct {
+	mu    sync.Mutex
+	saves []string
+	loads []string
+}
+
+func (o *recordingObserver) OnSave(aggregateID string, count, bytes int, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.saves = append(o.saves, fmt.Sprintf("%s:%d:%d", aggregateID, count, bytes))
+}
+
+func (o *recordingObserver) OnLoad(aggregateID string, count int, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.loads = append(o.loads, fmt.Sprintf("%s:%d", aggregateID, count))
+}
+
+func TestGormStore_Observer(t *testing.T) {
+	t.Parallel()
+
+	// Each test event's payload is stored as {"test":"data"}, 15 bytes.
+	tests := []struct {
+		name string
+		opts []infrastructure.GormEventStoreOption
+	}{
+		{name: "direct writes"},
+		{name: "group commit", opts: []infrastructure.GormEventStoreOption{infrastructure.WithGroupCommit(5*time.Millisecond, 8)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			observer := &recordingObserver{}
+			store, err := infrastructure.NewGormEventStore(newTestGormDB(t),
+				append(tt.opts, infrastructure.WithObserver(observer))...)
+			if err != nil {
+				t.Fatalf("failed to create gorm event store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			if err := store.Append(ctx, "agg-1", 0,
+				createTestEvent("agg-1", "ev-1", "test.created", 1),
+				createTestEvent("agg-1", "ev-2", "test.updated", 2),
+			); err != nil {
+				t.Fatalf("Append failed: %v", err)
+			}
+			if err := store.Append(ctx, "agg-1", 0, createTestEvent("agg-1", "ev-3", "test.updated", 1)); !errors.Is(err, domain.ErrConcurrencyConflict) {
+				t.Fatalf("Append error = %v, want ErrConcurrencyConflict", err)
+			}
+			if _, err := store.GetEvents(ctx, "agg-1"); err != nil {
+				t.Fatalf("GetEvents failed: %v", err)
+			}
+			if _, err := store.GetEventsFromVersion(ctx, "agg-1", 2); err != nil {
+				t.Fatalf("GetEventsFromVersion failed: %v", err)
+			}
+			if _, err := store.GetEventsRange(ctx, "agg-2", -1, -1); err != nil {
+				t.Fatalf("GetEventsRange failed: %v", err)
+			}
+
+			if want := []string{"agg-1:2:30"}; !reflect.DeepEqual(observer.saves, want) {
+				t.Errorf("saves = %v, want %v", observer.saves, want)
+			}
+			if want := []string{"agg-1:2", "agg-1:1", "agg-2:0"}; !reflect.DeepEqual(observer.loads, want) {
+				t.Errorf("loads = %v, want %v", observer.loads, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// DefaultKafkaConsumerGroup is the consumer group of a KafkaEventDispatcher
+// created without WithKafkaConsumerGroup.
+const DefaultKafkaConsumerGroup = "pericarp"
+
+// kafkaEventTypeHeader carries the event type on every published message, so
+// consumers can filter without decoding the value.
+const kafkaEventTypeHeader = "event_type"
+
+// KafkaEventDispatcherOption configures a KafkaEventDispatcher.
+type KafkaEventDispatcherOption func(*KafkaEventDispatcher)
+
+// WithKafkaConsumerGroup sets the consumer group Run joins. Replicas of one
+// service share a group and split the topics' partitions between them; each
+// service that must see every event needs a group of its own.
+func WithKafkaConsumerGroup(groupID string) KafkaEventDispatcherOption {
+	return func(k *KafkaEventDispatcher) {
+		k.groupID = groupID
+	}
+}
+
+// WithKafkaTopicPrefix prefixes every topic name, such as "prod." to keep
+// environments sharing a cluster apart.
+func WithKafkaTopicPrefix(prefix string) KafkaEventDispatcherOption {
+	return func(k *KafkaEventDispatcher) {
+		k.topicPrefix = prefix
+	}
+}
+
+// WithKafkaLogger sets the logger Run reports messages it cannot decode to
+// (default slog.Default()).
+func WithKafkaLogger(logger *slog.Logger) KafkaEventDispatcherOption {
+	return func(k *KafkaEventDispatcher) {
+		if logger != nil {
+			k.logger = logger
+		}
+	}
+}
+
+// WithKafkaUndecodableHandler sets a function Run passes every message it
+// cannot decode as an event, with the decoding error, such as one that
+// publishes the message to a dead-letter topic. The message is committed once
+// fn returns nil; an error stops Run without committing it, so it is read
+// again when Run is started again. Without a handler, such messages are
+// logged and committed.
+func WithKafkaUndecodableHandler(fn func(ctx context.Context, message kafka.Message, err error) error) KafkaEventDispatcherOption {
+	return func(k *KafkaEventDispatcher) {
+		k.undecodable = fn
+	}
+}
+
+// KafkaEventDispatcher carries events between services over Kafka. Dispatch
+// publishes an event to the topic named for its category, the part of the
+// event type before the first dot, so "user.created" goes to topic "user".
+// Run consumes those topics in another process and dispatches each event to
+// the handlers of a local EventDispatcher, which are registered on it as
+// usual with Subscribe.
+//
+// Messages are keyed by aggregate ID, so each aggregate's events land on one
+// partition and are consumed in order. The value is the envelope as JSON;
+// consumed payloads are decoded into map[string]any, as events read back from
+// an event store are, so handlers subscribe with map[string]any or any.
+//
+// Dispatch publishes when it is called, so an event dispatched after its
+// commit is lost if the process stops before Dispatch returns. For
+// at-least-once publishing, drive Dispatch from a subscriptions.Subscriber on
+// the event store's feed, or register it with SubscribeWildcard on the
+// dispatcher an application.OutboxRelay delivers to.
+type KafkaEventDispatcher struct {
+	brokers     []string
+	local       *domain.EventDispatcher
+	groupID     string
+	topicPrefix string
+	writer      *kafka.Writer
+	logger      *slog.Logger
+	undecodable func(ctx context.Context, message kafka.Message, err error) error
+}
+
+// kafkaReader is the part of a kafka.Reader that Run consumes through.
+type kafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, messages ...kafka.Message) error
+}
+
+// NewKafkaEventDispatcher creates a dispatcher on the given brokers that
+// delivers consumed events to local.
+func NewKafkaEventDispatcher(brokers []string, local *domain.EventDispatcher, opts ...KafkaEventDispatcherOption) *KafkaEventDispatcher {
+	if len(brokers) == 0 {
+		panic("kafka brokers must not be empty")
+	}
+	if local == nil {
+		panic("local event dispatcher must not be nil")
+	}
+	k := &KafkaEventDispatcher{
+		brokers: slices.Clone(brokers),
+		local:   local,
+		groupID: DefaultKafkaConsumerGroup,
+		logger:  slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	k.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(k.brokers...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+	}
+	return k
+}
+
+// Dispatch publishes envelope to its category's topic and returns once the
+// brokers have acknowledged it.
+func (k *KafkaEventDispatcher) Dispatch(ctx context.Context, envelope domain.EventEnvelope[any]) error {
+	return k.DispatchBatch(ctx, []domain.EventEnvelope[any]{envelope})
+}
+
+// DispatchBatch publishes envelopes, such as the events of one commit, in a
+// single produce request. Kafka does not make the batch atomic: when it fails,
+// some of the envelopes may have been published.
+func (k *KafkaEventDispatcher) DispatchBatch(ctx context.Context, envelopes []domain.EventEnvelope[any]) error {
+	if len(envelopes) == 0 {
+		return nil
+	}
+	messages := make([]kafka.Message, len(envelopes))
+	for i, envelope := range envelopes {
+		if envelope.EventType == "" {
+			return fmt.Errorf("%w: event type is required", domain.ErrInvalidEvent)
+		}
+		value, err := json.Marshal(envelope)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", envelope.ID, err)
+		}
+		messages[i] = kafka.Message{
+			Topic:   k.topic(envelope.EventType),
+			Key:     []byte(envelope.AggregateID),
+			Value:   value,
+			Headers: []kafka.Header{{Key: kafkaEventTypeHeader, Value: []byte(envelope.EventType)}},
+		}
+	}
+	if err := k.writer.WriteMessages(ctx, messages...); err != nil {
+		return fmt.Errorf("failed to publish %d events: %w", len(messages), err)
+	}
+	return nil
+}
+
+// Run consumes the topics of eventTypes, which may be patterns such as
+// "user.*" or "user.created" alike since only their category names the
+// topic, and dispatches every event read to the local dispatcher. It blocks
+// until ctx is cancelled, when it returns nil.
+//
+// A message's offset is committed only after its dispatch succeeds, so
+// delivery is at least once and handlers must be idempotent. A dispatch error
+// stops Run with the error, leaving the message to be redelivered when Run is
+// started again; give the local dispatcher WithDeadLetterStore to park
+// failing events instead of stopping. A message that cannot be decoded as an
+// event would fail the same way on every start, so it is logged and
+// committed instead, or handed to WithKafkaUndecodableHandler.
+func (k *KafkaEventDispatcher) Run(ctx context.Context, eventTypes ...string) error {
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("at least one event type is required")
+	}
+	var topics []string
+	for _, eventType := range eventTypes {
+		if topic := k.topic(eventType); !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     k.brokers,
+		GroupID:     k.groupID,
+		GroupTopics: topics,
+	})
+	defer func() { _ = reader.Close() }()
+	return k.consume(ctx, reader)
+}
+
+// consume dispatches the messages of reader until ctx is cancelled.
+func (k *KafkaEventDispatcher) consume(ctx context.Context, reader kafkaReader) error {
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch event from kafka: %w", err)
+		}
+
+		var envelope domain.EventEnvelope[any]
+		if err := json.Unmarshal(message.Value, &envelope); err != nil {
+			if err := k.skipUndecodable(ctx, reader, message, err); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			continue
+		}
+		if err := k.local.Dispatch(ctx, envelope); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to dispatch event %s: %w", envelope.ID, err)
+		}
+		if err := reader.CommitMessages(ctx, message); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to commit offset of event %s: %w", envelope.ID, err)
+		}
+	}
+}
+
+// skipUndecodable hands message, which failed to decode with cause, to the
+// undecodable handler or logs it, then commits it on reader.
+func (k *KafkaEventDispatcher) skipUndecodable(ctx context.Context, reader kafkaReader, message kafka.Message, cause error) error {
+	if k.undecodable != nil {
+		if err := k.undecodable(ctx, message, cause); err != nil {
+			return fmt.Errorf("failed to handle undecodable message at %s/%d offset %d: %w",
+				message.Topic, message.Partition, message.Offset, err)
+		}
+	} else {
+		k.logger.ErrorContext(ctx, "skipping kafka message that is not an event",
+			slog.String("topic", message.Topic),
+			slog.Int("partition", message.Partition),
+			slog.Int64("offset", message.Offset),
+			slog.Any("error", cause))
+	}
+	if err := reader.CommitMessages(ctx, message); err != nil {
+		return fmt.Errorf("failed to commit offset of undecodable message at %s/%d offset %d: %w",
+			message.Topic, message.Partition, message.Offset, err)
+	}
+	return nil
+}
+
+// Close flushes and closes the producer. A running Run is stopped by
+// cancelling its context.
+func (k *KafkaEventDispatcher) Close() error {
+	return k.writer.Close()
+}
+
+// topic returns the topic of eventType's category.
+func (k *KafkaEventDispatcher) topic(eventType string) string {
+	return k.topicPrefix + domain.EventCategory(eventType)
+}
@@ -0,0 +1,133 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// fakeKafkaReader serves messages in order, then cancels the consuming
+// context as a shutdown would.
+type fakeKafkaReader struct {
+	messages  []kafka.Message
+	cancel    context.CancelFunc
+	committed []int64
+}
+
+func (r *fakeKafkaReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.messages) == 0 {
+		r.cancel()
+		return kafka.Message{}, ctx.Err()
+	}
+	message := r.messages[0]
+	r.messages = r.messages[1:]
+	return message, nil
+}
+
+func (r *fakeKafkaReader) CommitMessages(_ context.Context, messages ...kafka.Message) error {
+	for _, message := range messages {
+		r.committed = append(r.committed, message.Offset)
+	}
+	return nil
+}
+
+func kafkaTestMessages(t *testing.T) []kafka.Message {
+	t.Helper()
+	messages := []kafka.Message{{Topic: "user", Offset: 1, Value: []byte("not json")}}
+	for i, id := range []string{"ev-1", "ev-2"} {
+		value, err := json.Marshal(domain.EventEnvelope[any]{ID: id, AggregateID: "user-1", EventType: "user.created", Payload: map[string]any{}})
+		if err != nil {
+			t.Fatalf("failed to encode event: %v", err)
+		}
+		messages = append(messages, kafka.Message{Topic: "user", Offset: int64(i + 2), Value: value})
+	}
+	return messages
+}
+
+func TestKafkaEventDispatcher_UndecodableMessages(t *testing.T) {
+	t.Parallel()
+
+	newDispatcher := func(opts ...KafkaEventDispatcherOption) (*KafkaEventDispatcher, *[]string) {
+		local := domain.NewEventDispatcher()
+		var dispatched []string
+		if _, err := domain.Subscribe[any](local, "user.created", func(_ context.Context, env domain.EventEnvelope[any]) error {
+			dispatched = append(dispatched, env.ID)
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+		return NewKafkaEventDispatcher([]string{"localhost:9092"}, local, opts...), &dispatched
+	}
+
+	t.Run("logged and committed without a handler", func(t *testing.T) {
+		t.Parallel()
+
+		var logs bytes.Buffer
+		k, dispatched := newDispatcher(WithKafkaLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+		ctx, cancel := context.WithCancel(context.Background())
+		reader := &fakeKafkaReader{messages: kafkaTestMessages(t), cancel: cancel}
+		if err := k.consume(ctx, reader); err != nil {
+			t.Fatalf("consume() error = %v", err)
+		}
+		if want := []string{"ev-1", "ev-2"}; !slices.Equal(*dispatched, want) {
+			t.Errorf("dispatched = %v, want %v", *dispatched, want)
+		}
+		if want := []int64{1, 2, 3}; !slices.Equal(reader.committed, want) {
+			t.Errorf("committed offsets = %v, want %v", reader.committed, want)
+		}
+		if !strings.Contains(logs.String(), "offset=1") {
+			t.Errorf("logs = %q, want the undecodable message reported", logs.String())
+		}
+	})
+
+	t.Run("handed to the undecodable handler", func(t *testing.T) {
+		t.Parallel()
+
+		var deadLettered []int64
+		k, dispatched := newDispatcher(WithKafkaUndecodableHandler(func(_ context.Context, message kafka.Message, err error) error {
+			if err == nil {
+				t.Error("handler called without the decoding error")
+			}
+			deadLettered = append(deadLettered, message.Offset)
+			return nil
+		}))
+		ctx, cancel := context.WithCancel(context.Background())
+		reader := &fakeKafkaReader{messages: kafkaTestMessages(t), cancel: cancel}
+		if err := k.consume(ctx, reader); err != nil {
+			t.Fatalf("consume() error = %v", err)
+		}
+		if want := []int64{1}; !slices.Equal(deadLettered, want) {
+			t.Errorf("dead-lettered offsets = %v, want %v", deadLettered, want)
+		}
+		if len(*dispatched) != 2 || len(reader.committed) != 3 {
+			t.Errorf("dispatched %v and committed %v, want every message past the bad one", *dispatched, reader.committed)
+		}
+	})
+
+	t.Run("a failing handler stops Run without committing", func(t *testing.T) {
+		t.Parallel()
+
+		unavailable := errors.New("dead-letter topic unavailable")
+		k, dispatched := newDispatcher(WithKafkaUndecodableHandler(func(context.Context, kafka.Message, error) error {
+			return unavailable
+		}))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		reader := &fakeKafkaReader{messages: kafkaTestMessages(t), cancel: cancel}
+		if err := k.consume(ctx, reader); !errors.Is(err, unavailable) {
+			t.Fatalf("consume() error = %v, want %v", err, unavailable)
+		}
+		if len(*dispatched) != 0 || len(reader.committed) != 0 {
+			t.Errorf("dispatched %v and committed %v, want nothing past the bad message", *dispatched, reader.committed)
+		}
+	})
+}
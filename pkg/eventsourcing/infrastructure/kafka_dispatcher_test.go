@@ -0,0 +1,95 @@
+package infrastructure_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/ksuid"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+// kafkaTestBrokers returns the comma-separated brokers in KAFKA_TEST_BROKERS,
+// skipping the test when it is unset.
+func kafkaTestBrokers(t *testing.T) []string {
+	t.Helper()
+	brokers := os.Getenv("KAFKA_TEST_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_TEST_BROKERS not set")
+	}
+	return strings.Split(brokers, ",")
+}
+
+func TestKafkaEventDispatcher_Integration(t *testing.T) {
+	t.Parallel()
+	brokers := kafkaTestBrokers(t)
+
+	// A fresh topic prefix and group per run, so earlier runs' messages and
+	// offsets do not leak in.
+	run := ksuid.New().String()
+	prefix := "pericarp-test-" + run + "."
+
+	publisher := infrastructure.NewKafkaEventDispatcher(brokers, domain.NewEventDispatcher(),
+		infrastructure.WithKafkaTopicPrefix(prefix))
+	defer func() { _ = publisher.Close() }()
+
+	local := domain.NewEventDispatcher()
+	received := make(chan domain.EventEnvelope[map[string]any], 10)
+	if _, err := domain.Subscribe(local, "user.created", func(ctx context.Context, env domain.EventEnvelope[map[string]any]) error {
+		received <- env
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	consumer := infrastructure.NewKafkaEventDispatcher(brokers, local,
+		infrastructure.WithKafkaTopicPrefix(prefix),
+		infrastructure.WithKafkaConsumerGroup("group-"+run))
+	defer func() { _ = consumer.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	created := createTestEvent("user-1", "ev-1", "user.created", 1)
+	created.Metadata["actor"] = "alice"
+	if err := publisher.DispatchBatch(ctx, []domain.EventEnvelope[any]{
+		created,
+		createTestEvent("user-1", "ev-2", "user.renamed", 2),
+	}); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	runErr := make(chan error, 1)
+	runCtx, stop := context.WithCancel(ctx)
+	go func() { runErr <- consumer.Run(runCtx, "user.*") }()
+
+	select {
+	case env := <-received:
+		if env.ID != "ev-1" || env.AggregateID != "user-1" || env.SequenceNo != 1 {
+			t.Errorf("unexpected envelope: %+v", env)
+		}
+		if env.Payload["test"] != "data" {
+			t.Errorf("payload = %v, want test=data", env.Payload)
+		}
+		if env.Metadata["actor"] != "alice" {
+			t.Errorf("metadata = %v, want actor=alice", env.Metadata)
+		}
+	case err := <-runErr:
+		t.Fatalf("Run stopped before delivering: %v", err)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the consumed event")
+	}
+
+	stop()
+	if err := <-runErr; err != nil {
+		t.Errorf("Run returned %v after cancellation, want nil", err)
+	}
+	select {
+	case env := <-received:
+		t.Errorf("handler for user.created received %s", env.EventType)
+	default:
+	}
+}
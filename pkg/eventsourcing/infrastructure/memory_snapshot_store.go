@@ -0,0 +1,48 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// MemorySnapshotStore is an in-memory implementation of domain.SnapshotStore,
+// suitable for tests and single-process use.
+type MemorySnapshotStore struct {
+	mu        sync.RWMutex
+	snapshots map[string]domain.Snapshot
+}
+
+var _ domain.SnapshotStore = (*MemorySnapshotStore)(nil)
+
+// NewMemorySnapshotStore creates an empty MemorySnapshotStore.
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{snapshots: make(map[string]domain.Snapshot)}
+}
+
+// SaveSnapshot stores a copy of snapshot unless one of the same schema
+// version at a higher sequence number is already stored.
+func (s *MemorySnapshotStore) SaveSnapshot(ctx context.Context, snapshot domain.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.snapshots[snapshot.AggregateID]; ok &&
+		existing.SchemaVersion == snapshot.SchemaVersion && existing.SequenceNo > snapshot.SequenceNo {
+		return nil
+	}
+	snapshot.State = append([]byte(nil), snapshot.State...)
+	s.snapshots[snapshot.AggregateID] = snapshot
+	return nil
+}
+
+// LoadSnapshot returns a copy of the aggregate's snapshot.
+func (s *MemorySnapshotStore) LoadSnapshot(ctx context.Context, aggregateID string) (domain.Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshot, ok := s.snapshots[aggregateID]
+	if !ok {
+		return domain.Snapshot{}, domain.ErrSnapshotNotFound
+	}
+	snapshot.State = append([]byte(nil), snapshot.State...)
+	return snapshot, nil
+}
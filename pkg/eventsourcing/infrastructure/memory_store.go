@@ -2,6 +2,7 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
 	"sort"
@@ -10,7 +11,38 @@ import (
 	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
 )
 
-var _ domain.EventStore = (*MemoryStore)(nil)
+var (
+	_ domain.EventStore           = (*MemoryStore)(nil)
+	_ domain.AggregateLister      = (*MemoryStore)(nil)
+	_ domain.CategoryFeedReader   = (*MemoryStore)(nil)
+	_ domain.WorkflowTracer       = (*MemoryStore)(nil)
+	_ domain.MultiAggregateReader = (*MemoryStore)(nil)
+)
+
+var (
+	// ErrStoreFull is returned by a bounded MemoryStore using RejectWhenFull
+	// when an append would exceed its event limit.
+	ErrStoreFull = errors.New("event store is full")
+
+	// ErrHistoryTruncated is returned by a bounded MemoryStore using
+	// EvictOldest when a read asks for events that have been evicted, so a
+	// partially evicted aggregate is never rebuilt into a wrong state.
+	ErrHistoryTruncated = errors.New("event history truncated")
+)
+
+// EvictionPolicy decides what a bounded MemoryStore does when full.
+type EvictionPolicy int
+
+const (
+	// RejectWhenFull fails appends that would exceed the limit with ErrStoreFull.
+	RejectWhenFull EvictionPolicy = iota
+
+	// EvictOldest accepts the append and evicts the oldest events in global
+	// order until the store is back within its limit. Reads that reach into
+	// an aggregate's evicted history, and feed reads from before the oldest
+	// retained event, fail with ErrHistoryTruncated.
+	EvictOldest
+)
 
 // MemoryStore is an in-memory implementation of EventStore.
 // It's useful for testing and development, but not suitable for production
@@ -22,15 +54,38 @@ type MemoryStore struct {
 	versions   map[string]int                         // aggregateID -> current version
 	log        []domain.EventEnvelope[any]            // all events in global commit order
 	lastPos    int64                                  // last assigned global position
+
+	maxEvents int            // 0 means unbounded
+	eviction  EvictionPolicy // applied when maxEvents is reached
+	truncated map[string]int // aggregateID -> highest evicted sequence number
+	evicted   int64          // highest evicted global position
+}
+
+// MemoryStoreOption configures a MemoryStore.
+type MemoryStoreOption func(*MemoryStore)
+
+// WithMaxEvents bounds the store to at most n events, applying policy when
+// an append would exceed the bound. It keeps long-running soak and fuzz
+// tests from growing without limit; n <= 0 means unbounded (the default).
+func WithMaxEvents(n int, policy EvictionPolicy) MemoryStoreOption {
+	return func(m *MemoryStore) {
+		m.maxEvents = n
+		m.eviction = policy
+	}
 }
 
 // NewMemoryStore creates a new in-memory event store.
-func NewMemoryStore() *MemoryStore {
-	return &MemoryStore{
+func NewMemoryStore(opts ...MemoryStoreOption) *MemoryStore {
+	m := &MemoryStore{
 		events:     make(map[string][]domain.EventEnvelope[any]),
 		eventsByID: make(map[string]domain.EventEnvelope[any]),
 		versions:   make(map[string]int),
+		truncated:  make(map[string]int),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // Append appends events to the store for the given aggregate.
@@ -58,15 +113,23 @@ func (m *MemoryStore) Append(ctx context.Context, aggregateID string, expectedVe
 		return fmt.Errorf("%w: expected version %d, got %d", domain.ErrConcurrencyConflict, expectedVersion, currentVersion)
 	}
 
+	if m.maxEvents > 0 {
+		if len(events) > m.maxEvents || (m.eviction == RejectWhenFull && len(m.log)+len(events) > m.maxEvents) {
+			return fmt.Errorf("%w: appending %d events to %d would exceed the limit of %d",
+				ErrStoreFull, len(events), len(m.log), m.maxEvents)
+		}
+	}
+
 	// Append events
 	eventList := m.events[aggregateID]
 	if eventList == nil {
 		eventList = make([]domain.EventEnvelope[any], 0)
 	}
 
-	// Append events preserving their SequenceNo as set by the domain.
-	// Each event is copied so the store-assigned global Position does not
-	// mutate the caller's envelopes.
+	// Append events preserving their SequenceNo as set by the domain, in
+	// sequence order. Each event is copied so the store-assigned global
+	// Position does not mutate the caller's envelopes.
+	events = sortedBySequence(events)
 	for _, event := range events {
 		m.lastPos++
 		event.Position = m.lastPos
@@ -77,6 +140,7 @@ func (m *MemoryStore) Append(ctx context.Context, aggregateID string, expectedVe
 
 	m.events[aggregateID] = eventList
 	m.versions[aggregateID] = events[len(events)-1].SequenceNo
+	m.evictLocked()
 
 	return nil
 }
@@ -86,6 +150,10 @@ func (m *MemoryStore) GetEvents(ctx context.Context, aggregateID string) ([]doma
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if err := m.checkTruncatedLocked(aggregateID, 1); err != nil {
+		return nil, err
+	}
+
 	events := m.events[aggregateID]
 	if events == nil {
 		return []domain.EventEnvelope[any]{}, nil
@@ -99,6 +167,10 @@ func (m *MemoryStore) GetEventsFromVersion(ctx context.Context, aggregateID stri
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if err := m.checkTruncatedLocked(aggregateID, fromVersion); err != nil {
+		return nil, err
+	}
+
 	events := m.events[aggregateID]
 	if events == nil {
 		return []domain.EventEnvelope[any]{}, nil
@@ -129,6 +201,9 @@ func (m *MemoryStore) GetEventsRange(ctx context.Context, aggregateID string, fr
 	if fromVersion == -1 {
 		fromVersion = 1
 	}
+	if err := m.checkTruncatedLocked(aggregateID, fromVersion); err != nil {
+		return nil, err
+	}
 
 	result := make([]domain.EventEnvelope[any], 0)
 	for _, event := range events {
@@ -193,11 +268,17 @@ func (m *MemoryStore) GetCurrentVersion(ctx context.Context, aggregateID string)
 }
 
 // ReadAfter returns events with Position > afterPosition across all aggregates,
-// ordered by Position ascending, up to limit (limit <= 0 means no limit).
+// ordered by Position ascending, up to limit (limit <= 0 means no limit). A
+// read from before the oldest event EvictOldest kept fails with
+// ErrHistoryTruncated rather than skipping the evicted events.
 func (m *MemoryStore) ReadAfter(ctx context.Context, afterPosition int64, limit int) ([]domain.EventEnvelope[any], error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	if err := m.checkFeedTruncatedLocked(afterPosition); err != nil {
+		return nil, err
+	}
+
 	result := make([]domain.EventEnvelope[any], 0)
 	for _, event := range m.log {
 		if event.Position <= afterPosition {
@@ -212,6 +293,33 @@ func (m *MemoryStore) ReadAfter(ctx context.Context, afterPosition int64, limit
 	return result, nil
 }
 
+// ReadAfterInCategories is ReadAfter restricted to events whose category is
+// in categories; an empty slice means every category.
+func (m *MemoryStore) ReadAfterInCategories(ctx context.Context, afterPosition int64, limit int, categories []string) ([]domain.EventEnvelope[any], error) {
+	if len(categories) == 0 {
+		return m.ReadAfter(ctx, afterPosition, limit)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := m.checkFeedTruncatedLocked(afterPosition); err != nil {
+		return nil, err
+	}
+	result := make([]domain.EventEnvelope[any], 0)
+	for _, event := range m.log {
+		if event.Position <= afterPosition || !slices.Contains(categories, domain.EventCategory(event.EventType)) {
+			continue
+		}
+		result = append(result, event)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
 // HeadPosition returns the highest position assigned so far.
 func (m *MemoryStore) HeadPosition(ctx context.Context) (int64, error) {
 	m.mu.RLock()
@@ -231,10 +339,59 @@ func (m *MemoryStore) Close() error {
 	m.eventsByID = make(map[string]domain.EventEnvelope[any])
 	m.versions = make(map[string]int)
 	m.log = nil
+	m.truncated = make(map[string]int)
+	m.evicted = 0
+
+	return nil
+}
+
+// evictLocked drops the oldest events in global order until the store is
+// within maxEvents, recording how far each affected aggregate was truncated.
+// The log is in position order and each aggregate's positions follow its
+// sequence, so the evicted event is always its aggregate's earliest remaining
+// one. The caller must hold the write lock.
+func (m *MemoryStore) evictLocked() {
+	if m.maxEvents <= 0 || m.eviction != EvictOldest {
+		return
+	}
+	for len(m.log) > m.maxEvents {
+		oldest := m.log[0]
+		m.log = m.log[1:]
+		delete(m.eventsByID, oldest.ID)
+		m.events[oldest.AggregateID] = m.events[oldest.AggregateID][1:]
+		m.truncated[oldest.AggregateID] = oldest.SequenceNo
+		m.evicted = oldest.Position
+	}
+}
 
+// checkTruncatedLocked returns ErrHistoryTruncated if a read starting at
+// fromVersion would include evicted events. The caller must hold the lock.
+func (m *MemoryStore) checkTruncatedLocked(aggregateID string, fromVersion int) error {
+	if evicted := m.truncated[aggregateID]; evicted > 0 && fromVersion <= evicted {
+		return fmt.Errorf("%w: aggregate %s has no events before version %d",
+			ErrHistoryTruncated, aggregateID, evicted+1)
+	}
+	return nil
+}
+
+// checkFeedTruncatedLocked returns ErrHistoryTruncated if a feed read after
+// afterPosition would skip evicted events. The caller must hold the lock.
+func (m *MemoryStore) checkFeedTruncatedLocked(afterPosition int64) error {
+	if afterPosition < m.evicted {
+		return fmt.Errorf("%w: the feed has no events at or before position %d",
+			ErrHistoryTruncated, m.evicted)
+	}
 	return nil
 }
 
+// ListAggregateIDs returns a page of the aggregate IDs with events in
+// category, in ascending order.
+func (m *MemoryStore) ListAggregateIDs(ctx context.Context, category string, opts domain.ListAggregateIDsOptions) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return listAggregateIDs(m.events, category, opts), nil
+}
+
 // GetAllAggregateIDs returns all aggregate IDs in the store (useful for testing).
 func (m *MemoryStore) GetAllAggregateIDs() []string {
 	m.mu.RLock()
@@ -247,3 +404,39 @@ func (m *MemoryStore) GetAllAggregateIDs() []string {
 	sort.Strings(ids)
 	return ids
 }
+
+// TraceWorkflow returns the account's events with the given correlation ID
+// in position order.
+func (m *MemoryStore) TraceWorkflow(ctx context.Context, accountID, correlationID string) ([]domain.EventEnvelope[any], error) {
+	if correlationID == "" {
+		return nil, fmt.Errorf("%w: correlation ID must not be empty", domain.ErrInvalidEvent)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]domain.EventEnvelope[any], 0)
+	for _, event := range m.log {
+		if domain.CorrelationID(event) == correlationID && domain.AccountID(event) == accountID {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+// GetEventsForAggregates returns the events of each aggregate in
+// aggregateIDs that has any, under a single read lock.
+func (m *MemoryStore) GetEventsForAggregates(ctx context.Context, aggregateIDs []string) (map[string][]domain.EventEnvelope[any], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string][]domain.EventEnvelope[any], len(aggregateIDs))
+	for _, aggregateID := range aggregateIDs {
+		if err := m.checkTruncatedLocked(aggregateID, 1); err != nil {
+			return nil, err
+		}
+		if events := m.events[aggregateID]; len(events) > 0 {
+			result[aggregateID] = events
+		}
+	}
+	return result, nil
+}
@@ -3,6 +3,8 @@ package infrastructure_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
@@ -193,3 +195,192 @@ func TestMemoryStore_GetAllAggregateIDs(t *testing.T) {
 		}
 	}
 }
+
+func TestMemoryStore_MaxEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("reject when full", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore(infrastructure.WithMaxEvents(2, infrastructure.RejectWhenFull))
+
+		if err := store.Append(ctx, "agg-1", 0,
+			createTestEvent("agg-1", "e1", "test.created", 1),
+			createTestEvent("agg-1", "e2", "test.updated", 2),
+		); err != nil {
+			t.Fatalf("Append within bound failed: %v", err)
+		}
+		err := store.Append(ctx, "agg-1", 2, createTestEvent("agg-1", "e3", "test.updated", 3))
+		if !errors.Is(err, infrastructure.ErrStoreFull) {
+			t.Fatalf("Expected ErrStoreFull, got %v", err)
+		}
+		if version, _ := store.GetCurrentVersion(ctx, "agg-1"); version != 2 {
+			t.Errorf("Expected rejected append to leave version 2, got %d", version)
+		}
+	})
+
+	t.Run("evict oldest", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore(infrastructure.WithMaxEvents(3, infrastructure.EvictOldest))
+
+		for i := 1; i <= 3; i++ {
+			if err := store.Append(ctx, "agg-1", i-1, createTestEvent("agg-1", fmt.Sprintf("a%d", i), "test.updated", i)); err != nil {
+				t.Fatalf("Append agg-1 #%d failed: %v", i, err)
+			}
+		}
+		if err := store.Append(ctx, "agg-2", 0, createTestEvent("agg-2", "b1", "test.created", 1)); err != nil {
+			t.Fatalf("Append agg-2 failed: %v", err)
+		}
+
+		// The oldest agg-1 event was evicted: a full load must fail rather
+		// than rebuild from version 2.
+		if _, err := store.GetEvents(ctx, "agg-1"); !errors.Is(err, infrastructure.ErrHistoryTruncated) {
+			t.Errorf("GetEvents: expected ErrHistoryTruncated, got %v", err)
+		}
+		if _, err := store.GetEventsRange(ctx, "agg-1", -1, 2); !errors.Is(err, infrastructure.ErrHistoryTruncated) {
+			t.Errorf("GetEventsRange: expected ErrHistoryTruncated, got %v", err)
+		}
+		if _, err := store.GetEventByID(ctx, "a1"); !errors.Is(err, domain.ErrEventNotFound) {
+			t.Errorf("GetEventByID: expected ErrEventNotFound for evicted event, got %v", err)
+		}
+
+		// Reads that stay within the retained history still work.
+		tail, err := store.GetEventsFromVersion(ctx, "agg-1", 2)
+		if err != nil {
+			t.Fatalf("GetEventsFromVersion failed: %v", err)
+		}
+		if len(tail) != 2 {
+			t.Errorf("Expected 2 retained agg-1 events, got %d", len(tail))
+		}
+		if events, err := store.GetEvents(ctx, "agg-2"); err != nil || len(events) != 1 {
+			t.Errorf("Expected agg-2 intact, got %d events, err %v", len(events), err)
+		}
+		if version, _ := store.GetCurrentVersion(ctx, "agg-1"); version != 3 {
+			t.Errorf("Expected agg-1 version 3, got %d", version)
+		}
+
+		// A feed reader behind the eviction must not skip the evicted event.
+		if _, err := store.ReadAfter(ctx, 0, 0); !errors.Is(err, infrastructure.ErrHistoryTruncated) {
+			t.Errorf("ReadAfter: expected ErrHistoryTruncated, got %v", err)
+		}
+		if _, err := store.ReadAfterInCategories(ctx, 0, 0, []string{"test"}); !errors.Is(err, infrastructure.ErrHistoryTruncated) {
+			t.Errorf("ReadAfterInCategories: expected ErrHistoryTruncated, got %v", err)
+		}
+		feed, err := store.ReadAfter(ctx, 1, 0)
+		if err != nil {
+			t.Fatalf("ReadAfter failed: %v", err)
+		}
+		if len(feed) != 3 {
+			t.Errorf("Expected feed bounded to 3 events, got %d", len(feed))
+		}
+	})
+}
+
+// TestMemoryStore_ConcurrentAccess exercises the store from many goroutines,
+// as handler tests that share one store across parallel subtests do. Run it
+// with -race: the store's mutex must cover every read of state an append
+// mutates.
+func TestMemoryStore_ConcurrentAccess(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("contended appends have one winner per version", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+
+		const writers = 16
+		var wg sync.WaitGroup
+		errs := make([]error, writers)
+		for w := range writers {
+			wg.Go(func() {
+				errs[w] = store.Append(ctx, "agg-1", 0,
+					createTestEvent("agg-1", fmt.Sprintf("e-%d", w), "test.created", 1))
+			})
+		}
+		wg.Wait()
+
+		won := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				won++
+			case !errors.Is(err, domain.ErrConcurrencyConflict):
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		if won != 1 {
+			t.Errorf("%d appends won version 1, want 1", won)
+		}
+	})
+
+	t.Run("concurrent appends and reads", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+
+		const writers = 8
+		const eventsPerWriter = 20
+		var wg sync.WaitGroup
+		errCh := make(chan error, writers*eventsPerWriter*4)
+
+		// Each writer appends to its own aggregate one event at a time while
+		// reading its neighbour's aggregate and the global feed.
+		for w := range writers {
+			wg.Go(func() {
+				id := fmt.Sprintf("agg-%d", w)
+				other := fmt.Sprintf("agg-%d", (w+1)%writers)
+				for seq := 1; seq <= eventsPerWriter; seq++ {
+					event := createTestEvent(id, fmt.Sprintf("%s-e%d", id, seq), "test.updated", seq)
+					if err := store.Append(ctx, id, seq-1, event); err != nil {
+						errCh <- fmt.Errorf("Append(%s, %d): %w", id, seq, err)
+						return
+					}
+					if _, err := store.GetEvents(ctx, other); err != nil {
+						errCh <- fmt.Errorf("GetEvents(%s): %w", other, err)
+					}
+					if _, err := store.GetEventsFromVersion(ctx, other, seq); err != nil {
+						errCh <- fmt.Errorf("GetEventsFromVersion(%s): %w", other, err)
+					}
+					if _, err := store.ReadAfter(ctx, 0, 0); err != nil {
+						errCh <- fmt.Errorf("ReadAfter: %w", err)
+					}
+				}
+			})
+		}
+		wg.Wait()
+		close(errCh)
+		for err := range errCh {
+			t.Error(err)
+		}
+
+		for w := range writers {
+			id := fmt.Sprintf("agg-%d", w)
+			events, err := store.GetEvents(ctx, id)
+			if err != nil {
+				t.Fatalf("GetEvents(%s) failed: %v", id, err)
+			}
+			if len(events) != eventsPerWriter {
+				t.Errorf("%s has %d events, want %d", id, len(events), eventsPerWriter)
+			}
+			for i, event := range events {
+				if event.SequenceNo != i+1 {
+					t.Errorf("%s event %d has sequence %d, want %d", id, i, event.SequenceNo, i+1)
+				}
+			}
+		}
+
+		feed, err := store.ReadAfter(ctx, 0, 0)
+		if err != nil {
+			t.Fatalf("ReadAfter failed: %v", err)
+		}
+		if len(feed) != writers*eventsPerWriter {
+			t.Fatalf("feed has %d events, want %d", len(feed), writers*eventsPerWriter)
+		}
+		for i, event := range feed {
+			if event.Position != int64(i+1) {
+				t.Errorf("feed event %d has position %d, want %d", i, event.Position, i+1)
+			}
+		}
+	})
+}
@@ -465,3 +465,135 @@ func TestPostgresStore_BackfillsLegacyRows(t *testing.T) {
 		t.Errorf("expected new event at position 4, got %d", events[0].Position)
 	}
 }
+
+// TestPostgresStore_LockAggregates_Timeout holds an aggregate lock in one
+// transaction and checks that a second locker fails with ErrLockTimeout
+// instead of hanging, then succeeds once the first transaction ends.
+func TestPostgresStore_LockAggregates_Timeout(t *testing.T) {
+	db := setupPostgresDB(t)
+	ctx := context.Background()
+
+	store, err := infrastructure.NewGormEventStore(db, infrastructure.WithLockTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	holderDone := make(chan error, 1)
+	go func() {
+		holderDone <- store.InTransaction(ctx, func(ctx context.Context) error {
+			if err := store.LockAggregates(ctx, "ledger-2", "ledger-1"); err != nil {
+				return err
+			}
+			close(locked)
+			<-release
+			return nil
+		})
+	}()
+	<-locked
+
+	err = store.InTransaction(ctx, func(ctx context.Context) error {
+		return store.LockAggregates(ctx, "ledger-1")
+	})
+	if !errors.Is(err, domain.ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout while the lock is held, got %v", err)
+	}
+
+	close(release)
+	if err := <-holderDone; err != nil {
+		t.Fatalf("holder transaction failed: %v", err)
+	}
+	if err := store.InTransaction(ctx, func(ctx context.Context) error {
+		return store.LockAggregates(ctx, "ledger-1")
+	}); err != nil {
+		t.Fatalf("expected lock after release, got %v", err)
+	}
+
+	if err := store.LockAggregates(ctx, "ledger-1"); err == nil {
+		t.Error("expected LockAggregates outside InTransaction to fail")
+	}
+}
+
+func TestPostgresStore_ConcurrentAppend_SameVersionOneWins(t *testing.T) {
+	db := setupPostgresDB(t)
+	ctx := context.Background()
+
+	store, err := infrastructure.NewGormEventStore(db)
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Append(ctx, "agg-1", 0, createTestEvent("agg-1", "ev-0", "test.created", 1)); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errCh := make(chan error, writers)
+	for i := range writers {
+		wg.Go(func() {
+			errCh <- store.Append(ctx, "agg-1", 1, createTestEvent("agg-1", fmt.Sprintf("ev-%d", i+1), "test.updated", 2))
+		})
+	}
+	wg.Wait()
+	close(errCh)
+
+	wins := 0
+	for err := range errCh {
+		switch {
+		case err == nil:
+			wins++
+		case !errors.Is(err, domain.ErrConcurrencyConflict):
+			t.Errorf("expected ErrConcurrencyConflict for a losing writer, got %v", err)
+		}
+	}
+	if wins != 1 {
+		t.Fatalf("expected exactly one writer to win, got %d", wins)
+	}
+	if version, err := store.GetCurrentVersion(ctx, "agg-1"); err != nil || version != 2 {
+		t.Fatalf("expected version 2, got %d, %v", version, err)
+	}
+}
+
+func TestPostgresStore_Serializable_ConcurrentAppendOneWins(t *testing.T) {
+	db := setupPostgresDB(t)
+	ctx := context.Background()
+
+	store, err := infrastructure.NewGormEventStore(db, infrastructure.WithIsolationLevel(sql.LevelSerializable))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Append(ctx, "ledger-1", 0, createTestEvent("ledger-1", "ev-0", "ledger.opened", 1)); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errCh := make(chan error, writers)
+	for i := range writers {
+		wg.Go(func() {
+			errCh <- store.Append(ctx, "ledger-1", 1, createTestEvent("ledger-1", fmt.Sprintf("ev-%d", i+1), "ledger.posted", 2))
+		})
+	}
+	wg.Wait()
+	close(errCh)
+
+	wins := 0
+	for err := range errCh {
+		switch {
+		case err == nil:
+			wins++
+		case !errors.Is(err, domain.ErrConcurrencyConflict):
+			t.Errorf("expected ErrConcurrencyConflict for a losing writer, got %v", err)
+		}
+	}
+	if wins != 1 {
+		t.Fatalf("expected exactly one writer to win, got %d", wins)
+	}
+	if version, err := store.GetCurrentVersion(ctx, "ledger-1"); err != nil || version != 2 {
+		t.Fatalf("expected version 2, got %d, %v", version, err)
+	}
+}
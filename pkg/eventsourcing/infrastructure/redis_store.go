@@ -0,0 +1,477 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+var _ domain.EventStore = (*RedisEventStore)(nil)
+
+const (
+	// DefaultRedisKeyPrefix is the key prefix of a RedisEventStore created
+	// without WithRedisKeyPrefix. The braces are a Redis Cluster hash tag.
+	DefaultRedisKeyPrefix = "{pericarp}:"
+
+	// DefaultRedisAppendRetries is how many times a RedisEventStore re-runs an
+	// append whose transaction was aborted by a concurrent append, unless
+	// configured otherwise with WithRedisAppendRetries.
+	DefaultRedisAppendRetries = 10
+
+	redisTimeFormat = time.RFC3339Nano
+	redisScanBatch  = 500
+
+	// redisAppendRetryBackoff is the delay before the first re-run of an
+	// aborted append; it doubles per attempt up to redisAppendMaxBackoff and
+	// is jittered so colliding writers do not collide again in lockstep.
+	redisAppendRetryBackoff = time.Millisecond
+	redisAppendMaxBackoff   = 100 * time.Millisecond
+)
+
+// RedisEventStoreOption configures a RedisEventStore.
+type RedisEventStoreOption func(*RedisEventStore)
+
+// WithRedisKeyPrefix sets the prefix of every key the store uses, so several
+// stores can share one Redis database. On Redis Cluster the prefix must
+// contain a hash tag such as "{orders}:", because an append updates several
+// keys in one transaction and they must all live in the same slot.
+func WithRedisKeyPrefix(prefix string) RedisEventStoreOption {
+	return func(s *RedisEventStore) {
+		s.prefix = prefix
+	}
+}
+
+// WithRedisAppendRetries sets how many times an append whose transaction was
+// aborted by a concurrent append is re-run before the store gives up with
+// domain.ErrConcurrencyConflict (default DefaultRedisAppendRetries). Zero
+// disables retrying; a negative n keeps the default.
+func WithRedisAppendRetries(n int) RedisEventStoreOption {
+	return func(s *RedisEventStore) {
+		if n >= 0 {
+			s.appendRetries = n
+		}
+	}
+}
+
+// RedisEventStore is a Redis Streams implementation of EventStore. Each
+// aggregate's events are kept in their own stream, keyed
+// "<prefix>events:<aggregateID>" with the sequence number as the entry ID,
+// and every event is also added to a global stream whose entry IDs are the
+// store-assigned positions, which backs ReadAfter. An append is a MULTI/EXEC
+// transaction that WATCHes the aggregate's stream and the position counter,
+// so a concurrent writer makes it re-check the expected version and retry
+// after a short, jittered backoff.
+//
+// Redis trades durability for latency, and the store is only as durable as
+// the server's persistence settings:
+//
+//   - With RDB snapshots only, everything written since the last snapshot is
+//     lost on a crash. Run with appendonly yes.
+//   - With appendfsync everysec, the default for AOF, up to a second of
+//     acknowledged appends can be lost on a crash; appendfsync always closes
+//     that window at a large cost in write throughput.
+//   - Replication is asynchronous, so a failover can promote a replica that
+//     missed the latest acknowledged appends, and Sentinel or Cluster will
+//     not bring them back. WAIT narrows the window but does not close it.
+//   - maxmemory-policy must be noeviction: any other policy may evict streams
+//     silently.
+//
+// Every key shares the prefix's hash tag, so on Redis Cluster the whole store
+// lives on one shard and all appends are serialized through one position
+// counter. Events are stored twice, once in their aggregate's stream and once
+// in the global stream, and are never trimmed. Use the store where low
+// latency matters more than the guarantees of a database-backed store.
+type RedisEventStore struct {
+	client        redis.UniversalClient
+	prefix        string
+	appendRetries int
+}
+
+// NewRedisEventStore creates a Redis-backed event store on client, which the
+// caller owns and closes.
+func NewRedisEventStore(client redis.UniversalClient, opts ...RedisEventStoreOption) *RedisEventStore {
+	if client == nil {
+		panic("redis client must not be nil")
+	}
+	s := &RedisEventStore{client: client, prefix: DefaultRedisKeyPrefix, appendRetries: DefaultRedisAppendRetries}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Append appends events to the store for the given aggregate.
+// If expectedVersion is not -1, optimistic concurrency control is enforced.
+// expectedVersion == -1 skips the version check but still refuses to
+// overwrite: events must continue past the aggregate's current version, and
+// an event whose ID is already stored fails with ErrConcurrencyConflict.
+// A transaction aborted by a concurrent append is retried after a jittered
+// backoff, up to WithRedisAppendRetries times; an append still aborted once
+// retries are exhausted fails with ErrConcurrencyConflict.
+func (s *RedisEventStore) Append(ctx context.Context, aggregateID string, expectedVersion int, events ...domain.EventEnvelope[any]) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	for _, event := range events {
+		if event.AggregateID != aggregateID {
+			return fmt.Errorf("%w: aggregate ID mismatch", domain.ErrInvalidEvent)
+		}
+		if event.ID == "" {
+			return fmt.Errorf("%w: event ID is required", domain.ErrInvalidEvent)
+		}
+		if event.SequenceNo < 1 {
+			return fmt.Errorf("%w: sequence number must be positive, got %d", domain.ErrInvalidEvent, event.SequenceNo)
+		}
+	}
+	events = sortedBySequence(events)
+	for i := 1; i < len(events); i++ {
+		if events[i].SequenceNo == events[i-1].SequenceNo {
+			return fmt.Errorf("%w: duplicate sequence number %d", domain.ErrInvalidEvent, events[i].SequenceNo)
+		}
+	}
+
+	streamKey := s.streamKey(aggregateID)
+	positionKey := s.positionKey()
+	backoff := redisAppendRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+			return s.appendWatched(ctx, tx, aggregateID, expectedVersion, events)
+		}, streamKey, positionKey)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+		if attempt >= s.appendRetries {
+			return fmt.Errorf("%w: append for aggregate %s aborted by concurrent appends %d times",
+				domain.ErrConcurrencyConflict, aggregateID, attempt+1)
+		}
+
+		timer := time.NewTimer(backoff/2 + rand.N(backoff))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to append events for aggregate %s: %w", aggregateID, ctx.Err())
+		}
+		backoff = min(backoff*2, redisAppendMaxBackoff)
+	}
+}
+
+// appendWatched checks events against the watched state and queues their
+// writes in a transaction, which fails with redis.TxFailedErr when a watched
+// key changed in the meantime.
+func (s *RedisEventStore) appendWatched(ctx context.Context, tx *redis.Tx, aggregateID string, expectedVersion int, events []domain.EventEnvelope[any]) error {
+	currentVersion, err := s.currentVersion(ctx, tx, aggregateID)
+	if err != nil {
+		return err
+	}
+	if expectedVersion != -1 && currentVersion != expectedVersion {
+		return fmt.Errorf("%w: expected version %d, got %d", domain.ErrConcurrencyConflict, expectedVersion, currentVersion)
+	}
+	if first := events[0].SequenceNo; first <= currentVersion {
+		return fmt.Errorf("%w: sequence number %d already exists for aggregate %s", domain.ErrConcurrencyConflict, first, aggregateID)
+	}
+
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	stored, err := tx.HMGet(ctx, s.eventIDsKey(), ids...).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check event IDs: %w", err)
+	}
+	for i, position := range stored {
+		if position != nil {
+			return fmt.Errorf("%w: event %s already exists", domain.ErrConcurrencyConflict, ids[i])
+		}
+	}
+
+	head, err := s.headPosition(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	values := make([]map[string]any, len(events))
+	for i, event := range events {
+		event.Position = head + int64(i) + 1
+		if values[i], err = redisStreamValues(event); err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+		}
+	}
+
+	_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		positions := make([]any, 0, 2*len(events))
+		for i, event := range events {
+			position := head + int64(i) + 1
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: s.streamKey(aggregateID),
+				ID:     strconv.Itoa(event.SequenceNo) + "-0",
+				Values: values[i],
+			})
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: s.allKey(),
+				ID:     strconv.FormatInt(position, 10) + "-0",
+				Values: values[i],
+			})
+			positions = append(positions, event.ID, position)
+		}
+		pipe.HSet(ctx, s.eventIDsKey(), positions...)
+		pipe.Set(ctx, s.positionKey(), head+int64(len(events)), 0)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.TxFailedErr) {
+		return fmt.Errorf("failed to append events for aggregate %s: %w", aggregateID, err)
+	}
+	return err
+}
+
+// GetEvents retrieves all events for the given aggregate ID.
+func (s *RedisEventStore) GetEvents(ctx context.Context, aggregateID string) ([]domain.EventEnvelope[any], error) {
+	return s.rangeStream(ctx, s.streamKey(aggregateID), "-", "+", 0)
+}
+
+// GetEventsFromVersion retrieves events starting from the specified version.
+func (s *RedisEventStore) GetEventsFromVersion(ctx context.Context, aggregateID string, fromVersion int) ([]domain.EventEnvelope[any], error) {
+	return s.GetEventsRange(ctx, aggregateID, fromVersion, -1)
+}
+
+// GetEventsRange retrieves events within a version range.
+// If fromVersion is -1, it defaults to 1. If toVersion is -1, all events from fromVersion onwards are returned.
+func (s *RedisEventStore) GetEventsRange(ctx context.Context, aggregateID string, fromVersion, toVersion int) ([]domain.EventEnvelope[any], error) {
+	start, end := "-", "+"
+	if fromVersion > 0 {
+		start = strconv.Itoa(fromVersion) + "-0"
+	}
+	if toVersion != -1 {
+		if toVersion < 1 || (fromVersion > 0 && toVersion < fromVersion) {
+			return []domain.EventEnvelope[any]{}, nil
+		}
+		end = strconv.Itoa(toVersion) + "-0"
+	}
+	return s.rangeStream(ctx, s.streamKey(aggregateID), start, end, 0)
+}
+
+// GetEventByID retrieves a specific event by its ID through the event ID index.
+func (s *RedisEventStore) GetEventByID(ctx context.Context, eventID string) (domain.EventEnvelope[any], error) {
+	position, err := s.client.HGet(ctx, s.eventIDsKey(), eventID).Int64()
+	if errors.Is(err, redis.Nil) {
+		return domain.EventEnvelope[any]{}, domain.ErrEventNotFound
+	}
+	if err != nil {
+		return domain.EventEnvelope[any]{}, fmt.Errorf("failed to look up event %s: %w", eventID, err)
+	}
+
+	id := strconv.FormatInt(position, 10) + "-0"
+	events, err := s.rangeStream(ctx, s.allKey(), id, id, 1)
+	if err != nil {
+		return domain.EventEnvelope[any]{}, err
+	}
+	if len(events) == 0 {
+		return domain.EventEnvelope[any]{}, domain.ErrEventNotFound
+	}
+	return events[0], nil
+}
+
+// GetEventsByTransactionID retrieves all events with the given transaction ID.
+// This scans the whole global stream since transaction IDs are not indexed.
+func (s *RedisEventStore) GetEventsByTransactionID(ctx context.Context, transactionID string) ([]domain.EventEnvelope[any], error) {
+	if transactionID == "" {
+		return nil, fmt.Errorf("%w: transaction ID must not be empty", domain.ErrInvalidEvent)
+	}
+
+	envelopes := []domain.EventEnvelope[any]{}
+	var after int64
+	for {
+		batch, err := s.ReadAfter(ctx, after, redisScanBatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan events by transaction ID %s: %w", transactionID, err)
+		}
+		for _, env := range batch {
+			if env.TransactionID == transactionID {
+				envelopes = append(envelopes, env)
+			}
+		}
+		if len(batch) < redisScanBatch {
+			break
+		}
+		after = batch[len(batch)-1].Position
+	}
+
+	slices.SortFunc(envelopes, compareEnvelopes)
+	return envelopes, nil
+}
+
+// GetCurrentVersion returns the current version for the aggregate.
+// Returns 0 if the aggregate doesn't exist.
+func (s *RedisEventStore) GetCurrentVersion(ctx context.Context, aggregateID string) (int, error) {
+	return s.currentVersion(ctx, s.client, aggregateID)
+}
+
+// ReadAfter returns events across all aggregates whose Position is greater
+// than afterPosition, in Position order. limit <= 0 means no limit. Appends
+// are serialized by the position counter, so positions become visible in
+// order and nothing needs to be withheld.
+func (s *RedisEventStore) ReadAfter(ctx context.Context, afterPosition int64, limit int) ([]domain.EventEnvelope[any], error) {
+	return s.rangeStream(ctx, s.allKey(), strconv.FormatInt(afterPosition+1, 10)+"-0", "+", int64(max(limit, 0)))
+}
+
+// HeadPosition returns the position of the most recently appended event, or
+// 0 when the store is empty.
+func (s *RedisEventStore) HeadPosition(ctx context.Context) (int64, error) {
+	return s.headPosition(ctx, s.client)
+}
+
+// Close closes the Redis event store (no-op since the client is managed externally).
+func (s *RedisEventStore) Close() error {
+	return nil
+}
+
+func (s *RedisEventStore) currentVersion(ctx context.Context, c redis.Cmdable, aggregateID string) (int, error) {
+	messages, err := c.XRevRangeN(ctx, s.streamKey(aggregateID), "+", "-", 1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query current version: %w", err)
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	sequence, _, _ := strings.Cut(messages[0].ID, "-")
+	version, err := strconv.Atoi(sequence)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse stream entry ID %q: %w", messages[0].ID, err)
+	}
+	return version, nil
+}
+
+func (s *RedisEventStore) headPosition(ctx context.Context, c redis.Cmdable) (int64, error) {
+	head, err := c.Get(ctx, s.positionKey()).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read head position: %w", err)
+	}
+	return head, nil
+}
+
+// rangeStream reads the entries of stream between start and end, inclusive,
+// at most count of them when count is positive.
+func (s *RedisEventStore) rangeStream(ctx context.Context, stream, start, end string, count int64) ([]domain.EventEnvelope[any], error) {
+	var messages []redis.XMessage
+	var err error
+	if count > 0 {
+		messages, err = s.client.XRangeN(ctx, stream, start, end, count).Result()
+	} else {
+		messages, err = s.client.XRange(ctx, stream, start, end).Result()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream %s: %w", stream, err)
+	}
+
+	envelopes := make([]domain.EventEnvelope[any], len(messages))
+	for i, message := range messages {
+		if envelopes[i], err = redisMessageToEnvelope(message); err != nil {
+			return nil, err
+		}
+	}
+	return envelopes, nil
+}
+
+func (s *RedisEventStore) streamKey(aggregateID string) string {
+	return s.prefix + "events:" + aggregateID
+}
+
+func (s *RedisEventStore) allKey() string {
+	return s.prefix + "all"
+}
+
+func (s *RedisEventStore) positionKey() string {
+	return s.prefix + "position"
+}
+
+func (s *RedisEventStore) eventIDsKey() string {
+	return s.prefix + "event-ids"
+}
+
+func redisStreamValues(env domain.EventEnvelope[any]) (map[string]any, error) {
+	payload, err := toAnyMap(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert payload: %w", err)
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	metadata := env.Metadata
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	return map[string]any{
+		"id":             env.ID,
+		"aggregate_id":   env.AggregateID,
+		"event_type":     env.EventType,
+		"sequence_no":    env.SequenceNo,
+		"position":       env.Position,
+		"transaction_id": env.TransactionID,
+		"payload":        string(payloadJSON),
+		"metadata":       string(metadataJSON),
+		"created_at":     env.Created.UTC().Format(redisTimeFormat),
+	}, nil
+}
+
+func redisMessageToEnvelope(message redis.XMessage) (domain.EventEnvelope[any], error) {
+	field := func(name string) string {
+		value, _ := message.Values[name].(string)
+		return value
+	}
+
+	id := field("id")
+	sequenceNo, err := strconv.Atoi(field("sequence_no"))
+	if err != nil {
+		return domain.EventEnvelope[any]{}, fmt.Errorf("failed to parse sequence_no of event %s: %w", id, err)
+	}
+	position, err := strconv.ParseInt(field("position"), 10, 64)
+	if err != nil {
+		return domain.EventEnvelope[any]{}, fmt.Errorf("failed to parse position of event %s: %w", id, err)
+	}
+	created, err := time.Parse(redisTimeFormat, field("created_at"))
+	if err != nil {
+		return domain.EventEnvelope[any]{}, fmt.Errorf("failed to parse created_at of event %s: %w", id, err)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(field("payload")), &payload); err != nil {
+		return domain.EventEnvelope[any]{}, fmt.Errorf("failed to unmarshal payload of event %s: %w", id, err)
+	}
+	var metadata map[string]any
+	if err := json.Unmarshal([]byte(field("metadata")), &metadata); err != nil {
+		return domain.EventEnvelope[any]{}, fmt.Errorf("failed to unmarshal metadata of event %s: %w", id, err)
+	}
+
+	return domain.EventEnvelope[any]{
+		ID:            id,
+		AggregateID:   field("aggregate_id"),
+		EventType:     field("event_type"),
+		Payload:       payload,
+		Created:       created,
+		SequenceNo:    sequenceNo,
+		Position:      position,
+		TransactionID: field("transaction_id"),
+		Metadata:      metadata,
+	}, nil
+}
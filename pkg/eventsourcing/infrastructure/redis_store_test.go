@@ -0,0 +1,343 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/ksuid"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+// setupRedisStore returns a RedisEventStore on the server at REDIS_TEST_URL
+// (a redis:// URL), skipping the test when it is unset. Each store gets its
+// own key prefix, and its keys are deleted when the test ends.
+func setupRedisStore(t *testing.T) domain.EventStore {
+	t.Helper()
+
+	url := os.Getenv("REDIS_TEST_URL")
+	if url == "" {
+		t.Skip("REDIS_TEST_URL not set")
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("failed to parse REDIS_TEST_URL: %v", err)
+	}
+	client := redis.NewClient(opts)
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		t.Fatalf("failed to reach Redis at REDIS_TEST_URL: %v", err)
+	}
+
+	prefix := fmt.Sprintf("{pericarp-test-%s}:", ksuid.New().String())
+	t.Cleanup(func() {
+		iter := client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			_ = client.Del(ctx, iter.Val()).Err()
+		}
+		_ = client.Close()
+	})
+	return infrastructure.NewRedisEventStore(client, infrastructure.WithRedisKeyPrefix(prefix))
+}
+
+func TestRedisStore_Integration(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("round-trips events in sequence order", func(t *testing.T) {
+		store := setupRedisStore(t)
+
+		event := createTestEventWithTxID("agg-1", "ev-1", "test.created", 1, "tx-1")
+		event.Metadata["actor"] = "alice"
+		if err := store.Append(ctx, "agg-1", 0, event,
+			createTestEvent("agg-1", "ev-2", "test.updated", 2)); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+
+		events, err := store.GetEvents(ctx, "agg-1")
+		if err != nil {
+			t.Fatalf("GetEvents failed: %v", err)
+		}
+		assertEventIDs(t, events, []string{"ev-1", "ev-2"})
+		got := events[0]
+		if got.EventType != "test.created" || got.SequenceNo != 1 || got.TransactionID != "tx-1" {
+			t.Errorf("unexpected envelope: %+v", got)
+		}
+		if payload, _ := got.Payload.(map[string]any); payload["test"] != "data" {
+			t.Errorf("payload = %v, want test=data", got.Payload)
+		}
+		if got.Metadata["actor"] != "alice" {
+			t.Errorf("metadata = %v, want actor=alice", got.Metadata)
+		}
+		if !got.Created.Equal(event.Created) {
+			t.Errorf("created = %v, want %v", got.Created, event.Created)
+		}
+
+		version, err := store.GetCurrentVersion(ctx, "agg-1")
+		if err != nil {
+			t.Fatalf("GetCurrentVersion failed: %v", err)
+		}
+		if version != 2 {
+			t.Errorf("version = %d, want 2", version)
+		}
+	})
+
+	t.Run("reads version ranges", func(t *testing.T) {
+		store := setupRedisStore(t)
+
+		for seq := 1; seq <= 5; seq++ {
+			if err := store.Append(ctx, "agg-1", seq-1,
+				createTestEvent("agg-1", fmt.Sprintf("ev-%d", seq), "test.updated", seq)); err != nil {
+				t.Fatalf("failed to append event %d: %v", seq, err)
+			}
+		}
+
+		events, err := store.GetEventsFromVersion(ctx, "agg-1", 4)
+		if err != nil {
+			t.Fatalf("GetEventsFromVersion failed: %v", err)
+		}
+		assertEventIDs(t, events, []string{"ev-4", "ev-5"})
+
+		events, err = store.GetEventsRange(ctx, "agg-1", 2, 3)
+		if err != nil {
+			t.Fatalf("GetEventsRange failed: %v", err)
+		}
+		assertEventIDs(t, events, []string{"ev-2", "ev-3"})
+
+		events, err = store.GetEventsRange(ctx, "agg-1", -1, 1)
+		if err != nil {
+			t.Fatalf("GetEventsRange failed: %v", err)
+		}
+		assertEventIDs(t, events, []string{"ev-1"})
+	})
+
+	t.Run("rejects a stale expected version", func(t *testing.T) {
+		store := setupRedisStore(t)
+
+		if err := store.Append(ctx, "agg-1", 0, createTestEvent("agg-1", "ev-1", "test.created", 1)); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+		err := store.Append(ctx, "agg-1", 0, createTestEvent("agg-1", "ev-2", "test.updated", 2))
+		if !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("error = %v, want ErrConcurrencyConflict", err)
+		}
+	})
+
+	t.Run("never overwrites without a version check", func(t *testing.T) {
+		store := setupRedisStore(t)
+
+		if err := store.Append(ctx, "agg-1", -1, createTestEvent("agg-1", "ev-1", "test.created", 1)); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+		err := store.Append(ctx, "agg-1", -1,
+			createTestEvent("agg-1", "colliding", "test.updated", 1),
+			createTestEvent("agg-1", "new", "test.updated", 2),
+		)
+		if !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("error = %v, want ErrConcurrencyConflict", err)
+		}
+		err = store.Append(ctx, "agg-2", -1, createTestEvent("agg-2", "ev-1", "test.created", 1))
+		if !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("duplicate event ID: error = %v, want ErrConcurrencyConflict", err)
+		}
+
+		events, err := store.GetEvents(ctx, "agg-1")
+		if err != nil {
+			t.Fatalf("GetEvents failed: %v", err)
+		}
+		assertEventIDs(t, events, []string{"ev-1"})
+	})
+
+	t.Run("looks up events by ID and transaction", func(t *testing.T) {
+		store := setupRedisStore(t)
+
+		if err := store.Append(ctx, "agg-b", -1,
+			createTestEventWithTxID("agg-b", "ev-1", "test.created", 1, "tx-1")); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+		if err := store.Append(ctx, "agg-a", -1,
+			createTestEventWithTxID("agg-a", "ev-2", "test.created", 1, "tx-1"),
+			createTestEventWithTxID("agg-a", "ev-3", "test.updated", 2, "tx-2")); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+
+		event, err := store.GetEventByID(ctx, "ev-3")
+		if err != nil {
+			t.Fatalf("GetEventByID failed: %v", err)
+		}
+		if event.AggregateID != "agg-a" || event.SequenceNo != 2 || event.Position != 3 {
+			t.Errorf("unexpected event: %+v", event)
+		}
+		if _, err := store.GetEventByID(ctx, "missing"); !errors.Is(err, domain.ErrEventNotFound) {
+			t.Errorf("error = %v, want ErrEventNotFound", err)
+		}
+
+		events, err := store.GetEventsByTransactionID(ctx, "tx-1")
+		if err != nil {
+			t.Fatalf("GetEventsByTransactionID failed: %v", err)
+		}
+		assertEventIDs(t, events, []string{"ev-2", "ev-1"})
+	})
+
+	t.Run("concurrent appends have one winner per version", func(t *testing.T) {
+		store := setupRedisStore(t)
+
+		const writers = 8
+		var wg sync.WaitGroup
+		errs := make([]error, writers)
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = store.Append(ctx, "agg-1", 0,
+					createTestEvent("agg-1", fmt.Sprintf("ev-%d", i), "test.created", 1))
+			}()
+		}
+		wg.Wait()
+
+		won := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				won++
+			case !errors.Is(err, domain.ErrConcurrencyConflict):
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		if won != 1 {
+			t.Errorf("%d appends won, want 1", won)
+		}
+	})
+
+	t.Run("concurrent appends to different aggregates get dense positions", func(t *testing.T) {
+		store := setupRedisStore(t)
+
+		const writers = 8
+		var wg sync.WaitGroup
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				aggregateID := fmt.Sprintf("agg-%d", i)
+				if err := store.Append(ctx, aggregateID, 0,
+					createTestEvent(aggregateID, aggregateID+"-ev", "test.created", 1)); err != nil {
+					t.Errorf("failed to append %s: %v", aggregateID, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		events, err := store.ReadAfter(ctx, 0, 0)
+		if err != nil {
+			t.Fatalf("ReadAfter failed: %v", err)
+		}
+		if len(events) != writers {
+			t.Fatalf("got %d events, want %d", len(events), writers)
+		}
+		for i, event := range events {
+			if event.Position != int64(i+1) {
+				t.Errorf("event %d has position %d, want %d", i, event.Position, i+1)
+			}
+		}
+	})
+}
+
+// newMiniredisStore returns a RedisEventStore on an in-process miniredis
+// server, for tests that must run without REDIS_TEST_URL.
+func newMiniredisStore(t *testing.T, opts ...infrastructure.RedisEventStoreOption) (*infrastructure.RedisEventStore, *miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return infrastructure.NewRedisEventStore(client, opts...), server, client
+}
+
+// contendingHook makes every append transaction lose to a concurrent append
+// by bumping the store's position counter just before EXEC.
+type contendingHook struct {
+	server *miniredis.Miniredis
+	key    string
+	execs  *atomic.Int32
+}
+
+func (h contendingHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h contendingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook { return next }
+
+func (h contendingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !slices.ContainsFunc(cmds, func(cmd redis.Cmder) bool { return cmd.Name() == "exec" }) {
+			return next(ctx, cmds)
+		}
+		h.execs.Add(1)
+		if _, err := h.server.Incr(h.key, 1); err != nil {
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func TestRedisStore_Append(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("rejects a stale expected version", func(t *testing.T) {
+		t.Parallel()
+		store, _, _ := newMiniredisStore(t)
+
+		if err := store.Append(ctx, "agg-1", 0, createTestEvent("agg-1", "ev-1", "test.created", 1)); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+		err := store.Append(ctx, "agg-1", 0, createTestEvent("agg-1", "ev-2", "test.updated", 2))
+		if !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("error = %v, want ErrConcurrencyConflict", err)
+		}
+		if err := store.Append(ctx, "agg-1", 1, createTestEvent("agg-1", "ev-2", "test.updated", 2)); err != nil {
+			t.Fatalf("append at the current version: %v", err)
+		}
+	})
+
+	t.Run("rejects a duplicate event ID", func(t *testing.T) {
+		t.Parallel()
+		store, _, _ := newMiniredisStore(t)
+
+		if err := store.Append(ctx, "agg-1", -1, createTestEvent("agg-1", "ev-1", "test.created", 1)); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+		err := store.Append(ctx, "agg-2", -1, createTestEvent("agg-2", "ev-1", "test.created", 1))
+		if !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("error = %v, want ErrConcurrencyConflict", err)
+		}
+		if events, err := store.GetEvents(ctx, "agg-2"); err != nil || len(events) != 0 {
+			t.Errorf("GetEvents() = %d events, %v, want none", len(events), err)
+		}
+	})
+
+	t.Run("gives up after the retry limit", func(t *testing.T) {
+		t.Parallel()
+		store, server, client := newMiniredisStore(t, infrastructure.WithRedisAppendRetries(2))
+		var execs atomic.Int32
+		client.AddHook(contendingHook{server: server, key: infrastructure.DefaultRedisKeyPrefix + "position", execs: &execs})
+
+		err := store.Append(ctx, "agg-1", 0, createTestEvent("agg-1", "ev-1", "test.created", 1))
+		if !errors.Is(err, domain.ErrConcurrencyConflict) {
+			t.Fatalf("error = %v, want ErrConcurrencyConflict", err)
+		}
+		if got := execs.Load(); got != 3 {
+			t.Errorf("transactions = %d, want the first attempt and 2 retries", got)
+		}
+		if events, err := store.GetEvents(ctx, "agg-1"); err != nil || len(events) != 0 {
+			t.Errorf("GetEvents() = %d events, %v, want none", len(events), err)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+func TestSnapshotStores(t *testing.T) {
+	t.Parallel()
+
+	stores := []struct {
+		name string
+		new  func(t *testing.T) domain.SnapshotStore
+	}{
+		{"memory", func(t *testing.T) domain.SnapshotStore { return infrastructure.NewMemorySnapshotStore() }},
+		{"gorm", func(t *testing.T) domain.SnapshotStore {
+			store, err := infrastructure.NewGormSnapshotStore(newTestGormDB(t))
+			if err != nil {
+				t.Fatalf("failed to create gorm snapshot store: %v", err)
+			}
+			return store
+		}},
+	}
+
+	for _, tc := range stores {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			store := tc.new(t)
+
+			if _, err := store.LoadSnapshot(ctx, "agg-1"); !errors.Is(err, domain.ErrSnapshotNotFound) {
+				t.Fatalf("expected ErrSnapshotNotFound, got %v", err)
+			}
+
+			for _, save := range []struct {
+				seq   int
+				state string
+			}{{10, "ten"}, {30, "thirty"}, {20, "twenty"}} {
+				if err := store.SaveSnapshot(ctx, domain.Snapshot{AggregateID: "agg-1", SequenceNo: save.seq, State: []byte(save.state)}); err != nil {
+					t.Fatalf("SaveSnapshot(%d) failed: %v", save.seq, err)
+				}
+			}
+
+			snapshot, err := store.LoadSnapshot(ctx, "agg-1")
+			if err != nil {
+				t.Fatalf("LoadSnapshot failed: %v", err)
+			}
+			if snapshot.SequenceNo != 30 || string(snapshot.State) != "thirty" {
+				t.Errorf("expected the highest snapshot (30, thirty), got (%d, %s)", snapshot.SequenceNo, snapshot.State)
+			}
+			if _, err := store.LoadSnapshot(ctx, "agg-2"); !errors.Is(err, domain.ErrSnapshotNotFound) {
+				t.Errorf("expected snapshots to be per aggregate, got %v", err)
+			}
+
+			// A snapshot of another schema version replaces the stored one
+			// even at a lower sequence number.
+			if err := store.SaveSnapshot(ctx, domain.Snapshot{AggregateID: "agg-1", SequenceNo: 25, SchemaVersion: 2, State: []byte("v2")}); err != nil {
+				t.Fatalf("SaveSnapshot(v2) failed: %v", err)
+			}
+			snapshot, err = store.LoadSnapshot(ctx, "agg-1")
+			if err != nil {
+				t.Fatalf("LoadSnapshot failed: %v", err)
+			}
+			if snapshot.SchemaVersion != 2 || snapshot.SequenceNo != 25 || string(snapshot.State) != "v2" {
+				t.Errorf("expected the v2 snapshot to replace the stale one, got %+v", snapshot)
+			}
+		})
+	}
+}
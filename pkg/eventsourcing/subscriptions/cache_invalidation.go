@@ -0,0 +1,277 @@
+package subscriptions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+const (
+	// DefaultInvalidationRetries is how many times a failed Cache.Invalidate
+	// call is retried (after the initial attempt) within one Handle call.
+	DefaultInvalidationRetries = 3
+
+	// DefaultInvalidationBackoff is the first delay between invalidation
+	// attempts; it doubles per attempt.
+	DefaultInvalidationBackoff = 50 * time.Millisecond
+)
+
+// Cache is the read-side cache a CacheInvalidator busts. Implementations
+// adapt Redis, memcached or an in-process cache; Invalidate must tolerate keys
+// that are not present.
+type Cache interface {
+	Invalidate(ctx context.Context, keys []string) error
+}
+
+// KeyFunc derives the cache keys to invalidate for an event.
+type KeyFunc func(event domain.EventEnvelope[any]) ([]string, error)
+
+// CacheInvalidator maps event types to cache keys and invalidates them when a
+// matching event is handled. It is a projector: Handle satisfies the Handler
+// signature, so run it as a Subscriber for durable, checkpointed invalidation,
+// or register it with EventDispatcher.SubscribeWildcard for in-commit
+// invalidation.
+//
+// Invalidation failures never fail a commit. Handle retries the cache with
+// backoff, logs, and then returns the error: under a Subscriber the batch is
+// retried (and the event parked if a ParkingLot is configured), so the cache
+// cannot serve stale data indefinitely; under the dispatcher the unit of work
+// treats the error as non-fatal.
+type CacheInvalidator struct {
+	cache   Cache
+	logger  *slog.Logger
+	retries int
+	backoff time.Duration
+
+	mu    sync.RWMutex
+	rules []invalidationRule
+}
+
+type invalidationRule struct {
+	pattern string
+	keys    []KeyFunc
+}
+
+// CacheInvalidatorOption configures a CacheInvalidator.
+type CacheInvalidatorOption func(*CacheInvalidator)
+
+// WithInvalidationRetries sets how many times a failed invalidation is
+// retried and the first delay between attempts, which doubles per attempt
+// (defaults DefaultInvalidationRetries and DefaultInvalidationBackoff).
+func WithInvalidationRetries(n int, backoff time.Duration) CacheInvalidatorOption {
+	return func(c *CacheInvalidator) {
+		c.retries = n
+		c.backoff = backoff
+	}
+}
+
+// WithInvalidationLogger sets the logger for failed invalidations (default
+// slog.Default()).
+func WithInvalidationLogger(logger *slog.Logger) CacheInvalidatorOption {
+	return func(c *CacheInvalidator) { c.logger = logger }
+}
+
+// NewCacheInvalidator creates an invalidator with no rules; add them with On.
+func NewCacheInvalidator(cache Cache, opts ...CacheInvalidatorOption) (*CacheInvalidator, error) {
+	if cache == nil {
+		return nil, errors.New("cache must not be nil")
+	}
+	c := &CacheInvalidator{
+		cache:   cache,
+		logger:  slog.Default(),
+		retries: DefaultInvalidationRetries,
+		backoff: DefaultInvalidationBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retries < 0 {
+		return nil, fmt.Errorf("invalidation retries must not be negative, got %d", c.retries)
+	}
+	if c.retries > 0 && c.backoff <= 0 {
+		return nil, fmt.Errorf("invalidation backoff must be positive, got %v", c.backoff)
+	}
+	if c.logger == nil {
+		c.logger = slog.Default()
+	}
+	return c, nil
+}
+
+// On registers key derivations for an event type. eventType may use the same
+// dot-separated wildcards as EventDispatcher subscriptions ("user.*",
+// "*.deleted", "*.*"), matched by domain.MatchesEventType. Keys from every
+// matching rule are invalidated together.
+func (c *CacheInvalidator) On(eventType string, keys ...KeyFunc) error {
+	if eventType == "" {
+		return errors.New("event type cannot be empty")
+	}
+	if len(keys) == 0 {
+		return errors.New("at least one key function is required")
+	}
+	for _, k := range keys {
+		if k == nil {
+			return errors.New("key function cannot be nil")
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = append(c.rules, invalidationRule{pattern: eventType, keys: keys})
+	return nil
+}
+
+// Handle derives the keys for event and invalidates them. Events with no
+// matching rule are ignored.
+func (c *CacheInvalidator) Handle(ctx context.Context, event domain.EventEnvelope[any]) error {
+	keys, err := c.keysFor(event)
+	if err != nil {
+		c.logger.Error("cache invalidation: deriving keys failed",
+			"event_id", event.ID, "event_type", event.EventType, "error", err)
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := c.invalidate(ctx, keys); err != nil {
+		c.logger.Error("cache invalidation failed",
+			"event_id", event.ID, "event_type", event.EventType, "keys", keys, "error", err)
+		return fmt.Errorf("invalidate %v for event %s: %w", keys, event.ID, err)
+	}
+	return nil
+}
+
+// invalidate calls the cache, retrying with doubling backoff until it
+// succeeds, the retries are spent, or ctx is cancelled.
+func (c *CacheInvalidator) invalidate(ctx context.Context, keys []string) error {
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := c.cache.Invalidate(ctx, keys)
+		if err == nil || attempt >= c.retries {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// keysFor collects de-duplicated keys from every rule matching the event type.
+func (c *CacheInvalidator) keysFor(event domain.EventEnvelope[any]) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, rule := range c.rules {
+		if !domain.MatchesEventType(rule.pattern, event.EventType) {
+			continue
+		}
+		for _, fn := range rule.keys {
+			derived, err := fn(event)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", rule.pattern, err)
+			}
+			for _, k := range derived {
+				if k != "" && !seen[k] {
+					seen[k] = true
+					keys = append(keys, k)
+				}
+			}
+		}
+	}
+	return keys, nil
+}
+
+// KeyTemplate returns a KeyFunc that expands placeholders in template:
+// {aggregate_id}, {event_type}, {metadata.<key>} and {payload.<field>}, where
+// payload fields are resolved by their JSON names. A placeholder that cannot
+// be resolved is an error rather than an empty segment, so a typo cannot
+// silently invalidate the wrong key.
+//
+//	KeyTemplate("user:{aggregate_id}")
+//	KeyTemplate("tenant:{metadata.account_id}:users")
+func KeyTemplate(template string) KeyFunc {
+	return func(event domain.EventEnvelope[any]) ([]string, error) {
+		var (
+			out     strings.Builder
+			payload map[string]any
+			rest    = template
+		)
+		for {
+			start := strings.IndexByte(rest, '{')
+			if start < 0 {
+				out.WriteString(rest)
+				break
+			}
+			end := strings.IndexByte(rest[start:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated placeholder in %q", template)
+			}
+			out.WriteString(rest[:start])
+			name := rest[start+1 : start+end]
+			rest = rest[start+end+1:]
+
+			var (
+				value any
+				ok    bool
+			)
+			switch {
+			case name == "aggregate_id":
+				value, ok = event.AggregateID, event.AggregateID != ""
+			case name == "event_type":
+				value, ok = event.EventType, true
+			case strings.HasPrefix(name, "metadata."):
+				value, ok = event.Metadata[strings.TrimPrefix(name, "metadata.")]
+			case strings.HasPrefix(name, "payload."):
+				if payload == nil {
+					var err error
+					if payload, err = payloadFields(event.Payload); err != nil {
+						return nil, err
+					}
+				}
+				value, ok = payload[strings.TrimPrefix(name, "payload.")]
+			default:
+				return nil, fmt.Errorf("unknown placeholder {%s} in %q", name, template)
+			}
+			if !ok || value == nil {
+				return nil, fmt.Errorf("placeholder {%s} has no value for event %s", name, event.ID)
+			}
+			fmt.Fprint(&out, value)
+		}
+		return []string{out.String()}, nil
+	}
+}
+
+// payloadFields views a payload as a JSON object: maps are used directly,
+// anything else is round-tripped through encoding/json. Numbers decode as
+// json.Number, so they render exactly as encoded rather than as float64
+// (12345678, not 1.2345678e+07).
+func payloadFields(payload any) (map[string]any, error) {
+	if m, ok := payload.(map[string]any); ok {
+		return m, nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+	var m map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,242 @@
+package subscriptions_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/application"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/subscriptions"
+)
+
+// recordingCache records invalidated keys and fails the first failures calls.
+type recordingCache struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	keys     [][]string
+}
+
+func (c *recordingCache) Invalidate(_ context.Context, keys []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.calls <= c.failures {
+		return errors.New("cache unavailable")
+	}
+	c.keys = append(c.keys, keys)
+	return nil
+}
+
+func (c *recordingCache) invalidated() [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]string(nil), c.keys...)
+}
+
+func quietLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestCacheInvalidator_DerivesKeysFromMatchingRules(t *testing.T) {
+	t.Parallel()
+
+	cache := &recordingCache{}
+	inv, err := subscriptions.NewCacheInvalidator(cache, subscriptions.WithInvalidationLogger(quietLogger()))
+	if err != nil {
+		t.Fatalf("NewCacheInvalidator: %v", err)
+	}
+	if err := inv.On("user.*", subscriptions.KeyTemplate("user:{aggregate_id}")); err != nil {
+		t.Fatalf("On: %v", err)
+	}
+	if err := inv.On("user.email_changed",
+		subscriptions.KeyTemplate("user-by-email:{payload.email}"),
+		subscriptions.KeyTemplate("tenant:{metadata.account_id}:users"),
+	); err != nil {
+		t.Fatalf("On: %v", err)
+	}
+
+	type emailChanged struct {
+		Email string `json:"email"`
+	}
+	event := domain.ToAnyEnvelope(domain.NewEventEnvelope(emailChanged{Email: "a@example.com"}, "u-1", "user.email_changed", 2))
+	event.Metadata["account_id"] = "acct-9"
+
+	if err := inv.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if err := inv.Handle(context.Background(), domain.EventEnvelope[any]{ID: "x", EventType: "order.placed"}); err != nil {
+		t.Fatalf("Handle unmatched: %v", err)
+	}
+
+	want := [][]string{{"user:u-1", "user-by-email:a@example.com", "tenant:acct-9:users"}}
+	if got := cache.invalidated(); !reflect.DeepEqual(got, want) {
+		t.Errorf("invalidated = %v, want %v", got, want)
+	}
+}
+
+func TestCacheInvalidator_RetriesThenSucceeds(t *testing.T) {
+	t.Parallel()
+
+	cache := &recordingCache{failures: 2}
+	inv, err := subscriptions.NewCacheInvalidator(cache,
+		subscriptions.WithInvalidationRetries(2, time.Millisecond),
+		subscriptions.WithInvalidationLogger(quietLogger()))
+	if err != nil {
+		t.Fatalf("NewCacheInvalidator: %v", err)
+	}
+	if err := inv.On("user.updated", subscriptions.KeyTemplate("user:{aggregate_id}")); err != nil {
+		t.Fatalf("On: %v", err)
+	}
+
+	if err := inv.Handle(context.Background(), domain.EventEnvelope[any]{ID: "e1", AggregateID: "u-1", EventType: "user.updated"}); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if got := len(cache.invalidated()); got != 1 {
+		t.Errorf("expected 1 successful invalidation, got %d", got)
+	}
+}
+
+func TestCacheInvalidator_UnresolvedPlaceholderIsAnError(t *testing.T) {
+	t.Parallel()
+
+	cache := &recordingCache{}
+	inv, err := subscriptions.NewCacheInvalidator(cache, subscriptions.WithInvalidationLogger(quietLogger()))
+	if err != nil {
+		t.Fatalf("NewCacheInvalidator: %v", err)
+	}
+	if err := inv.On("user.updated", subscriptions.KeyTemplate("tenant:{metadata.account_id}")); err != nil {
+		t.Fatalf("On: %v", err)
+	}
+
+	if err := inv.Handle(context.Background(), domain.EventEnvelope[any]{ID: "e1", EventType: "user.updated"}); err == nil {
+		t.Fatal("expected error for missing metadata key")
+	}
+	if got := len(cache.invalidated()); got != 0 {
+		t.Errorf("expected no invalidation, got %d", got)
+	}
+}
+
+func TestCacheInvalidator_RendersNumericPayloadFieldsExactly(t *testing.T) {
+	t.Parallel()
+
+	cache := &recordingCache{}
+	inv, err := subscriptions.NewCacheInvalidator(cache, subscriptions.WithInvalidationLogger(quietLogger()))
+	if err != nil {
+		t.Fatalf("NewCacheInvalidator: %v", err)
+	}
+	if err := inv.On("order.placed", subscriptions.KeyTemplate("customer:{payload.customer_id}:orders")); err != nil {
+		t.Fatalf("On: %v", err)
+	}
+
+	type orderPlaced struct {
+		CustomerID int64 `json:"customer_id"`
+	}
+	event := domain.ToAnyEnvelope(domain.NewEventEnvelope(orderPlaced{CustomerID: 12345678}, "o-1", "order.placed", 1))
+	if err := inv.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	want := [][]string{{"customer:12345678:orders"}}
+	if got := cache.invalidated(); !reflect.DeepEqual(got, want) {
+		t.Errorf("invalidated = %v, want %v", got, want)
+	}
+}
+
+func TestCacheInvalidator_MatchesLikeSubscribe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern   string
+		eventType string
+		want      bool
+	}{
+		{pattern: "user.created", eventType: "user.created", want: true},
+		{pattern: "user.*", eventType: "user.created", want: true},
+		{pattern: "*.created", eventType: "user.created", want: true},
+		{pattern: "*.*", eventType: "user.created", want: true},
+		{pattern: "*", eventType: "user.created", want: false},
+		{pattern: "*", eventType: "heartbeat", want: true},
+		{pattern: "order.*", eventType: "user.created", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.eventType, func(t *testing.T) {
+			t.Parallel()
+
+			cache := &recordingCache{}
+			inv, err := subscriptions.NewCacheInvalidator(cache, subscriptions.WithInvalidationLogger(quietLogger()))
+			if err != nil {
+				t.Fatalf("NewCacheInvalidator: %v", err)
+			}
+			if err := inv.On(tt.pattern, subscriptions.KeyTemplate("{event_type}")); err != nil {
+				t.Fatalf("On: %v", err)
+			}
+			if err := inv.Handle(context.Background(), domain.EventEnvelope[any]{ID: "e1", EventType: tt.eventType}); err != nil {
+				t.Fatalf("Handle: %v", err)
+			}
+			if got := len(cache.invalidated()) == 1; got != tt.want {
+				t.Errorf("invalidated = %v, want %v", got, tt.want)
+			}
+			if got := domain.MatchesEventType(tt.pattern, tt.eventType); got != tt.want {
+				t.Errorf("domain.MatchesEventType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCacheInvalidator_FailureDoesNotFailCommit wires the invalidator into
+// the in-commit dispatcher: the cache is down for good, yet the commit
+// succeeds and the events are persisted.
+func TestCacheInvalidator_FailureDoesNotFailCommit(t *testing.T) {
+	t.Parallel()
+
+	cache := &recordingCache{failures: 1 << 30}
+	inv, err := subscriptions.NewCacheInvalidator(cache,
+		subscriptions.WithInvalidationRetries(1, time.Millisecond),
+		subscriptions.WithInvalidationLogger(quietLogger()))
+	if err != nil {
+		t.Fatalf("NewCacheInvalidator: %v", err)
+	}
+	if err := inv.On("*.*", subscriptions.KeyTemplate("{event_type}:{aggregate_id}")); err != nil {
+		t.Fatalf("On: %v", err)
+	}
+
+	dispatcher := domain.NewEventDispatcher()
+	if _, err := dispatcher.SubscribeWildcard(inv.Handle); err != nil {
+		t.Fatalf("SubscribeWildcard: %v", err)
+	}
+
+	store := infrastructure.NewMemoryStore()
+	uow := application.NewSimpleUnitOfWork(store, dispatcher)
+	entity := &recordingEntity{id: "u-1", events: []domain.EventEnvelope[any]{
+		domain.NewEventEnvelope[any](map[string]any{"name": "x"}, "u-1", "user.created", 1),
+	}}
+	if err := uow.Track(entity); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if err := uow.Commit(context.Background()); err != nil {
+		t.Fatalf("Commit should not fail on invalidation errors: %v", err)
+	}
+	events, err := store.GetEvents(context.Background(), "u-1")
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected the event to be persisted, got %d (%v)", len(events), err)
+	}
+}
+
+// recordingEntity is a minimal domain.Entity carrying pre-built events.
+type recordingEntity struct {
+	id     string
+	events []domain.EventEnvelope[any]
+}
+
+func (e *recordingEntity) GetID() string                                     { return e.id }
+func (e *recordingEntity) GetSequenceNo() int                                { return len(e.events) }
+func (e *recordingEntity) GetUncommittedEvents() []domain.EventEnvelope[any] { return e.events }
+func (e *recordingEntity) ClearUncommittedEvents()                           { e.events = nil }
@@ -7,6 +7,22 @@
 // Synchronous in-commit dispatch via the UnitOfWork's EventDispatcher is
 // unaffected; this package is for consumers that need resumable, transactional
 // background processing.
+//
+// # Delivery guarantees
+//
+// A crash between a handler finishing and its batch's checkpoint commit
+// redelivers the batch on restart. What that means depends on the setup:
+//
+//   - MemoryCheckpointStore, or any handler with effects outside the
+//     database: at-least-once. Handlers must be idempotent; at-least-once
+//     plus an idempotent handler is effectively-once.
+//   - GormCheckpointStore with a handler writing through TxFromContext (or a
+//     BatchFlusher): exactly-once effect. The writes and the checkpoint
+//     advance commit or roll back together, so redelivery re-applies nothing.
+//   - WithDeliveryTracking: a handler that completed before the crash is
+//     skipped on redelivery. A crash during the handler, before its
+//     completion is recorded, still re-runs it, so the handler must still be
+//     idempotent; the tracker narrows the window to a single event.
 package subscriptions
 
 import (
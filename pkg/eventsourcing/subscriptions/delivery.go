@@ -0,0 +1,78 @@
+package subscriptions
+
+import (
+	"context"
+	"sync"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// DeliveryTracker records which events a subscriber's handler has completed,
+// independently of the checkpoint. Wired with WithDeliveryTracking, it closes
+// the window between a handler finishing and its batch committing: events
+// redelivered after a crash in that window are skipped instead of handled
+// again. Use it for handlers whose effects cannot join the batch transaction
+// (HTTP calls, another database); handlers that write through TxFromContext
+// are already exactly-once and do not need it.
+//
+// Records must be durable independently of the batch transaction, otherwise
+// they roll back with the batch and protect nothing.
+type DeliveryTracker interface {
+	// Delivered reports whether the subscriber has completed event.
+	Delivered(ctx context.Context, subscriber string, event domain.EventEnvelope[any]) (bool, error)
+
+	// MarkDelivered records that the subscriber has completed event.
+	MarkDelivered(ctx context.Context, subscriber string, event domain.EventEnvelope[any]) error
+
+	// Forget drops the subscriber's records at or below position once the
+	// checkpoint covers them, so the records stay bounded and a checkpoint
+	// reset replays those events in full.
+	Forget(ctx context.Context, subscriber string, position int64) error
+}
+
+// MemoryDeliveryTracker is an in-memory DeliveryTracker for tests and
+// single-process setups. Its records do not survive a restart, so it only
+// guards against redelivery within one process (for example after a failed
+// checkpoint commit).
+type MemoryDeliveryTracker struct {
+	mu        sync.Mutex
+	delivered map[string]map[string]int64 // subscriber -> eventID -> position
+}
+
+var _ DeliveryTracker = (*MemoryDeliveryTracker)(nil)
+
+// NewMemoryDeliveryTracker creates an empty in-memory delivery tracker.
+func NewMemoryDeliveryTracker() *MemoryDeliveryTracker {
+	return &MemoryDeliveryTracker{delivered: make(map[string]map[string]int64)}
+}
+
+// Delivered reports whether the subscriber has completed event.
+func (m *MemoryDeliveryTracker) Delivered(ctx context.Context, subscriber string, event domain.EventEnvelope[any]) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.delivered[subscriber][event.ID]
+	return ok, nil
+}
+
+// MarkDelivered records that the subscriber has completed event.
+func (m *MemoryDeliveryTracker) MarkDelivered(ctx context.Context, subscriber string, event domain.EventEnvelope[any]) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.delivered[subscriber] == nil {
+		m.delivered[subscriber] = make(map[string]int64)
+	}
+	m.delivered[subscriber][event.ID] = event.Position
+	return nil
+}
+
+// Forget drops the subscriber's records at or below position.
+func (m *MemoryDeliveryTracker) Forget(ctx context.Context, subscriber string, position int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, pos := range m.delivered[subscriber] {
+		if pos <= position {
+			delete(m.delivered[subscriber], id)
+		}
+	}
+	return nil
+}
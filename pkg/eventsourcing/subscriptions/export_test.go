@@ -0,0 +1,149 @@
+package subscriptions_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/subscriptions"
+)
+
+// orderSummary is a read model exported in these tests.
+type orderSummary struct {
+	ID        string    `gorm:"primaryKey;column:id"`
+	AccountID string    `gorm:"column:account_id"`
+	Total     float64   `gorm:"column:total"`
+	Note      *string   `gorm:"column:note"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (orderSummary) TableName() string { return "order_summaries" }
+
+func newExportFixture(t *testing.T) (*gorm.DB, time.Time) {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "export.db")
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&orderSummary{}); err != nil {
+		t.Fatalf("failed to migrate read model: %v", err)
+	}
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	note := "gift, wrap it"
+	rows := []orderSummary{
+		{ID: "order-1", AccountID: "acct-a", Total: 10, UpdatedAt: base},
+		{ID: "order-2", AccountID: "acct-b", Total: 20.5, Note: &note, UpdatedAt: base.Add(time.Minute)},
+		{ID: "order-3", AccountID: "acct-a", Total: 30, UpdatedAt: base.Add(2 * time.Minute)},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("failed to seed read model: %v", err)
+	}
+	return db, base
+}
+
+func TestExportReadModel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("jsonl full export orders by updated_at", func(t *testing.T) {
+		t.Parallel()
+		db, base := newExportFixture(t)
+
+		var out bytes.Buffer
+		result, err := subscriptions.ExportReadModel(context.Background(), db, "order_summaries", &out, subscriptions.ExportOptions{})
+		if err != nil {
+			t.Fatalf("ExportReadModel() error = %v", err)
+		}
+		if result.Rows != 3 || !result.Watermark.Equal(base.Add(2*time.Minute)) {
+			t.Errorf("result = %+v, want 3 rows up to %v", result, base.Add(2*time.Minute))
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("got %d lines, want 3:\n%s", len(lines), out.String())
+		}
+		var ids []string
+		for _, line := range lines {
+			var row map[string]any
+			if err := json.Unmarshal([]byte(line), &row); err != nil {
+				t.Fatalf("line %q is not JSON: %v", line, err)
+			}
+			ids = append(ids, row["id"].(string))
+		}
+		if strings.Join(ids, ",") != "order-1,order-2,order-3" {
+			t.Errorf("ids = %v, want updated_at order", ids)
+		}
+	})
+
+	t.Run("csv incremental export for one account", func(t *testing.T) {
+		t.Parallel()
+		db, base := newExportFixture(t)
+
+		var out bytes.Buffer
+		result, err := subscriptions.ExportReadModel(context.Background(), db, "order_summaries", &out, subscriptions.ExportOptions{
+			Format:    subscriptions.ExportCSV,
+			Since:     base.Add(time.Minute),
+			AccountID: "acct-a",
+		})
+		if err != nil {
+			t.Fatalf("ExportReadModel() error = %v", err)
+		}
+		records, err := csv.NewReader(&out).ReadAll()
+		if err != nil {
+			t.Fatalf("output is not CSV: %v", err)
+		}
+		if len(records) != 2 || records[0][0] != "id" || records[1][0] != "order-3" {
+			t.Errorf("records = %v, want a header and order-3", records)
+		}
+		if result.Rows != 1 || !result.Watermark.Equal(base.Add(2*time.Minute)) {
+			t.Errorf("result = %+v, want 1 row up to %v", result, base.Add(2*time.Minute))
+		}
+	})
+
+	t.Run("csv writes NULL as empty and quotes values", func(t *testing.T) {
+		t.Parallel()
+		db, _ := newExportFixture(t)
+
+		var out bytes.Buffer
+		if _, err := subscriptions.ExportReadModel(context.Background(), db, "order_summaries", &out, subscriptions.ExportOptions{Format: subscriptions.ExportCSV}); err != nil {
+			t.Fatalf("ExportReadModel() error = %v", err)
+		}
+		records, err := csv.NewReader(&out).ReadAll()
+		if err != nil {
+			t.Fatalf("output is not CSV: %v", err)
+		}
+		noteColumn := -1
+		for i, name := range records[0] {
+			if name == "note" {
+				noteColumn = i
+			}
+		}
+		if noteColumn < 0 || records[1][noteColumn] != "" || records[2][noteColumn] != "gift, wrap it" {
+			t.Errorf("records = %v, want an empty NULL note and the quoted one intact", records)
+		}
+	})
+
+	t.Run("nothing new keeps the watermark", func(t *testing.T) {
+		t.Parallel()
+		db, base := newExportFixture(t)
+
+		since := base.Add(time.Hour)
+		var out bytes.Buffer
+		result, err := subscriptions.ExportReadModel(context.Background(), db, "order_summaries", &out, subscriptions.ExportOptions{Since: since})
+		if err != nil {
+			t.Fatalf("ExportReadModel() error = %v", err)
+		}
+		if result.Rows != 0 || !result.Watermark.Equal(since) || out.Len() != 0 {
+			t.Errorf("result = %+v, output %q, want nothing exported and the watermark kept", result, out.String())
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package subscriptions
+
+import (
+	"context"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// DisabledPolicy decides what a Subscriber's checkpoint does while its
+// feature flag is off.
+type DisabledPolicy int
+
+const (
+	// DisabledSkip advances the checkpoint past events the flag disables.
+	// The handler never sees them, even after the flag is turned back on;
+	// rebuild the read model (ResetCheckpoint) if it must be complete. Use
+	// it for percentage rollouts, where most disabled events belong to
+	// aggregates outside the rollout anyway.
+	DisabledSkip DisabledPolicy = iota
+
+	// DisabledPause stops the subscriber at the first disabled event: the
+	// events before it are handled and committed, and the checkpoint stays
+	// put until the flag enables that event, at which point the subscriber
+	// catches up from the checkpoint. Use it for kill-switches. A percentage
+	// rollout under DisabledPause stalls at the first event outside it.
+	DisabledPause
+)
+
+// WithFeatureFlag runs the handler only for events flags enables flag for,
+// so a projector can be rolled out gradually or switched off during an
+// incident without a redeploy. policy decides whether the checkpoint skips
+// the disabled events or pauses at them. flags is consulted once per event
+// per cycle, including for every re-read of a paused event, so it should
+// answer from memory.
+func WithFeatureFlag(flags domain.FeatureFlags, flag string, policy DisabledPolicy) SubscriberOption {
+	return func(s *Subscriber) {
+		s.flags = flags
+		s.flag = flag
+		s.disabledPolicy = policy
+	}
+}
+
+// enabled reports whether the subscriber's feature flag enables event.
+func (s *Subscriber) enabled(ctx context.Context, event domain.EventEnvelope[any]) bool {
+	return s.flags == nil || s.flags.Enabled(ctx, s.flag, event)
+}
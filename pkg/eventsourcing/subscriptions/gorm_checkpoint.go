@@ -12,9 +12,10 @@ import (
 // GormCheckpointModel is the GORM model for subscriber checkpoints. The table
 // is owned and auto-migrated by pericarp.
 type GormCheckpointModel struct {
-	Subscriber string    `gorm:"primaryKey;column:subscriber"`
-	Position   int64     `gorm:"column:position;not null"`
-	UpdatedAt  time.Time `gorm:"column:updated_at"`
+	Subscriber    string    `gorm:"primaryKey;column:subscriber"`
+	Position      int64     `gorm:"column:position;not null"`
+	SchemaVersion int       `gorm:"column:schema_version;not null;default:0"`
+	UpdatedAt     time.Time `gorm:"column:updated_at"`
 }
 
 // TableName returns the table name for the checkpoint model.
@@ -36,7 +37,10 @@ type GormCheckpointStore struct {
 	postgres bool
 }
 
-var _ CheckpointStore = (*GormCheckpointStore)(nil)
+var (
+	_ CheckpointStore = (*GormCheckpointStore)(nil)
+	_ SchemaMigrator  = (*GormCheckpointStore)(nil)
+)
 
 // NewGormCheckpointStore creates a checkpoint store and auto-migrates the
 // subscriber_checkpoints table. Like the event feed itself, it supports
@@ -122,6 +126,58 @@ func (g *GormCheckpointStore) Reset(ctx context.Context, subscriber string, posi
 	}).Create(&GormCheckpointModel{Subscriber: subscriber, Position: position, UpdatedAt: time.Now()}).Error
 }
 
+// MigrateSchema applies the pending migrations and records the new schema
+// version in one transaction, so a failed migration leaves both the read
+// model and the recorded version untouched. The transaction first locks the
+// subscriber's checkpoint row (on SQLite, takes the write lock), which makes
+// concurrent migrators wait and then see the updated version, and keeps
+// batches from being acquired while the schema changes. A Rebuild migration
+// resets the checkpoint to 0 in the same transaction.
+func (g *GormCheckpointStore) MigrateSchema(ctx context.Context, subscriber string, migrations []SchemaMigration) (int, error) {
+	if err := g.ensure(ctx, subscriber, 0); err != nil {
+		return 0, err
+	}
+
+	from := 0
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		row := tx.Model(&GormCheckpointModel{}).Where("subscriber = ?", subscriber)
+		if g.postgres {
+			row = row.Clauses(clause.Locking{Strength: "UPDATE"})
+		} else if err := tx.Model(&GormCheckpointModel{}).Where("subscriber = ?", subscriber).
+			Update("updated_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("failed to lock checkpoint row: %w", err)
+		}
+		var current GormCheckpointModel
+		if err := row.First(&current).Error; err != nil {
+			return fmt.Errorf("failed to lock checkpoint row: %w", err)
+		}
+		from = current.SchemaVersion
+
+		pending, err := pendingMigrations(migrations, from)
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+		migrationCtx := context.WithValue(ctx, txContextKey{}, tx)
+		rebuild := false
+		for _, migration := range pending {
+			if err := applyMigration(migrationCtx, subscriber, migration); err != nil {
+				return err
+			}
+			rebuild = rebuild || migration.Rebuild
+		}
+
+		updates := map[string]any{"schema_version": pending[len(pending)-1].Version, "updated_at": time.Now()}
+		if rebuild {
+			updates["position"] = 0
+		}
+		if err := tx.Model(&GormCheckpointModel{}).Where("subscriber = ?", subscriber).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+		return nil
+	})
+	return from, err
+}
+
 // ensure creates the checkpoint row at the given position if it doesn't exist.
 func (g *GormCheckpointStore) ensure(ctx context.Context, subscriber string, position int64) error {
 	err := g.db.WithContext(ctx).Clauses(clause.OnConflict{
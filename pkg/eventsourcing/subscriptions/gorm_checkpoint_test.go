@@ -3,6 +3,7 @@ package subscriptions_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"sync"
 	"testing"
@@ -390,3 +391,170 @@ func TestSubscriber_ResetReplayRebuildsProjection(t *testing.T) {
 		}
 	}
 }
+
+func TestGormCheckpointStore_MigrateSchema(t *testing.T) {
+	t.Parallel()
+
+	db, _, checkpoints := newGormFixture(t)
+	ctx := context.Background()
+	if err := checkpoints.Reset(ctx, "projector", 5); err != nil {
+		t.Fatalf("failed to seed checkpoint: %v", err)
+	}
+
+	addColumn := subscriptions.SchemaMigration{Version: 1, Migrate: func(ctx context.Context) error {
+		tx := subscriptions.TxFromContext(ctx)
+		if tx == nil {
+			return errors.New("migration expected the transaction in context")
+		}
+		return tx.Exec("ALTER TABLE projection_rows ADD COLUMN note TEXT").Error
+	}}
+	failing := subscriptions.SchemaMigration{Version: 2, Rebuild: true, Migrate: func(ctx context.Context) error {
+		return errors.New("boom")
+	}}
+
+	// A failed migration rolls back the whole set: no column, no version.
+	if _, err := checkpoints.MigrateSchema(ctx, "projector", []subscriptions.SchemaMigration{addColumn, failing}); err == nil {
+		t.Fatal("expected the failing migration to abort")
+	}
+	if db.Migrator().HasColumn(&projectionRow{}, "note") {
+		t.Fatal("expected the failed migration set to roll back")
+	}
+
+	failing.Migrate = nil
+	from, err := checkpoints.MigrateSchema(ctx, "projector", []subscriptions.SchemaMigration{addColumn, failing})
+	if err != nil {
+		t.Fatalf("MigrateSchema failed: %v", err)
+	}
+	if from != 0 {
+		t.Errorf("expected migration from version 0, got %d", from)
+	}
+	if !db.Migrator().HasColumn(&projectionRow{}, "note") {
+		t.Error("expected the migration to add the column")
+	}
+	if position, _ := checkpoints.Position(ctx, "projector"); position != 0 {
+		t.Errorf("expected the rebuild migration to reset the checkpoint, got %d", position)
+	}
+
+	// Re-running is a no-op.
+	if from, err := checkpoints.MigrateSchema(ctx, "projector", []subscriptions.SchemaMigration{addColumn, failing}); err != nil || from != 2 {
+		t.Fatalf("expected no-op at version 2, got from=%d err=%v", from, err)
+	}
+}
+
+// aggregateCountRow is a read model keyed by aggregate ID.
+type aggregateCountRow struct {
+	AggregateID string `gorm:"primaryKey;column:aggregate_id"`
+	LastEventID string `gorm:"column:last_event_id"`
+	Version     int    `gorm:"column:version"`
+}
+
+func (aggregateCountRow) TableName() string { return "aggregate_versions" }
+
+func TestUpsertBuffer_FlushesWithTheBatch(t *testing.T) {
+	t.Parallel()
+
+	db, store, checkpoints := newGormFixture(t)
+	if err := db.AutoMigrate(&aggregateCountRow{}); err != nil {
+		t.Fatalf("failed to migrate read model: %v", err)
+	}
+	ctx := context.Background()
+	for version := 1; version <= 3; version++ {
+		for _, aggregateID := range []string{"agg-a", "agg-b"} {
+			eventID := fmt.Sprintf("%s-%d", aggregateID, version)
+			if err := store.Append(ctx, aggregateID, version-1, createTestEvent(aggregateID, eventID, "test.updated", version)); err != nil {
+				t.Fatalf("failed to append %s: %v", eventID, err)
+			}
+		}
+	}
+
+	buffer := subscriptions.NewUpsertBuffer(func(r aggregateCountRow) string { return r.AggregateID }, []string{"aggregate_id"}, 0)
+	handler := func(ctx context.Context, event domain.EventEnvelope[any]) error {
+		buffer.Put(aggregateCountRow{AggregateID: event.AggregateID, LastEventID: event.ID, Version: event.SequenceNo})
+		return nil
+	}
+	sub, err := subscriptions.NewSubscriber("projector", store, checkpoints, handler,
+		subscriptions.WithPollInterval(subscriptionTestPollInterval),
+		subscriptions.WithBatchSize(4),
+		subscriptions.WithBatchFlusher(buffer))
+	if err != nil {
+		t.Fatalf("failed to create subscriber: %v", err)
+	}
+
+	stop := runSubscriber(t, sub)
+	waitForCheckpoint(t, checkpoints, "projector", 6)
+	stop()
+
+	var rows []aggregateCountRow
+	if err := db.Order("aggregate_id").Find(&rows).Error; err != nil {
+		t.Fatalf("failed to read read model: %v", err)
+	}
+	want := []aggregateCountRow{
+		{AggregateID: "agg-a", LastEventID: "agg-a-3", Version: 3},
+		{AggregateID: "agg-b", LastEventID: "agg-b-3", Version: 3},
+	}
+	if len(rows) != len(want) || rows[0] != want[0] || rows[1] != want[1] {
+		t.Fatalf("expected %v, got %v", want, rows)
+	}
+	if buffer.Len() != 0 {
+		t.Errorf("expected an empty buffer after flushing, got %d rows", buffer.Len())
+	}
+}
+
+func TestUpsertBuffer_FlushOutsideBatchFails(t *testing.T) {
+	t.Parallel()
+
+	buffer := subscriptions.NewUpsertBuffer(func(r aggregateCountRow) string { return r.AggregateID }, []string{"aggregate_id"}, 0)
+	if err := buffer.Flush(context.Background()); err != nil {
+		t.Fatalf("expected an empty flush to succeed, got %v", err)
+	}
+	buffer.Put(aggregateCountRow{AggregateID: "agg-a"})
+	buffer.Put(aggregateCountRow{AggregateID: "agg-a", Version: 2})
+	if buffer.Len() != 1 {
+		t.Fatalf("expected rows de-duplicated by key, got %d", buffer.Len())
+	}
+	if err := buffer.Flush(context.Background()); err == nil {
+		t.Fatal("expected flushing without a batch transaction to fail")
+	}
+	buffer.Reset()
+	if buffer.Len() != 0 {
+		t.Errorf("expected Reset to empty the buffer, got %d rows", buffer.Len())
+	}
+}
+
+func TestGormDeliveryTracker(t *testing.T) {
+	t.Parallel()
+
+	db, _, _ := newGormFixture(t)
+	tracker, err := subscriptions.NewGormDeliveryTracker(db)
+	if err != nil {
+		t.Fatalf("failed to create delivery tracker: %v", err)
+	}
+	ctx := context.Background()
+	first := domain.EventEnvelope[any]{ID: "ev-1", Position: 1}
+	second := domain.EventEnvelope[any]{ID: "ev-2", Position: 2}
+
+	for _, event := range []domain.EventEnvelope[any]{first, second, first} {
+		if err := tracker.MarkDelivered(ctx, "projector", event); err != nil {
+			t.Fatalf("MarkDelivered(%s) failed: %v", event.ID, err)
+		}
+	}
+	if delivered, err := tracker.Delivered(ctx, "other", first); err != nil || delivered {
+		t.Fatalf("expected records to be per subscriber, got %v, %v", delivered, err)
+	}
+
+	if err := tracker.Forget(ctx, "projector", 1); err != nil {
+		t.Fatalf("Forget failed: %v", err)
+	}
+	for _, tc := range []struct {
+		event domain.EventEnvelope[any]
+		want  bool
+	}{{first, false}, {second, true}} {
+		delivered, err := tracker.Delivered(ctx, "projector", tc.event)
+		if err != nil {
+			t.Fatalf("Delivered(%s) failed: %v", tc.event.ID, err)
+		}
+		if delivered != tc.want {
+			t.Errorf("Delivered(%s) = %v, want %v", tc.event.ID, delivered, tc.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+// GormDeliveryModel is the GORM model for delivery records. The table is
+// owned and auto-migrated by pericarp.
+type GormDeliveryModel struct {
+	Subscriber  string    `gorm:"primaryKey;column:subscriber;index:idx_subscriber_deliveries_position,priority:1"`
+	EventID     string    `gorm:"primaryKey;column:event_id"`
+	Position    int64     `gorm:"column:position;index:idx_subscriber_deliveries_position,priority:2"`
+	DeliveredAt time.Time `gorm:"column:delivered_at"`
+}
+
+// TableName returns the table name for the delivery model.
+func (GormDeliveryModel) TableName() string {
+	return "subscriber_deliveries"
+}
+
+// GormDeliveryTracker is a database-backed DeliveryTracker. It always writes
+// through its own connection, never the batch transaction, so a record
+// survives the batch rolling back — which is the case it exists for.
+type GormDeliveryTracker struct {
+	db *gorm.DB
+}
+
+var _ DeliveryTracker = (*GormDeliveryTracker)(nil)
+
+// NewGormDeliveryTracker creates a delivery tracker and auto-migrates the
+// subscriber_deliveries table.
+func NewGormDeliveryTracker(db *gorm.DB) (*GormDeliveryTracker, error) {
+	if err := db.AutoMigrate(&GormDeliveryModel{}); err != nil {
+		return nil, fmt.Errorf("failed to auto-migrate subscriber_deliveries table: %w", err)
+	}
+	return &GormDeliveryTracker{db: db}, nil
+}
+
+// Delivered reports whether the subscriber has completed event.
+func (g *GormDeliveryTracker) Delivered(ctx context.Context, subscriber string, event domain.EventEnvelope[any]) (bool, error) {
+	var count int64
+	err := g.db.WithContext(ctx).Model(&GormDeliveryModel{}).
+		Where("subscriber = ? AND event_id = ?", subscriber, event.ID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to look up delivery of event %s: %w", event.ID, err)
+	}
+	return count > 0, nil
+}
+
+// MarkDelivered records that the subscriber has completed event; marking an
+// event twice is a no-op.
+func (g *GormDeliveryTracker) MarkDelivered(ctx context.Context, subscriber string, event domain.EventEnvelope[any]) error {
+	model := GormDeliveryModel{
+		Subscriber:  subscriber,
+		EventID:     event.ID,
+		Position:    event.Position,
+		DeliveredAt: time.Now(),
+	}
+	err := g.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model).Error
+	if err != nil {
+		return fmt.Errorf("failed to record delivery of event %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// Forget drops the subscriber's records at or below position.
+func (g *GormDeliveryTracker) Forget(ctx context.Context, subscriber string, position int64) error {
+	err := g.db.WithContext(ctx).
+		Where("subscriber = ? AND position <= ?", subscriber, position).
+		Delete(&GormDeliveryModel{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to forget deliveries: %w", err)
+	}
+	return nil
+}
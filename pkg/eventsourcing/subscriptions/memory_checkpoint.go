@@ -13,15 +13,24 @@ type MemoryCheckpointStore struct {
 	mu        sync.Mutex
 	positions map[string]int64
 	held      map[string]bool
+
+	// schemaMu serializes MigrateSchema without holding mu while migrations
+	// run.
+	schemaMu       sync.Mutex
+	schemaVersions map[string]int
 }
 
-var _ CheckpointStore = (*MemoryCheckpointStore)(nil)
+var (
+	_ CheckpointStore = (*MemoryCheckpointStore)(nil)
+	_ SchemaMigrator  = (*MemoryCheckpointStore)(nil)
+)
 
 // NewMemoryCheckpointStore creates an empty in-memory checkpoint store.
 func NewMemoryCheckpointStore() *MemoryCheckpointStore {
 	return &MemoryCheckpointStore{
-		positions: make(map[string]int64),
-		held:      make(map[string]bool),
+		positions:      make(map[string]int64),
+		held:           make(map[string]bool),
+		schemaVersions: make(map[string]int),
 	}
 }
 
@@ -57,6 +66,35 @@ func (m *MemoryCheckpointStore) Reset(ctx context.Context, subscriber string, po
 	return nil
 }
 
+// MigrateSchema applies the pending migrations one at a time, recording each
+// version (and resetting the checkpoint for Rebuild migrations) as it
+// succeeds, since there is no transaction to make the set atomic.
+func (m *MemoryCheckpointStore) MigrateSchema(ctx context.Context, subscriber string, migrations []SchemaMigration) (int, error) {
+	m.schemaMu.Lock()
+	defer m.schemaMu.Unlock()
+
+	m.mu.Lock()
+	from := m.schemaVersions[subscriber]
+	m.mu.Unlock()
+
+	pending, err := pendingMigrations(migrations, from)
+	if err != nil {
+		return from, err
+	}
+	for _, migration := range pending {
+		if err := applyMigration(ctx, subscriber, migration); err != nil {
+			return from, err
+		}
+		m.mu.Lock()
+		m.schemaVersions[subscriber] = migration.Version
+		if migration.Rebuild {
+			m.positions[subscriber] = 0
+		}
+		m.mu.Unlock()
+	}
+	return from, nil
+}
+
 type memoryBatch struct {
 	store      *MemoryCheckpointStore
 	subscriber string
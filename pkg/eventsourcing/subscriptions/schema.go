@@ -0,0 +1,80 @@
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSchemaAhead is returned when a subscriber's read model is recorded at a
+// schema version newer than any of its migrations — typically an older build
+// starting after a newer one migrated. Such a subscriber must not write into
+// a schema it does not know.
+var ErrSchemaAhead = errors.New("read model schema is newer than the subscriber's migrations")
+
+// SchemaMigration moves a subscriber's read model to schema Version. Migrate
+// runs the DDL or data change; with GormCheckpointStore its context carries
+// the migration transaction (TxFromContext), so the change and the recorded
+// version commit together. Rebuild marks a change the existing rows cannot
+// be carried across: once it is applied the subscriber's checkpoint is reset
+// to 0 and the read model is rebuilt from history.
+type SchemaMigration struct {
+	Version int
+	Migrate func(ctx context.Context) error
+	Rebuild bool
+}
+
+// SchemaMigrator is implemented by checkpoint stores that track each
+// subscriber's read-model schema version next to its checkpoint.
+type SchemaMigrator interface {
+	// MigrateSchema applies, in order, the migrations above the subscriber's
+	// recorded schema version and records the new version, returning the
+	// version it started from. Callers migrating the same subscriber
+	// concurrently are serialized: one applies the migrations, the others
+	// wait for it and then find nothing pending.
+	MigrateSchema(ctx context.Context, subscriber string, migrations []SchemaMigration) (from int, err error)
+}
+
+// validateMigrations checks that migration versions are positive and
+// strictly increasing.
+func validateMigrations(migrations []SchemaMigration) error {
+	previous := 0
+	for _, m := range migrations {
+		if m.Version <= previous {
+			return fmt.Errorf("schema migration versions must be positive and strictly increasing, got %d after %d", m.Version, previous)
+		}
+		previous = m.Version
+	}
+	return nil
+}
+
+// pendingMigrations returns the migrations above current, or ErrSchemaAhead
+// when current is past the last one.
+func pendingMigrations(migrations []SchemaMigration, current int) ([]SchemaMigration, error) {
+	if err := validateMigrations(migrations); err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, nil
+	}
+	if target := migrations[len(migrations)-1].Version; current > target {
+		return nil, fmt.Errorf("%w: recorded version %d, latest migration %d", ErrSchemaAhead, current, target)
+	}
+	for i, m := range migrations {
+		if m.Version > current {
+			return migrations[i:], nil
+		}
+	}
+	return nil, nil
+}
+
+// applyMigration runs one migration, wrapping its error with the version.
+func applyMigration(ctx context.Context, subscriber string, m SchemaMigration) error {
+	if m.Migrate == nil {
+		return nil
+	}
+	if err := m.Migrate(ctx); err != nil {
+		return fmt.Errorf("schema migration %d for subscriber %q failed: %w", m.Version, subscriber, err)
+	}
+	return nil
+}
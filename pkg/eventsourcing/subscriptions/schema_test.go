@@ -0,0 +1,159 @@
+package subscriptions_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/subscriptions"
+)
+
+func TestMemoryCheckpointStore_MigrateSchema(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var applied atomic.Int32
+	count := func(ctx context.Context) error {
+		applied.Add(1)
+		return nil
+	}
+
+	t.Run("concurrent migrators apply each migration once", func(t *testing.T) {
+		t.Parallel()
+		checkpoints := subscriptions.NewMemoryCheckpointStore()
+		var runs atomic.Int32
+		migrations := []subscriptions.SchemaMigration{
+			{Version: 1, Migrate: func(ctx context.Context) error { runs.Add(1); return nil }},
+			{Version: 2, Migrate: func(ctx context.Context) error { runs.Add(1); return nil }},
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := checkpoints.MigrateSchema(ctx, "projector", migrations); err != nil {
+					t.Errorf("MigrateSchema failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if got := runs.Load(); got != 2 {
+			t.Errorf("expected 2 migration runs, got %d", got)
+		}
+	})
+
+	t.Run("rebuild migration resets the checkpoint", func(t *testing.T) {
+		t.Parallel()
+		checkpoints := subscriptions.NewMemoryCheckpointStore()
+		if err := checkpoints.Reset(ctx, "projector", 7); err != nil {
+			t.Fatalf("failed to seed checkpoint: %v", err)
+		}
+		if _, err := checkpoints.MigrateSchema(ctx, "projector", []subscriptions.SchemaMigration{{Version: 1, Migrate: count}}); err != nil {
+			t.Fatalf("MigrateSchema failed: %v", err)
+		}
+		if position, _ := checkpoints.Position(ctx, "projector"); position != 7 {
+			t.Fatalf("compatible migration moved the checkpoint to %d", position)
+		}
+
+		from, err := checkpoints.MigrateSchema(ctx, "projector", []subscriptions.SchemaMigration{
+			{Version: 1, Migrate: count},
+			{Version: 2, Migrate: count, Rebuild: true},
+		})
+		if err != nil {
+			t.Fatalf("MigrateSchema failed: %v", err)
+		}
+		if from != 1 {
+			t.Errorf("expected migration from version 1, got %d", from)
+		}
+		if position, _ := checkpoints.Position(ctx, "projector"); position != 0 {
+			t.Errorf("expected rebuild to reset the checkpoint, got %d", position)
+		}
+	})
+
+	t.Run("schema ahead of the migrations", func(t *testing.T) {
+		t.Parallel()
+		checkpoints := subscriptions.NewMemoryCheckpointStore()
+		if _, err := checkpoints.MigrateSchema(ctx, "projector", []subscriptions.SchemaMigration{{Version: 1}, {Version: 2}}); err != nil {
+			t.Fatalf("MigrateSchema failed: %v", err)
+		}
+		_, err := checkpoints.MigrateSchema(ctx, "projector", []subscriptions.SchemaMigration{{Version: 1}})
+		if !errors.Is(err, subscriptions.ErrSchemaAhead) {
+			t.Fatalf("expected ErrSchemaAhead, got %v", err)
+		}
+	})
+}
+
+func TestSubscriber_MigratesSchemaBeforeConsuming(t *testing.T) {
+	t.Parallel()
+
+	store := infrastructure.NewMemoryStore()
+	checkpoints := subscriptions.NewMemoryCheckpointStore()
+	appendNumberedEvents(t, store, 1, 2)
+
+	var mu sync.Mutex
+	migrated := false
+	handler := &recordingHandler{}
+	sub, err := subscriptions.NewSubscriber("projector", store, checkpoints,
+		func(ctx context.Context, event domain.EventEnvelope[any]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !migrated {
+				t.Errorf("event %s handled before the schema migration", event.ID)
+			}
+			return handler.handle(ctx, event)
+		},
+		subscriptions.WithPollInterval(subscriptionTestPollInterval),
+		subscriptions.WithSchemaMigrations(subscriptions.SchemaMigration{Version: 1, Migrate: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			migrated = true
+			return nil
+		}}))
+	if err != nil {
+		t.Fatalf("failed to create subscriber: %v", err)
+	}
+
+	stop := runSubscriber(t, sub)
+	waitForCheckpoint(t, checkpoints, "projector", 2)
+	stop()
+
+	if got := handler.handled(); len(got) != 2 {
+		t.Errorf("expected 2 events handled, got %v", got)
+	}
+}
+
+func TestSubscriber_FailedSchemaMigrationStopsRun(t *testing.T) {
+	t.Parallel()
+
+	store := infrastructure.NewMemoryStore()
+	appendNumberedEvents(t, store, 1, 1)
+	handler := &recordingHandler{}
+	errMigration := errors.New("column already exists")
+	sub, err := subscriptions.NewSubscriber("projector", store, subscriptions.NewMemoryCheckpointStore(), handler.handle,
+		subscriptions.WithSchemaMigrations(subscriptions.SchemaMigration{Version: 1, Migrate: func(ctx context.Context) error {
+			return errMigration
+		}}))
+	if err != nil {
+		t.Fatalf("failed to create subscriber: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- sub.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, errMigration) {
+			t.Fatalf("expected the migration error, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not fail on a failed schema migration")
+	}
+	if got := handler.handled(); len(got) != 0 {
+		t.Errorf("expected no events handled, got %v", got)
+	}
+}
@@ -0,0 +1,326 @@
+package subscriptions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMaxDiscrepancies caps the discrepancies a ShadowReport lists when
+// ShadowComparison.MaxDiscrepancies is not set.
+const DefaultMaxDiscrepancies = 100
+
+// DiscrepancyKind classifies a row that differs between the production and
+// shadow read models.
+type DiscrepancyKind int
+
+const (
+	// MissingInShadow is a production row the shadow projector did not
+	// produce.
+	MissingInShadow DiscrepancyKind = iota
+
+	// MissingInProduction is a shadow row production does not have.
+	MissingInProduction
+
+	// ValueMismatch is a row both sides have with different values.
+	ValueMismatch
+)
+
+func (k DiscrepancyKind) String() string {
+	switch k {
+	case MissingInShadow:
+		return "missing in shadow"
+	case MissingInProduction:
+		return "missing in production"
+	case ValueMismatch:
+		return "value mismatch"
+	default:
+		return fmt.Sprintf("DiscrepancyKind(%d)", int(k))
+	}
+}
+
+// ShadowComparison describes how CompareShadow diffs a shadow read model
+// against the production one.
+//
+// To validate a rewritten projector, run it as its own Subscriber (with its
+// own name, so its own checkpoint) writing to a copy of the production
+// table. It then consumes the feed asynchronously and in isolation: it adds
+// no latency to production dispatch, and its failures cannot hold up the
+// production projector. Once it has caught up, compare the two tables.
+type ShadowComparison struct {
+	// Production and Shadow name the two tables. They must have the same
+	// columns.
+	Production string
+	Shadow     string
+
+	// Key names the columns identifying a row in both tables.
+	Key []string
+
+	// Ignore names columns left out of the comparison, such as write
+	// timestamps that legitimately differ.
+	Ignore []string
+
+	// PositionColumn names a column recording the feed position of the last
+	// event applied to each row. With it, rows at a position past Cutoff on
+	// either side are still catching up and are excluded rather than
+	// reported. Without it every row is compared, so both projectors must be
+	// idle and at the same checkpoint.
+	PositionColumn string
+
+	// Cutoff is the feed position up to which both projectors have caught
+	// up, usually ShadowCutoff.
+	Cutoff int64
+
+	// MaxDiscrepancies caps the discrepancies listed in the report (default
+	// DefaultMaxDiscrepancies); all of them are still counted.
+	MaxDiscrepancies int
+}
+
+// Discrepancy is one row that differs between the two read models.
+type Discrepancy struct {
+	Kind DiscrepancyKind
+
+	// Key holds the row's key column values.
+	Key map[string]any
+
+	// Columns lists the differing columns of a ValueMismatch.
+	Columns []string
+
+	// Production and Shadow hold the row as each side has it; nil on the side
+	// it is missing from.
+	Production map[string]any
+	Shadow     map[string]any
+}
+
+// ShadowReport is the result of CompareShadow.
+type ShadowReport struct {
+	// Compared counts the rows checked, on either side.
+	Compared int
+
+	// Excluded counts rows skipped as still catching up.
+	Excluded int
+
+	// Mismatched counts all discrepancies found, including those beyond
+	// MaxDiscrepancies.
+	Mismatched int
+
+	// Discrepancies lists the first MaxDiscrepancies discrepancies.
+	Discrepancies []Discrepancy
+}
+
+// Matches reports whether the comparison found no discrepancies.
+func (r ShadowReport) Matches() bool {
+	return r.Mismatched == 0
+}
+
+// ShadowCutoff returns the feed position both the production and the shadow
+// subscriber have reached: the lower of their checkpoints. Pass it as
+// ShadowComparison.Cutoff.
+func ShadowCutoff(ctx context.Context, checkpoints CheckpointStore, production, shadow string) (int64, error) {
+	productionPosition, err := checkpoints.Position(ctx, production)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checkpoint of %q: %w", production, err)
+	}
+	shadowPosition, err := checkpoints.Position(ctx, shadow)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checkpoint of %q: %w", shadow, err)
+	}
+	return min(productionPosition, shadowPosition), nil
+}
+
+// CompareShadow diffs the shadow read model against the production one and
+// reports the rows that differ. Both tables are read in one read-only
+// transaction (REPEATABLE READ on Postgres), so the comparison sees a single
+// snapshot of both. The shadow table is held in memory while the production
+// table is streamed past it.
+func CompareShadow(ctx context.Context, db *gorm.DB, cmp ShadowComparison) (ShadowReport, error) {
+	if len(cmp.Key) == 0 {
+		return ShadowReport{}, fmt.Errorf("shadow comparison needs at least one key column")
+	}
+	if cmp.MaxDiscrepancies <= 0 {
+		cmp.MaxDiscrepancies = DefaultMaxDiscrepancies
+	}
+
+	var report ShadowReport
+	var txOpts []*sql.TxOptions
+	if db.Name() == "postgres" {
+		txOpts = append(txOpts, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		shadowRows := make(map[string]map[string]any)
+		err := scanTable(tx, cmp.Shadow, func(row map[string]any) {
+			shadowRows[rowKey(row, cmp.Key)] = row
+		})
+		if err != nil {
+			return err
+		}
+
+		err = scanTable(tx, cmp.Production, func(production map[string]any) {
+			key := rowKey(production, cmp.Key)
+			shadow, ok := shadowRows[key]
+			delete(shadowRows, key)
+			if cmp.inFlight(production) || (ok && cmp.inFlight(shadow)) {
+				report.Excluded++
+				return
+			}
+			report.Compared++
+			if !ok {
+				report.add(cmp, Discrepancy{Kind: MissingInShadow, Key: keyValues(production, cmp.Key), Production: production})
+				return
+			}
+			if columns := cmp.differingColumns(production, shadow); len(columns) > 0 {
+				report.add(cmp, Discrepancy{Kind: ValueMismatch, Key: keyValues(production, cmp.Key), Columns: columns, Production: production, Shadow: shadow})
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		// What is left exists only in the shadow; report it in key order so
+		// reports are reproducible.
+		keys := make([]string, 0, len(shadowRows))
+		for key := range shadowRows {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			shadow := shadowRows[key]
+			if cmp.inFlight(shadow) {
+				report.Excluded++
+				continue
+			}
+			report.Compared++
+			report.add(cmp, Discrepancy{Kind: MissingInProduction, Key: keyValues(shadow, cmp.Key), Shadow: shadow})
+		}
+		return nil
+	}, txOpts...)
+	if err != nil {
+		return ShadowReport{}, fmt.Errorf("failed to compare %q with shadow %q: %w", cmp.Production, cmp.Shadow, err)
+	}
+	return report, nil
+}
+
+// add counts d and lists it while under the cap.
+func (r *ShadowReport) add(cmp ShadowComparison, d Discrepancy) {
+	r.Mismatched++
+	if len(r.Discrepancies) < cmp.MaxDiscrepancies {
+		r.Discrepancies = append(r.Discrepancies, d)
+	}
+}
+
+// inFlight reports whether row was written by an event past the cutoff.
+func (cmp ShadowComparison) inFlight(row map[string]any) bool {
+	if cmp.PositionColumn == "" {
+		return false
+	}
+	var position int64
+	switch v := row[cmp.PositionColumn].(type) {
+	case int64:
+		position = v
+	case int:
+		position = int64(v)
+	case int32:
+		position = int64(v)
+	case float64:
+		position = int64(v)
+	default:
+		return false
+	}
+	return position > cmp.Cutoff
+}
+
+// differingColumns returns the compared columns whose values differ, sorted.
+func (cmp ShadowComparison) differingColumns(production, shadow map[string]any) []string {
+	var columns []string
+	seen := make(map[string]bool, len(production))
+	check := func(column string) {
+		if seen[column] {
+			return
+		}
+		seen[column] = true
+		if column == cmp.PositionColumn || slices.Contains(cmp.Ignore, column) {
+			return
+		}
+		if !sameValue(production[column], shadow[column]) {
+			columns = append(columns, column)
+		}
+	}
+	for column := range production {
+		check(column)
+	}
+	for column := range shadow {
+		check(column)
+	}
+	slices.Sort(columns)
+	return columns
+}
+
+// scanTable calls fn with each row of table as a map of column values, with
+// []byte values converted to strings.
+func scanTable(tx *gorm.DB, table string, fn func(row map[string]any)) error {
+	rows, err := tx.Table("?", clause.Table{Name: table}).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		row := make(map[string]any, len(columns))
+		for i, name := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[name] = string(b)
+			} else {
+				row[name] = values[i]
+			}
+		}
+		fn(row)
+	}
+	return rows.Err()
+}
+
+// rowKey encodes a row's key column values as a map key.
+func rowKey(row map[string]any, key []string) string {
+	parts := make([]string, len(key))
+	for i, column := range key {
+		parts[i] = fmt.Sprintf("%T:%v", row[column], row[column])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// keyValues returns a row's key column values.
+func keyValues(row map[string]any, key []string) map[string]any {
+	values := make(map[string]any, len(key))
+	for _, column := range key {
+		values[column] = row[column]
+	}
+	return values
+}
+
+// sameValue compares two scanned column values, treating times as equal when
+// they denote the same instant.
+func sameValue(a, b any) bool {
+	if at, ok := a.(time.Time); ok {
+		bt, ok := b.(time.Time)
+		return ok && at.Equal(bt)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,162 @@
+package subscriptions_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/subscriptions"
+)
+
+// balanceRow is the read model compared in these tests; shadowBalanceRow is
+// the same model in its shadow table.
+type balanceRow struct {
+	AccountID string    `gorm:"primaryKey;column:account_id"`
+	Balance   int64     `gorm:"column:balance"`
+	Position  int64     `gorm:"column:position"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (balanceRow) TableName() string { return "balances" }
+
+type shadowBalanceRow balanceRow
+
+func (shadowBalanceRow) TableName() string { return "balances_shadow" }
+
+func newShadowFixture(t *testing.T, production, shadow []balanceRow) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "shadow.db")
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&balanceRow{}, &shadowBalanceRow{}); err != nil {
+		t.Fatalf("failed to migrate read models: %v", err)
+	}
+	for _, row := range production {
+		if err := db.Create(&row).Error; err != nil {
+			t.Fatalf("failed to seed production: %v", err)
+		}
+	}
+	for _, row := range shadow {
+		shadowRow := shadowBalanceRow(row)
+		// Shadow rows are written later, so write timestamps differ.
+		shadowRow.UpdatedAt = row.UpdatedAt.Add(time.Minute)
+		if err := db.Create(&shadowRow).Error; err != nil {
+			t.Fatalf("failed to seed shadow: %v", err)
+		}
+	}
+	return db
+}
+
+func TestCompareShadow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	comparison := subscriptions.ShadowComparison{
+		Production:     "balances",
+		Shadow:         "balances_shadow",
+		Key:            []string{"account_id"},
+		Ignore:         []string{"updated_at"},
+		PositionColumn: "position",
+		Cutoff:         10,
+	}
+
+	t.Run("identical projections match", func(t *testing.T) {
+		t.Parallel()
+		rows := []balanceRow{
+			{AccountID: "a", Balance: 100, Position: 3, UpdatedAt: now},
+			{AccountID: "b", Balance: 50, Position: 7, UpdatedAt: now},
+		}
+		db := newShadowFixture(t, rows, rows)
+
+		report, err := subscriptions.CompareShadow(context.Background(), db, comparison)
+		if err != nil {
+			t.Fatalf("CompareShadow() error = %v", err)
+		}
+		if !report.Matches() || report.Compared != 2 || report.Excluded != 0 {
+			t.Errorf("report = %+v, want 2 matching rows", report)
+		}
+	})
+
+	t.Run("differences are reported and in-flight rows excluded", func(t *testing.T) {
+		t.Parallel()
+		production := []balanceRow{
+			{AccountID: "a", Balance: 100, Position: 3, UpdatedAt: now},
+			{AccountID: "b", Balance: 50, Position: 7, UpdatedAt: now},
+			{AccountID: "c", Balance: 10, Position: 9, UpdatedAt: now},
+			{AccountID: "d", Balance: 70, Position: 12, UpdatedAt: now},
+		}
+		shadow := []balanceRow{
+			{AccountID: "a", Balance: 100, Position: 3, UpdatedAt: now},
+			{AccountID: "b", Balance: 55, Position: 7, UpdatedAt: now},
+			{AccountID: "e", Balance: 5, Position: 8, UpdatedAt: now},
+			{AccountID: "f", Balance: 1, Position: 11, UpdatedAt: now},
+		}
+		db := newShadowFixture(t, production, shadow)
+
+		report, err := subscriptions.CompareShadow(context.Background(), db, comparison)
+		if err != nil {
+			t.Fatalf("CompareShadow() error = %v", err)
+		}
+		if report.Compared != 4 || report.Excluded != 2 || report.Mismatched != 3 {
+			t.Fatalf("report = %+v, want 4 compared, 2 in flight, 3 mismatched", report)
+		}
+		got := map[string]subscriptions.Discrepancy{}
+		for _, d := range report.Discrepancies {
+			got[d.Key["account_id"].(string)] = d
+		}
+		if d := got["b"]; d.Kind != subscriptions.ValueMismatch || len(d.Columns) != 1 || d.Columns[0] != "balance" {
+			t.Errorf("b = %+v, want a balance mismatch", d)
+		}
+		if d := got["c"]; d.Kind != subscriptions.MissingInShadow || d.Shadow != nil {
+			t.Errorf("c = %+v, want missing in shadow", d)
+		}
+		if d := got["e"]; d.Kind != subscriptions.MissingInProduction || d.Production != nil {
+			t.Errorf("e = %+v, want missing in production", d)
+		}
+	})
+
+	t.Run("listed discrepancies are capped but all counted", func(t *testing.T) {
+		t.Parallel()
+		production := []balanceRow{
+			{AccountID: "a", Balance: 1, Position: 1, UpdatedAt: now},
+			{AccountID: "b", Balance: 2, Position: 2, UpdatedAt: now},
+			{AccountID: "c", Balance: 3, Position: 3, UpdatedAt: now},
+		}
+		db := newShadowFixture(t, production, nil)
+
+		capped := comparison
+		capped.MaxDiscrepancies = 2
+		report, err := subscriptions.CompareShadow(context.Background(), db, capped)
+		if err != nil {
+			t.Fatalf("CompareShadow() error = %v", err)
+		}
+		if report.Mismatched != 3 || len(report.Discrepancies) != 2 {
+			t.Errorf("report = %+v, want 3 mismatched with 2 listed", report)
+		}
+	})
+}
+
+func TestShadowCutoff(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	checkpoints := subscriptions.NewMemoryCheckpointStore()
+	if err := checkpoints.Reset(ctx, "balances", 42); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if err := checkpoints.Reset(ctx, "balances-v2", 17); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+
+	cutoff, err := subscriptions.ShadowCutoff(ctx, checkpoints, "balances", "balances-v2")
+	if err != nil || cutoff != 17 {
+		t.Errorf("ShadowCutoff() = %d, %v, want 17", cutoff, err)
+	}
+}
@@ -41,6 +41,20 @@ const (
 	wakeRetryInterval = 200 * time.Millisecond
 )
 
+// Clock is the time source for a Subscriber's poll waits, retry backoff and
+// parking timestamps. The default is the system clock; tests inject a fake to
+// drive the polling loop deterministically.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
 // Subscriber runs a Handler as a crash-safe background worker over the event
 // store's global ordered feed. Each cycle acquires the subscriber's
 // checkpoint, reads a batch of events past it via EventStore.ReadAfter,
@@ -59,7 +73,9 @@ type Subscriber struct {
 	handler      Handler
 	batchSize    int
 	pollInterval time.Duration
+	maxIdleWait  time.Duration
 	logger       *slog.Logger
+	clock        Clock
 
 	// Poison-event handling (only active with a ParkingLot configured).
 	parking      ParkingLot
@@ -71,6 +87,43 @@ type Subscriber struct {
 	// poll-only. Polling continues regardless, so lost notifications cost at
 	// most one poll interval, never correctness.
 	wake <-chan struct{}
+
+	// migrations bring the read model's schema up to date before Run
+	// consumes anything.
+	migrations []SchemaMigration
+
+	// categories restricts the feed to these event categories; empty means
+	// every category.
+	categories []string
+
+	// flusher writes the handler's buffered changes before each commit.
+	flusher BatchFlusher
+
+	// deliveries skips events the handler already completed; see
+	// WithDeliveryTracking.
+	deliveries DeliveryTracker
+
+	// Feature-flag gating; see WithFeatureFlag. flags is nil when the
+	// handler always runs.
+	flags          domain.FeatureFlags
+	flag           string
+	disabledPolicy DisabledPolicy
+}
+
+// BatchFlusher is implemented by projections that buffer their writes across
+// a batch and write them in bulk (see UpsertBuffer), which is much faster than
+// a write per event when catching up on a large backlog.
+type BatchFlusher interface {
+	// Flush writes everything buffered since the last Reset. It runs with the
+	// handler context after the batch's last event, before the checkpoint
+	// commit, so with GormCheckpointStore the flushed rows and the
+	// checkpoint advance commit in the same transaction. An error abandons
+	// the batch.
+	Flush(ctx context.Context) error
+
+	// Reset discards anything buffered. The subscriber calls it before every
+	// batch, so rows buffered by an abandoned batch are never flushed.
+	Reset()
 }
 
 // SubscriberOption configures a Subscriber.
@@ -89,6 +142,20 @@ func WithPollInterval(d time.Duration) SubscriberOption {
 	return func(s *Subscriber) { s.pollInterval = d }
 }
 
+// WithIdleBackoff lets an idle subscriber poll less often: each consecutive
+// poll that finds nothing doubles the wait, starting from the poll interval
+// and capped at maximum. Any event, batch failure or wake signal resets the
+// wait to the poll interval. The default is no backoff (maximum equal to the
+// poll interval), trading database load for latency.
+func WithIdleBackoff(maximum time.Duration) SubscriberOption {
+	return func(s *Subscriber) { s.maxIdleWait = maximum }
+}
+
+// WithClock sets the time source (default: the system clock).
+func WithClock(clock Clock) SubscriberOption {
+	return func(s *Subscriber) { s.clock = clock }
+}
+
 // WithLogger sets the logger for batch failures and lifecycle events. The
 // default is slog.Default() — a permanently failing subscriber must be
 // visible somewhere out of the box.
@@ -139,6 +206,44 @@ func WithWakeSignal(wake <-chan struct{}) SubscriberOption {
 	return func(s *Subscriber) { s.wake = wake }
 }
 
+// WithSchemaMigrations declares the subscriber's read-model schema as a list
+// of migrations in increasing version order; the last version is the schema
+// the handler writes. Run applies the pending ones through the checkpoint
+// store (which must implement SchemaMigrator) before consuming any event, and
+// refuses to start on migration failure or a schema newer than it knows
+// (ErrSchemaAhead). A migration marked Rebuild resets the checkpoint so the
+// read model is rebuilt from history.
+func WithSchemaMigrations(migrations ...SchemaMigration) SubscriberOption {
+	return func(s *Subscriber) { s.migrations = append(s.migrations, migrations...) }
+}
+
+// WithCategories restricts the subscriber to events whose category (see
+// domain.EventCategory) is one of categories, filtered at the storage layer
+// when the event store implements domain.CategoryFeedReader. The checkpoint
+// still advances through the global feed, so skipped events are never
+// re-read. Calling it with no categories keeps the default of every category.
+func WithCategories(categories ...string) SubscriberOption {
+	return func(s *Subscriber) { s.categories = append(s.categories, categories...) }
+}
+
+// WithBatchFlusher makes the subscriber flush flusher once per batch,
+// atomically with the checkpoint advance, instead of relying on the handler
+// to write per event. The handler should buffer into flusher only after it
+// has otherwise succeeded for an event, since a failed attempt is retried.
+func WithBatchFlusher(flusher BatchFlusher) SubscriberOption {
+	return func(s *Subscriber) { s.flusher = flusher }
+}
+
+// WithDeliveryTracking records each event the handler completes in tracker
+// and skips events it has already recorded, so a crash between the handler
+// finishing and the checkpoint committing does not run the handler twice.
+// Records are forgotten once the checkpoint passes them. It cannot be
+// combined with WithBatchFlusher, whose writes land only at the end of the
+// batch.
+func WithDeliveryTracking(tracker DeliveryTracker) SubscriberOption {
+	return func(s *Subscriber) { s.deliveries = tracker }
+}
+
 // NewSubscriber creates a subscriber. name identifies the checkpoint —
 // processes using the same name share one position. handler is invoked for
 // every event in feed order; EventDispatcher.Dispatch satisfies the Handler
@@ -178,15 +283,35 @@ func NewSubscriber(name string, events domain.EventStore, checkpoints Checkpoint
 	if s.pollInterval <= 0 {
 		return nil, fmt.Errorf("poll interval must be positive, got %v", s.pollInterval)
 	}
+	if s.maxIdleWait == 0 {
+		s.maxIdleWait = s.pollInterval
+	}
+	if s.maxIdleWait < s.pollInterval {
+		return nil, fmt.Errorf("idle backoff cap must be at least the poll interval, got %v", s.maxIdleWait)
+	}
 	if s.maxRetries < 0 {
 		return nil, fmt.Errorf("max retries must not be negative, got %d", s.maxRetries)
 	}
 	if s.retryBackoff <= 0 || s.maxBackoff < s.retryBackoff {
 		return nil, fmt.Errorf("retry backoff must be positive and its cap at least the initial delay, got %v/%v", s.retryBackoff, s.maxBackoff)
 	}
+	if len(s.migrations) > 0 {
+		if _, ok := checkpoints.(SchemaMigrator); !ok {
+			return nil, fmt.Errorf("schema migrations need a checkpoint store implementing SchemaMigrator, got %T", checkpoints)
+		}
+		if err := validateMigrations(s.migrations); err != nil {
+			return nil, err
+		}
+	}
+	if s.deliveries != nil && s.flusher != nil {
+		return nil, errors.New("delivery tracking cannot be combined with a batch flusher")
+	}
 	if s.logger == nil {
 		s.logger = slog.Default()
 	}
+	if s.clock == nil {
+		s.clock = systemClock{}
+	}
 	return s, nil
 }
 
@@ -197,15 +322,26 @@ func (s *Subscriber) Name() string { return s.name }
 // flight when cancellation arrives is drained — handlers finish and the
 // checkpoint advances — before Run returns nil.
 //
+// A full batch is followed immediately by the next read so a backlog drains
+// without waiting; a partial batch means the subscriber has caught up, so it
+// waits the poll interval (stretched by WithIdleBackoff while the feed stays
+// empty).
+//
 // Transient errors (database hiccups, handler failures) are logged and
 // retried after the poll interval; they never stop the subscriber. The only
-// error Run returns is fatal misconfiguration: an event store without a
-// global ordered feed (ErrGlobalOrderingNotSupported).
+// errors Run returns are fatal: an event store without a global ordered feed
+// (ErrGlobalOrderingNotSupported), or a read-model schema that could not be
+// brought up to date (see WithSchemaMigrations).
 func (s *Subscriber) Run(ctx context.Context) error {
+	if err := s.migrateSchema(ctx); err != nil {
+		return fmt.Errorf("subscriber %q cannot run: %w", s.name, err)
+	}
+
 	s.logger.Info("subscriber started", "subscriber", s.name)
 	defer s.logger.Info("subscriber stopped", "subscriber", s.name)
 
 	woken := false
+	idlePolls := 0
 	for {
 		processed, err := s.processBatch(ctx)
 		if err != nil {
@@ -223,28 +359,34 @@ func (s *Subscriber) Run(ctx context.Context) error {
 		if ctx.Err() != nil {
 			return nil
 		}
-		if processed > 0 && err == nil {
-			// There may be more backlog; read again immediately.
+		if processed >= s.batchSize && err == nil {
+			// A full batch means there is probably more backlog; read again
+			// immediately.
 			woken = false
+			idlePolls = 0
 			continue
 		}
 
+		wait := s.pollInterval
+		switch {
+		case err != nil || processed > 0:
+			idlePolls = 0
+		default:
+			wait = s.idleDelay(idlePolls)
+			idlePolls++
+		}
 		// A wake that found nothing usually means the notifying commit is
 		// still withheld by the feed's visibility guard — re-check soon
 		// instead of sleeping the full poll interval.
-		wait := s.pollInterval
 		if woken && err == nil {
 			wait = min(wait, wakeRetryInterval)
 		}
 		woken = false
 
-		timer := time.NewTimer(wait)
 		select {
 		case <-ctx.Done():
-			timer.Stop()
 			return nil
 		case _, ok := <-s.wake: // nil when unset; a nil channel never fires
-			timer.Stop()
 			if !ok {
 				// A closed wake channel would otherwise fire every
 				// iteration and turn the loop hot; drop to pure polling.
@@ -253,12 +395,29 @@ func (s *Subscriber) Run(ctx context.Context) error {
 				s.wake = nil
 			} else {
 				woken = true
+				idlePolls = 0
 			}
-		case <-timer.C:
+		case <-s.clock.After(wait):
 		}
 	}
 }
 
+// migrateSchema applies pending schema migrations, if any are configured.
+func (s *Subscriber) migrateSchema(ctx context.Context) error {
+	if len(s.migrations) == 0 {
+		return nil
+	}
+	from, err := s.checkpoints.(SchemaMigrator).MigrateSchema(ctx, s.name, s.migrations)
+	if err != nil {
+		return err
+	}
+	if to := s.migrations[len(s.migrations)-1].Version; from != to {
+		s.logger.Info("read model schema migrated",
+			"subscriber", s.name, "from", from, "to", to)
+	}
+	return nil
+}
+
 // processBatch runs one cycle: acquire checkpoint, read, handle, advance.
 // It returns the number of events processed. Once a batch has been read,
 // processing and the checkpoint commit are shielded from ctx cancellation so
@@ -293,7 +452,7 @@ func (s *Subscriber) processBatch(ctx context.Context) (processed int, err error
 		}
 	}()
 
-	events, err := s.events.ReadAfter(ctx, batch.Position(), s.batchSize)
+	events, err := domain.ReadAfterInCategories(ctx, s.events, batch.Position(), s.batchSize, s.categories)
 	if err != nil {
 		return 0, fmt.Errorf("failed to read feed after position %d: %w", batch.Position(), err)
 	}
@@ -305,11 +464,31 @@ func (s *Subscriber) processBatch(ctx context.Context) (processed int, err error
 	// panic propagates to the caller after the deferred rollback runs.
 	drainCtx := context.WithoutCancel(ctx)
 	handlerCtx := batch.HandlerContext(drainCtx)
-	for _, event := range events {
+	if s.flusher != nil {
+		s.flusher.Reset()
+	}
+	for i, event := range events {
+		if !s.enabled(ctx, event) {
+			if s.disabledPolicy == DisabledPause {
+				s.logger.Debug("feature flag disabled; pausing at checkpoint",
+					"subscriber", s.name, "flag", s.flag, "position", event.Position)
+				events = events[:i]
+				break
+			}
+			continue
+		}
 		if err := s.processEvent(handlerCtx, ctx, batch, event); err != nil {
 			return 0, err
 		}
 	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	if s.flusher != nil {
+		if err := s.flusher.Flush(handlerCtx); err != nil {
+			return 0, fmt.Errorf("failed to flush batch: %w", err)
+		}
+	}
 
 	last := events[len(events)-1].Position
 	err = batch.Commit(drainCtx, last)
@@ -317,6 +496,12 @@ func (s *Subscriber) processBatch(ctx context.Context) (processed int, err error
 	if err != nil {
 		return 0, fmt.Errorf("failed to commit batch at position %d: %w", last, err)
 	}
+	if s.deliveries != nil {
+		if err := s.deliveries.Forget(drainCtx, s.name, last); err != nil {
+			s.logger.Warn("failed to forget delivery records behind the checkpoint",
+				"subscriber", s.name, "position", last, "error", err)
+		}
+	}
 	return len(events), nil
 }
 
@@ -330,6 +515,18 @@ func (s *Subscriber) processBatch(ctx context.Context) (processed int, err error
 // runCtx (cancellable) aborts retry backoff on shutdown: a poison event must
 // not hold up draining; the batch rolls back and is redelivered on restart.
 func (s *Subscriber) processEvent(handlerCtx, runCtx context.Context, batch Batch, event domain.EventEnvelope[any]) error {
+	if s.deliveries != nil {
+		delivered, err := s.deliveries.Delivered(handlerCtx, s.name, event)
+		if err != nil {
+			return fmt.Errorf("failed to check delivery of event %s: %w", event.ID, err)
+		}
+		if delivered {
+			s.logger.Debug("skipping event already delivered before the checkpoint advanced",
+				"subscriber", s.name, "event_id", event.ID, "position", event.Position)
+			return nil
+		}
+	}
+
 	var lastErr error
 	for attempt := 0; ; attempt++ {
 		if err := batch.Savepoint(handlerCtx, retrySavepoint); err != nil {
@@ -337,6 +534,7 @@ func (s *Subscriber) processEvent(handlerCtx, runCtx context.Context, batch Batc
 		}
 		lastErr = s.handler(handlerCtx, event)
 		if lastErr == nil {
+			s.markDelivered(handlerCtx, event)
 			return nil
 		}
 		if err := batch.RollbackToSavepoint(handlerCtx, retrySavepoint); err != nil {
@@ -350,15 +548,13 @@ func (s *Subscriber) processEvent(handlerCtx, runCtx context.Context, batch Batc
 			break
 		}
 
-		timer := time.NewTimer(s.backoffDelay(attempt))
 		select {
 		case <-runCtx.Done():
-			timer.Stop()
 			// Wrap the context error too so Run recognizes this as
 			// shutdown rather than logging a spurious batch failure.
 			return errors.Join(runCtx.Err(),
 				fmt.Errorf("shutdown while retrying event %s: %w", event.ID, lastErr))
-		case <-timer.C:
+		case <-s.clock.After(s.backoffDelay(attempt)):
 		}
 	}
 
@@ -374,7 +570,7 @@ func (s *Subscriber) processEvent(handlerCtx, runCtx context.Context, batch Batc
 		Position:   event.Position,
 		Error:      lastErr.Error(),
 		Attempts:   s.maxRetries + 1,
-		ParkedAt:   time.Now(),
+		ParkedAt:   s.clock.Now(),
 	}
 	if err := s.parking.Park(handlerCtx, parked); err != nil {
 		return errors.Join(
@@ -393,6 +589,19 @@ func (s *Subscriber) processEvent(handlerCtx, runCtx context.Context, batch Batc
 	return nil
 }
 
+// markDelivered records a completed event with the delivery tracker. A failed
+// record only reopens the redelivery window the tracker narrows, so it is
+// logged rather than failing the batch.
+func (s *Subscriber) markDelivered(ctx context.Context, event domain.EventEnvelope[any]) {
+	if s.deliveries == nil {
+		return
+	}
+	if err := s.deliveries.MarkDelivered(ctx, s.name, event); err != nil {
+		s.logger.Warn("failed to record event delivery",
+			"subscriber", s.name, "event_id", event.ID, "error", err)
+	}
+}
+
 // backoffDelay doubles per attempt from retryBackoff, capped at maxBackoff.
 func (s *Subscriber) backoffDelay(attempt int) time.Duration {
 	delay := s.retryBackoff
@@ -407,6 +616,19 @@ func (s *Subscriber) backoffDelay(attempt int) time.Duration {
 	return min(delay, s.maxBackoff)
 }
 
+// idleDelay doubles the poll interval per consecutive empty poll, capped at
+// maxIdleWait.
+func (s *Subscriber) idleDelay(emptyPolls int) time.Duration {
+	delay := s.pollInterval
+	for range emptyPolls {
+		if delay > s.maxIdleWait/2 {
+			return s.maxIdleWait
+		}
+		delay *= 2
+	}
+	return min(delay, s.maxIdleWait)
+}
+
 // Lag returns how far the subscriber's committed checkpoint trails the feed
 // head (0 when caught up). Consumers log or meter it; pericarp does not.
 //
@@ -488,6 +488,83 @@ func TestSubscriber_RunFailsFastWithoutOrderedFeed(t *testing.T) {
 	}
 }
 
+// fakeClock reports every wait the subscriber requests and releases it only
+// when the test fires.
+type fakeClock struct {
+	waits chan time.Duration
+	fire  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{waits: make(chan time.Duration, 16), fire: make(chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time { return time.Unix(0, 0) }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+func (c *fakeClock) nextWait(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.waits:
+		return d
+	case <-time.After(10 * time.Second):
+		t.Fatal("subscriber never waited")
+		return 0
+	}
+}
+
+func TestSubscriber_AdaptivePolling(t *testing.T) {
+	t.Parallel()
+
+	store := infrastructure.NewMemoryStore()
+	checkpoints := subscriptions.NewMemoryCheckpointStore()
+	appendNumberedEvents(t, store, 1, 5)
+
+	clock := newFakeClock()
+	handler := &recordingHandler{}
+	sub, err := subscriptions.NewSubscriber("adaptive", store, checkpoints, handler.handle,
+		subscriptions.WithBatchSize(2),
+		subscriptions.WithPollInterval(time.Second),
+		subscriptions.WithIdleBackoff(4*time.Second),
+		subscriptions.WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to create subscriber: %v", err)
+	}
+	stop := runSubscriber(t, sub)
+	defer stop()
+
+	// Two full batches drain back to back; only the trailing partial batch
+	// waits, so the whole backlog is handled before the first wait.
+	if got := clock.nextWait(t); got != time.Second {
+		t.Fatalf("first wait = %v, want the poll interval", got)
+	}
+	if got := len(handler.handled()); got != 5 {
+		t.Fatalf("handled %d events before the first wait, want 5", got)
+	}
+
+	// Empty polls back off from the poll interval up to the cap.
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		clock.fire <- time.Time{}
+		if got := clock.nextWait(t); got != want {
+			t.Fatalf("idle wait = %v, want %v", got, want)
+		}
+	}
+
+	// New events reset the backoff.
+	appendNumberedEvents(t, store, 6, 1)
+	clock.fire <- time.Time{}
+	if got := clock.nextWait(t); got != time.Second {
+		t.Fatalf("wait after new events = %v, want the poll interval", got)
+	}
+	if got := len(handler.handled()); got != 6 {
+		t.Fatalf("handled %d events, want 6", got)
+	}
+}
+
 func TestNewSubscriber_Validation(t *testing.T) {
 	t.Parallel()
 
@@ -517,6 +594,19 @@ func TestNewSubscriber_Validation(t *testing.T) {
 		{"non-positive poll interval", func() (*subscriptions.Subscriber, error) {
 			return subscriptions.NewSubscriber("s", store, checkpoints, handler, subscriptions.WithPollInterval(0))
 		}},
+		{"idle backoff below poll interval", func() (*subscriptions.Subscriber, error) {
+			return subscriptions.NewSubscriber("s", store, checkpoints, handler,
+				subscriptions.WithPollInterval(time.Second), subscriptions.WithIdleBackoff(time.Millisecond))
+		}},
+		{"schema migrations out of order", func() (*subscriptions.Subscriber, error) {
+			return subscriptions.NewSubscriber("s", store, checkpoints, handler,
+				subscriptions.WithSchemaMigrations(subscriptions.SchemaMigration{Version: 2}, subscriptions.SchemaMigration{Version: 1}))
+		}},
+		{"delivery tracking with a batch flusher", func() (*subscriptions.Subscriber, error) {
+			return subscriptions.NewSubscriber("s", store, checkpoints, handler,
+				subscriptions.WithDeliveryTracking(subscriptions.NewMemoryDeliveryTracker()),
+				subscriptions.WithBatchFlusher(&bufferingFlusher{}))
+		}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -527,3 +617,266 @@ func TestNewSubscriber_Validation(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriber_WithCategoriesSkipsOtherEventTypes(t *testing.T) {
+	t.Parallel()
+
+	store := infrastructure.NewMemoryStore()
+	checkpoints := subscriptions.NewMemoryCheckpointStore()
+	ctx := context.Background()
+	for i, eventType := range []string{"user.created", "payment.settled", "order.placed"} {
+		aggregateID := fmt.Sprintf("agg-%d", i)
+		if err := store.Append(ctx, aggregateID, -1, createTestEvent(aggregateID, eventType, eventType, 1)); err != nil {
+			t.Fatalf("failed to append %s: %v", eventType, err)
+		}
+	}
+
+	handler := &recordingHandler{}
+	sub, err := subscriptions.NewSubscriber("projector", store, checkpoints, handler.handle,
+		subscriptions.WithPollInterval(subscriptionTestPollInterval),
+		subscriptions.WithCategories("user", "order"))
+	if err != nil {
+		t.Fatalf("failed to create subscriber: %v", err)
+	}
+
+	stop := runSubscriber(t, sub)
+	waitForCheckpoint(t, checkpoints, "projector", 3)
+	stop()
+
+	got := handler.handled()
+	if len(got) != 2 || got[0] != "user.created" || got[1] != "order.placed" {
+		t.Fatalf("expected only [user.created order.placed], got %v", got)
+	}
+}
+
+// bufferingFlusher buffers handled event IDs and moves them to flushed on
+// Flush, failing the first failFlushes flushes.
+type bufferingFlusher struct {
+	mu          sync.Mutex
+	buffered    []string
+	flushed     []string
+	failFlushes int
+}
+
+func (f *bufferingFlusher) handle(ctx context.Context, event domain.EventEnvelope[any]) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buffered = append(f.buffered, event.ID)
+	return nil
+}
+
+func (f *bufferingFlusher) Flush(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failFlushes > 0 {
+		f.failFlushes--
+		return errors.New("bulk write failed")
+	}
+	f.flushed = append(f.flushed, f.buffered...)
+	f.buffered = nil
+	return nil
+}
+
+func (f *bufferingFlusher) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buffered = nil
+}
+
+func (f *bufferingFlusher) written() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.flushed...)
+}
+
+func TestSubscriber_WithBatchFlusherFlushesBeforeCommit(t *testing.T) {
+	t.Parallel()
+
+	store := infrastructure.NewMemoryStore()
+	checkpoints := subscriptions.NewMemoryCheckpointStore()
+	appendNumberedEvents(t, store, 1, 5)
+
+	// The first flush fails: the batch is abandoned without advancing the
+	// checkpoint, and its buffered rows are discarded rather than flushed
+	// twice when the batch is retried.
+	flusher := &bufferingFlusher{failFlushes: 1}
+	sub, err := subscriptions.NewSubscriber("projector", store, checkpoints, flusher.handle,
+		subscriptions.WithPollInterval(subscriptionTestPollInterval),
+		subscriptions.WithBatchSize(2),
+		subscriptions.WithBatchFlusher(flusher))
+	if err != nil {
+		t.Fatalf("failed to create subscriber: %v", err)
+	}
+
+	stop := runSubscriber(t, sub)
+	waitForCheckpoint(t, checkpoints, "projector", 5)
+	stop()
+
+	want := []string{"ev-1", "ev-2", "ev-3", "ev-4", "ev-5"}
+	got := flusher.written()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected flushed %v, got %v", want, got)
+	}
+}
+
+// crashingCheckpoints fails the first crashes batch commits after the handler
+// has run — a crash between handling and the checkpoint save, as far as the
+// subscriber can tell.
+type crashingCheckpoints struct {
+	subscriptions.CheckpointStore
+	mu      sync.Mutex
+	crashes int
+}
+
+func (c *crashingCheckpoints) Acquire(ctx context.Context, subscriber string) (subscriptions.Batch, bool, error) {
+	batch, acquired, err := c.CheckpointStore.Acquire(ctx, subscriber)
+	if err != nil || !acquired {
+		return batch, acquired, err
+	}
+	return &crashingBatch{Batch: batch, owner: c}, true, nil
+}
+
+type crashingBatch struct {
+	subscriptions.Batch
+	owner *crashingCheckpoints
+}
+
+func (b *crashingBatch) Commit(ctx context.Context, position int64) error {
+	b.owner.mu.Lock()
+	crash := b.owner.crashes > 0
+	if crash {
+		b.owner.crashes--
+	}
+	b.owner.mu.Unlock()
+	if crash {
+		_ = b.Batch.Rollback()
+		return errors.New("process crashed before the checkpoint was saved")
+	}
+	return b.Batch.Commit(ctx, position)
+}
+
+// TestSubscriber_CrashBetweenHandleAndCheckpoint is the conformance scenario
+// for the delivery guarantees: the handler completes every event, the
+// checkpoint save is lost, and the batch is redelivered. Without tracking
+// that is at-least-once; with a DeliveryTracker no event is handled twice.
+func TestSubscriber_CrashBetweenHandleAndCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		tracker   subscriptions.DeliveryTracker
+		wantTimes int
+	}{
+		{name: "at-least-once without tracking", wantTimes: 2},
+		{name: "once with delivery tracking", tracker: subscriptions.NewMemoryDeliveryTracker(), wantTimes: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := infrastructure.NewMemoryStore()
+			checkpoints := &crashingCheckpoints{CheckpointStore: subscriptions.NewMemoryCheckpointStore(), crashes: 1}
+			appendNumberedEvents(t, store, 1, 3)
+
+			handler := &recordingHandler{}
+			opts := []subscriptions.SubscriberOption{subscriptions.WithPollInterval(subscriptionTestPollInterval)}
+			if tt.tracker != nil {
+				opts = append(opts, subscriptions.WithDeliveryTracking(tt.tracker))
+			}
+			sub, err := subscriptions.NewSubscriber("projector", store, checkpoints, handler.handle, opts...)
+			if err != nil {
+				t.Fatalf("failed to create subscriber: %v", err)
+			}
+
+			stop := runSubscriber(t, sub)
+			waitForCheckpoint(t, checkpoints, "projector", 3)
+			stop()
+
+			times := map[string]int{}
+			for _, id := range handler.handled() {
+				times[id]++
+			}
+			for _, id := range []string{"ev-1", "ev-2", "ev-3"} {
+				if times[id] != tt.wantTimes {
+					t.Errorf("%s handled %d times, want %d", id, times[id], tt.wantTimes)
+				}
+			}
+			if tt.tracker != nil {
+				delivered, err := tt.tracker.Delivered(context.Background(), "projector", domain.EventEnvelope[any]{ID: "ev-1"})
+				if err != nil || delivered {
+					t.Errorf("expected records behind the checkpoint to be forgotten, got %v, %v", delivered, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriber_WithFeatureFlag(t *testing.T) {
+	t.Parallel()
+
+	t.Run("skip advances past disabled events", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+		checkpoints := subscriptions.NewMemoryCheckpointStore()
+		appendNumberedEvents(t, store, 1, 4)
+
+		flags := domain.FeatureFlagsFunc(func(_ context.Context, flag string, event domain.EventEnvelope[any]) bool {
+			return flag == "new-projector" && event.ID != "ev-2"
+		})
+		handler := &recordingHandler{}
+		sub, err := subscriptions.NewSubscriber("projector", store, checkpoints, handler.handle,
+			subscriptions.WithPollInterval(subscriptionTestPollInterval),
+			subscriptions.WithFeatureFlag(flags, "new-projector", subscriptions.DisabledSkip))
+		if err != nil {
+			t.Fatalf("failed to create subscriber: %v", err)
+		}
+
+		stop := runSubscriber(t, sub)
+		waitForCheckpoint(t, checkpoints, "projector", 4)
+		stop()
+
+		if got := handler.handled(); fmt.Sprint(got) != "[ev-1 ev-3 ev-4]" {
+			t.Fatalf("expected [ev-1 ev-3 ev-4], got %v", got)
+		}
+	})
+
+	t.Run("pause holds the checkpoint and catches up on re-enable", func(t *testing.T) {
+		t.Parallel()
+		store := infrastructure.NewMemoryStore()
+		checkpoints := subscriptions.NewMemoryCheckpointStore()
+		appendNumberedEvents(t, store, 1, 2)
+
+		var enabled sync.Map
+		enabled.Store("ev-1", true)
+		flags := domain.FeatureFlagsFunc(func(_ context.Context, _ string, event domain.EventEnvelope[any]) bool {
+			_, ok := enabled.Load(event.ID)
+			return ok
+		})
+		handler := &recordingHandler{}
+		sub, err := subscriptions.NewSubscriber("projector", store, checkpoints, handler.handle,
+			subscriptions.WithPollInterval(subscriptionTestPollInterval),
+			subscriptions.WithFeatureFlag(flags, "kill-switch", subscriptions.DisabledPause))
+		if err != nil {
+			t.Fatalf("failed to create subscriber: %v", err)
+		}
+
+		stop := runSubscriber(t, sub)
+		defer stop()
+		waitForCheckpoint(t, checkpoints, "projector", 1)
+		time.Sleep(10 * subscriptionTestPollInterval)
+		if position, _ := checkpoints.Position(context.Background(), "projector"); position != 1 {
+			t.Fatalf("checkpoint moved to %d while paused, want 1", position)
+		}
+
+		appendNumberedEvents(t, store, 3, 1)
+		enabled.Store("ev-2", true)
+		enabled.Store("ev-3", true)
+		waitForCheckpoint(t, checkpoints, "projector", 3)
+		stop()
+
+		if got := handler.handled(); fmt.Sprint(got) != "[ev-1 ev-2 ev-3]" {
+			t.Fatalf("expected [ev-1 ev-2 ev-3] after catching up, got %v", got)
+		}
+	})
+}
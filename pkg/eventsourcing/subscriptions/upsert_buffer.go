@@ -0,0 +1,112 @@
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm/clause"
+)
+
+// DefaultUpsertChunkSize is the number of rows per INSERT statement when
+// NewUpsertBuffer is given a non-positive chunk size.
+const DefaultUpsertChunkSize = 500
+
+// UpsertBuffer collects read-model rows during a batch and writes them as
+// multi-row upserts through the batch transaction. Wire it with
+// WithBatchFlusher and Put rows from the handler:
+//
+//	buf := subscriptions.NewUpsertBuffer(func(r UserRow) string { return r.ID }, []string{"id"}, 0)
+//	handler := func(ctx context.Context, event domain.EventEnvelope[any]) error {
+//		buf.Put(projectUser(event))
+//		return nil
+//	}
+//	sub, err := subscriptions.NewSubscriber("users", store, checkpoints, handler,
+//		subscriptions.WithBatchSize(5000), subscriptions.WithBatchFlusher(buf))
+//
+// Rows are keyed: a later Put for the same key replaces the earlier row, so
+// each key is written once per flush (Postgres rejects an upsert that touches
+// the same row twice).
+type UpsertBuffer[T any] struct {
+	mu        sync.Mutex
+	key       func(T) string
+	conflict  []clause.Column
+	chunkSize int
+	rows      []T
+	index     map[string]int
+}
+
+var _ BatchFlusher = (*UpsertBuffer[struct{}])(nil)
+
+// NewUpsertBuffer creates a buffer for rows of the GORM model T. key
+// identifies a row and conflictColumns name the unique columns the upsert
+// conflicts on; chunkSize bounds the rows per INSERT statement (<= 0 means
+// DefaultUpsertChunkSize).
+func NewUpsertBuffer[T any](key func(T) string, conflictColumns []string, chunkSize int) *UpsertBuffer[T] {
+	if chunkSize <= 0 {
+		chunkSize = DefaultUpsertChunkSize
+	}
+	columns := make([]clause.Column, len(conflictColumns))
+	for i, name := range conflictColumns {
+		columns[i] = clause.Column{Name: name}
+	}
+	return &UpsertBuffer[T]{
+		key:       key,
+		conflict:  columns,
+		chunkSize: chunkSize,
+		index:     make(map[string]int),
+	}
+}
+
+// Put buffers row, replacing any buffered row with the same key.
+func (b *UpsertBuffer[T]) Put(row T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	k := b.key(row)
+	if i, ok := b.index[k]; ok {
+		b.rows[i] = row
+		return
+	}
+	b.index[k] = len(b.rows)
+	b.rows = append(b.rows, row)
+}
+
+// Len returns the number of buffered rows.
+func (b *UpsertBuffer[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.rows)
+}
+
+// Flush upserts the buffered rows through the batch transaction in ctx and
+// empties the buffer. It fails when ctx carries no batch transaction, since
+// writing outside it would break the atomicity with the checkpoint.
+func (b *UpsertBuffer[T]) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.rows) == 0 {
+		return nil
+	}
+	tx := TxFromContext(ctx)
+	if tx == nil {
+		return errors.New("upsert buffer flushed outside a database-backed batch")
+	}
+	if err := tx.Clauses(clause.OnConflict{Columns: b.conflict, UpdateAll: true}).
+		CreateInBatches(&b.rows, b.chunkSize).Error; err != nil {
+		return err
+	}
+	b.resetLocked()
+	return nil
+}
+
+// Reset discards the buffered rows.
+func (b *UpsertBuffer[T]) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetLocked()
+}
+
+func (b *UpsertBuffer[T]) resetLocked() {
+	b.rows = nil
+	b.index = make(map[string]int)
+}
@@ -0,0 +1,137 @@
+// Package testutil provides test doubles for code built on the event
+// sourcing packages.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/application"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+var _ application.UnitOfWork = (*RecordingUnitOfWork)(nil)
+
+// RecordingUnitOfWork is an in-memory UnitOfWork for handler tests. It keeps
+// no event store: Commit moves the tracked entities' uncommitted events into
+// a log that tests inspect with CommittedEvents, and counts Commit and
+// Rollback calls. SetCommitError makes commits fail so the error path can be
+// exercised; like SimpleUnitOfWork, a failed commit drops the tracking but
+// leaves the entities' events uncommitted. It is safe for concurrent use.
+type RecordingUnitOfWork struct {
+	mu        sync.Mutex
+	tracked   []domain.Entity
+	committed []domain.EventEnvelope[any]
+	commits   int
+	rollbacks int
+	commitErr error
+}
+
+// NewRecordingUnitOfWork creates an empty RecordingUnitOfWork.
+func NewRecordingUnitOfWork() *RecordingUnitOfWork {
+	return &RecordingUnitOfWork{}
+}
+
+// SetCommitError makes every subsequent Commit and DryRun fail with err;
+// nil restores successful commits.
+func (u *RecordingUnitOfWork) SetCommitError(err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.commitErr = err
+}
+
+// Track registers entities, applying the same validation as
+// SimpleUnitOfWork: no nil entities, empty IDs or duplicates.
+func (u *RecordingUnitOfWork) Track(entities ...domain.Entity) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	seen := make(map[string]bool, len(u.tracked)+len(entities))
+	for _, entity := range u.tracked {
+		seen[entity.GetID()] = true
+	}
+	for _, entity := range entities {
+		if entity == nil {
+			return fmt.Errorf("entity cannot be nil")
+		}
+		if entity.GetID() == "" {
+			return fmt.Errorf("entity must have a non-empty aggregate ID")
+		}
+		if seen[entity.GetID()] {
+			return fmt.Errorf("entity with aggregate ID %q is already tracked", entity.GetID())
+		}
+		seen[entity.GetID()] = true
+	}
+	u.tracked = append(u.tracked, entities...)
+	return nil
+}
+
+// Commit records the tracked entities' uncommitted events, in tracking
+// order, and clears them from the entities.
+func (u *RecordingUnitOfWork) Commit(ctx context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.commits++
+	tracked := u.tracked
+	u.tracked = nil
+	if u.commitErr != nil {
+		return u.commitErr
+	}
+	for _, entity := range tracked {
+		u.committed = append(u.committed, entity.GetUncommittedEvents()...)
+		entity.ClearUncommittedEvents()
+	}
+	return nil
+}
+
+// DryRun reports the configured commit error, if any.
+func (u *RecordingUnitOfWork) DryRun(ctx context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.commitErr
+}
+
+// Rollback drops the tracked entities without touching their events.
+func (u *RecordingUnitOfWork) Rollback() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.rollbacks++
+	u.tracked = nil
+	return nil
+}
+
+// CommittedEvents returns a copy of every event committed so far, in commit
+// order.
+func (u *RecordingUnitOfWork) CommittedEvents() []domain.EventEnvelope[any] {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]domain.EventEnvelope[any](nil), u.committed...)
+}
+
+// CommittedEventTypes returns the event types of CommittedEvents, which is
+// often all a handler test needs to assert.
+func (u *RecordingUnitOfWork) CommittedEventTypes() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	types := make([]string, len(u.committed))
+	for i, event := range u.committed {
+		types[i] = event.EventType
+	}
+	return types
+}
+
+// Commits returns how many times Commit was called, including failures.
+func (u *RecordingUnitOfWork) Commits() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.commits
+}
+
+// Rollbacks returns how many times Rollback was called.
+func (u *RecordingUnitOfWork) Rollbacks() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.rollbacks
+}
@@ -0,0 +1,96 @@
+package testutil_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/akeemphilbert/pericarp/pkg/ddd"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/testutil"
+)
+
+func newUser(t *testing.T, id string, eventTypes ...string) *ddd.BaseEntity {
+	t.Helper()
+	entity := ddd.NewBaseEntity(id)
+	for _, eventType := range eventTypes {
+		if err := entity.RecordEvent(map[string]string{"id": id}, eventType); err != nil {
+			t.Fatalf("failed to record %s: %v", eventType, err)
+		}
+	}
+	return entity
+}
+
+func TestRecordingUnitOfWork(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records committed events", func(t *testing.T) {
+		t.Parallel()
+		uow := testutil.NewRecordingUnitOfWork()
+		user := newUser(t, "user-1", "user.created", "user.activated")
+		if err := uow.Track(user); err != nil {
+			t.Fatalf("Track failed: %v", err)
+		}
+		if err := uow.Commit(context.Background()); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		if got, want := uow.CommittedEventTypes(), []string{"user.created", "user.activated"}; !slices.Equal(got, want) {
+			t.Errorf("CommittedEventTypes() = %v, want %v", got, want)
+		}
+		if uow.Commits() != 1 {
+			t.Errorf("Commits() = %d, want 1", uow.Commits())
+		}
+		if n := len(user.GetUncommittedEvents()); n != 0 {
+			t.Errorf("expected uncommitted events cleared, got %d", n)
+		}
+	})
+
+	t.Run("simulated commit failure", func(t *testing.T) {
+		t.Parallel()
+		uow := testutil.NewRecordingUnitOfWork()
+		errStore := errors.New("store unavailable")
+		uow.SetCommitError(errStore)
+
+		user := newUser(t, "user-1", "user.created")
+		if err := uow.Track(user); err != nil {
+			t.Fatalf("Track failed: %v", err)
+		}
+		if err := uow.Commit(context.Background()); !errors.Is(err, errStore) {
+			t.Fatalf("expected the simulated error, got %v", err)
+		}
+		if n := len(uow.CommittedEvents()); n != 0 {
+			t.Errorf("expected nothing committed, got %d events", n)
+		}
+		if n := len(user.GetUncommittedEvents()); n != 1 {
+			t.Errorf("expected the event to stay uncommitted, got %d", n)
+		}
+	})
+
+	t.Run("rejects duplicate tracking", func(t *testing.T) {
+		t.Parallel()
+		uow := testutil.NewRecordingUnitOfWork()
+		if err := uow.Track(newUser(t, "user-1"), newUser(t, "user-1")); err == nil {
+			t.Fatal("expected an error tracking the same aggregate twice")
+		}
+	})
+
+	t.Run("concurrent handlers", func(t *testing.T) {
+		t.Parallel()
+		uow := testutil.NewRecordingUnitOfWork()
+		var wg sync.WaitGroup
+		for _, id := range []string{"user-1", "user-2", "user-3", "user-4"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = uow.Track(newUser(t, id, "user.created"))
+				_ = uow.Commit(context.Background())
+			}()
+		}
+		wg.Wait()
+		if n := len(uow.CommittedEvents()); n != 4 {
+			t.Errorf("expected 4 committed events, got %d", n)
+		}
+	})
+}
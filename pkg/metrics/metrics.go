@@ -0,0 +1,84 @@
+// Package metrics exports event store measurements to Prometheus.
+//
+// Register a PrometheusObserver and hand it to the store:
+//
+//	observer, err := metrics.NewPrometheusObserver(prometheus.DefaultRegisterer)
+//	store, err := infrastructure.NewGormEventStore(db, infrastructure.WithObserver(observer))
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+)
+
+var _ infrastructure.EventStoreObserver = (*PrometheusObserver)(nil)
+
+// PrometheusObserver is an infrastructure.EventStoreObserver that records
+// saves and loads as Prometheus metrics:
+//
+//   - pericarp_eventstore_save_duration_seconds, a histogram of Append
+//     durations
+//   - pericarp_eventstore_load_duration_seconds, a histogram of read
+//     durations
+//   - pericarp_eventstore_events_total, a counter of events saved and
+//     loaded, labelled by operation ("save" or "load")
+//   - pericarp_eventstore_save_payload_bytes, a histogram of the stored
+//     payload size of each Append
+//
+// Metrics are not labelled by aggregate ID, which would give every aggregate
+// a series of its own.
+type PrometheusObserver struct {
+	saveDuration prometheus.Histogram
+	loadDuration prometheus.Histogram
+	events       *prometheus.CounterVec
+	payloadBytes prometheus.Histogram
+}
+
+// NewPrometheusObserver creates a PrometheusObserver and registers its
+// metrics with reg. It fails if reg already has metrics of the same names.
+func NewPrometheusObserver(reg prometheus.Registerer) (*PrometheusObserver, error) {
+	o := &PrometheusObserver{
+		saveDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "pericarp_eventstore_save_duration_seconds",
+			Help:    "Time taken to append events to the event store.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		loadDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "pericarp_eventstore_load_duration_seconds",
+			Help:    "Time taken to read an aggregate's events from the event store.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pericarp_eventstore_events_total",
+			Help: "Events saved to and loaded from the event store.",
+		}, []string{"operation"}),
+		payloadBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "pericarp_eventstore_save_payload_bytes",
+			Help:    "Stored size of the event payloads of each append.",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}),
+	}
+	for _, c := range []prometheus.Collector{o.saveDuration, o.loadDuration, o.events, o.payloadBytes} {
+		if err := reg.Register(c); err != nil {
+			return nil, fmt.Errorf("failed to register event store metrics: %w", err)
+		}
+	}
+	return o, nil
+}
+
+// OnSave records an Append of count events.
+func (o *PrometheusObserver) OnSave(_ string, count, bytes int, duration time.Duration) {
+	o.saveDuration.Observe(duration.Seconds())
+	o.events.WithLabelValues("save").Add(float64(count))
+	o.payloadBytes.Observe(float64(bytes))
+}
+
+// OnLoad records a read of count events.
+func (o *PrometheusObserver) OnLoad(_ string, count int, duration time.Duration) {
+	o.loadDuration.Observe(duration.Seconds())
+	o.events.WithLabelValues("load").Add(float64(count))
+}
@@ -0,0 +1,83 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+	"github.com/akeemphilbert/pericarp/pkg/metrics"
+)
+
+func TestPrometheusObserver(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	observer, err := metrics.NewPrometheusObserver(reg)
+	if err != nil {
+		t.Fatalf("NewPrometheusObserver() error = %v", err)
+	}
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open in-memory sqlite: %v", err)
+	}
+	store, err := infrastructure.NewGormEventStore(db, infrastructure.WithObserver(observer))
+	if err != nil {
+		t.Fatalf("failed to create gorm event store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	if err := store.Append(ctx, "user-1", 0,
+		domain.NewEventEnvelope[any](map[string]any{"name": "Ada"}, "user-1", "user.created", 1),
+		domain.NewEventEnvelope[any](map[string]any{"name": "Ada L"}, "user-1", "user.renamed", 2),
+	); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	for range 2 {
+		if _, err := store.GetEvents(ctx, "user-1"); err != nil {
+			t.Fatalf("GetEvents() error = %v", err)
+		}
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	got := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range metric.GetLabel() {
+				name += "{" + label.GetName() + "=" + label.GetValue() + "}"
+			}
+			if h := metric.GetHistogram(); h != nil {
+				got[name] = float64(h.GetSampleCount())
+			} else {
+				got[name] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	want := map[string]float64{
+		"pericarp_eventstore_save_duration_seconds":        1,
+		"pericarp_eventstore_load_duration_seconds":        2,
+		"pericarp_eventstore_save_payload_bytes":           1,
+		"pericarp_eventstore_events_total{operation=save}": 2,
+		"pericarp_eventstore_events_total{operation=load}": 4,
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %v, want %v", name, got[name], value)
+		}
+	}
+
+	if _, err := metrics.NewPrometheusObserver(reg); err == nil {
+		t.Error("registering the metrics twice succeeded, want an error")
+	}
+}
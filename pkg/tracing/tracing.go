@@ -0,0 +1,121 @@
+// Package tracing traces commands and events with OpenTelemetry, so a request
+// can be followed from the command that handled it to every projector that
+// reacted to its events.
+//
+// Add CommandMiddleware to a cqrs.CommandDispatcher and DispatchMiddleware to
+// a domain.EventDispatcher:
+//
+//	commands.Use(tracing.CommandMiddleware(tracer))
+//	events.Use(tracing.DispatchMiddleware(tracer))
+//
+// Each receiver invocation gets a span that is a child of the span in the
+// dispatch context, such as an incoming HTTP request's. While it runs, events
+// committed by a UnitOfWork under its context record the span's trace and
+// span IDs in their metadata (MetadataTraceID, MetadataSpanID). Each event
+// handler invocation gets a span that is a child of the dispatch context's
+// span and links to the span recorded in the event's metadata, so events
+// dispatched later or in another process, by a subscriptions.Subscriber,
+// an outbox relay or Kafka, still lead back to the command that raised them.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+)
+
+const (
+	// MetadataTraceID is the event metadata key holding the hex trace ID of
+	// the command span the event was committed under.
+	MetadataTraceID = "trace_id"
+
+	// MetadataSpanID is the event metadata key holding the hex span ID of
+	// the command span the event was committed under.
+	MetadataSpanID = "span_id"
+)
+
+// CommandMiddleware starts a span named "command <type>" around every
+// receiver invocation and records the receiver's error on it. Events
+// committed under the receiver's context carry the span in their metadata.
+func CommandMiddleware(tracer trace.Tracer) cqrs.CommandMiddleware {
+	return func(next cqrs.ReceiverFunc) cqrs.ReceiverFunc {
+		return func(ctx context.Context, env cqrs.CommandEnvelope[any]) (any, error) {
+			ctx, span := tracer.Start(ctx, "command "+env.CommandType,
+				trace.WithAttributes(
+					attribute.String("pericarp.command.id", env.ID),
+					attribute.String("pericarp.command.type", env.CommandType),
+				))
+			defer span.End()
+
+			if sc := span.SpanContext(); sc.IsValid() {
+				ctx = domain.ContextWithEventMetadata(ctx, map[string]string{
+					MetadataTraceID: sc.TraceID().String(),
+					MetadataSpanID:  sc.SpanID().String(),
+				})
+			}
+			value, err := next(ctx, env)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return value, err
+		}
+	}
+}
+
+// DispatchMiddleware starts a span named "event <type>" around every handler
+// invocation, linked to the span recorded in the event's metadata, and
+// records the handler's error on it.
+func DispatchMiddleware(tracer trace.Tracer) domain.DispatchMiddleware {
+	return func(next domain.HandlerFunc) domain.HandlerFunc {
+		return func(ctx context.Context, env domain.EventEnvelope[any]) error {
+			opts := []trace.SpanStartOption{
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("pericarp.event.id", env.ID),
+					attribute.String("pericarp.event.type", env.EventType),
+					attribute.String("pericarp.aggregate.id", env.AggregateID),
+					attribute.Int("pericarp.event.sequence_no", env.SequenceNo),
+				),
+			}
+			if origin := SpanContextFromMetadata(env.Metadata); origin.IsValid() {
+				opts = append(opts, trace.WithLinks(trace.Link{SpanContext: origin}))
+			}
+			ctx, span := tracer.Start(ctx, "event "+env.EventType, opts...)
+			defer span.End()
+
+			err := next(ctx, env)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// SpanContextFromMetadata returns the span recorded in an event's metadata by
+// CommandMiddleware, for handlers that start spans of their own. The result
+// is invalid when the metadata holds no span.
+func SpanContextFromMetadata(metadata map[string]any) trace.SpanContext {
+	traceIDHex, _ := metadata[MetadataTraceID].(string)
+	spanIDHex, _ := metadata[MetadataSpanID].(string)
+	traceID, err := trace.TraceIDFromHex(traceIDHex)
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	spanID, err := trace.SpanIDFromHex(spanIDHex)
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Remote:  true,
+	})
+}
@@ -0,0 +1,147 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/akeemphilbert/pericarp/pkg/cqrs"
+	"github.com/akeemphilbert/pericarp/pkg/ddd"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/application"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/domain"
+	"github.com/akeemphilbert/pericarp/pkg/eventsourcing/infrastructure"
+	"github.com/akeemphilbert/pericarp/pkg/tracing"
+)
+
+type createUser struct {
+	ID string `json:"id"`
+}
+
+type userCreated struct {
+	Email string `json:"email"`
+}
+
+func newTracer(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return provider, exporter
+}
+
+func spanNamed(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no span named %q among %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("traces a command through commit to its projector", func(t *testing.T) {
+		t.Parallel()
+		provider, exporter := newTracer(t)
+		tracer := provider.Tracer("test")
+
+		store := infrastructure.NewMemoryStore()
+		events := domain.NewEventDispatcher()
+		events.Use(tracing.DispatchMiddleware(tracer))
+		if _, err := domain.Subscribe(events, "user.created", func(context.Context, domain.EventEnvelope[userCreated]) error {
+			return nil
+		}); err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+
+		commands := cqrs.NewQueuedCommandDispatcher()
+		commands.Use(tracing.CommandMiddleware(tracer))
+		if err := cqrs.RegisterReceiver(commands, "user.create", cqrs.CommandReceiver[createUser](func(ctx context.Context, env cqrs.CommandEnvelope[createUser]) (any, error) {
+			user := ddd.NewBaseEntity(env.Payload.ID)
+			if err := user.RecordEvent(userCreated{Email: "a@example.com"}, "user.created"); err != nil {
+				return nil, err
+			}
+			uow := application.NewSimpleUnitOfWork(store, events)
+			if err := uow.Track(user); err != nil {
+				return nil, err
+			}
+			return nil, uow.Commit(ctx)
+		})); err != nil {
+			t.Fatalf("failed to register receiver: %v", err)
+		}
+
+		ctx, request := tracer.Start(context.Background(), "request")
+		envelope := cqrs.NewCommandEnvelope[any](createUser{ID: "user-1"}, "user.create")
+		if results := commands.Dispatch(ctx, envelope).Wait(); len(results) != 1 || results[0].Error != nil {
+			t.Fatalf("results = %+v, want one success", results)
+		}
+		request.End()
+
+		spans := exporter.GetSpans()
+		command := spanNamed(t, spans, "command user.create")
+		if command.Parent.SpanID() != request.SpanContext().SpanID() {
+			t.Errorf("command span's parent = %s, want the request span %s",
+				command.Parent.SpanID(), request.SpanContext().SpanID())
+		}
+
+		stored, err := store.GetEvents(context.Background(), "user-1")
+		if err != nil || len(stored) != 1 {
+			t.Fatalf("GetEvents() = %v, %v; want one event", stored, err)
+		}
+		got := tracing.SpanContextFromMetadata(stored[0].Metadata)
+		if got.TraceID() != command.SpanContext.TraceID() || got.SpanID() != command.SpanContext.SpanID() {
+			t.Errorf("stored metadata names span %s, want the command span %s",
+				got.SpanID(), command.SpanContext.SpanID())
+		}
+
+		projector := spanNamed(t, spans, "event user.created")
+		if projector.Parent.SpanID() != command.SpanContext.SpanID() {
+			t.Errorf("projector span's parent = %s, want the command span", projector.Parent.SpanID())
+		}
+		if len(projector.Links) != 1 || projector.Links[0].SpanContext.SpanID() != command.SpanContext.SpanID() {
+			t.Errorf("projector span links = %+v, want one link to the command span", projector.Links)
+		}
+	})
+
+	t.Run("links a later dispatch to the command through metadata", func(t *testing.T) {
+		t.Parallel()
+		provider, exporter := newTracer(t)
+		tracer := provider.Tracer("test")
+
+		_, command := tracer.Start(context.Background(), "command")
+		command.End()
+
+		events := domain.NewEventDispatcher()
+		events.Use(tracing.DispatchMiddleware(tracer))
+		handlerErr := errors.New("projection failed")
+		if _, err := domain.Subscribe(events, "user.created", func(context.Context, domain.EventEnvelope[map[string]any]) error {
+			return handlerErr
+		}); err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+
+		envelope := domain.NewEventEnvelope[any](map[string]any{}, "user-1", "user.created", 1)
+		envelope.Metadata[tracing.MetadataTraceID] = command.SpanContext().TraceID().String()
+		envelope.Metadata[tracing.MetadataSpanID] = command.SpanContext().SpanID().String()
+		if err := events.Dispatch(context.Background(), envelope); !errors.Is(err, handlerErr) {
+			t.Fatalf("Dispatch() error = %v, want the handler's", err)
+		}
+
+		projector := spanNamed(t, exporter.GetSpans(), "event user.created")
+		if projector.Parent.IsValid() {
+			t.Errorf("projector span has parent %s, want a root span", projector.Parent.SpanID())
+		}
+		if len(projector.Links) != 1 || projector.Links[0].SpanContext.SpanID() != command.SpanContext().SpanID() {
+			t.Errorf("projector span links = %+v, want one link to the command span", projector.Links)
+		}
+		if projector.Status.Description != handlerErr.Error() {
+			t.Errorf("projector span status = %+v, want the handler's error", projector.Status)
+		}
+	})
+}